@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"PenbunAPI/config"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MFAChallengeTTL อายุของ Challenge Token ระหว่างขั้นตอนกรอกรหัส TOTP
+	MFAChallengeTTL = 5 * time.Minute
+
+	// MFAMaxAttempts จำนวนครั้งที่กรอกรหัสได้ต่อ Challenge Token หลังจากนั้นต้อง Login ขั้นแรกใหม่
+	MFAMaxAttempts = 5
+
+	// AMRPassword และ AMRMFA คือค่าใน amr claim ที่ระบุวิธียืนยันตัวตน (RFC 8176)
+	AMRPassword = "pwd"
+	AMRMFA      = "mfa"
+)
+
+// mfaAudience แยก aud ของ Challenge Token ออกจาก Access Token
+// ทำให้ ParseToken ปฏิเสธ Challenge Token ที่ถูกนำไปใช้เรียก API โดยอัตโนมัติ
+func mfaAudience() string {
+	return Audience() + ":mfa"
+}
+
+// IssueMFAChallenge ออก Challenge Token หลังตรวจรหัสผ่านผ่านแล้ว เพื่อใช้แลก Access Token ด้วยรหัส TOTP
+func IssueMFAChallenge(userName string) (string, error) {
+	claims, err := newClaims(userName, mfaAudience(), MFAChallengeTTL)
+	if err != nil {
+		return "", err
+	}
+	claims.AMR = []string{AMRPassword}
+	return sign(claims)
+}
+
+// mfaAttempts นับจำนวนครั้งที่ใช้ Challenge แต่ละใบ (ตาม jti) เก็บไว้จนกว่า Challenge จะหมดอายุ
+var (
+	mfaAttemptsMu sync.Mutex
+	mfaAttempts   = map[string]mfaAttempt{}
+)
+
+type mfaAttempt struct {
+	count  int
+	expire time.Time
+}
+
+// TakeMFAAttempt จองการกรอกรหัสหนึ่งครั้งของ Challenge ก่อนตรวจรหัส (Request ที่ส่งพร้อมกันจึงเดารหัสได้ไม่เกิน MFAMaxAttempts)
+// คืนจำนวนครั้งที่เหลือหลังครั้งนี้ และ false หากใช้ครบแล้ว
+func TakeMFAAttempt(challenge *Claims) (int, bool) {
+	now := time.Now()
+	mfaAttemptsMu.Lock()
+	defer mfaAttemptsMu.Unlock()
+	for jti, a := range mfaAttempts {
+		if now.After(a.expire) {
+			delete(mfaAttempts, jti)
+		}
+	}
+
+	a := mfaAttempts[challenge.ID]
+	if a.count >= MFAMaxAttempts {
+		return 0, false
+	}
+	a.count++
+	a.expire = now.Add(MFAChallengeTTL)
+	if challenge.ExpiresAt != nil {
+		a.expire = challenge.ExpiresAt.Time
+	}
+	mfaAttempts[challenge.ID] = a
+	return MFAMaxAttempts - a.count, true
+}
+
+// ParseMFAChallenge ตรวจสอบ Challenge Token ที่ได้จาก Login ขั้นแรก
+func ParseMFAChallenge(tokenString string) (*Claims, error) {
+	return parse(tokenString, mfaAudience())
+}
+
+// HasMFA ตรวจสอบว่า Token นี้ผ่านการยืนยันตัวตนแบบ MFA แล้วหรือไม่
+func (c *Claims) HasMFA() bool {
+	for _, m := range c.AMR {
+		if m == AMRMFA {
+			return true
+		}
+	}
+	return false
+}
+
+// MFARequiredRoles คืนค่ารายการ role ที่บังคับใช้ MFA (ตั้งค่าด้วย MFA_REQUIRED_ROLES คั่นด้วย comma)
+func MFARequiredRoles() []string {
+	var roles []string
+	for _, r := range strings.Split(config.GetEnv("MFA_REQUIRED_ROLES"), ",") {
+		if r = strings.TrimSpace(strings.ToLower(r)); r != "" {
+			roles = append(roles, r)
+		}
+	}
+	return roles
+}
+
+// RequiresMFA ตรวจสอบว่า role ใดของผู้ใช้ถูกบังคับให้ใช้ MFA
+func RequiresMFA(roles []string) bool {
+	c := &Claims{Roles: roles}
+	for _, r := range MFARequiredRoles() {
+		if c.HasRole(r) {
+			return true
+		}
+	}
+	return false
+}
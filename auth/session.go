@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"PenbunAPI/dialect"
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// sessionCheckInterval คือระยะเวลาที่ผลการตรวจ Session ถูก cache ไว้ในหน่วยความจำ
+// Session ที่ถูกยกเลิกจากเครื่องอื่น (กรณีรันหลาย instance) จะมีผลภายในช่วงเวลานี้
+const sessionCheckInterval = 30 * time.Second
+
+type sessionState struct {
+	userName string
+	checked  time.Time
+}
+
+// Sessions คือ Service ของ Session ผู้ใช้ใน tb_user_session พร้อม cache ผลการตรวจ
+// สร้างครั้งเดียวต่อฐานข้อมูล (ผ่าน container.New) เพื่อให้ทุก Request ใช้ cache ชุดเดียวกัน
+type Sessions struct {
+	db      *sql.DB
+	dialect dialect.Dialect
+	mu      sync.Mutex
+	cache   map[string]sessionState
+}
+
+// NewSessions สร้าง Sessions บนฐานข้อมูล db
+func NewSessions(db *sql.DB) *Sessions {
+	return &Sessions{db: db, dialect: dialect.Of(db), cache: map[string]sessionState{}}
+}
+
+// Start บันทึก Session ใหม่ใน tb_user_session และคืนค่า sid สำหรับใส่ใน Token
+func (s *Sessions) Start(ctx context.Context, userName, userAgent, ip string) (string, error) {
+	sid, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO tb_user_session (session_id, user_name, user_agent, ip_address, issue_date, last_seen_date, expire_date)
+		VALUES (@ID, @UserName, @UserAgent, @IP, `+s.dialect.Now()+`, `+s.dialect.Now()+`, `+s.dialect.NowPlusSeconds("@TTL")+`)
+	`, sql.Named("ID", sid), sql.Named("UserName", userName), sql.Named("UserAgent", truncate(userAgent, 512)),
+		sql.Named("IP", ip), sql.Named("TTL", int(TokenTTL/time.Second)))
+	if err != nil {
+		return "", err
+	}
+	return sid, nil
+}
+
+// Extend ต่ออายุ Session เมื่อมีการ Refresh Token
+func (s *Sessions) Extend(ctx context.Context, sid string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE tb_user_session SET expire_date = `+s.dialect.NowPlusSeconds("@TTL")+`
+		WHERE session_id = @ID AND revoke_date IS NULL
+	`, sql.Named("ID", sid), sql.Named("TTL", int(TokenTTL/time.Second)))
+	return err
+}
+
+// Active ตรวจสอบว่า Session ยังไม่ถูกยกเลิกหรือหมดอายุ และบันทึก last_seen_date
+// ผลการตรวจจะถูก cache ไว้ sessionCheckInterval เพื่อไม่ให้ทุก Request ต้องเข้าฐานข้อมูล
+func (s *Sessions) Active(ctx context.Context, sid, userName string) (bool, error) {
+	s.mu.Lock()
+	state, ok := s.cache[sid]
+	s.mu.Unlock()
+	if ok && time.Since(state.checked) < sessionCheckInterval {
+		return true, nil
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE tb_user_session SET last_seen_date = `+s.dialect.Now()+`
+		WHERE session_id = @ID AND user_name = @UserName AND revoke_date IS NULL AND expire_date > `+s.dialect.Now(),
+		sql.Named("ID", sid), sql.Named("UserName", userName))
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n != 1 {
+		delete(s.cache, sid)
+		return false, nil
+	}
+	s.prune()
+	s.cache[sid] = sessionState{userName: userName, checked: time.Now()}
+	return true, nil
+}
+
+// Revoke ยกเลิก Session เดียว (เฉพาะของ userName) คืนค่า false หากไม่พบ
+func (s *Sessions) Revoke(ctx context.Context, sid, userName, revokeBy string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE tb_user_session SET revoke_date = `+s.dialect.Now()+`, revoke_by = @RevokeBy
+		WHERE session_id = @ID AND user_name = @UserName AND revoke_date IS NULL
+	`, sql.Named("ID", sid), sql.Named("UserName", userName), sql.Named("RevokeBy", revokeBy))
+	if err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	delete(s.cache, sid)
+	s.mu.Unlock()
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// RevokeUser ยกเลิกทุก Session ของผู้ใช้ (Force Logout) ยกเว้น exceptSID หากระบุ
+func (s *Sessions) RevokeUser(ctx context.Context, userName, exceptSID, revokeBy string) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE tb_user_session SET revoke_date = `+s.dialect.Now()+`, revoke_by = @RevokeBy
+		WHERE user_name = @UserName AND session_id <> @Except AND revoke_date IS NULL
+	`, sql.Named("UserName", userName), sql.Named("Except", exceptSID), sql.Named("RevokeBy", revokeBy))
+	if err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	for sid, state := range s.cache {
+		if state.userName == userName && sid != exceptSID {
+			delete(s.cache, sid)
+		}
+	}
+	s.mu.Unlock()
+	return res.RowsAffected()
+}
+
+// prune ลบรายการที่เกินอายุ cache ออก (เรียกขณะถือ s.mu)
+func (s *Sessions) prune() {
+	if len(s.cache) < 1024 {
+		return
+	}
+	for sid, state := range s.cache {
+		if time.Since(state.checked) >= sessionCheckInterval {
+			delete(s.cache, sid)
+		}
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,169 @@
+// Package auth รวมการออกและตรวจสอบ JWT ของ PenbunAPI ไว้ที่เดียว (ใช้ github.com/golang-jwt/jwt/v5 เท่านั้น)
+package auth
+
+import (
+	"PenbunAPI/config"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// Issuer คือค่า iss ของ Token ทุกใบที่ออกโดย PenbunAPI
+	Issuer = "penbun-api"
+
+	// TokenTTL อายุของ Access Token
+	TokenTTL = time.Hour
+
+	// AuthTypeJWT และ AuthTypeAPIKey ระบุว่า Identity มาจาก JWT หรือ API Key
+	AuthTypeJWT    = "jwt"
+	AuthTypeAPIKey = "api_key"
+
+	// RoleAdmin คือ role ผู้ดูแลระบบ (เช่น Force Logout ผู้ใช้อื่น)
+	RoleAdmin = "admin"
+
+	// clockSkew ยอมให้นาฬิกาของแต่ละเครื่องคลาดเคลื่อนได้เล็กน้อยตอนตรวจ exp/nbf/iat
+	clockSkew = 30 * time.Second
+)
+
+// Claims คือข้อมูลผู้ใช้ที่อยู่ใน Token และถูกเก็บไว้ใน c.Locals("user")
+type Claims struct {
+	UserName string   `json:"user_name"`
+	Roles    []string `json:"roles,omitempty"`
+	AuthType string   `json:"auth_type,omitempty"`
+	ApiKeyID string   `json:"api_key_id,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
+	AMR      []string `json:"amr,omitempty"`
+	// SessionID (sid) ผูก Token กับแถวใน tb_user_session เพื่อให้ยกเลิก Session ได้จากฝั่ง Server
+	SessionID string `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// HasRole ตรวจสอบว่าผู้ใช้มี role ที่กำหนดหรือไม่ (ไม่สนตัวพิมพ์เล็ก/ใหญ่)
+func (c *Claims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if strings.EqualFold(r, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAPIKey ตรวจสอบว่า Identity นี้มาจาก API Key (Service Account)
+func (c *Claims) IsAPIKey() bool {
+	return c.AuthType == AuthTypeAPIKey
+}
+
+// Audience คืนค่า aud ที่ใช้ออกและตรวจสอบ Token (ตั้งค่าได้ด้วย JWT_AUDIENCE)
+func Audience() string {
+	if aud := config.GetEnv("JWT_AUDIENCE"); aud != "" {
+		return aud
+	}
+	return "penbun-api"
+}
+
+// IssueToken ออก Access Token ใหม่ให้ผู้ใช้ของ Session sid พร้อมวิธียืนยันตัวตนที่ใช้ (amr เช่น "pwd", "mfa")
+func IssueToken(userName string, roles []string, sid string, amr ...string) (string, *Claims, error) {
+	claims, err := newClaims(userName, Audience(), TokenTTL)
+	if err != nil {
+		return "", nil, err
+	}
+	claims.Roles = roles
+	claims.AMR = amr
+	claims.SessionID = sid
+	tokenString, err := sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return tokenString, claims, nil
+}
+
+// RefreshToken ออก Token ใบใหม่ (jti ใหม่) จาก Claims ของ Token เดิมที่ยังไม่หมดอายุ
+func RefreshToken(old *Claims) (string, *Claims, error) {
+	return IssueToken(old.UserName, old.Roles, old.SessionID, old.AMR...)
+}
+
+// ParseToken ตรวจสอบลายเซ็น, alg, iss, aud, exp/nbf/iat และ jti แล้วคืนค่า Claims
+func ParseToken(tokenString string) (*Claims, error) {
+	return parse(tokenString, Audience())
+}
+
+func newClaims(userName, audience string, ttl time.Duration) (*Claims, error) {
+	now := time.Now()
+	jti, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+	return &Claims{
+		UserName: userName,
+		AuthType: AuthTypeJWT,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    Issuer,
+			Subject:   userName,
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}, nil
+}
+
+func parse(tokenString, audience string) (*Claims, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(config.JWTAlgorithms()),
+		jwt.WithIssuer(Issuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
+	claims := &Claims{}
+	token, err := parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return config.JWTVerificationKey(token.Header)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("token is not valid")
+	}
+	if claims.ID == "" || claims.UserName == "" {
+		return nil, errors.New("token is missing jti or user_name")
+	}
+	claims.AuthType = AuthTypeJWT
+	return claims, nil
+}
+
+// FromContext คืนค่า Claims ของผู้ใช้ที่ผ่าน Middleware แล้ว (nil หากไม่มี)
+func FromContext(c *fiber.Ctx) *Claims {
+	claims, _ := c.Locals("user").(*Claims)
+	return claims
+}
+
+// BearerToken ตัด "Bearer " ออกจาก Header Authorization
+func BearerToken(c *fiber.Ctx) string {
+	return strings.TrimSpace(strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "))
+}
+
+func sign(claims *Claims) (string, error) {
+	alg, kid, key := config.JWTSigningKey()
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(alg), claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	return token.SignedString(key)
+}
+
+func newTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
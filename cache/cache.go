@@ -0,0 +1,65 @@
+// Package cache คือ Cache ของข้อมูลหลักที่อ่านบ่อยแต่เปลี่ยนน้อย (เช่น tb_unit_type, tb_reference)
+// มีสองแบบที่ใช้แทนกันได้: LRU ในหน่วยความจำของแต่ละ instance และ Redis ที่ทุก instance ใช้ร่วมกัน
+// ค่าใน Cache เป็น JSON ([]byte) เพื่อให้ทั้งสองแบบเก็บข้อมูลรูปแบบเดียวกัน
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// Cache คือที่เก็บค่าตาม key พร้อมอายุ (ttl <= 0 = ไม่หมดอายุ)
+// error ของ Cache ไม่ควรทำให้ Request ล้ม ผู้เรียกจึงควรใช้ ReadThrough และ Invalidate ซึ่งบันทึก Log แล้วอ่านจากฐานข้อมูลแทน
+type Cache interface {
+	// Get คืนค่าของ key (ok = false หากไม่มีหรือหมดอายุแล้ว)
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set เก็บค่าของ key ไว้ ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete ลบ keys (key ที่ไม่มีอยู่ไม่ถือเป็น error)
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// ReadThrough คืนค่าของ key จาก c หากมี มิฉะนั้นเรียก load แล้วเก็บผลลัพธ์ไว้ ttl
+// error ของ load คืนให้ผู้เรียกโดยไม่เก็บลง Cache ส่วน error ของ Cache บันทึก Log แล้วใช้ผลจาก load แทน
+func ReadThrough[T any](ctx context.Context, c Cache, key string, ttl time.Duration, load func(ctx context.Context) (T, error)) (T, error) {
+	if raw, ok, err := c.Get(ctx, key); err != nil {
+		log.Printf("[WARN] Cache get %s failed: %v", key, err)
+	} else if ok {
+		var v T
+		if err := json.Unmarshal(raw, &v); err == nil {
+			return v, nil
+		}
+		log.Printf("[WARN] Cache entry %s is not valid JSON, reloading", key)
+	}
+
+	v, err := load(ctx)
+	if err != nil {
+		return v, err
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("[WARN] Cache encode %s failed: %v", key, err)
+		return v, nil
+	}
+	if err := c.Set(ctx, key, raw, ttl); err != nil {
+		log.Printf("[WARN] Cache set %s failed: %v", key, err)
+	}
+	return v, nil
+}
+
+// Invalidate ลบ keys ออกจาก c หลังข้อมูลถูกแก้ไข error บันทึกเป็น Log เท่านั้น
+// (ข้อมูลที่ลบไม่สำเร็จจะค้างไม่เกิน ttl ที่ตั้งไว้ตอน Set)
+func Invalidate(ctx context.Context, c Cache, keys ...string) {
+	if err := c.Delete(ctx, keys...); err != nil {
+		log.Printf("[ERROR] Cache invalidate %v failed: %v", keys, err)
+	}
+}
+
+// Nop คือ Cache ที่ไม่เก็บอะไรเลย (CACHE_DRIVER=none) ทุกการอ่านจึงไปที่ฐานข้อมูล
+type Nop struct{}
+
+func (Nop) Get(context.Context, string) ([]byte, bool, error)        { return nil, false, nil }
+func (Nop) Set(context.Context, string, []byte, time.Duration) error { return nil }
+func (Nop) Delete(context.Context, ...string) error                  { return nil }
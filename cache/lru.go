@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRU คือ Cache ในหน่วยความจำของ instance นี้ จำกัดจำนวน key (ลบ key ที่ไม่ได้ใช้นานที่สุดออกก่อน)
+// การล้าง Cache มีผลเฉพาะ instance ที่เขียนข้อมูล instance อื่นจะเห็นข้อมูลใหม่เมื่อครบ ttl
+// (ใช้ Redis หากรันหลาย instance และต้องการให้เห็นข้อมูลใหม่ทันที)
+type LRU struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // หน้าสุด = ใช้ล่าสุด
+	items map[string]*list.Element
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time // zero = ไม่หมดอายุ
+}
+
+// NewLRU สร้าง LRU ที่เก็บได้ไม่เกิน size key (size <= 0 ใช้ 1000)
+func NewLRU(size int) *LRU {
+	if size <= 0 {
+		size = 1000
+	}
+	return &LRU{size: size, order: list.New(), items: map[string]*list.Element{}}
+}
+
+func (l *LRU) Get(_ context.Context, key string) ([]byte, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	el, ok := l.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := el.Value.(*lruEntry)
+	if !e.expires.IsZero() && time.Now().After(e.expires) {
+		l.remove(el)
+		return nil, false, nil
+	}
+	l.order.MoveToFront(el)
+	return append([]byte(nil), e.value...), true, nil
+}
+
+func (l *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	e := &lruEntry{key: key, value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if el, ok := l.items[key]; ok {
+		el.Value = e
+		l.order.MoveToFront(el)
+		return nil
+	}
+	l.items[key] = l.order.PushFront(e)
+	for l.order.Len() > l.size {
+		l.remove(l.order.Back())
+	}
+	return nil
+}
+
+func (l *LRU) Delete(_ context.Context, keys ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		if el, ok := l.items[key]; ok {
+			l.remove(el)
+		}
+	}
+	return nil
+}
+
+// Len คืนจำนวน key ที่เก็บอยู่ (รวมที่หมดอายุแล้วแต่ยังไม่ถูกอ่าน)
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+// remove ลบ el ออก (เรียกขณะถือ l.mu)
+func (l *LRU) remove(el *list.Element) {
+	l.order.Remove(el)
+	delete(l.items, el.Value.(*lruEntry).key)
+}
@@ -0,0 +1,217 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RedisOptions คือการเชื่อมต่อ Redis (อ่านจาก .env ผ่าน config.ConnectCache)
+type RedisOptions struct {
+	Addr     string        // REDIS_ADDR เช่น 127.0.0.1:6379
+	Password string        // REDIS_PASSWORD (ว่าง = ไม่ต้อง AUTH)
+	DB       int           // REDIS_DB
+	Prefix   string        // REDIS_PREFIX นำหน้าทุก key เพื่อแยกจากระบบอื่นที่ใช้ Redis เดียวกัน
+	PoolSize int           // จำนวน Connection ที่เก็บไว้ใช้ซ้ำ (ค่าเริ่มต้น 10)
+	Timeout  time.Duration // เวลาสูงสุดของการเชื่อมต่อและคำสั่งแต่ละครั้งเมื่อ ctx ไม่มี deadline (ค่าเริ่มต้น 1s)
+}
+
+// Redis คือ Cache บน Redis ที่ทุก instance ใช้ร่วมกัน คุยกับ Server ด้วย RESP โดยตรง (GET, SET PX, DEL, PING)
+type Redis struct {
+	opts RedisOptions
+	pool chan *redisConn
+}
+
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// redisError คือ Error ที่ Server ตอบกลับ (-ERR ...) ซึ่ง Connection ยังใช้ต่อได้
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// NewRedis สร้าง Redis ตาม opts (ยังไม่เชื่อมต่อจนกว่าจะมีคำสั่งแรก ใช้ Ping เพื่อตรวจตอนเริ่มระบบ)
+func NewRedis(opts RedisOptions) *Redis {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+	return &Redis{opts: opts, pool: make(chan *redisConn, opts.PoolSize)}
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.do(ctx, "GET", r.opts.Prefix+key)
+	if err != nil || reply == nil {
+		return nil, false, err
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected GET reply %T", reply)
+	}
+	return value, true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", r.opts.Prefix + key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := r.do(ctx, args...)
+	return err
+}
+
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := []string{"DEL"}
+	for _, key := range keys {
+		args = append(args, r.opts.Prefix+key)
+	}
+	_, err := r.do(ctx, args...)
+	return err
+}
+
+// Ping ตรวจว่าเชื่อมต่อ Redis ได้ (รวม AUTH และ SELECT)
+func (r *Redis) Ping(ctx context.Context) error {
+	_, err := r.do(ctx, "PING")
+	return err
+}
+
+// Close ปิด Connection ที่เก็บไว้ทั้งหมด
+func (r *Redis) Close() error {
+	for {
+		select {
+		case c := <-r.pool:
+			c.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do ส่งคำสั่งหนึ่งคำสั่งแล้วอ่านคำตอบ Connection ที่เกิด Error ระดับเครือข่ายจะถูกปิดทิ้ง
+func (r *Redis) do(ctx context.Context, args ...string) (interface{}, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.opts.Timeout)
+		defer cancel()
+	}
+	c, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := c.do(ctx, args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		c.conn.Close()
+		return nil, err
+	}
+	r.put(c)
+	return reply, err
+}
+
+// conn คืน Connection จาก pool หรือเปิดใหม่ (พร้อม AUTH และ SELECT)
+func (r *Redis) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case c := <-r.pool:
+		return c, nil
+	default:
+	}
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", r.opts.Addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &redisConn{conn: nc, r: bufio.NewReader(nc)}
+	if r.opts.Password != "" {
+		if _, err := c.do(ctx, "AUTH", r.opts.Password); err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+	if r.opts.DB != 0 {
+		if _, err := c.do(ctx, "SELECT", strconv.Itoa(r.opts.DB)); err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// put คืน Connection เข้า pool (ปิดทิ้งหาก pool เต็ม)
+func (r *Redis) put(c *redisConn) {
+	select {
+	case r.pool <- c:
+	default:
+		c.conn.Close()
+	}
+}
+
+func (c *redisConn) do(ctx context.Context, args ...string) (interface{}, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetDeadline(deadline)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return readReply(c.r)
+}
+
+// readReply อ่านคำตอบ RESP หนึ่งค่า: string (+), redisError (-), int64 (:), []byte หรือ nil ($), []interface{} (*)
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply %q", line)
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"PenbunAPI/cache"
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// ConnectCache สร้าง Cache ของข้อมูลหลักตาม CACHE_DRIVER
+//
+//	memory (ค่าเริ่มต้น)  LRU ในหน่วยความจำ จำนวน key ไม่เกิน CACHE_SIZE
+//	redis               Redis ที่ REDIS_ADDR (REDIS_PASSWORD, REDIS_DB, REDIS_PREFIX) ใช้ร่วมกันทุก instance
+//	none                ไม่ใช้ Cache
+//
+// Redis ที่ Ping ไม่ผ่านตอนเริ่มระบบจะถูกใช้ต่อ เพราะ error ของ Cache ทำให้อ่านจากฐานข้อมูลแทนเท่านั้น
+func ConnectCache() cache.Cache {
+	switch driver := strings.ToLower(GetEnvDefault("CACHE_DRIVER", "memory")); driver {
+	case "none":
+		log.Println("Master data cache disabled")
+		return cache.Nop{}
+	case "redis":
+		r := cache.NewRedis(cache.RedisOptions{
+			Addr:     GetEnvDefault("REDIS_ADDR", "127.0.0.1:6379"),
+			Password: GetEnv("REDIS_PASSWORD"),
+			DB:       GetEnvInt("REDIS_DB", 0),
+			Prefix:   GetEnvDefault("REDIS_PREFIX", "penbun:"),
+			PoolSize: GetEnvInt("REDIS_POOL_SIZE", 10),
+			Timeout:  GetEnvDuration("REDIS_TIMEOUT", time.Second),
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := r.Ping(ctx); err != nil {
+			log.Printf("[WARN] Redis cache is not reachable, reading master data from database until it recovers: %v", err)
+		} else {
+			log.Println("Redis cache connected successfully")
+		}
+		return r
+	default:
+		if driver != "memory" {
+			log.Printf("[WARN] Unknown CACHE_DRIVER %q, using memory", driver)
+		}
+		size := GetEnvInt("CACHE_SIZE", 1000)
+		log.Printf("Master data cache in memory (max %d keys)", size)
+		return cache.NewLRU(size)
+	}
+}
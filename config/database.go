@@ -1,28 +1,215 @@
 package config
 
 import (
+	"PenbunAPI/dialect"
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
+	"time"
 
 	_ "github.com/denisenkom/go-mssqldb"
 )
 
-var DB *sql.DB
+// DatabaseOptions คือค่าตั้งค่า Connection Pool, TLS, Timeout และการ Retry ของ SQL Server (อ่านจาก .env)
+type DatabaseOptions struct {
+	MaxOpenConns    int           // DB_MAX_OPEN_CONNS (ค่าเริ่มต้น 200)
+	MaxIdleConns    int           // DB_MAX_IDLE_CONNS (ค่าเริ่มต้น 50)
+	ConnMaxLifetime time.Duration // DB_CONN_MAX_LIFETIME (ค่าเริ่มต้น 1h)
+	ConnMaxIdleTime time.Duration // DB_CONN_MAX_IDLE_TIME (ค่าเริ่มต้น 0 = ไม่จำกัด)
 
-func ConnectDatabase() {
-	connString := fmt.Sprintf("server=%s;port=%s;user id=%s;password=%s;database=%s;encrypt=disable",
-		GetEnv("DB_HOST"), GetEnv("DB_PORT"), GetEnv("DB_USER"), GetEnv("DB_PASSWORD"), GetEnv("DB_NAME"))
+	Encrypt                string // DB_ENCRYPT: true (ค่าเริ่มต้น เข้ารหัสทั้งหมด), false (เข้ารหัสเฉพาะ Login), disable (ใช้กับ SQL Server ในเครื่องเท่านั้น)
+	TrustServerCertificate bool   // DB_TRUST_SERVER_CERTIFICATE ไม่ตรวจ Certificate ของ Server (ใช้เฉพาะ Dev)
+	Certificate            string // DB_CERTIFICATE ไฟล์ CA สำหรับตรวจ Certificate ของ Server
+	HostNameInCertificate  string // DB_HOSTNAME_IN_CERTIFICATE ชื่อ Host ที่ต้องตรงกับ Certificate (ค่าเริ่มต้น DB_HOST)
 
-	var err error
-	DB, err = sql.Open("sqlserver", connString)
+	ConnectTimeout time.Duration // DB_CONNECT_TIMEOUT เวลาสูงสุดของการเชื่อมต่อและ Ping แต่ละครั้ง (ค่าเริ่มต้น 15s)
+	QueryTimeout   time.Duration // DB_QUERY_TIMEOUT เวลาสูงสุดที่รอ Server ตอบระหว่าง Query (ค่าเริ่มต้น 0 = ไม่จำกัด)
+
+	ConnectAttempts int           // DB_CONNECT_ATTEMPTS จำนวนครั้งที่พยายามเชื่อมต่อตอนเริ่มระบบ (ค่าเริ่มต้น 5, 0 = ไม่จำกัด)
+	RetryBackoff    time.Duration // DB_CONNECT_BACKOFF เวลารอก่อนลองใหม่ครั้งแรก และเพิ่มเป็นสองเท่าทุกครั้ง (ค่าเริ่มต้น 1s)
+	MaxRetryBackoff time.Duration // DB_CONNECT_MAX_BACKOFF เวลารอสูงสุดระหว่างการลองใหม่ (ค่าเริ่มต้น 30s)
+}
+
+// LoadDatabaseOptions อ่าน DatabaseOptions จาก Environment ค่าที่อ่านไม่ได้จะใช้ค่าเริ่มต้นพร้อมบันทึก Warning
+// ค่าเวลาใช้รูปแบบของ time.ParseDuration (เช่น 30s, 5m, 1h) หรือตัวเลขเป็นวินาที
+func LoadDatabaseOptions() DatabaseOptions {
+	return DatabaseOptions{
+		MaxOpenConns:    GetEnvInt("DB_MAX_OPEN_CONNS", 200),
+		MaxIdleConns:    GetEnvInt("DB_MAX_IDLE_CONNS", 50),
+		ConnMaxLifetime: GetEnvDuration("DB_CONN_MAX_LIFETIME", time.Hour),
+		ConnMaxIdleTime: GetEnvDuration("DB_CONN_MAX_IDLE_TIME", 0),
+
+		Encrypt:                GetEnvDefault("DB_ENCRYPT", "true"),
+		TrustServerCertificate: GetEnvBool("DB_TRUST_SERVER_CERTIFICATE", false),
+		Certificate:            GetEnv("DB_CERTIFICATE"),
+		HostNameInCertificate:  GetEnv("DB_HOSTNAME_IN_CERTIFICATE"),
+
+		ConnectTimeout: GetEnvDuration("DB_CONNECT_TIMEOUT", 15*time.Second),
+		QueryTimeout:   GetEnvDuration("DB_QUERY_TIMEOUT", 0),
+
+		ConnectAttempts: GetEnvInt("DB_CONNECT_ATTEMPTS", 5),
+		RetryBackoff:    GetEnvDuration("DB_CONNECT_BACKOFF", time.Second),
+		MaxRetryBackoff: GetEnvDuration("DB_CONNECT_MAX_BACKOFF", 30*time.Second),
+	}
+}
+
+// ConnectDatabase เปิดการเชื่อมต่อฐานข้อมูลตามค่าใน .env
+// DB_DRIVER=sqlite ใช้ไฟล์ SQLite ที่ DB_PATH แทน SQL Server (สำหรับทดสอบและเดโม)
+// SQL Server ใช้ค่าจาก LoadDatabaseOptions และลองเชื่อมต่อใหม่แบบ Backoff ก่อนยอมหยุดระบบ
+// main นำ *sql.DB ที่ได้ไปสร้าง container.Container ซึ่งส่งต่อให้ทุก Handler
+func ConnectDatabase() *sql.DB {
+	if strings.EqualFold(GetEnv("DB_DRIVER"), "sqlite") {
+		path := GetEnv("DB_PATH")
+		if path == "" {
+			path = "penbun.db"
+		}
+		db, err := dialect.OpenSQLite(path)
+		if err != nil {
+			log.Fatalf("Failed to open SQLite database: %v", err)
+		}
+		log.Println("SQLite database opened:", path)
+		return db
+	}
+
+	opts := LoadDatabaseOptions()
+	db, err := sql.Open("sqlserver", sqlServerConnString(primaryServer(), opts))
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	db.SetMaxIdleConns(opts.MaxIdleConns)
+	db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
 
-	err = DB.Ping()
-	if err != nil {
+	if err := pingWithRetry(db, opts); err != nil {
 		log.Fatalf("Failed to ping database: %v", err)
 	}
-	log.Println("Database connected successfully")
+	log.Printf("Database connected successfully (encrypt=%s, max open=%d, max idle=%d, max lifetime=%s)",
+		opts.Encrypt, opts.MaxOpenConns, opts.MaxIdleConns, opts.ConnMaxLifetime)
+	return db
+}
+
+// ConnectReadReplica เปิดการเชื่อมต่อ Read Replica ตาม DB_READ_HOST (คืน nil หากไม่ได้ตั้งค่า หรือใช้ SQLite)
+// ใช้ TLS และ Timeout ชุดเดียวกับฐานข้อมูลหลัก และไม่หยุดระบบหาก Replica ยังไม่พร้อมตอนเริ่ม
+// เพราะ replica.Router จะอ่านจากฐานข้อมูลหลักแทนจนกว่า Replica จะกลับมา
+func ConnectReadReplica() *sql.DB {
+	server, ok := replicaServer()
+	if !ok || strings.EqualFold(GetEnv("DB_DRIVER"), "sqlite") {
+		return nil
+	}
+
+	opts := LoadDatabaseOptions()
+	db, err := sql.Open("sqlserver", sqlServerConnString(server, opts))
+	if err != nil {
+		log.Fatalf("Failed to open read replica: %v", err)
+	}
+	db.SetMaxOpenConns(GetEnvInt("DB_READ_MAX_OPEN_CONNS", opts.MaxOpenConns))
+	db.SetMaxIdleConns(GetEnvInt("DB_READ_MAX_IDLE_CONNS", opts.MaxIdleConns))
+	db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.ConnectTimeout)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		log.Printf("[WARN] Read replica %s is not reachable, reading from primary until it recovers: %v", server.host, err)
+	} else {
+		log.Printf("Read replica connected successfully (%s)", server.host)
+	}
+	return db
+}
+
+// sqlServer คือ Server และบัญชีที่ใช้เชื่อมต่อ (ฐานข้อมูลหลักหรือ Read Replica)
+type sqlServer struct {
+	host, port, user, password, database string
+	readOnly                             bool // ApplicationIntent=ReadOnly สำหรับ Listener ของ Availability Group
+}
+
+// primaryServer คือฐานข้อมูลหลักจาก DB_HOST, DB_PORT, DB_USER, DB_PASSWORD และ DB_NAME
+func primaryServer() sqlServer {
+	return sqlServer{
+		host:     GetEnv("DB_HOST"),
+		port:     GetEnv("DB_PORT"),
+		user:     GetEnv("DB_USER"),
+		password: GetEnv("DB_PASSWORD"),
+		database: GetEnv("DB_NAME"),
+	}
+}
+
+// replicaServer คือ Read Replica จาก DB_READ_HOST (ค่า DB_READ_* ที่ไม่ได้ตั้งใช้ค่าเดียวกับฐานข้อมูลหลัก)
+func replicaServer() (sqlServer, bool) {
+	primary := primaryServer()
+	host := GetEnv("DB_READ_HOST")
+	if host == "" {
+		return primary, false
+	}
+	return sqlServer{
+		host:     host,
+		port:     GetEnvDefault("DB_READ_PORT", primary.port),
+		user:     GetEnvDefault("DB_READ_USER", primary.user),
+		password: GetEnvDefault("DB_READ_PASSWORD", primary.password),
+		database: GetEnvDefault("DB_READ_NAME", primary.database),
+		readOnly: GetEnvBool("DB_READ_INTENT_READONLY", false),
+	}, true
+}
+
+// sqlServerConnString สร้าง Connection String ของ go-mssqldb ไปยัง server ตาม opts
+// dial timeout คือเวลาเปิด Socket ส่วน connection timeout คือเวลาที่รอ Server ตอบในการอ่าน/เขียนแต่ละครั้ง
+func sqlServerConnString(server sqlServer, opts DatabaseOptions) string {
+	params := []string{
+		"server=" + server.host,
+		"port=" + server.port,
+		"user id=" + server.user,
+		"password=" + server.password,
+		"database=" + server.database,
+		"encrypt=" + opts.Encrypt,
+		"dial timeout=" + strconv.Itoa(seconds(opts.ConnectTimeout)),
+		"connection timeout=" + strconv.Itoa(seconds(opts.QueryTimeout)),
+	}
+	if server.readOnly {
+		params = append(params, "ApplicationIntent=ReadOnly")
+	}
+	if !strings.EqualFold(opts.Encrypt, "disable") {
+		params = append(params, "TrustServerCertificate="+strconv.FormatBool(opts.TrustServerCertificate))
+		if opts.Certificate != "" {
+			params = append(params, "certificate="+opts.Certificate)
+		}
+		if opts.HostNameInCertificate != "" {
+			params = append(params, "hostNameInCertificate="+opts.HostNameInCertificate)
+		}
+	}
+	return strings.Join(params, ";")
+}
+
+// pingWithRetry Ping ฐานข้อมูลจนสำเร็จ โดยรอเพิ่มเป็นสองเท่าทุกครั้งที่ล้มเหลว (ไม่เกิน MaxRetryBackoff)
+// คืน error ของครั้งสุดท้ายเมื่อครบ ConnectAttempts
+func pingWithRetry(db *sql.DB, opts DatabaseOptions) error {
+	wait := opts.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), opts.ConnectTimeout)
+		err := db.PingContext(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if opts.ConnectAttempts > 0 && attempt >= opts.ConnectAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+		log.Printf("[WARN] Database not reachable (attempt %d): %v, retrying in %s", attempt, err, wait)
+		time.Sleep(wait)
+		wait *= 2
+		if wait > opts.MaxRetryBackoff {
+			wait = opts.MaxRetryBackoff
+		}
+	}
+}
+
+// seconds ปัดเวลาขึ้นเป็นวินาที (go-mssqldb รับ Timeout เป็นวินาทีเต็ม)
+func seconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
 }
@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -17,3 +19,57 @@ func LoadEnv() {
 func GetEnv(key string) string {
 	return os.Getenv(key)
 }
+
+// GetEnvDefault คืนค่าของ key หรือ fallback หากไม่ได้ตั้งค่า
+func GetEnvDefault(key, fallback string) string {
+	if v := GetEnv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// GetEnvInt คืนค่าของ key เป็นจำนวนเต็มที่ไม่ติดลบ หรือ fallback หากไม่ได้ตั้งค่าหรืออ่านไม่ได้
+func GetEnvInt(key string, fallback int) int {
+	v := GetEnv(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		log.Printf("[WARN] Invalid %s=%q, using %d", key, v, fallback)
+		return fallback
+	}
+	return n
+}
+
+// GetEnvBool คืนค่าของ key เป็น bool (true/false/1/0) หรือ fallback หากไม่ได้ตั้งค่าหรืออ่านไม่ได้
+func GetEnvBool(key string, fallback bool) bool {
+	v := GetEnv(key)
+	if v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("[WARN] Invalid %s=%q, using %t", key, v, fallback)
+		return fallback
+	}
+	return b
+}
+
+// GetEnvDuration คืนค่าของ key เป็นช่วงเวลา (เช่น 30s, 5m, 1h หรือตัวเลขเป็นวินาที)
+// หรือ fallback หากไม่ได้ตั้งค่าหรืออ่านไม่ได้
+func GetEnvDuration(key string, fallback time.Duration) time.Duration {
+	v := GetEnv(key)
+	if v == "" {
+		return fallback
+	}
+	if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+		return time.Duration(n) * time.Second
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		log.Printf("[WARN] Invalid %s=%q, using %s", key, v, fallback)
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,300 @@
+package config
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+)
+
+// JWTKey คือ Public Key ที่ใช้ตรวจสอบ JWT พร้อม kid และ alg ที่ผูกกับ key นั้น
+type JWTKey struct {
+	Kid    string
+	Alg    string
+	Public crypto.PublicKey
+}
+
+// jwtKeySet เก็บ key สำหรับเซ็น Token (ตัวเดียว) และ key สำหรับตรวจสอบ (หลายตัวเพื่อรองรับ Key Rotation)
+type jwtKeySet struct {
+	alg        string
+	signingKid string
+	signingKey interface{}
+	verify     map[string]JWTKey
+}
+
+var (
+	jwtKeys     *jwtKeySet
+	jwtKeysOnce sync.Once
+	jwtKeysErr  error
+)
+
+// LoadJWTKeys โหลด key ตาม Environment:
+//
+//	JWT_ALG                HS256 (ค่าเริ่มต้น), RS256 หรือ ES256
+//	JWT_SECRET             secret สำหรับ HS256
+//	JWT_PRIVATE_KEY_FILE   private key (PEM) สำหรับเซ็น Token เมื่อใช้ RS256/ES256
+//	JWT_PUBLIC_KEY_FILES   public key หรือ certificate (PEM) เพิ่มเติมที่ยังใช้ตรวจสอบได้ คั่นด้วย comma
+func LoadJWTKeys() error {
+	jwtKeysOnce.Do(func() {
+		jwtKeys, jwtKeysErr = loadJWTKeySet()
+		if jwtKeysErr == nil {
+			log.Printf("JWT keys loaded (alg=%s, verification keys=%d)", jwtKeys.alg, len(jwtKeys.verify))
+		}
+	})
+	return jwtKeysErr
+}
+
+func loadJWTKeySet() (*jwtKeySet, error) {
+	alg := strings.ToUpper(strings.TrimSpace(GetEnv("JWT_ALG")))
+	if alg == "" {
+		alg = "HS256"
+	}
+	set := &jwtKeySet{alg: alg, verify: map[string]JWTKey{}}
+
+	switch alg {
+	case "HS256":
+		secret := GetEnv("JWT_SECRET")
+		if secret == "" {
+			return nil, errors.New("JWT_SECRET is required for HS256")
+		}
+		set.signingKey = []byte(secret)
+		return set, nil
+	case "RS256", "ES256":
+	default:
+		return nil, fmt.Errorf("unsupported JWT_ALG %q", alg)
+	}
+
+	privateFile := GetEnv("JWT_PRIVATE_KEY_FILE")
+	if privateFile == "" {
+		return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE is required for %s", alg)
+	}
+	private, err := readPrivateKey(privateFile)
+	if err != nil {
+		return nil, err
+	}
+	public := private.(crypto.Signer).Public()
+	if keyAlg(public) != alg {
+		return nil, fmt.Errorf("%s does not contain a %s key", privateFile, alg)
+	}
+	kid, err := keyThumbprint(public)
+	if err != nil {
+		return nil, err
+	}
+	set.signingKid = kid
+	set.signingKey = private
+	set.verify[kid] = JWTKey{Kid: kid, Alg: alg, Public: public}
+
+	for _, file := range strings.Split(GetEnv("JWT_PUBLIC_KEY_FILES"), ",") {
+		if file = strings.TrimSpace(file); file == "" {
+			continue
+		}
+		pub, err := readPublicKey(file)
+		if err != nil {
+			return nil, err
+		}
+		kid, err := keyThumbprint(pub)
+		if err != nil {
+			return nil, err
+		}
+		set.verify[kid] = JWTKey{Kid: kid, Alg: keyAlg(pub), Public: pub}
+	}
+	return set, nil
+}
+
+func mustJWTKeys() *jwtKeySet {
+	if err := LoadJWTKeys(); err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+	return jwtKeys
+}
+
+// JWTSigningKey คืนค่า alg, kid และ key ที่ใช้เซ็น Token ใหม่ (kid ว่างเมื่อใช้ HS256)
+func JWTSigningKey() (alg string, kid string, key interface{}) {
+	set := mustJWTKeys()
+	return set.alg, set.signingKid, set.signingKey
+}
+
+// JWTAlgorithms คืนค่ารายการ alg ที่ยอมรับตอนตรวจสอบ Token
+func JWTAlgorithms() []string {
+	set := mustJWTKeys()
+	if set.alg == "HS256" {
+		return []string{"HS256"}
+	}
+	seen := map[string]bool{}
+	var algs []string
+	for _, k := range set.verify {
+		if !seen[k.Alg] {
+			seen[k.Alg] = true
+			algs = append(algs, k.Alg)
+		}
+	}
+	return algs
+}
+
+// JWTVerificationKey เลือก key สำหรับตรวจสอบ Token จาก Header (alg, kid)
+// Token ต้องใช้ alg ตรงกับ key ที่ระบบรู้จักเท่านั้น เพื่อป้องกันการสลับ algorithm
+func JWTVerificationKey(header map[string]interface{}) (interface{}, error) {
+	set := mustJWTKeys()
+	alg, _ := header["alg"].(string)
+	kid, _ := header["kid"].(string)
+
+	if set.alg == "HS256" {
+		if alg != "HS256" {
+			return nil, fmt.Errorf("unexpected signing method %q", alg)
+		}
+		return set.signingKey, nil
+	}
+
+	if kid == "" {
+		kid = set.signingKid
+	}
+	key, ok := set.verify[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if alg != key.Alg {
+		return nil, fmt.Errorf("unexpected signing method %q for key %q", alg, kid)
+	}
+	return key.Public, nil
+}
+
+// JWKS คืนค่า JSON Web Key Set ของ public key ทั้งหมดที่ยังใช้ตรวจสอบได้ (ว่างเมื่อใช้ HS256)
+func JWKS() map[string]interface{} {
+	set := mustJWTKeys()
+	keys := []map[string]string{}
+	for _, k := range set.verify {
+		jwk, err := publicJWK(k.Public)
+		if err != nil {
+			continue
+		}
+		jwk["kid"] = k.Kid
+		jwk["alg"] = k.Alg
+		jwk["use"] = "sig"
+		keys = append(keys, jwk)
+	}
+	return map[string]interface{}{"keys": keys}
+}
+
+func readPEMBlocks(file string) ([]*pem.Block, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", file, err)
+	}
+	var blocks []*pem.Block
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, block)
+	}
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("%s contains no PEM data", file)
+	}
+	return blocks, nil
+}
+
+func readPrivateKey(file string) (crypto.PrivateKey, error) {
+	blocks, err := readPEMBlocks(file)
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range blocks {
+		switch block.Type {
+		case "PRIVATE KEY":
+			return x509.ParsePKCS8PrivateKey(block.Bytes)
+		case "RSA PRIVATE KEY":
+			return x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			return x509.ParseECPrivateKey(block.Bytes)
+		}
+	}
+	return nil, fmt.Errorf("%s contains no private key", file)
+}
+
+func readPublicKey(file string) (crypto.PublicKey, error) {
+	blocks, err := readPEMBlocks(file)
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range blocks {
+		switch block.Type {
+		case "PUBLIC KEY":
+			return x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			return x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			return cert.PublicKey, nil
+		}
+	}
+	return nil, fmt.Errorf("%s contains no public key or certificate", file)
+}
+
+// keyAlg คืนค่า JWT alg ที่เหมาะกับชนิดของ key
+func keyAlg(pub crypto.PublicKey) string {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return "RS256"
+	case *ecdsa.PublicKey:
+		if k.Curve == elliptic.P256() {
+			return "ES256"
+		}
+	}
+	return ""
+}
+
+func publicJWK(pub crypto.PublicKey) (map[string]string, error) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return map[string]string{
+			"kty": "RSA",
+			"n":   b64(k.N.Bytes()),
+			"e":   b64(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return nil, errors.New("only P-256 EC keys are supported")
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		return map[string]string{
+			"kty": "EC",
+			"crv": "P-256",
+			"x":   b64(k.X.FillBytes(make([]byte, size))),
+			"y":   b64(k.Y.FillBytes(make([]byte, size))),
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported public key type %T", pub)
+}
+
+// keyThumbprint คำนวณ kid ตาม RFC 7638 (SHA-256 ของ JWK ที่เรียง member ตามตัวอักษร)
+func keyThumbprint(pub crypto.PublicKey) (string, error) {
+	jwk, err := publicJWK(pub)
+	if err != nil {
+		return "", err
+	}
+	// json.Marshal ของ map เรียง key ตามตัวอักษรอยู่แล้ว ตรงตาม RFC 7638
+	data, err := json.Marshal(jwk)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
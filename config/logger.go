@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 
 	"github.com/sirupsen/logrus"
 )
@@ -19,6 +20,11 @@ func InitLogger() {
 		logFile = "logs/transaction.log" // ค่าเริ่มต้น
 	}
 
+	// logs/ ไม่ได้อยู่ใน repository จึงสร้างโฟลเดอร์ให้ก่อนเปิดไฟล์
+	if err := os.MkdirAll(filepath.Dir(logFile), 0755); err != nil {
+		Logger.Fatal("Failed to create log directory: ", err)
+	}
+
 	file, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
 	if err != nil {
 		Logger.Fatal("Failed to open log file: ", err)
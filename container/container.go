@@ -0,0 +1,123 @@
+// Package container ประกอบ Dependency ของทั้งระบบ (ฐานข้อมูล, Logger, ค่าตั้งค่า และ Service)
+// main สร้าง Container ครั้งเดียวแล้วส่งผ่านการลงทะเบียน Route ไปยัง Constructor ของ Handler
+// แทนการให้ Handler อ่านตัวแปร global หรือ c.Locals เอง
+package container
+
+import (
+	"PenbunAPI/auth"
+	"PenbunAPI/cache"
+	"PenbunAPI/config"
+	"PenbunAPI/replica"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Config คือค่าตั้งค่าที่ Handler ใช้ อ่านจาก Environment ครั้งเดียวตอนเริ่มระบบ
+type Config struct {
+	Port      string // FIBER_PORT (ค่าเริ่มต้น 8089)
+	MFAIssuer string // MFA_ISSUER ชื่อที่แสดงในแอป Authenticator (ค่าเริ่มต้น PenbunAPI)
+
+	// RequestTimeout คืองบเวลาของทุก Request (REQUEST_TIMEOUT ค่าเริ่มต้น 30s, 0 = ไม่จำกัด)
+	RequestTimeout time.Duration
+	// GroupTimeouts คืองบเวลาเฉพาะ Group จาก REQUEST_TIMEOUT_<GROUP> เช่น REQUEST_TIMEOUT_RECEIVE=60s
+	// key เป็นตัวพิมพ์เล็กตามชื่อ module (public, admin, product, receive, ...)
+	GroupTimeouts map[string]time.Duration
+	// CacheTTL คืออายุของข้อมูลหลักใน Cache (CACHE_TTL ค่าเริ่มต้น 5m)
+	CacheTTL time.Duration
+	// MFARateLimit คือจำนวน Request ต่อนาทีต่อ IP ของ /login/mfa (MFA_RATE_LIMIT ค่าเริ่มต้น 10, 0 = ไม่จำกัด)
+	MFARateLimit int
+}
+
+// requestTimeoutPrefix คือชื่อ Environment ของงบเวลาเฉพาะ Group
+const requestTimeoutPrefix = "REQUEST_TIMEOUT_"
+
+// LoadConfig อ่าน Config จาก Environment
+func LoadConfig() Config {
+	cfg := Config{
+		Port:           config.GetEnv("FIBER_PORT"),
+		MFAIssuer:      config.GetEnv("MFA_ISSUER"),
+		RequestTimeout: config.GetEnvDuration("REQUEST_TIMEOUT", 30*time.Second),
+		GroupTimeouts:  map[string]time.Duration{},
+		CacheTTL:       config.GetEnvDuration("CACHE_TTL", 5*time.Minute),
+		MFARateLimit:   config.GetEnvInt("MFA_RATE_LIMIT", 10),
+	}
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		if group, ok := strings.CutPrefix(key, requestTimeoutPrefix); ok && group != "" {
+			cfg.GroupTimeouts[strings.ToLower(group)] = config.GetEnvDuration(key, cfg.RequestTimeout)
+		}
+	}
+	if cfg.Port == "" {
+		cfg.Port = "8089"
+	}
+	if cfg.MFAIssuer == "" {
+		cfg.MFAIssuer = "PenbunAPI"
+	}
+	return cfg
+}
+
+// Timeout คืองบเวลาของ group (REQUEST_TIMEOUT_<GROUP>) หรือ RequestTimeout หากไม่ได้กำหนด
+func (c Config) Timeout(group string) time.Duration {
+	if d, ok := c.GroupTimeouts[strings.ToLower(group)]; ok {
+		return d
+	}
+	return c.RequestTimeout
+}
+
+// Container คือ Dependency ที่ Route และ Handler ทุก Module ใช้ร่วมกัน
+type Container struct {
+	DB       *sql.DB         // ฐานข้อมูลหลัก (Primary)
+	Reads    *replica.Router // ฐานข้อมูลสำหรับ Select ของ Repository (Primary หากไม่ได้เรียก UseReadReplica)
+	Cache    cache.Cache     // Cache ของข้อมูลหลัก (LRU ในหน่วยความจำหากไม่ได้ตั้งค่า config.ConnectCache)
+	Logger   *logrus.Logger
+	Config   Config
+	Sessions *auth.Sessions
+}
+
+// New สร้าง Container จากฐานข้อมูลและ Logger ที่เปิดไว้แล้ว พร้อมสร้าง Service ที่ใช้ฐานข้อมูลนั้น
+func New(db *sql.DB, logger *logrus.Logger, cfg Config) *Container {
+	return &Container{
+		DB:       db,
+		Reads:    replica.New(db, nil),
+		Cache:    cache.NewLRU(0),
+		Logger:   logger,
+		Config:   cfg,
+		Sessions: auth.NewSessions(db),
+	}
+}
+
+// UseReadReplica ให้ Select ของ Repository อ่านจาก read (Read Replica) และตรวจสถานะของ read ทุก interval
+// ต้องเรียกก่อนลงทะเบียน Route เพราะ Repository ถูกสร้างตอนลงทะเบียน
+func (c *Container) UseReadReplica(read *sql.DB, interval time.Duration) {
+	c.Reads = replica.New(c.DB, read)
+	c.Reads.Watch(interval)
+}
+
+// Check คืน error หาก Dependency ใดยังไม่ได้ตั้งค่า (ใช้ก่อนลงทะเบียน Route)
+func (c *Container) Check() error {
+	var missing []string
+	if c.DB == nil {
+		missing = append(missing, "DB")
+	}
+	if c.Reads == nil {
+		missing = append(missing, "Reads")
+	}
+	if c.Cache == nil {
+		missing = append(missing, "Cache")
+	}
+	if c.Logger == nil {
+		missing = append(missing, "Logger")
+	}
+	if c.Sessions == nil {
+		missing = append(missing, "Sessions")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("container: missing %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"PenbunAPI/dialect"
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// dbPingTimeout คือเวลาสูงสุดของการ Ping ใน DatabaseStats
+const dbPingTimeout = 2 * time.Second
+
+// AdminHandler คือ Handler สำหรับผู้ดูแลระบบ (role admin) เช่นสถานะของฐานข้อมูล
+type AdminHandler struct {
+	dbs *replica.Router
+}
+
+// NewAdminHandler สร้าง AdminHandler
+func NewAdminHandler(dbs *replica.Router) *AdminHandler {
+	return &AdminHandler{dbs: dbs}
+}
+
+// DatabaseStats - สถานะ Connection Pool (open, in use, idle, wait count/duration) และผลการ Ping ฐานข้อมูล
+// พร้อมสถานะของ Read Replica ใน replica (หากตั้งค่า DB_READ_HOST)
+// ตอบ 503 พร้อมข้อมูลเดียวกันหาก Ping ฐานข้อมูลหลักไม่สำเร็จ เพื่อให้ระบบ Monitor แจ้งเตือนได้
+func (h *AdminHandler) DatabaseStats(c *fiber.Ctx) error {
+	stats := databaseStats(c.UserContext(), h.dbs.Primary())
+	if read := h.dbs.Replica(); read != nil {
+		rs := databaseStats(c.UserContext(), read)
+		stats.Replica = &rs
+	}
+
+	if !stats.Reachable {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "Database is not reachable",
+			Data:    stats,
+		})
+	}
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "Database stats retrieved successfully",
+		Data:    stats,
+	})
+}
+
+// databaseStats Ping db (ไม่เกิน dbPingTimeout) แล้วอ่านสถานะ Connection Pool
+func databaseStats(ctx context.Context, db *sql.DB) models.DatabaseStats {
+	ctx, cancel := context.WithTimeout(ctx, dbPingTimeout)
+	defer cancel()
+	start := time.Now()
+	pingErr := db.PingContext(ctx)
+
+	s := db.Stats()
+	return models.DatabaseStats{
+		Driver:             dialect.Of(db).Name(),
+		Reachable:          pingErr == nil,
+		PingMS:             time.Since(start).Milliseconds(),
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDurationMS:     s.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+	}
+}
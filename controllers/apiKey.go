@@ -0,0 +1,395 @@
+package controllers
+
+import (
+	"PenbunAPI/dialect"
+	"PenbunAPI/models"
+	"PenbunAPI/utils"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ApiKeyHandler คือ Handler ของ API Key (tb_api_key) สำหรับผู้ดูแลระบบ
+type ApiKeyHandler struct {
+	db      *sql.DB
+	dialect dialect.Dialect
+}
+
+// NewApiKeyHandler สร้าง ApiKeyHandler บนฐานข้อมูล db
+func NewApiKeyHandler(db *sql.DB) *ApiKeyHandler {
+	return &ApiKeyHandler{db: db, dialect: dialect.Of(db)}
+}
+
+const apiKeyColumns = `
+	api_key_id, key_name, key_prefix, scopes, expire_date, last_used_date, revoke_date,
+	description, update_by, update_date, is_active
+`
+
+// scanApiKey อ่านข้อมูล API Key หนึ่งแถวตามลำดับของ apiKeyColumns
+func scanApiKey(scanner interface{ Scan(...any) error }) (models.ApiKey, error) {
+	var item models.ApiKey
+	var scopes string
+	var expire, lastUsed, revoke, upd sql.NullTime
+	if err := scanner.Scan(&item.ApiKeyID, &item.KeyName, &item.KeyPrefix, &scopes, &expire, &lastUsed, &revoke,
+		&item.Description, &item.UpdateBy, &upd, &item.IsActive); err != nil {
+		return item, err
+	}
+	item.Scopes = utils.SplitScopes(scopes)
+	for _, f := range []struct {
+		src sql.NullTime
+		dst **string
+	}{{expire, &item.ExpireDate}, {lastUsed, &item.LastUsedDate}, {revoke, &item.RevokeDate}, {upd, &item.UpdateDate}} {
+		if f.src.Valid {
+			t := utils.FormatDate(f.src.Time)
+			*f.dst = &t
+		}
+	}
+	return item, nil
+}
+
+// parseExpireDate แปลง expire_date จาก Request (RFC3339 หรือ YYYY-MM-DD)
+func parseExpireDate(value *string) (*time.Time, error) {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, *value, time.Local); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fiber.NewError(fiber.StatusBadRequest, "expire_date must be YYYY-MM-DD or RFC3339")
+}
+
+func (h *ApiKeyHandler) SelectAllApiKeys(c *fiber.Ctx) error {
+	rows, err := h.db.QueryContext(c.UserContext(), `SELECT `+apiKeyColumns+` FROM tb_api_key WHERE is_delete = 0`)
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch API keys")
+	}
+	defer rows.Close()
+
+	var list []models.ApiKey
+	for rows.Next() {
+		item, err := scanApiKey(rows)
+		if err != nil {
+			return utils.Internal(err, "Failed to read data")
+		}
+		list = append(list, item)
+	}
+
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "",
+		Data:    list,
+	})
+}
+
+func (h *ApiKeyHandler) SelectApiKeyByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+	row := h.db.QueryRowContext(c.UserContext(), `SELECT `+apiKeyColumns+` FROM tb_api_key WHERE api_key_id = @ID AND is_delete = 0`, sql.Named("ID", id))
+
+	item, err := scanApiKey(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(models.ApiResponse{
+				Status:  "error",
+				Message: "API key not found",
+				Data:    nil,
+			})
+		}
+		return utils.Internal(err, "Failed to read data")
+	}
+
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "",
+		Data:    item,
+	})
+}
+
+// InsertApiKey สร้าง API Key ใหม่ และคืนค่า key จริงเพียงครั้งเดียว (ฐานข้อมูลเก็บเฉพาะ hash)
+func (h *ApiKeyHandler) InsertApiKey(c *fiber.Ctx) error {
+	var item models.ApiKey
+	if err := utils.Bind(c, &item); err != nil {
+		return err
+	}
+
+	scopes := utils.JoinScopes(item.Scopes)
+	if scopes == "" {
+		return c.Status(400).JSON(models.ApiResponse{Status: "fail", Message: "scopes is required"})
+	}
+	expire, err := parseExpireDate(item.ExpireDate)
+	if err != nil {
+		return c.Status(400).JSON(models.ApiResponse{Status: "fail", Message: err.Error()})
+	}
+
+	plain, display, hash, err := utils.GenerateAPIKey()
+	if err != nil {
+		return utils.Internal(err, "Failed to generate API key")
+	}
+	username := utils.ResolveUser(c)
+
+	query := `
+		INSERT INTO tb_api_key (key_name, key_prefix, key_hash, scopes, expire_date, description, update_by)
+		VALUES (@KeyName, @KeyPrefix, @KeyHash, @Scopes, @ExpireDate, @Description, @UpdateBy)
+	`
+	var id string
+	err = utils.ExecuteTransaction(c.UserContext(), h.db, []func(tx *sql.Tx) error{
+		func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(c.UserContext(), query,
+				sql.Named("KeyName", item.KeyName),
+				sql.Named("KeyPrefix", display),
+				sql.Named("KeyHash", hash),
+				sql.Named("Scopes", scopes),
+				sql.Named("ExpireDate", expire),
+				sql.Named("Description", item.Description),
+				sql.Named("UpdateBy", username),
+			)
+			return err
+		},
+		// api_key_id มาจาก DEFAULT ของตาราง จึงอ่านกลับด้วย key_hash (UNIQUE) ภายใน Transaction เดียวกัน
+		func(tx *sql.Tx) error {
+			return tx.QueryRowContext(c.UserContext(), `SELECT api_key_id FROM tb_api_key WHERE key_hash = @KeyHash`,
+				sql.Named("KeyHash", hash)).Scan(&id)
+		},
+	})
+	if err != nil {
+		return utils.Internal(err, "Failed to insert API key")
+	}
+	utils.SetCreatedID(c, id)
+
+	return c.Status(201).JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "API key created successfully. Store it now, it will not be shown again",
+		Data: fiber.Map{
+			"api_key_id": id,
+			"key_name":   item.KeyName,
+			"key_prefix": display,
+			"api_key":    plain,
+			"scopes":     utils.SplitScopes(scopes),
+		},
+	})
+}
+
+// UpdateApiKeyByID แก้ไขชื่อ, scopes, วันหมดอายุ หรือสถานะของ API Key (ไม่สามารถเปลี่ยนค่า key ได้)
+func (h *ApiKeyHandler) UpdateApiKeyByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var item models.ApiKey
+	if err := utils.BindPartial(c, &item); err != nil {
+		return err
+	}
+
+	expire, err := parseExpireDate(item.ExpireDate)
+	if err != nil {
+		return c.Status(400).JSON(models.ApiResponse{Status: "fail", Message: err.Error()})
+	}
+	var scopes *string
+	if item.Scopes != nil {
+		s := utils.JoinScopes(item.Scopes)
+		if s == "" {
+			return c.Status(400).JSON(models.ApiResponse{Status: "fail", Message: "scopes cannot be empty"})
+		}
+		scopes = &s
+	}
+	username := utils.ResolveUser(c)
+
+	query := `
+		UPDATE tb_api_key
+		SET key_name = COALESCE(NULLIF(@KeyName, ''), key_name),
+			scopes = COALESCE(@Scopes, scopes),
+			expire_date = COALESCE(@ExpireDate, expire_date),
+			description = COALESCE(@Description, description),
+			update_by = @UpdateBy,
+			is_active = COALESCE(@IsActive, is_active)
+		WHERE api_key_id = @ID AND is_delete = 0 AND revoke_date IS NULL
+	`
+	err = utils.ExecuteTransaction(c.UserContext(), h.db, []func(tx *sql.Tx) error{
+		func(tx *sql.Tx) error {
+			return utils.CheckRowVersion(c.UserContext(), tx, h.dialect, "tb_api_key", "api_key_id", id)
+		},
+		func(tx *sql.Tx) error {
+			res, err := tx.ExecContext(c.UserContext(), query,
+				sql.Named("KeyName", item.KeyName),
+				sql.Named("Scopes", scopes),
+				sql.Named("ExpireDate", expire),
+				sql.Named("Description", item.Description),
+				sql.Named("UpdateBy", username),
+				sql.Named("IsActive", item.IsActive),
+				sql.Named("ID", id),
+			)
+			if err != nil {
+				return err
+			}
+			rows, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if rows == 0 {
+				return sql.ErrNoRows
+			}
+			return nil
+		},
+		func(tx *sql.Tx) error {
+			return h.dialect.Touch(c.UserContext(), tx, "tb_api_key", "api_key_id", id)
+		},
+	})
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "API key not found or already revoked",
+			Data:    nil,
+		})
+	}
+	if err != nil {
+		return utils.Internal(err, "Failed to update API key")
+	}
+
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "API key updated successfully",
+		Data:    nil,
+	})
+}
+
+// RevokeApiKeyByID ยกเลิก API Key ทันที (ไม่สามารถเปิดใช้งานกลับได้)
+func (h *ApiKeyHandler) RevokeApiKeyByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+	username := utils.ResolveUser(c)
+
+	err := utils.ExecuteTransaction(c.UserContext(), h.db, []func(tx *sql.Tx) error{
+		func(tx *sql.Tx) error {
+			return utils.CheckRowVersion(c.UserContext(), tx, h.dialect, "tb_api_key", "api_key_id", id)
+		},
+		func(tx *sql.Tx) error {
+			res, err := tx.ExecContext(c.UserContext(), `
+				UPDATE tb_api_key
+				SET revoke_date = `+h.dialect.Now()+`,
+					is_active = 0,
+					update_by = @UpdateBy
+				WHERE api_key_id = @ID AND is_delete = 0 AND revoke_date IS NULL`,
+				sql.Named("ID", id),
+				sql.Named("UpdateBy", username),
+			)
+			if err != nil {
+				return err
+			}
+			rows, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if rows == 0 {
+				return sql.ErrNoRows
+			}
+			return nil
+		},
+		func(tx *sql.Tx) error {
+			return h.dialect.Touch(c.UserContext(), tx, "tb_api_key", "api_key_id", id)
+		},
+	})
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "API key not found or already revoked",
+			Data:    nil,
+		})
+	}
+	if err != nil {
+		return utils.Internal(err, "Failed to revoke API key")
+	}
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "API key revoked successfully",
+		Data:    nil,
+	})
+}
+
+func (h *ApiKeyHandler) DeleteApiKeyByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+	username := utils.ResolveUser(c)
+
+	err := utils.ExecuteTransaction(c.UserContext(), h.db, []func(tx *sql.Tx) error{
+		func(tx *sql.Tx) error {
+			return utils.CheckRowVersion(c.UserContext(), tx, h.dialect, "tb_api_key", "api_key_id", id)
+		},
+		func(tx *sql.Tx) error {
+			res, err := tx.ExecContext(c.UserContext(), `
+				UPDATE tb_api_key
+				SET is_delete = 1,
+					is_active = 0,
+					revoke_date = COALESCE(revoke_date, `+h.dialect.Now()+`),
+					update_by = @UpdateBy
+				WHERE api_key_id = @ID AND is_delete = 0`,
+				sql.Named("ID", id),
+				sql.Named("UpdateBy", username),
+			)
+			if err != nil {
+				return err
+			}
+			rows, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if rows == 0 {
+				return sql.ErrNoRows
+			}
+			return nil
+		},
+		func(tx *sql.Tx) error {
+			return h.dialect.Touch(c.UserContext(), tx, "tb_api_key", "api_key_id", id)
+		},
+	})
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "API key not found",
+			Data:    nil,
+		})
+	}
+	if err != nil {
+		return utils.Internal(err, "Failed to delete API key")
+	}
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "API key deleted successfully",
+		Data:    nil,
+	})
+}
+
+func (h *ApiKeyHandler) RemoveApiKeyByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+	err := utils.ExecuteTransaction(c.UserContext(), h.db, []func(tx *sql.Tx) error{
+		func(tx *sql.Tx) error {
+			return utils.CheckRowVersion(c.UserContext(), tx, h.dialect, "tb_api_key", "api_key_id", id)
+		},
+		func(tx *sql.Tx) error {
+			res, err := tx.ExecContext(c.UserContext(), `DELETE FROM tb_api_key WHERE api_key_id = @ID`, sql.Named("ID", id))
+			if err != nil {
+				return err
+			}
+			rows, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if rows == 0 {
+				return sql.ErrNoRows
+			}
+			return nil
+		},
+	})
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "API key not found",
+			Data:    nil,
+		})
+	}
+	if err != nil {
+		return utils.Internal(err, "Failed to remove API key")
+	}
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "API key removed successfully",
+		Data:    nil,
+	})
+}
@@ -1,62 +1,27 @@
 package controllers
 
 import (
-	"PenbunAPI/config"
 	"PenbunAPI/models"
+	"PenbunAPI/repository"
 	"PenbunAPI/utils"
-	"database/sql"
-	"log"
-	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SelectAllCustomers(c *fiber.Ctx) error {
-	query := `
-		SELECT c.customer_id, c.customer_type_id, c.customer_name, c.tax_id, c.branch_name,
-		       c.contact_person, c.phone1, c.phone2, c.email, c.line_id,
-		       c.address, c.sub_district, c.district, c.province, c.zip_code,
-		       c.credit_limit, c.credit_term_day, c.note,
-		       c.update_by, c.update_date, c.is_active,
-		       ct.customer_type_name
-		FROM tb_customer c
-		LEFT JOIN tb_customer_type ct ON c.customer_type_id = ct.customer_type_id
-		WHERE c.is_delete = 0
-	`
-	rows, err := config.DB.Query(query)
-	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch customers",
-			Data:    nil,
-		})
-	}
-	defer rows.Close()
+// CustomerHandler คือ Handler ของ Customer API ซึ่งเข้าถึงข้อมูลผ่าน CustomerRepository
+type CustomerHandler struct {
+	repo repository.CustomerRepository
+}
+
+// NewCustomerHandler สร้าง CustomerHandler (ใช้ repository.NewMemoryCustomerRepository เมื่อทดสอบโดยไม่มีฐานข้อมูล)
+func NewCustomerHandler(repo repository.CustomerRepository) *CustomerHandler {
+	return &CustomerHandler{repo: repo}
+}
 
-	var list []models.Customer
-	for rows.Next() {
-		var item models.Customer
-		var upd sql.NullTime
-		if err := rows.Scan(
-			&item.CustomerID, &item.CustomerTypeID, &item.CustomerName, &item.TaxID, &item.BranchName,
-			&item.ContactPerson, &item.Phone1, &item.Phone2, &item.Email, &item.LineID,
-			&item.Address, &item.SubDistrict, &item.District, &item.Province, &item.ZipCode,
-			&item.CreditLimit, &item.CreditTermDay, &item.Note,
-			&item.UpdateBy, &upd, &item.IsActive, &item.CustomerTypeName,
-		); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			item.UpdateDate = &t
-		}
-		list = append(list, item)
+func (h *CustomerHandler) SelectAllCustomers(c *fiber.Ctx) error {
+	list, err := h.repo.List(c.UserContext())
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch customers")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -66,123 +31,77 @@ func SelectAllCustomers(c *fiber.Ctx) error {
 	})
 }
 
-func SelectPageCustomers(c *fiber.Ctx) error {
-	page := c.QueryInt("page", 1)
-	limit := c.QueryInt("limit", 10)
-	offset := (page - 1) * limit
+// customerListSpec คือ field ที่ SelectPageCustomers อนุญาตให้ใช้กับ ?filter=, ?sort= และ ?q=
+var customerListSpec = utils.ListSpec{
+	Fields: map[string]utils.ListField{
+		"customer_id":        {Column: "c.customer_id", Type: utils.FieldText},
+		"customer_type_id":   {Column: "c.customer_type_id", Type: utils.FieldText},
+		"customer_type_name": {Column: "ct.customer_type_name", Type: utils.FieldText},
+		"customer_name":      {Column: "c.customer_name", Type: utils.FieldText},
+		"tax_id":             {Column: "c.tax_id", Type: utils.FieldText},
+		"branch_name":        {Column: "c.branch_name", Type: utils.FieldText},
+		"contact_person":     {Column: "c.contact_person", Type: utils.FieldText},
+		"phone1":             {Column: "c.phone1", Type: utils.FieldText},
+		"email":              {Column: "c.email", Type: utils.FieldText},
+		"province":           {Column: "c.province", Type: utils.FieldText},
+		"district":           {Column: "c.district", Type: utils.FieldText},
+		"sub_district":       {Column: "c.sub_district", Type: utils.FieldText},
+		"zip_code":           {Column: "c.zip_code", Type: utils.FieldText},
+		"credit_limit":       {Column: "c.credit_limit", Type: utils.FieldNumber},
+		"credit_term_day":    {Column: "c.credit_term_day", Type: utils.FieldNumber},
+		"is_active":          {Column: "c.is_active", Type: utils.FieldBool},
+		"update_by":          {Column: "c.update_by", Type: utils.FieldText},
+		"update_date":        {Column: "c.update_date", Type: utils.FieldDate},
+	},
+	Search:      []string{"c.customer_id", "c.customer_name", "c.tax_id", "c.contact_person", "c.phone1", "c.email"},
+	DefaultSort: "c.update_date DESC",
+	Key:         "customer_id",
+}
 
-	query := `
-		SELECT c.customer_id, c.customer_type_id, c.customer_name, c.tax_id, c.branch_name,
-		       c.contact_person, c.phone1, c.phone2, c.email, c.line_id,
-		       c.address, c.sub_district, c.district, c.province, c.zip_code,
-		       c.credit_limit, c.credit_term_day, c.note,
-		       c.update_by, c.update_date, c.is_active,
-		       ct.customer_type_name
-		FROM tb_customer c
-		LEFT JOIN tb_customer_type ct ON c.customer_type_id = ct.customer_type_id
-		WHERE c.is_delete = 0
-		ORDER BY c.update_date DESC
-		OFFSET @Offset ROWS FETCH NEXT @Limit ROWS ONLY
-	`
-	rows, err := config.DB.Query(query, sql.Named("Offset", offset), sql.Named("Limit", limit))
+func (h *CustomerHandler) SelectPageCustomers(c *fiber.Ctx) error {
+	lq, err := utils.ParseListQuery(c, customerListSpec)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch customers",
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: err.Error(),
 			Data:    nil,
 		})
 	}
-	defer rows.Close()
+	page, limit := lq.Page, lq.Limit
 
-	var list []models.Customer
-	for rows.Next() {
-		var item models.Customer
-		var upd sql.NullTime
-		if err := rows.Scan(
-			&item.CustomerID, &item.CustomerTypeID, &item.CustomerName, &item.TaxID, &item.BranchName,
-			&item.ContactPerson, &item.Phone1, &item.Phone2, &item.Email, &item.LineID,
-			&item.Address, &item.SubDistrict, &item.District, &item.Province, &item.ZipCode,
-			&item.CreditLimit, &item.CreditTermDay, &item.Note,
-			&item.UpdateBy, &upd, &item.IsActive, &item.CustomerTypeName,
-		); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			item.UpdateDate = &t
-		}
-		list = append(list, item)
+	list, total, err := h.repo.Page(c.UserContext(), lq)
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch customers")
 	}
 
-	var total int
-	err = config.DB.QueryRow(`SELECT COUNT(*) FROM tb_customer WHERE is_delete = 0`).Scan(&total)
-	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to count records",
-			Data:    nil,
-		})
+	list, next := utils.CursorPage(lq, list, func(item models.Customer) interface{} { return item.CustomerID })
+
+	if utils.UsePageEnvelope(c) {
+		return utils.SendPage(c, lq, list, total, next)
 	}
 
 	return c.JSON(models.ApiResponse{
 		Status: "success",
-		Data: fiber.Map{
+		Data: lq.Paged(fiber.Map{
 			"page":     page,
 			"limit":    limit,
 			"total":    total,
 			"customer": list,
-		},
+		}, next),
 	})
 }
 
-func SelectCustomerByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `
-		SELECT c.customer_id, c.customer_type_id, c.customer_name, c.tax_id, c.branch_name,
-		       c.contact_person, c.phone1, c.phone2, c.email, c.line_id,
-		       c.address, c.sub_district, c.district, c.province, c.zip_code,
-		       c.credit_limit, c.credit_term_day, c.note,
-		       c.update_by, c.update_date, c.is_active,
-		       ct.customer_type_name
-		FROM tb_customer c
-		LEFT JOIN tb_customer_type ct ON c.customer_type_id = ct.customer_type_id
-		WHERE c.customer_id = @ID AND c.is_delete = 0
-	`
-	row := config.DB.QueryRow(query, sql.Named("ID", id))
-
-	var item models.Customer
-	var upd sql.NullTime
-	if err := row.Scan(
-		&item.CustomerID, &item.CustomerTypeID, &item.CustomerName, &item.TaxID, &item.BranchName,
-		&item.ContactPerson, &item.Phone1, &item.Phone2, &item.Email, &item.LineID,
-		&item.Address, &item.SubDistrict, &item.District, &item.Province, &item.ZipCode,
-		&item.CreditLimit, &item.CreditTermDay, &item.Note,
-		&item.UpdateBy, &upd, &item.IsActive, &item.CustomerTypeName,
-	); err != nil {
-		if err == sql.ErrNoRows {
-			return c.Status(404).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Customer not found",
-				Data:    nil,
-			})
-		}
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
+func (h *CustomerHandler) SelectCustomerByID(c *fiber.Ctx) error {
+	item, err := h.repo.Get(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
-			Message: "Failed to read data",
+			Message: "Customer not found",
 			Data:    nil,
 		})
 	}
-	if upd.Valid {
-		t := upd.Time.Format("2006-01-02T15:04:05")
-		item.UpdateDate = &t
+	if err != nil {
+		return utils.Internal(err, "Failed to read data")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -192,53 +111,10 @@ func SelectCustomerByID(c *fiber.Ctx) error {
 	})
 }
 
-func SelectCustomerByName(c *fiber.Ctx) error {
-	name := c.Params("name")
-	query := `
-		SELECT c.customer_id, c.customer_type_id, c.customer_name, c.tax_id, c.branch_name,
-		       c.contact_person, c.phone1, c.phone2, c.email, c.line_id,
-		       c.address, c.sub_district, c.district, c.province, c.zip_code,
-		       c.credit_limit, c.credit_term_day, c.note,
-		       c.update_by, c.update_date, c.is_active,
-		       ct.customer_type_name
-		FROM tb_customer c
-		LEFT JOIN tb_customer_type ct ON c.customer_type_id = ct.customer_type_id
-		WHERE c.customer_name LIKE '%' + @Name + '%' AND c.is_delete = 0
-	`
-	rows, err := config.DB.Query(query, sql.Named("Name", name))
+func (h *CustomerHandler) SelectCustomerByName(c *fiber.Ctx) error {
+	list, err := h.repo.Search(c.UserContext(), c.Params("name"))
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch customers",
-			Data:    nil,
-		})
-	}
-	defer rows.Close()
-
-	var list []models.Customer
-	for rows.Next() {
-		var item models.Customer
-		var upd sql.NullTime
-		if err := rows.Scan(
-			&item.CustomerID, &item.CustomerTypeID, &item.CustomerName, &item.TaxID, &item.BranchName,
-			&item.ContactPerson, &item.Phone1, &item.Phone2, &item.Email, &item.LineID,
-			&item.Address, &item.SubDistrict, &item.District, &item.Province, &item.ZipCode,
-			&item.CreditLimit, &item.CreditTermDay, &item.Note,
-			&item.UpdateBy, &upd, &item.IsActive, &item.CustomerTypeName,
-		); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			item.UpdateDate = &t
-		}
-		list = append(list, item)
+		return utils.Internal(err, "Failed to fetch customers")
 	}
 
 	if len(list) == 0 {
@@ -256,162 +132,38 @@ func SelectCustomerByName(c *fiber.Ctx) error {
 	})
 }
 
-func InsertCustomer(c *fiber.Ctx) error {
+func (h *CustomerHandler) InsertCustomer(c *fiber.Ctx) error {
 	var item models.Customer
-	if err := c.BodyParser(&item); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Invalid request body",
-			Data:    nil,
-		})
+	if err := utils.Bind(c, &item); err != nil {
+		return err
 	}
 
-	if item.CustomerTypeID == "" {
-		return c.Status(400).JSON(models.ApiResponse{Status: "error", Message: "customer_type_id is required"})
-	}
-	if strings.TrimSpace(item.CustomerName) == "" {
-		return c.Status(400).JSON(models.ApiResponse{Status: "error", Message: "customer_name is required"})
-	}
-
-	if item.UpdateBy == nil || *item.UpdateBy == "" {
-		u := utils.ResolveUser(c)
-		item.UpdateBy = &u
-	}
-
-	query := `
-		INSERT INTO tb_customer (
-			customer_type_id, customer_name, tax_id, branch_name,
-			contact_person, phone1, phone2, email, line_id,
-			address, sub_district, district, province, zip_code,
-			credit_limit, credit_term_day, note, update_by
-		)
-		VALUES (
-			@TypeID, @Name, @TaxID, @Branch,
-			@Contact, @Phone1, @Phone2, @Email, @LineID,
-			@Address, @SubDistrict, @District, @Province, @ZipCode,
-			COALESCE(@CreditLimit, 0), COALESCE(@CreditTerm, 0), @Note, @UpdateBy
-		)
-	`
+	item.UpdateBy = utils.StampUser(c, item.UpdateBy)
 
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			_, err := tx.Exec(query,
-				sql.Named("TypeID", item.CustomerTypeID),
-				sql.Named("Name", item.CustomerName),
-				sql.Named("TaxID", item.TaxID),
-				sql.Named("Branch", item.BranchName),
-				sql.Named("Contact", item.ContactPerson),
-				sql.Named("Phone1", item.Phone1),
-				sql.Named("Phone2", item.Phone2),
-				sql.Named("Email", item.Email),
-				sql.Named("LineID", item.LineID),
-				sql.Named("Address", item.Address),
-				sql.Named("SubDistrict", item.SubDistrict),
-				sql.Named("District", item.District),
-				sql.Named("Province", item.Province),
-				sql.Named("ZipCode", item.ZipCode),
-				sql.Named("CreditLimit", item.CreditLimit),
-				sql.Named("CreditTerm", item.CreditTermDay),
-				sql.Named("Note", item.Note),
-				sql.Named("UpdateBy", item.UpdateBy),
-			)
-			return err
-		},
-	})
+	newID, err := h.repo.Create(c.UserContext(), &item)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to insert customer",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to insert customer")
 	}
 
+	utils.SetCreatedID(c, newID)
 	return c.Status(201).JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "Customer added successfully",
-		Data:    nil,
+		Data:    fiber.Map{"customer_id": newID},
 	})
 }
 
-func UpdateCustomerByID(c *fiber.Ctx) error {
+func (h *CustomerHandler) UpdateCustomerByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var item models.Customer
-	if err := c.BodyParser(&item); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Invalid request body",
-			Data:    nil,
-		})
-	}
-
-	if item.UpdateBy == nil || *item.UpdateBy == "" {
-		u := utils.ResolveUser(c)
-		item.UpdateBy = &u
+	if err := utils.BindPartial(c, &item); err != nil {
+		return err
 	}
 
-	query := `
-		UPDATE tb_customer
-		SET customer_type_id = COALESCE(NULLIF(@TypeID, ''), customer_type_id),
-			customer_name = COALESCE(NULLIF(@Name, ''), customer_name),
-			tax_id = COALESCE(@TaxID, tax_id),
-			branch_name = COALESCE(@Branch, branch_name),
-			contact_person = COALESCE(@Contact, contact_person),
-			phone1 = COALESCE(@Phone1, phone1),
-			phone2 = COALESCE(@Phone2, phone2),
-			email = COALESCE(@Email, email),
-			line_id = COALESCE(@LineID, line_id),
-			address = COALESCE(@Address, address),
-			sub_district = COALESCE(@SubDistrict, sub_district),
-			district = COALESCE(@District, district),
-			province = COALESCE(@Province, province),
-			zip_code = COALESCE(@ZipCode, zip_code),
-			credit_limit = COALESCE(@CreditLimit, credit_limit),
-			credit_term_day = COALESCE(@CreditTerm, credit_term_day),
-			note = COALESCE(@Note, note),
-			update_by = @UpdateBy,
-			is_active = COALESCE(@IsActive, is_active)
-		WHERE customer_id = @ID AND is_delete = 0
-	`
+	item.UpdateBy = utils.StampUser(c, item.UpdateBy)
 
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(query,
-				sql.Named("TypeID", item.CustomerTypeID),
-				sql.Named("Name", item.CustomerName),
-				sql.Named("TaxID", item.TaxID),
-				sql.Named("Branch", item.BranchName),
-				sql.Named("Contact", item.ContactPerson),
-				sql.Named("Phone1", item.Phone1),
-				sql.Named("Phone2", item.Phone2),
-				sql.Named("Email", item.Email),
-				sql.Named("LineID", item.LineID),
-				sql.Named("Address", item.Address),
-				sql.Named("SubDistrict", item.SubDistrict),
-				sql.Named("District", item.District),
-				sql.Named("Province", item.Province),
-				sql.Named("ZipCode", item.ZipCode),
-				sql.Named("CreditLimit", item.CreditLimit),
-				sql.Named("CreditTerm", item.CreditTermDay),
-				sql.Named("Note", item.Note),
-				sql.Named("UpdateBy", item.UpdateBy),
-				sql.Named("IsActive", item.IsActive),
-				sql.Named("ID", id),
-			)
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+	err := h.repo.Update(c.UserContext(), id, &item)
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Customer not found",
@@ -419,12 +171,7 @@ func UpdateCustomerByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to update customer",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to update customer")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -434,36 +181,13 @@ func UpdateCustomerByID(c *fiber.Ctx) error {
 	})
 }
 
-func DeleteCustomerByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	username := utils.ResolveUser(c)
-
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(`
-				UPDATE tb_customer
-				SET is_delete = 1,
-					is_active = 0,
-					update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE 'SE Asia Standard Time' AS DATETIME),
-					update_by = @UpdateBy
-				WHERE customer_id = @ID AND is_delete = 0`,
-				sql.Named("ID", id),
-				sql.Named("UpdateBy", username),
-			)
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+func (h *CustomerHandler) DeleteCustomerByID(c *fiber.Ctx) error {
+	cascade, err := utils.CascadeSoft(c)
+	if err != nil {
+		return err
+	}
+	err = h.repo.SoftDelete(c.UserContext(), c.Params("id"), utils.ResolveUser(c), cascade)
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Customer not found",
@@ -471,12 +195,7 @@ func DeleteCustomerByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to delete customer",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to delete customer")
 	}
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
@@ -485,25 +204,9 @@ func DeleteCustomerByID(c *fiber.Ctx) error {
 	})
 }
 
-func RemoveCustomerByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(`DELETE FROM tb_customer WHERE customer_id = @ID`, sql.Named("ID", id))
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+func (h *CustomerHandler) RemoveCustomerByID(c *fiber.Ctx) error {
+	err := h.repo.Purge(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Customer not found",
@@ -511,12 +214,7 @@ func RemoveCustomerByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to remove customer",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to remove customer")
 	}
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
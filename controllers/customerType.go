@@ -1,49 +1,27 @@
 package controllers
 
 import (
-	"PenbunAPI/config"
 	"PenbunAPI/models"
+	"PenbunAPI/repository"
 	"PenbunAPI/utils"
-	"database/sql"
-	"log"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SelectAllCustomerTypes(c *fiber.Ctx) error {
-	query := `
-		SELECT customer_type_id, customer_type_name, base_credit_day, description, update_by, update_date, is_active
-		FROM tb_customer_type
-		WHERE is_delete = 0
-	`
-	rows, err := config.DB.Query(query)
-	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch customer types",
-			Data:    nil,
-		})
-	}
-	defer rows.Close()
+// CustomerTypeHandler คือ Handler ของ Customer Type API ซึ่งเข้าถึงข้อมูลผ่าน CustomerTypeRepository
+type CustomerTypeHandler struct {
+	repo repository.CustomerTypeRepository
+}
+
+// NewCustomerTypeHandler สร้าง CustomerTypeHandler (ใช้ repository.NewMemoryCustomerTypeRepository เมื่อทดสอบโดยไม่มีฐานข้อมูล)
+func NewCustomerTypeHandler(repo repository.CustomerTypeRepository) *CustomerTypeHandler {
+	return &CustomerTypeHandler{repo: repo}
+}
 
-	var list []models.CustomerType
-	for rows.Next() {
-		var item models.CustomerType
-		var upd sql.NullTime
-		if err := rows.Scan(&item.CustomerTypeID, &item.CustomerTypeName, &item.BaseCreditDay, &item.Description, &item.UpdateBy, &upd, &item.IsActive); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			item.UpdateDate = &t
-		}
-		list = append(list, item)
+func (h *CustomerTypeHandler) SelectAllCustomerTypes(c *fiber.Ctx) error {
+	list, err := h.repo.List(c.UserContext())
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch customer types")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -53,99 +31,65 @@ func SelectAllCustomerTypes(c *fiber.Ctx) error {
 	})
 }
 
-func SelectPageCustomerTypes(c *fiber.Ctx) error {
-	page := c.QueryInt("page", 1)
-	limit := c.QueryInt("limit", 10)
-	offset := (page - 1) * limit
+// customerTypeListSpec คือ field ที่ SelectPageCustomerTypes อนุญาตให้ใช้กับ ?filter=, ?sort= และ ?q=
+var customerTypeListSpec = utils.ListSpec{
+	Fields: map[string]utils.ListField{
+		"customer_type_id":   {Column: "customer_type_id", Type: utils.FieldText},
+		"customer_type_name": {Column: "customer_type_name", Type: utils.FieldText},
+		"base_credit_day":    {Column: "base_credit_day", Type: utils.FieldNumber},
+		"is_active":          {Column: "is_active", Type: utils.FieldBool},
+		"update_by":          {Column: "update_by", Type: utils.FieldText},
+		"update_date":        {Column: "update_date", Type: utils.FieldDate},
+	},
+	Search:      []string{"customer_type_id", "customer_type_name", "description"},
+	DefaultSort: "update_date DESC",
+	Key:         "customer_type_id",
+}
 
-	query := `
-		SELECT customer_type_id, customer_type_name, base_credit_day, description, update_by, update_date, is_active
-		FROM tb_customer_type
-		WHERE is_delete = 0
-		ORDER BY update_date DESC
-		OFFSET @Offset ROWS FETCH NEXT @Limit ROWS ONLY
-	`
-	rows, err := config.DB.Query(query, sql.Named("Offset", offset), sql.Named("Limit", limit))
+func (h *CustomerTypeHandler) SelectPageCustomerTypes(c *fiber.Ctx) error {
+	lq, err := utils.ParseListQuery(c, customerTypeListSpec)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch customer types",
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: err.Error(),
 			Data:    nil,
 		})
 	}
-	defer rows.Close()
+	page, limit := lq.Page, lq.Limit
 
-	var list []models.CustomerType
-	for rows.Next() {
-		var item models.CustomerType
-		var upd sql.NullTime
-		if err := rows.Scan(&item.CustomerTypeID, &item.CustomerTypeName, &item.BaseCreditDay, &item.Description, &item.UpdateBy, &upd, &item.IsActive); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			item.UpdateDate = &t
-		}
-		list = append(list, item)
+	list, total, err := h.repo.Page(c.UserContext(), lq)
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch customer types")
 	}
 
-	var total int
-	err = config.DB.QueryRow(`SELECT COUNT(*) FROM tb_customer_type WHERE is_delete = 0`).Scan(&total)
-	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to count records",
-			Data:    nil,
-		})
+	list, next := utils.CursorPage(lq, list, func(item models.CustomerType) interface{} { return item.CustomerTypeID })
+
+	if utils.UsePageEnvelope(c) {
+		return utils.SendPage(c, lq, list, total, next)
 	}
 
 	return c.JSON(models.ApiResponse{
 		Status: "success",
-		Data: fiber.Map{
+		Data: lq.Paged(fiber.Map{
 			"page":         page,
 			"limit":        limit,
 			"total":        total,
 			"items":        list,
-		},
+		}, next),
 	})
 }
 
-func SelectCustomerTypeByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `
-		SELECT customer_type_id, customer_type_name, base_credit_day, description, update_by, update_date, is_active
-		FROM tb_customer_type
-		WHERE customer_type_id = @ID AND is_delete = 0
-	`
-	row := config.DB.QueryRow(query, sql.Named("ID", id))
-
-	var item models.CustomerType
-	var upd sql.NullTime
-	if err := row.Scan(&item.CustomerTypeID, &item.CustomerTypeName, &item.BaseCreditDay, &item.Description, &item.UpdateBy, &upd, &item.IsActive); err != nil {
-		if err == sql.ErrNoRows {
-			return c.Status(404).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Customer type not found",
-				Data:    nil,
-			})
-		}
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
+func (h *CustomerTypeHandler) SelectCustomerTypeByID(c *fiber.Ctx) error {
+	item, err := h.repo.Get(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
-			Message: "Failed to read data",
+			Message: "Customer type not found",
 			Data:    nil,
 		})
 	}
-	if upd.Valid {
-		t := upd.Time.Format("2006-01-02T15:04:05")
-		item.UpdateDate = &t
+	if err != nil {
+		return utils.Internal(err, "Failed to read data")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -155,41 +99,10 @@ func SelectCustomerTypeByID(c *fiber.Ctx) error {
 	})
 }
 
-func SelectCustomerTypeByName(c *fiber.Ctx) error {
-	name := c.Params("name")
-	query := `
-		SELECT customer_type_id, customer_type_name, base_credit_day, description, update_by, update_date, is_active
-		FROM tb_customer_type
-		WHERE customer_type_name LIKE '%' + @Name + '%' AND is_delete = 0
-	`
-	rows, err := config.DB.Query(query, sql.Named("Name", name))
+func (h *CustomerTypeHandler) SelectCustomerTypeByName(c *fiber.Ctx) error {
+	results, err := h.repo.Search(c.UserContext(), c.Params("name"))
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch customer types",
-			Data:    nil,
-		})
-	}
-	defer rows.Close()
-
-	var results []models.CustomerType
-	for rows.Next() {
-		var item models.CustomerType
-		var upd sql.NullTime
-		if err := rows.Scan(&item.CustomerTypeID, &item.CustomerTypeName, &item.BaseCreditDay, &item.Description, &item.UpdateBy, &upd, &item.IsActive); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			item.UpdateDate = &t
-		}
-		results = append(results, item)
+		return utils.Internal(err, "Failed to fetch customer types")
 	}
 
 	if len(results) == 0 {
@@ -207,93 +120,38 @@ func SelectCustomerTypeByName(c *fiber.Ctx) error {
 	})
 }
 
-func InsertCustomerType(c *fiber.Ctx) error {
+func (h *CustomerTypeHandler) InsertCustomerType(c *fiber.Ctx) error {
 	var item models.CustomerType
-	if err := c.BodyParser(&item); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Invalid request body",
-			Data:    nil,
-		})
+	if err := utils.Bind(c, &item); err != nil {
+		return err
 	}
 
-	query := `
-		INSERT INTO tb_customer_type (customer_type_name, base_credit_day, description, update_by)
-		VALUES (@CustomerTypeName, @BaseCreditDay, @Description, @UpdateBy)
-	`
+	item.UpdateBy = utils.StampUser(c, item.UpdateBy)
 
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			_, err := tx.Exec(query,
-				sql.Named("CustomerTypeName", item.CustomerTypeName),
-				sql.Named("BaseCreditDay", item.BaseCreditDay),
-				sql.Named("Description", item.Description),
-				sql.Named("UpdateBy", item.UpdateBy),
-			)
-			return err
-		},
-	})
+	newID, err := h.repo.Create(c.UserContext(), &item)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to insert customer type",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to insert customer type")
 	}
 
+	utils.SetCreatedID(c, newID)
 	return c.Status(201).JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "Customer type added successfully",
-		Data:    nil,
+		Data:    fiber.Map{"customer_type_id": newID},
 	})
 }
 
-func UpdateCustomerTypeByID(c *fiber.Ctx) error {
+func (h *CustomerTypeHandler) UpdateCustomerTypeByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var item models.CustomerType
-	if err := c.BodyParser(&item); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Invalid request body",
-			Data:    nil,
-		})
+	if err := utils.BindPartial(c, &item); err != nil {
+		return err
 	}
 
-	query := `
-		UPDATE tb_customer_type
-		SET customer_type_name = COALESCE(NULLIF(@CustomerTypeName, ''), customer_type_name),
-			base_credit_day = COALESCE(@BaseCreditDay, base_credit_day),
-			description = COALESCE(@Description, description),
-			update_by = @UpdateBy,
-			is_active = COALESCE(@IsActive, is_active)
-		WHERE customer_type_id = @ID AND is_delete = 0
-	`
+	item.UpdateBy = utils.StampUser(c, item.UpdateBy)
 
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(query,
-				sql.Named("CustomerTypeName", item.CustomerTypeName),
-				sql.Named("BaseCreditDay", item.BaseCreditDay),
-				sql.Named("Description", item.Description),
-				sql.Named("UpdateBy", item.UpdateBy),
-				sql.Named("IsActive", item.IsActive),
-				sql.Named("ID", id),
-			)
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+	err := h.repo.Update(c.UserContext(), id, &item)
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Customer type not found",
@@ -301,12 +159,7 @@ func UpdateCustomerTypeByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to update customer type",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to update customer type")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -316,32 +169,13 @@ func UpdateCustomerTypeByID(c *fiber.Ctx) error {
 	})
 }
 
-func DeleteCustomerTypeByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `
-		UPDATE tb_customer_type
-		SET is_delete = 1,
-		    is_active = 0,
-		    update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE 'SE Asia Standard Time' AS DATETIME)
-		WHERE customer_type_id = @ID
-	`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(query, sql.Named("ID", id))
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+func (h *CustomerTypeHandler) DeleteCustomerTypeByID(c *fiber.Ctx) error {
+	cascade, err := utils.CascadeSoft(c)
+	if err != nil {
+		return err
+	}
+	err = h.repo.SoftDelete(c.UserContext(), c.Params("id"), utils.ResolveUser(c), cascade)
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Customer type not found",
@@ -349,12 +183,7 @@ func DeleteCustomerTypeByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to soft delete customer type",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to soft delete customer type")
 	}
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
@@ -363,26 +192,9 @@ func DeleteCustomerTypeByID(c *fiber.Ctx) error {
 	})
 }
 
-func RemoveCustomerTypeByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `DELETE FROM tb_customer_type WHERE customer_type_id = @ID`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(query, sql.Named("ID", id))
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+func (h *CustomerTypeHandler) RemoveCustomerTypeByID(c *fiber.Ctx) error {
+	err := h.repo.Purge(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Customer type not found",
@@ -390,12 +202,7 @@ func RemoveCustomerTypeByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to hard delete customer type",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to hard delete customer type")
 	}
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
@@ -1,70 +1,27 @@
 package controllers
 
 import (
-	"PenbunAPI/config"
 	"PenbunAPI/models"
+	"PenbunAPI/repository"
 	"PenbunAPI/utils"
-	"database/sql"
-	"log"
-	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SelectAllDiscount(c *fiber.Ctx) error {
-	query := `
-		SELECT d.discount_id, d.discount_type_id, d.discount_name, d.discount_code, d.description,
-		       d.discount_value, d.is_percent, d.min_order_amount,
-		       d.start_date, d.end_date,
-		       d.update_by, d.update_date, d.is_active,
-		       dt.discount_type_name
-		FROM tb_discount d
-		LEFT JOIN tb_discount_type dt ON d.discount_type_id = dt.discount_type_id
-		WHERE d.is_delete = 0
-	`
-	rows, err := config.DB.Query(query)
-	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch discounts",
-			Data:    nil,
-		})
-	}
-	defer rows.Close()
+// DiscountHandler คือ Handler ของ Discount API ซึ่งเข้าถึงข้อมูลผ่าน DiscountRepository
+type DiscountHandler struct {
+	repo repository.DiscountRepository
+}
+
+// NewDiscountHandler สร้าง DiscountHandler (ใช้ repository.NewMemoryDiscountRepository เมื่อทดสอบโดยไม่มีฐานข้อมูล)
+func NewDiscountHandler(repo repository.DiscountRepository) *DiscountHandler {
+	return &DiscountHandler{repo: repo}
+}
 
-	var list []models.Discount
-	for rows.Next() {
-		var item models.Discount
-		var upd sql.NullTime
-		var start sql.NullTime
-		var end sql.NullTime
-		if err := rows.Scan(
-			&item.DiscountID, &item.DiscountTypeID, &item.DiscountName, &item.DiscountCode, &item.Description,
-			&item.DiscountValue, &item.IsPercent, &item.MinOrderAmount,
-			&start, &end,
-			&item.UpdateBy, &upd, &item.IsActive, &item.DiscountTypeName,
-		); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			item.UpdateDate = &t
-		}
-		if start.Valid {
-			t := start.Time.Format("2006-01-02T15:04:05")
-			item.StartDate = &t
-		}
-		if end.Valid {
-			t := end.Time.Format("2006-01-02T15:04:05")
-			item.EndDate = &t
-		}
-		list = append(list, item)
+func (h *DiscountHandler) SelectAllDiscount(c *fiber.Ctx) error {
+	list, err := h.repo.List(c.UserContext())
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch discounts")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -74,139 +31,72 @@ func SelectAllDiscount(c *fiber.Ctx) error {
 	})
 }
 
-func SelectPageDiscount(c *fiber.Ctx) error {
-	page := c.QueryInt("page", 1)
-	limit := c.QueryInt("limit", 10)
-	offset := (page - 1) * limit
+// discountListSpec คือ field ที่ SelectPageDiscount อนุญาตให้ใช้กับ ?filter=, ?sort= และ ?q=
+var discountListSpec = utils.ListSpec{
+	Fields: map[string]utils.ListField{
+		"discount_id":        {Column: "d.discount_id", Type: utils.FieldText},
+		"discount_type_id":   {Column: "d.discount_type_id", Type: utils.FieldText},
+		"discount_type_name": {Column: "dt.discount_type_name", Type: utils.FieldText},
+		"discount_name":      {Column: "d.discount_name", Type: utils.FieldText},
+		"discount_code":      {Column: "d.discount_code", Type: utils.FieldText},
+		"discount_value":     {Column: "d.discount_value", Type: utils.FieldNumber},
+		"is_percent":         {Column: "d.is_percent", Type: utils.FieldBool},
+		"min_order_amount":   {Column: "d.min_order_amount", Type: utils.FieldNumber},
+		"start_date":         {Column: "d.start_date", Type: utils.FieldDate},
+		"end_date":           {Column: "d.end_date", Type: utils.FieldDate},
+		"is_active":          {Column: "d.is_active", Type: utils.FieldBool},
+		"update_by":          {Column: "d.update_by", Type: utils.FieldText},
+		"update_date":        {Column: "d.update_date", Type: utils.FieldDate},
+	},
+	Search:      []string{"d.discount_id", "d.discount_name", "d.discount_code", "d.description"},
+	DefaultSort: "d.update_date DESC",
+	Key:         "discount_id",
+}
 
-	query := `
-		SELECT d.discount_id, d.discount_type_id, d.discount_name, d.discount_code, d.description,
-		       d.discount_value, d.is_percent, d.min_order_amount,
-		       d.start_date, d.end_date,
-		       d.update_by, d.update_date, d.is_active,
-		       dt.discount_type_name
-		FROM tb_discount d
-		LEFT JOIN tb_discount_type dt ON d.discount_type_id = dt.discount_type_id
-		WHERE d.is_delete = 0
-		ORDER BY d.update_date DESC
-		OFFSET @Offset ROWS FETCH NEXT @Limit ROWS ONLY
-	`
-	rows, err := config.DB.Query(query, sql.Named("Offset", offset), sql.Named("Limit", limit))
+func (h *DiscountHandler) SelectPageDiscount(c *fiber.Ctx) error {
+	lq, err := utils.ParseListQuery(c, discountListSpec)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch discounts",
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: err.Error(),
 			Data:    nil,
 		})
 	}
-	defer rows.Close()
+	page, limit := lq.Page, lq.Limit
 
-	var list []models.Discount
-	for rows.Next() {
-		var item models.Discount
-		var upd sql.NullTime
-		var start sql.NullTime
-		var end sql.NullTime
-		if err := rows.Scan(
-			&item.DiscountID, &item.DiscountTypeID, &item.DiscountName, &item.DiscountCode, &item.Description,
-			&item.DiscountValue, &item.IsPercent, &item.MinOrderAmount,
-			&start, &end,
-			&item.UpdateBy, &upd, &item.IsActive, &item.DiscountTypeName,
-		); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			item.UpdateDate = &t
-		}
-		if start.Valid {
-			t := start.Time.Format("2006-01-02T15:04:05")
-			item.StartDate = &t
-		}
-		if end.Valid {
-			t := end.Time.Format("2006-01-02T15:04:05")
-			item.EndDate = &t
-		}
-		list = append(list, item)
+	list, total, err := h.repo.Page(c.UserContext(), lq)
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch discounts")
 	}
 
-	var total int
-	err = config.DB.QueryRow(`SELECT COUNT(*) FROM tb_discount WHERE is_delete = 0`).Scan(&total)
-	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to count records",
-			Data:    nil,
-		})
+	list, next := utils.CursorPage(lq, list, func(item models.Discount) interface{} { return item.DiscountID })
+
+	if utils.UsePageEnvelope(c) {
+		return utils.SendPage(c, lq, list, total, next)
 	}
 
 	return c.JSON(models.ApiResponse{
 		Status: "success",
-		Data: fiber.Map{
+		Data: lq.Paged(fiber.Map{
 			"page":     page,
 			"limit":    limit,
 			"total":    total,
 			"discount": list,
-		},
+		}, next),
 	})
 }
 
-func SelectDiscountByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `
-		SELECT d.discount_id, d.discount_type_id, d.discount_name, d.discount_code, d.description,
-		       d.discount_value, d.is_percent, d.min_order_amount,
-		       d.start_date, d.end_date,
-		       d.update_by, d.update_date, d.is_active,
-		       dt.discount_type_name
-		FROM tb_discount d
-		LEFT JOIN tb_discount_type dt ON d.discount_type_id = dt.discount_type_id
-		WHERE d.discount_id = @ID AND d.is_delete = 0
-	`
-	row := config.DB.QueryRow(query, sql.Named("ID", id))
-
-	var item models.Discount
-	var upd sql.NullTime
-	var start sql.NullTime
-	var end sql.NullTime
-	if err := row.Scan(
-		&item.DiscountID, &item.DiscountTypeID, &item.DiscountName, &item.DiscountCode, &item.Description,
-		&item.DiscountValue, &item.IsPercent, &item.MinOrderAmount,
-		&start, &end,
-		&item.UpdateBy, &upd, &item.IsActive, &item.DiscountTypeName,
-	); err != nil {
-		if err == sql.ErrNoRows {
-			return c.Status(404).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Discount not found",
-				Data:    nil,
-			})
-		}
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
+func (h *DiscountHandler) SelectDiscountByID(c *fiber.Ctx) error {
+	item, err := h.repo.Get(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
-			Message: "Failed to read data",
+			Message: "Discount not found",
 			Data:    nil,
 		})
 	}
-	if upd.Valid {
-		t := upd.Time.Format("2006-01-02T15:04:05")
-		item.UpdateDate = &t
-	}
-	if start.Valid {
-		t := start.Time.Format("2006-01-02T15:04:05")
-		item.StartDate = &t
-	}
-	if end.Valid {
-		t := end.Time.Format("2006-01-02T15:04:05")
-		item.EndDate = &t
+	if err != nil {
+		return utils.Internal(err, "Failed to read data")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -216,61 +106,10 @@ func SelectDiscountByID(c *fiber.Ctx) error {
 	})
 }
 
-func SelectDiscountByName(c *fiber.Ctx) error {
-	name := c.Params("name")
-	query := `
-		SELECT d.discount_id, d.discount_type_id, d.discount_name, d.discount_code, d.description,
-		       d.discount_value, d.is_percent, d.min_order_amount,
-		       d.start_date, d.end_date,
-		       d.update_by, d.update_date, d.is_active,
-		       dt.discount_type_name
-		FROM tb_discount d
-		LEFT JOIN tb_discount_type dt ON d.discount_type_id = dt.discount_type_id
-		WHERE d.discount_name LIKE '%' + @Name + '%' AND d.is_delete = 0
-	`
-	rows, err := config.DB.Query(query, sql.Named("Name", name))
+func (h *DiscountHandler) SelectDiscountByName(c *fiber.Ctx) error {
+	list, err := h.repo.Search(c.UserContext(), c.Params("name"))
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch discounts",
-			Data:    nil,
-		})
-	}
-	defer rows.Close()
-
-	var list []models.Discount
-	for rows.Next() {
-		var item models.Discount
-		var upd sql.NullTime
-		var start sql.NullTime
-		var end sql.NullTime
-		if err := rows.Scan(
-			&item.DiscountID, &item.DiscountTypeID, &item.DiscountName, &item.DiscountCode, &item.Description,
-			&item.DiscountValue, &item.IsPercent, &item.MinOrderAmount,
-			&start, &end,
-			&item.UpdateBy, &upd, &item.IsActive, &item.DiscountTypeName,
-		); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			item.UpdateDate = &t
-		}
-		if start.Valid {
-			t := start.Time.Format("2006-01-02T15:04:05")
-			item.StartDate = &t
-		}
-		if end.Valid {
-			t := end.Time.Format("2006-01-02T15:04:05")
-			item.EndDate = &t
-		}
-		list = append(list, item)
+		return utils.Internal(err, "Failed to fetch discounts")
 	}
 
 	if len(list) == 0 {
@@ -288,136 +127,38 @@ func SelectDiscountByName(c *fiber.Ctx) error {
 	})
 }
 
-func InsertDiscount(c *fiber.Ctx) error {
+func (h *DiscountHandler) InsertDiscount(c *fiber.Ctx) error {
 	var item models.Discount
-	if err := c.BodyParser(&item); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Invalid request body",
-			Data:    nil,
-		})
-	}
-
-	if item.DiscountTypeID == "" {
-		return c.Status(400).JSON(models.ApiResponse{Status: "error", Message: "discount_type_id is required"})
-	}
-	if strings.TrimSpace(item.DiscountName) == "" {
-		return c.Status(400).JSON(models.ApiResponse{Status: "error", Message: "discount_name is required"})
+	if err := utils.Bind(c, &item); err != nil {
+		return err
 	}
 
-	if item.UpdateBy == nil || *item.UpdateBy == "" {
-		u := utils.ResolveUser(c)
-		item.UpdateBy = &u
-	}
+	item.UpdateBy = utils.StampUser(c, item.UpdateBy)
 
-	query := `
-		INSERT INTO tb_discount (
-			discount_type_id, discount_name, discount_code, description,
-			discount_value, is_percent, min_order_amount,
-			start_date, end_date, update_by
-		)
-		VALUES (
-			@TypeID, @Name, @Code, @Desc,
-			@Value, @IsPercent, @MinOrder,
-			@StartDate, @EndDate, @UpdateBy
-		)
-	`
-	
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			_, err := tx.Exec(query,
-				sql.Named("TypeID", item.DiscountTypeID),
-				sql.Named("Name", item.DiscountName),
-				sql.Named("Code", item.DiscountCode),
-				sql.Named("Desc", item.Description),
-				sql.Named("Value", item.DiscountValue),
-				sql.Named("IsPercent", item.IsPercent),
-				sql.Named("MinOrder", item.MinOrderAmount),
-				sql.Named("StartDate", item.StartDate), // Driver converts string to datetime if format is correct
-				sql.Named("EndDate", item.EndDate),
-				sql.Named("UpdateBy", item.UpdateBy),
-			)
-			return err
-		},
-	})
+	newID, err := h.repo.Create(c.UserContext(), &item)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to insert discount",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to insert discount")
 	}
 
+	utils.SetCreatedID(c, newID)
 	return c.Status(201).JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "Discount added successfully",
-		Data:    nil,
+		Data:    fiber.Map{"discount_id": newID},
 	})
 }
 
-func UpdateDiscountByID(c *fiber.Ctx) error {
+func (h *DiscountHandler) UpdateDiscountByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var item models.Discount
-	if err := c.BodyParser(&item); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Invalid request body",
-			Data:    nil,
-		})
-	}
-
-	if item.UpdateBy == nil || *item.UpdateBy == "" {
-		u := utils.ResolveUser(c)
-		item.UpdateBy = &u
+	if err := utils.BindPartial(c, &item); err != nil {
+		return err
 	}
 
-	query := `
-		UPDATE tb_discount
-		SET discount_type_id = COALESCE(NULLIF(@TypeID, ''), discount_type_id),
-			discount_name = COALESCE(NULLIF(@Name, ''), discount_name),
-			discount_code = COALESCE(@Code, discount_code),
-			description = COALESCE(@Desc, description),
-			discount_value = COALESCE(@Value, discount_value),
-			is_percent = COALESCE(@IsPercent, is_percent),
-			min_order_amount = COALESCE(@MinOrder, min_order_amount),
-			start_date = COALESCE(@StartDate, start_date),
-			end_date = COALESCE(@EndDate, end_date),
-			update_by = @UpdateBy,
-			is_active = COALESCE(@IsActive, is_active)
-		WHERE discount_id = @ID AND is_delete = 0
-	`
+	item.UpdateBy = utils.StampUser(c, item.UpdateBy)
 
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(query,
-				sql.Named("TypeID", item.DiscountTypeID),
-				sql.Named("Name", item.DiscountName),
-				sql.Named("Code", item.DiscountCode),
-				sql.Named("Desc", item.Description),
-				sql.Named("Value", item.DiscountValue),
-				sql.Named("IsPercent", item.IsPercent),
-				sql.Named("MinOrder", item.MinOrderAmount),
-				sql.Named("StartDate", item.StartDate),
-				sql.Named("EndDate", item.EndDate),
-				sql.Named("UpdateBy", item.UpdateBy),
-				sql.Named("IsActive", item.IsActive),
-				sql.Named("ID", id),
-			)
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+	err := h.repo.Update(c.UserContext(), id, &item)
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Discount not found",
@@ -425,12 +166,7 @@ func UpdateDiscountByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to update discount",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to update discount")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -440,36 +176,9 @@ func UpdateDiscountByID(c *fiber.Ctx) error {
 	})
 }
 
-func DeleteDiscountByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	username := utils.ResolveUser(c)
-
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(`
-				UPDATE tb_discount
-				SET is_delete = 1,
-					is_active = 0,
-					update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE 'SE Asia Standard Time' AS DATETIME),
-					update_by = @UpdateBy
-				WHERE discount_id = @ID AND is_delete = 0`,
-				sql.Named("ID", id),
-				sql.Named("UpdateBy", username),
-			)
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+func (h *DiscountHandler) DeleteDiscountByID(c *fiber.Ctx) error {
+	err := h.repo.SoftDelete(c.UserContext(), c.Params("id"), utils.ResolveUser(c), false)
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Discount not found",
@@ -477,12 +186,7 @@ func DeleteDiscountByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to delete discount",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to delete discount")
 	}
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
@@ -491,25 +195,9 @@ func DeleteDiscountByID(c *fiber.Ctx) error {
 	})
 }
 
-func RemoveDiscountByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(`DELETE FROM tb_discount WHERE discount_id = @ID`, sql.Named("ID", id))
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+func (h *DiscountHandler) RemoveDiscountByID(c *fiber.Ctx) error {
+	err := h.repo.Purge(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Discount not found",
@@ -517,12 +205,7 @@ func RemoveDiscountByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to remove discount",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to remove discount")
 	}
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
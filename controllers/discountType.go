@@ -1,49 +1,27 @@
 package controllers
 
 import (
-	"PenbunAPI/config"
 	"PenbunAPI/models"
+	"PenbunAPI/repository"
 	"PenbunAPI/utils"
-	"database/sql"
-	"log"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SelectAllDiscountType(c *fiber.Ctx) error {
-	query := `
-		SELECT discount_type_id, discount_type_name, description, update_by, update_date, is_active
-		FROM tb_discount_type
-		WHERE is_delete = 0
-	`
-	rows, err := config.DB.Query(query)
-	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch discount types",
-			Data:    nil,
-		})
-	}
-	defer rows.Close()
+// DiscountTypeHandler คือ Handler ของ Discount Type API ซึ่งเข้าถึงข้อมูลผ่าน DiscountTypeRepository
+type DiscountTypeHandler struct {
+	repo repository.DiscountTypeRepository
+}
+
+// NewDiscountTypeHandler สร้าง DiscountTypeHandler (ใช้ repository.NewMemoryDiscountTypeRepository เมื่อทดสอบโดยไม่มีฐานข้อมูล)
+func NewDiscountTypeHandler(repo repository.DiscountTypeRepository) *DiscountTypeHandler {
+	return &DiscountTypeHandler{repo: repo}
+}
 
-	var list []models.DiscountType
-	for rows.Next() {
-		var item models.DiscountType
-		var upd sql.NullTime
-		if err := rows.Scan(&item.DiscountTypeID, &item.DiscountTypeName, &item.Description, &item.UpdateBy, &upd, &item.IsActive); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			item.UpdateDate = &t
-		}
-		list = append(list, item)
+func (h *DiscountTypeHandler) SelectAllDiscountType(c *fiber.Ctx) error {
+	list, err := h.repo.List(c.UserContext())
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch discount types")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -53,99 +31,64 @@ func SelectAllDiscountType(c *fiber.Ctx) error {
 	})
 }
 
-func SelectPageDiscountType(c *fiber.Ctx) error {
-	page := c.QueryInt("page", 1)
-	limit := c.QueryInt("limit", 10)
-	offset := (page - 1) * limit
+// discountTypeListSpec คือ field ที่ SelectPageDiscountType อนุญาตให้ใช้กับ ?filter=, ?sort= และ ?q=
+var discountTypeListSpec = utils.ListSpec{
+	Fields: map[string]utils.ListField{
+		"discount_type_id":   {Column: "discount_type_id", Type: utils.FieldText},
+		"discount_type_name": {Column: "discount_type_name", Type: utils.FieldText},
+		"is_active":          {Column: "is_active", Type: utils.FieldBool},
+		"update_by":          {Column: "update_by", Type: utils.FieldText},
+		"update_date":        {Column: "update_date", Type: utils.FieldDate},
+	},
+	Search:      []string{"discount_type_id", "discount_type_name", "description"},
+	DefaultSort: "update_date DESC",
+	Key:         "discount_type_id",
+}
 
-	query := `
-		SELECT discount_type_id, discount_type_name, description, update_by, update_date, is_active
-		FROM tb_discount_type
-		WHERE is_delete = 0
-		ORDER BY update_date DESC
-		OFFSET @Offset ROWS FETCH NEXT @Limit ROWS ONLY
-	`
-	rows, err := config.DB.Query(query, sql.Named("Offset", offset), sql.Named("Limit", limit))
+func (h *DiscountTypeHandler) SelectPageDiscountType(c *fiber.Ctx) error {
+	lq, err := utils.ParseListQuery(c, discountTypeListSpec)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch discount types",
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: err.Error(),
 			Data:    nil,
 		})
 	}
-	defer rows.Close()
+	page, limit := lq.Page, lq.Limit
 
-	var list []models.DiscountType
-	for rows.Next() {
-		var item models.DiscountType
-		var upd sql.NullTime
-		if err := rows.Scan(&item.DiscountTypeID, &item.DiscountTypeName, &item.Description, &item.UpdateBy, &upd, &item.IsActive); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			item.UpdateDate = &t
-		}
-		list = append(list, item)
+	list, total, err := h.repo.Page(c.UserContext(), lq)
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch discount types")
 	}
 
-	var total int
-	err = config.DB.QueryRow(`SELECT COUNT(*) FROM tb_discount_type WHERE is_delete = 0`).Scan(&total)
-	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to count records",
-			Data:    nil,
-		})
+	list, next := utils.CursorPage(lq, list, func(item models.DiscountType) interface{} { return item.DiscountTypeID })
+
+	if utils.UsePageEnvelope(c) {
+		return utils.SendPage(c, lq, list, total, next)
 	}
 
 	return c.JSON(models.ApiResponse{
 		Status: "success",
-		Data: fiber.Map{
+		Data: lq.Paged(fiber.Map{
 			"page":         page,
 			"limit":        limit,
 			"total":        total,
 			"items":        list,
-		},
+		}, next),
 	})
 }
 
-func SelectDiscountTypeByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `
-		SELECT discount_type_id, discount_type_name, description, update_by, update_date, is_active
-		FROM tb_discount_type
-		WHERE discount_type_id = @ID AND is_delete = 0
-	`
-	row := config.DB.QueryRow(query, sql.Named("ID", id))
-
-	var item models.DiscountType
-	var upd sql.NullTime
-	if err := row.Scan(&item.DiscountTypeID, &item.DiscountTypeName, &item.Description, &item.UpdateBy, &upd, &item.IsActive); err != nil {
-		if err == sql.ErrNoRows {
-			return c.Status(404).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Discount type not found",
-				Data:    nil,
-			})
-		}
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
+func (h *DiscountTypeHandler) SelectDiscountTypeByID(c *fiber.Ctx) error {
+	item, err := h.repo.Get(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
-			Message: "Failed to read data",
+			Message: "Discount type not found",
 			Data:    nil,
 		})
 	}
-	if upd.Valid {
-		t := upd.Time.Format("2006-01-02T15:04:05")
-		item.UpdateDate = &t
+	if err != nil {
+		return utils.Internal(err, "Failed to read data")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -155,44 +98,13 @@ func SelectDiscountTypeByID(c *fiber.Ctx) error {
 	})
 }
 
-func SelectDiscountTypeByName(c *fiber.Ctx) error {
-	name := c.Params("name")
-	query := `
-		SELECT discount_type_id, discount_type_name, description, update_by, update_date, is_active
-		FROM tb_discount_type
-		WHERE discount_type_name LIKE '%' + @Name + '%' AND is_delete = 0
-	`
-	rows, err := config.DB.Query(query, sql.Named("Name", name))
+func (h *DiscountTypeHandler) SelectDiscountTypeByName(c *fiber.Ctx) error {
+	list, err := h.repo.Search(c.UserContext(), c.Params("name"))
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch discount types",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to fetch discount types")
 	}
-	defer rows.Close()
 
-	var results []models.DiscountType
-	for rows.Next() {
-		var item models.DiscountType
-		var upd sql.NullTime
-		if err := rows.Scan(&item.DiscountTypeID, &item.DiscountTypeName, &item.Description, &item.UpdateBy, &upd, &item.IsActive); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			item.UpdateDate = &t
-		}
-		results = append(results, item)
-	}
-
-	if len(results) == 0 {
+	if len(list) == 0 {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "No matching discount type found",
@@ -203,94 +115,42 @@ func SelectDiscountTypeByName(c *fiber.Ctx) error {
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "",
-		Data:    results,
+		Data:    list,
 	})
 }
 
-func InsertDiscountType(c *fiber.Ctx) error {
+func (h *DiscountTypeHandler) InsertDiscountType(c *fiber.Ctx) error {
 	var item models.DiscountType
-	if err := c.BodyParser(&item); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Invalid request body",
-			Data:    nil,
-		})
+	if err := utils.Bind(c, &item); err != nil {
+		return err
 	}
 
-	query := `
-		INSERT INTO tb_discount_type (discount_type_name, description, update_by)
-		VALUES (@DiscountTypeName, @Description, @UpdateBy)
-	`
+	item.UpdateBy = utils.StampUser(c, item.UpdateBy)
 
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			_, err := tx.Exec(query,
-				sql.Named("DiscountTypeName", item.DiscountTypeName),
-				sql.Named("Description", item.Description),
-				sql.Named("UpdateBy", item.UpdateBy),
-			)
-			return err
-		},
-	})
+	newID, err := h.repo.Create(c.UserContext(), &item)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to insert discount type",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to insert discount type")
 	}
 
+	utils.SetCreatedID(c, newID)
 	return c.Status(201).JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "Discount type added successfully",
-		Data:    nil,
+		Data:    fiber.Map{"discount_type_id": newID},
 	})
 }
 
-func UpdateDiscountTypeByID(c *fiber.Ctx) error {
+func (h *DiscountTypeHandler) UpdateDiscountTypeByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var item models.DiscountType
-	if err := c.BodyParser(&item); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Invalid request body",
-			Data:    nil,
-		})
+	if err := utils.BindPartial(c, &item); err != nil {
+		return err
 	}
 
-	query := `
-		UPDATE tb_discount_type
-		SET discount_type_name = COALESCE(NULLIF(@DiscountTypeName, ''), discount_type_name),
-			description = COALESCE(@Description, description),
-			update_by = @UpdateBy,
-			is_active = COALESCE(@IsActive, is_active)
-		WHERE discount_type_id = @ID AND is_delete = 0
-	`
+	item.UpdateBy = utils.StampUser(c, item.UpdateBy)
 
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(query,
-				sql.Named("DiscountTypeName", item.DiscountTypeName),
-				sql.Named("Description", item.Description),
-				sql.Named("UpdateBy", item.UpdateBy),
-				sql.Named("IsActive", item.IsActive),
-				sql.Named("ID", id),
-			)
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+	err := h.repo.Update(c.UserContext(), id, &item)
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Discount type not found",
@@ -298,12 +158,7 @@ func UpdateDiscountTypeByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to update discount type",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to update discount type")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -313,32 +168,13 @@ func UpdateDiscountTypeByID(c *fiber.Ctx) error {
 	})
 }
 
-func DeleteDiscountTypeByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `
-		UPDATE tb_discount_type
-		SET is_delete = 1,
-			is_active = 0,
-			update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE 'SE Asia Standard Time' AS DATETIME)
-		WHERE discount_type_id = @ID AND is_delete = 0
-	`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(query, sql.Named("ID", id))
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+func (h *DiscountTypeHandler) DeleteDiscountTypeByID(c *fiber.Ctx) error {
+	cascade, err := utils.CascadeSoft(c)
+	if err != nil {
+		return err
+	}
+	err = h.repo.SoftDelete(c.UserContext(), c.Params("id"), utils.ResolveUser(c), cascade)
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Discount type not found",
@@ -346,12 +182,7 @@ func DeleteDiscountTypeByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to delete discount type",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to delete discount type")
 	}
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
@@ -360,26 +191,9 @@ func DeleteDiscountTypeByID(c *fiber.Ctx) error {
 	})
 }
 
-func RemoveDiscountTypeByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `DELETE FROM tb_discount_type WHERE discount_type_id = @ID`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(query, sql.Named("ID", id))
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+func (h *DiscountTypeHandler) RemoveDiscountTypeByID(c *fiber.Ctx) error {
+	err := h.repo.Purge(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Discount type not found",
@@ -387,12 +201,7 @@ func RemoveDiscountTypeByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to remove discount type",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to remove discount type")
 	}
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
@@ -0,0 +1,13 @@
+package controllers
+
+import (
+	"PenbunAPI/config"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetJWKS - เผยแพร่ Public Key (JWKS) ให้ระบบอื่นใช้ตรวจสอบ Token ที่ออกโดย PenbunAPI
+func GetJWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(config.JWKS())
+}
@@ -0,0 +1,457 @@
+package controllers
+
+import (
+	"PenbunAPI/auth"
+	"PenbunAPI/config"
+	"PenbunAPI/dialect"
+	"PenbunAPI/models"
+	"PenbunAPI/utils"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// MFAHandler คือ Handler ของ Login ขั้นที่ 2 และการจัดการ MFA (TOTP) ของผู้ใช้
+type MFAHandler struct {
+	db       *sql.DB
+	dialect  dialect.Dialect
+	sessions *auth.Sessions
+	log      *logrus.Logger
+	issuer   string // ชื่อที่แสดงในแอป Authenticator (MFA_ISSUER)
+}
+
+// NewMFAHandler สร้าง MFAHandler
+func NewMFAHandler(db *sql.DB, sessions *auth.Sessions, logger *logrus.Logger, issuer string) *MFAHandler {
+	return &MFAHandler{db: db, dialect: dialect.Of(db), sessions: sessions, log: logger, issuer: issuer}
+}
+
+// mfaUser คือข้อมูล MFA ของผู้ใช้ที่อ่านจาก tb_users
+type mfaUser struct {
+	Secret  string
+	Enabled bool
+	Roles   []string
+}
+
+func (h *MFAHandler) loadMFAUser(ctx context.Context, userName string) (mfaUser, error) {
+	var u mfaUser
+	var role string
+	err := h.db.QueryRowContext(ctx, `
+		SELECT COALESCE(mfa_secret, ''), COALESCE(mfa_enabled, 0), COALESCE(user_role, '')
+		FROM tb_users WHERE user_name = @UserName
+	`, sql.Named("UserName", userName)).Scan(&u.Secret, &u.Enabled, &role)
+	u.Roles = splitRoles(role)
+	return u, err
+}
+
+// verifySecondFactor ตรวจสอบรหัส TOTP หรือ Recovery Code อย่างใดอย่างหนึ่ง
+// รหัส TOTP ที่ใช้แล้วจะใช้ซ้ำไม่ได้ (mfa_last_step) และ Recovery Code ใช้ได้ครั้งเดียว
+func (h *MFAHandler) verifySecondFactor(ctx context.Context, userName, secret string, req models.MFARequest) (bool, error) {
+	var (
+		res sql.Result
+		err error
+	)
+	switch {
+	case req.Code != "":
+		step, ok := utils.ValidateTOTP(secret, req.Code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		res, err = h.db.ExecContext(ctx, `
+			UPDATE tb_users SET mfa_last_step = @Step
+			WHERE user_name = @UserName AND COALESCE(mfa_last_step, 0) < @Step
+		`, sql.Named("Step", step), sql.Named("UserName", userName))
+	case req.RecoveryCode != "":
+		res, err = h.db.ExecContext(ctx, `
+			UPDATE tb_user_recovery_code
+			SET used_date = `+h.dialect.Now()+`
+			WHERE user_name = @UserName AND code_hash = @Hash AND used_date IS NULL
+		`, sql.Named("UserName", userName), sql.Named("Hash", utils.HashRecoveryCode(req.RecoveryCode)))
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// replaceRecoveryCodes ลบ Recovery Code เดิมทั้งหมดและออกชุดใหม่ คืนค่ารหัสจริงเพื่อแสดงครั้งเดียว
+func (h *MFAHandler) replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userName string) ([]string, error) {
+	plain, hashes, err := utils.GenerateRecoveryCodes(utils.RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM tb_user_recovery_code WHERE user_name = @UserName`, sql.Named("UserName", userName)); err != nil {
+		return nil, err
+	}
+	for _, hash := range hashes {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO tb_user_recovery_code (user_name, code_hash, create_date)
+			VALUES (@UserName, @Hash, `+h.dialect.Now()+`)
+		`, sql.Named("UserName", userName), sql.Named("Hash", hash)); err != nil {
+			return nil, err
+		}
+	}
+	return plain, nil
+}
+
+// LoginMFA - Login ขั้นที่ 2: แลก Challenge Token + รหัส TOTP (หรือ Recovery Code) เป็น JWT
+func (h *MFAHandler) LoginMFA(c *fiber.Ctx) error {
+	var req models.MFARequest
+	if err := c.BodyParser(&req); err != nil || req.MFAToken == "" {
+		h.log.Warn("MFA login attempt failed: Invalid request body")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "fail",
+			"error":  "Invalid request",
+		})
+	}
+
+	if config.IsBlacklisted(req.MFAToken) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status": "fail",
+			"error":  "MFA challenge already used",
+		})
+	}
+	challenge, err := auth.ParseMFAChallenge(req.MFAToken)
+	if err != nil {
+		h.log.WithError(err).Warn("MFA login attempt: Invalid or expired challenge")
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status": "fail",
+			"error":  "Invalid or expired MFA challenge",
+		})
+	}
+
+	remaining, ok := auth.TakeMFAAttempt(challenge)
+	if !ok {
+		return h.rejectChallenge(c, req.MFAToken, challenge.UserName)
+	}
+
+	user, err := h.loadMFAUser(c.UserContext(), challenge.UserName)
+	if err != nil {
+		h.log.WithError(err).Error("Database error during MFA login")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Database error",
+		})
+	}
+	if !user.Enabled {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status": "fail",
+			"error":  "MFA is not enabled for this user",
+		})
+	}
+
+	ok, err = h.verifySecondFactor(c.UserContext(), challenge.UserName, user.Secret, req)
+	if err != nil {
+		h.log.WithError(err).Error("Database error during MFA verification")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Database error",
+		})
+	}
+	if !ok {
+		if remaining == 0 {
+			return h.rejectChallenge(c, req.MFAToken, challenge.UserName)
+		}
+		h.log.WithFields(logrus.Fields{
+			"user_name": challenge.UserName,
+			"remaining": remaining,
+		}).Warn("MFA login attempt: Invalid code")
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":             "fail",
+			"error":              "Invalid MFA code",
+			"attempts_remaining": remaining,
+		})
+	}
+
+	tokenString, err := issueSessionToken(c, h.sessions, challenge.UserName, user.Roles, auth.AMRPassword, auth.AMRMFA)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to sign token during MFA login")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status": "error",
+			"error":  "Failed to create token",
+		})
+	}
+	// Challenge Token ใช้ได้ครั้งเดียว
+	config.AddToBlacklist(req.MFAToken)
+
+	h.log.WithFields(logrus.Fields{
+		"user_name":     challenge.UserName,
+		"recovery_code": req.Code == "",
+	}).Info("User logged in successfully with MFA")
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"token":  tokenString,
+	})
+}
+
+// rejectChallenge ยกเลิก Challenge Token ที่กรอกรหัสผิดครบ auth.MFAMaxAttempts ครั้ง ผู้ใช้ต้อง Login ด้วยรหัสผ่านใหม่
+func (h *MFAHandler) rejectChallenge(c *fiber.Ctx, token, userName string) error {
+	config.AddToBlacklist(token)
+	h.log.WithField("user_name", userName).Warn("MFA challenge revoked after too many invalid codes")
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"status": "fail",
+		"error":  "Too many invalid MFA codes, log in again",
+	})
+}
+
+// SelectMFAStatus - ดูสถานะ MFA ของผู้ใช้ปัจจุบัน
+func (h *MFAHandler) SelectMFAStatus(c *fiber.Ctx) error {
+	userName := utils.ResolveUser(c)
+	user, err := h.loadMFAUser(c.UserContext(), userName)
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch MFA status")
+	}
+
+	status := models.MFAStatus{Enabled: user.Enabled, Required: auth.RequiresMFA(user.Roles)}
+	if err := h.db.QueryRowContext(c.UserContext(), `
+		SELECT COUNT(*) FROM tb_user_recovery_code WHERE user_name = @UserName AND used_date IS NULL
+	`, sql.Named("UserName", userName)).Scan(&status.RecoveryCodesRemaining); err != nil {
+		return utils.Internal(err, "Failed to fetch MFA status")
+	}
+
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "MFA status retrieved successfully",
+		Data:    status,
+	})
+}
+
+// EnrollMFA - สร้าง Secret ใหม่และคืนค่า otpauth URI (ยังไม่เปิดใช้จนกว่าจะ verify)
+func (h *MFAHandler) EnrollMFA(c *fiber.Ctx) error {
+	userName := utils.ResolveUser(c)
+	user, err := h.loadMFAUser(c.UserContext(), userName)
+	if err != nil {
+		return utils.Internal(err, "Failed to start MFA enrolment")
+	}
+	if user.Enabled {
+		return c.Status(fiber.StatusConflict).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: "MFA is already enabled",
+			Data:    nil,
+		})
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return utils.Internal(err, "Failed to start MFA enrolment")
+	}
+	if _, err := h.db.ExecContext(c.UserContext(), `
+		UPDATE tb_users SET mfa_secret = @Secret, mfa_enabled = 0, mfa_last_step = NULL
+		WHERE user_name = @UserName
+	`, sql.Named("Secret", secret), sql.Named("UserName", userName)); err != nil {
+		return utils.Internal(err, "Failed to start MFA enrolment")
+	}
+
+	h.log.WithField("user_name", userName).Info("MFA enrolment started")
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "Scan the otpauth URI with your authenticator app, then verify a code",
+		Data: models.MFAEnrollment{
+			Secret:     secret,
+			OtpauthURI: utils.TOTPURI(h.issuer, userName, secret),
+		},
+	})
+}
+
+// VerifyMFA - ยืนยันรหัส TOTP ครั้งแรกเพื่อเปิดใช้ MFA และออก Recovery Code
+func (h *MFAHandler) VerifyMFA(c *fiber.Ctx) error {
+	var req models.MFARequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: "code is required",
+			Data:    nil,
+		})
+	}
+
+	userName := utils.ResolveUser(c)
+	user, err := h.loadMFAUser(c.UserContext(), userName)
+	if err != nil {
+		return utils.Internal(err, "Failed to verify MFA")
+	}
+	if user.Enabled {
+		return c.Status(fiber.StatusConflict).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: "MFA is already enabled",
+			Data:    nil,
+		})
+	}
+	if user.Secret == "" {
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: "MFA enrolment has not been started",
+			Data:    nil,
+		})
+	}
+
+	step, ok := utils.ValidateTOTP(user.Secret, req.Code, time.Now())
+	if !ok {
+		h.log.WithField("user_name", userName).Warn("MFA verification failed: Invalid code")
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: "Invalid MFA code",
+			Data:    nil,
+		})
+	}
+
+	var codes []string
+	err = utils.ExecuteTransaction(c.UserContext(), h.db, []func(tx *sql.Tx) error{
+		func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(c.UserContext(), `
+				UPDATE tb_users
+				SET mfa_enabled = 1,
+					mfa_last_step = @Step,
+					mfa_enable_date = `+h.dialect.Now()+`
+				WHERE user_name = @UserName
+			`, sql.Named("Step", step), sql.Named("UserName", userName))
+			return err
+		},
+		func(tx *sql.Tx) error {
+			var err error
+			codes, err = h.replaceRecoveryCodes(c.UserContext(), tx, userName)
+			return err
+		},
+	})
+	if err != nil {
+		return utils.Internal(err, "Failed to enable MFA")
+	}
+
+	h.log.WithField("user_name", userName).Info("MFA enabled")
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "MFA enabled. Store the recovery codes in a safe place; they are shown only once",
+		Data:    fiber.Map{"recovery_codes": codes},
+	})
+}
+
+// RegenerateRecoveryCodes - ออก Recovery Code ชุดใหม่ (ต้องยืนยันด้วยรหัส TOTP ปัจจุบัน)
+func (h *MFAHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	var req models.MFARequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: "code is required",
+			Data:    nil,
+		})
+	}
+
+	userName := utils.ResolveUser(c)
+	user, err := h.loadMFAUser(c.UserContext(), userName)
+	if err != nil {
+		return utils.Internal(err, "Failed to regenerate recovery codes")
+	}
+	if !user.Enabled {
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: "MFA is not enabled",
+			Data:    nil,
+		})
+	}
+
+	// ต้องใช้รหัส TOTP เท่านั้น (ไม่รับ Recovery Code) เพื่อยืนยันว่ายังถือ Authenticator อยู่
+	ok, err := h.verifySecondFactor(c.UserContext(), userName, user.Secret, models.MFARequest{Code: req.Code})
+	if err != nil {
+		return utils.Internal(err, "Failed to regenerate recovery codes")
+	}
+	if !ok {
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: "Invalid MFA code",
+			Data:    nil,
+		})
+	}
+
+	var codes []string
+	err = utils.ExecuteTransaction(c.UserContext(), h.db, []func(tx *sql.Tx) error{
+		func(tx *sql.Tx) error {
+			var err error
+			codes, err = h.replaceRecoveryCodes(c.UserContext(), tx, userName)
+			return err
+		},
+	})
+	if err != nil {
+		return utils.Internal(err, "Failed to regenerate recovery codes")
+	}
+
+	h.log.WithField("user_name", userName).Info("MFA recovery codes regenerated")
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "Recovery codes regenerated",
+		Data:    fiber.Map{"recovery_codes": codes},
+	})
+}
+
+// DisableMFA - ปิด MFA (ยืนยันด้วยรหัส TOTP หรือ Recovery Code) ยกเว้น role ที่ถูกบังคับใช้ MFA
+func (h *MFAHandler) DisableMFA(c *fiber.Ctx) error {
+	var req models.MFARequest
+	if err := c.BodyParser(&req); err != nil || (req.Code == "" && req.RecoveryCode == "") {
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: "code or recovery_code is required",
+			Data:    nil,
+		})
+	}
+
+	userName := utils.ResolveUser(c)
+	user, err := h.loadMFAUser(c.UserContext(), userName)
+	if err != nil {
+		return utils.Internal(err, "Failed to disable MFA")
+	}
+	if !user.Enabled {
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: "MFA is not enabled",
+			Data:    nil,
+		})
+	}
+	if auth.RequiresMFA(user.Roles) {
+		return c.Status(fiber.StatusForbidden).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: "MFA is mandatory for your role",
+			Data:    nil,
+		})
+	}
+
+	ok, err := h.verifySecondFactor(c.UserContext(), userName, user.Secret, req)
+	if err != nil {
+		return utils.Internal(err, "Failed to disable MFA")
+	}
+	if !ok {
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: "Invalid MFA code",
+			Data:    nil,
+		})
+	}
+
+	err = utils.ExecuteTransaction(c.UserContext(), h.db, []func(tx *sql.Tx) error{
+		func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(c.UserContext(), `
+				UPDATE tb_users
+				SET mfa_enabled = 0, mfa_secret = NULL, mfa_last_step = NULL, mfa_enable_date = NULL
+				WHERE user_name = @UserName
+			`, sql.Named("UserName", userName))
+			return err
+		},
+		func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(c.UserContext(), `DELETE FROM tb_user_recovery_code WHERE user_name = @UserName`, sql.Named("UserName", userName))
+			return err
+		},
+	})
+	if err != nil {
+		return utils.Internal(err, "Failed to disable MFA")
+	}
+
+	h.log.WithField("user_name", userName).Info("MFA disabled")
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "MFA disabled",
+		Data:    nil,
+	})
+}
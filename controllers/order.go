@@ -2,270 +2,177 @@ package controllers
 
 import (
 	"PenbunAPI/models"
-	"database/sql"
-	
+	"PenbunAPI/repository"
+	"PenbunAPI/utils"
+
 	"github.com/gofiber/fiber/v2"
 )
 
-// SelectAllOrders ดึงข้อมูลใบสั่งขายทั้งหมด
-func SelectAllOrders(c *fiber.Ctx) error {
-	db := c.Locals("db").(*sql.DB)
+// OrderHandler คือ Handler ของ Order API ซึ่งเข้าถึงข้อมูลผ่าน OrderRepository
+type OrderHandler struct {
+	repo repository.OrderRepository
+}
 
-	query := `
-		SELECT o.order_id, o.customer_id, c.customer_name, o.warehouse_id, w.warehouse_name,
-		       o.doc_date, o.doc_type, o.total_amount, o.discount_amount, o.net_amount, o.vat_amount, o.grand_total,
-		       o.update_by, o.update_date, o.is_active
-		FROM tb_order o
-		LEFT JOIN tb_customer c ON o.customer_id = c.customer_id
-		LEFT JOIN tb_warehouse w ON o.warehouse_id = w.warehouse_id
-		WHERE o.is_delete = 0
-		ORDER BY o.doc_date DESC
-	`
+// NewOrderHandler สร้าง OrderHandler (ใช้ repository.NewMemoryOrderRepository เมื่อทดสอบโดยไม่มีฐานข้อมูล)
+func NewOrderHandler(repo repository.OrderRepository) *OrderHandler {
+	return &OrderHandler{repo: repo}
+}
 
-	rows, err := db.Query(query)
+// SelectAllOrders ดึงข้อมูลใบสั่งขายทั้งหมด
+func (h *OrderHandler) SelectAllOrders(c *fiber.Ctx) error {
+	orders, err := h.repo.List(c.UserContext())
 	if err != nil {
-		return c.Status(500).SendString(err.Error())
+		return utils.Internal(err, "Failed to fetch orders")
 	}
-	defer rows.Close()
 
-	var orders []models.Order
-	for rows.Next() {
-		var o models.Order
-		var customerName, warehouseName *string
-		if err := rows.Scan(&o.OrderID, &o.CustomerID, &customerName, &o.WarehouseID, &warehouseName,
-			&o.DocDate, &o.DocType, &o.TotalAmount, &o.DiscountAmount, &o.NetAmount, &o.VatAmount, &o.GrandTotal,
-			&o.UpdateBy, &o.UpdateDate, &o.IsActive); err != nil {
-			return c.Status(500).SendString(err.Error())
-		}
-		o.CustomerName = customerName
-		orders = append(orders, o)
-	}
+	return c.JSON(models.ApiResponse{
+		Status: "success",
+		Data:   orders,
+	})
+}
 
-	return c.JSON(fiber.Map{"data": orders})
+// orderListSpec คือ field ที่ SelectPageOrders อนุญาตให้ใช้กับ ?filter=, ?sort= และ ?q=
+var orderListSpec = utils.ListSpec{
+	Fields: map[string]utils.ListField{
+		"order_id":        {Column: "o.order_id", Type: utils.FieldText},
+		"customer_id":     {Column: "o.customer_id", Type: utils.FieldText},
+		"customer_name":   {Column: "c.customer_name", Type: utils.FieldText},
+		"warehouse_id":    {Column: "o.warehouse_id", Type: utils.FieldText},
+		"warehouse_name":  {Column: "w.warehouse_name", Type: utils.FieldText},
+		"doc_date":        {Column: "o.doc_date", Type: utils.FieldDate},
+		"doc_type":        {Column: "o.doc_type", Type: utils.FieldText},
+		"total_amount":    {Column: "o.total_amount", Type: utils.FieldNumber},
+		"discount_amount": {Column: "o.discount_amount", Type: utils.FieldNumber},
+		"net_amount":      {Column: "o.net_amount", Type: utils.FieldNumber},
+		"vat_amount":      {Column: "o.vat_amount", Type: utils.FieldNumber},
+		"grand_total":     {Column: "o.grand_total", Type: utils.FieldNumber},
+		"is_active":       {Column: "o.is_active", Type: utils.FieldBool},
+		"update_by":       {Column: "o.update_by", Type: utils.FieldText},
+		"update_date":     {Column: "o.update_date", Type: utils.FieldDate},
+	},
+	Search:      []string{"o.order_id", "c.customer_name", "w.warehouse_name"},
+	DefaultSort: "o.doc_date DESC",
+	Key:         "order_id",
 }
 
 // SelectPageOrders ดึงข้อมูลใบสั่งขายแบบ Paging
-func SelectPageOrders(c *fiber.Ctx) error {
-	db := c.Locals("db").(*sql.DB)
-	page := c.QueryInt("page", 1)
-	limit := c.QueryInt("limit", 10)
-	offset := (page - 1) * limit
-
-	var total int
-	countQuery := `SELECT COUNT(*) FROM tb_order WHERE is_delete = 0`
-	if err := db.QueryRow(countQuery).Scan(&total); err != nil {
-		return c.Status(500).SendString(err.Error())
+func (h *OrderHandler) SelectPageOrders(c *fiber.Ctx) error {
+	lq, err := utils.ParseListQuery(c, orderListSpec)
+	if err != nil {
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: err.Error(),
+			Data:    nil,
+		})
 	}
+	page, limit := lq.Page, lq.Limit
 
-	query := `
-		SELECT o.order_id, o.customer_id, c.customer_name, o.warehouse_id, w.warehouse_name,
-		       o.doc_date, o.doc_type, o.total_amount, o.discount_amount, o.net_amount, o.vat_amount, o.grand_total,
-		       o.update_by, o.update_date, o.is_active
-		FROM tb_order o
-		LEFT JOIN tb_customer c ON o.customer_id = c.customer_id
-		LEFT JOIN tb_warehouse w ON o.warehouse_id = w.warehouse_id
-		WHERE o.is_delete = 0
-		ORDER BY o.doc_date DESC
-		OFFSET @Offset ROWS FETCH NEXT @Limit ROWS ONLY
-	`
-
-	rows, err := db.Query(query, sql.Named("Offset", offset), sql.Named("Limit", limit))
+	orders, total, err := h.repo.Page(c.UserContext(), lq)
 	if err != nil {
-		return c.Status(500).SendString(err.Error())
+		return utils.Internal(err, "Failed to fetch orders")
 	}
-	defer rows.Close()
 
-	var orders []models.Order
-	for rows.Next() {
-		var o models.Order
-		var customerName, warehouseName *string
-		if err := rows.Scan(&o.OrderID, &o.CustomerID, &customerName, &o.WarehouseID, &warehouseName,
-			&o.DocDate, &o.DocType, &o.TotalAmount, &o.DiscountAmount, &o.NetAmount, &o.VatAmount, &o.GrandTotal,
-			&o.UpdateBy, &o.UpdateDate, &o.IsActive); err != nil {
-			return c.Status(500).SendString(err.Error())
-		}
-		o.CustomerName = customerName
-		orders = append(orders, o)
+	orders, next := utils.CursorPage(lq, orders, func(item models.Order) interface{} { return item.OrderID })
+
+	if utils.UsePageEnvelope(c) {
+		return utils.SendPage(c, lq, orders, total, next)
 	}
 
-	return c.JSON(fiber.Map{
+	return c.JSON(lq.Paged(fiber.Map{
 		"data":  orders,
 		"total": total,
 		"page":  page,
 		"limit": limit,
-	})
+	}, next))
 }
 
-// SelectOrderByID ดึงข้อมูลใบสั่งขายตาม ID
-func SelectOrderByID(c *fiber.Ctx) error {
-	db := c.Locals("db").(*sql.DB)
-	id := c.Params("id")
-
-	// 1. Header
-	queryHeader := `
-		SELECT o.order_id, o.customer_id, c.customer_name, o.warehouse_id, w.warehouse_name,
-		       o.doc_date, o.doc_type, o.total_amount, o.discount_amount, o.net_amount, o.vat_amount, o.grand_total,
-		       o.update_by, o.update_date, o.is_active
-		FROM tb_order o
-		LEFT JOIN tb_customer c ON o.customer_id = c.customer_id
-		LEFT JOIN tb_warehouse w ON o.warehouse_id = w.warehouse_id
-		WHERE o.order_id = @ID AND o.is_delete = 0
-	`
-	var o models.Order
-	var customerName, warehouseName *string
-	err := db.QueryRow(queryHeader, sql.Named("ID", id)).Scan(
-		&o.OrderID, &o.CustomerID, &customerName, &o.WarehouseID, &warehouseName,
-		&o.DocDate, &o.DocType, &o.TotalAmount, &o.DiscountAmount, &o.NetAmount, &o.VatAmount, &o.GrandTotal,
-		&o.UpdateBy, &o.UpdateDate, &o.IsActive,
-	)
-	if err == sql.ErrNoRows {
-		return c.Status(404).SendString("Order not found")
+// SelectOrderByID ดึงข้อมูลใบสั่งขายตาม ID (พร้อม Items)
+func (h *OrderHandler) SelectOrderByID(c *fiber.Ctx) error {
+	doc, err := h.repo.Get(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "Order not found",
+			Data:    nil,
+		})
 	} else if err != nil {
-		return c.Status(500).SendString(err.Error())
-	}
-	o.CustomerName = customerName
-
-	// 2. Items
-	queryItems := `
-		SELECT oi.auto_id, oi.order_id, oi.product_id, p.product_name,
-		       oi.qty, oi.unit_price, oi.discount_amount, oi.line_total, oi.remark
-		FROM tb_order_item oi
-		LEFT JOIN tb_product p ON oi.product_id = p.product_id
-		WHERE oi.order_id = @ID AND oi.is_delete = 0
-	`
-	rows, err := db.Query(queryItems, sql.Named("ID", id))
-	if err != nil { return c.Status(500).SendString(err.Error()) }
-	defer rows.Close()
-
-	var items []models.OrderItem
-	for rows.Next() {
-		var item models.OrderItem
-		var productName *string
-		if err := rows.Scan(&item.AutoID, &item.OrderID, &item.ProductID, &productName,
-			&item.Qty, &item.UnitPrice, &item.DiscountAmount, &item.LineTotal, &item.Remark); err != nil {
-			return c.Status(500).SendString(err.Error())
-		}
-		item.ProductName = productName
-		items = append(items, item)
+		return utils.Internal(err, "Failed to fetch order")
 	}
 
-	return c.JSON(fiber.Map{"header": o, "items": items})
+	return c.JSON(models.ApiResponse{
+		Status: "success",
+		Data:   fiber.Map{"header": doc.Header, "items": doc.Items},
+	})
 }
 
-// InsertOrder เพิ่มใบสั่งขาย
-func InsertOrder(c *fiber.Ctx) error {
-	db := c.Locals("db").(*sql.DB)
-	
-	type InsertRequest struct {
-		Header models.Order      `json:"header"`
-		Items  []models.OrderItem `json:"items"`
-	}
-
-	var req InsertRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).SendString("Invalid Body")
+// InsertOrder เพิ่มใบสั่งขาย (Header + Items ใน Transaction เดียว)
+func (h *OrderHandler) InsertOrder(c *fiber.Ctx) error {
+	var req repository.Order
+	if err := utils.Bind(c, &req); err != nil {
+		return err
 	}
 
-	tx, err := db.Begin()
-	if err != nil { return c.Status(500).SendString(err.Error()) }
-
-	user := "System" // Or from auth
-
-	// 1. Header
-	queryHeader := `
-		INSERT INTO tb_order (
-			customer_id, warehouse_id, doc_date, doc_type,
-			total_amount, discount_amount, net_amount, vat_amount, grand_total,
-			update_by, is_active
-		) VALUES (
-			@CustomerID, @WarehouseID, @DocDate, @DocType,
-			@Total, @Discount, @Net, @Vat, @Grand,
-			@UpdateBy, 1
-		)
-	`
-	_, err = tx.Exec(queryHeader,
-		sql.Named("CustomerID", req.Header.CustomerID),
-		sql.Named("WarehouseID", req.Header.WarehouseID),
-		sql.Named("DocDate", req.Header.DocDate),
-		sql.Named("DocType", req.Header.DocType),
-		sql.Named("Total", req.Header.TotalAmount),
-		sql.Named("Discount", req.Header.DiscountAmount),
-		sql.Named("Net", req.Header.NetAmount),
-		sql.Named("Vat", req.Header.VatAmount),
-		sql.Named("Grand", req.Header.GrandTotal),
-		sql.Named("UpdateBy", user),
-	)
-	if err != nil { tx.Rollback(); return c.Status(500).SendString("Insert Header Fail: "+err.Error()) }
-
-	// 2. Fetch ID
-	var newID string
-	err = tx.QueryRow("SELECT TOP 1 order_id FROM tb_order WHERE update_by = @UpdateBy ORDER BY autoID DESC", sql.Named("UpdateBy", user)).Scan(&newID)
-	if err != nil { tx.Rollback(); return c.Status(500).SendString("Fetch ID Fail: "+err.Error()) }
-
-	// 3. Items
-	queryItem := `
-		INSERT INTO tb_order_item (order_id, product_id, qty, unit_price, discount_amount, line_total, remark)
-		VALUES (@OrderID, @ProductID, @Qty, @Price, @Discount, @Total, @Remark)
-	`
-	for _, item := range req.Items {
-		_, err := tx.Exec(queryItem,
-			sql.Named("OrderID", newID),
-			sql.Named("ProductID", item.ProductID),
-			sql.Named("Qty", item.Qty),
-			sql.Named("Price", item.UnitPrice),
-			sql.Named("Discount", item.DiscountAmount),
-			sql.Named("Total", item.LineTotal),
-			sql.Named("Remark", item.Remark),
-		)
-		if err != nil { tx.Rollback(); return c.Status(500).SendString("Insert Item Fail: "+err.Error()) }
+	// ผู้บันทึกเอกสารมาจากผู้ใช้ที่ยืนยันตัวตนแล้วเสมอ
+	newID, err := h.repo.Create(c.UserContext(), &req, utils.ResolveUser(c))
+	if err != nil {
+		return utils.Internal(err, "Failed to insert order")
 	}
 
-	if err := tx.Commit(); err != nil { return c.Status(500).SendString("Commit Fail: "+err.Error()) }
-
-	return c.JSON(fiber.Map{"status": "success", "id": newID})
+	utils.SetCreatedID(c, newID)
+	return c.Status(201).JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "Order added successfully",
+		Data:    fiber.Map{"order_id": newID},
+	})
 }
 
 // UpdateOrderByID (Optional updates)
-func UpdateOrderByID(c *fiber.Ctx) error {
-	return c.Status(501).SendString("Update logic omitted for MVP")
+func (h *OrderHandler) UpdateOrderByID(c *fiber.Ctx) error {
+	return c.Status(501).JSON(models.ApiResponse{
+		Status:  "error",
+		Message: "Update logic omitted for MVP",
+		Data:    nil,
+	})
 }
 
-// DeleteOrderByID (Soft)
-func DeleteOrderByID(c *fiber.Ctx) error {
-	db := c.Locals("db").(*sql.DB)
-	id := c.Params("id")
-	
-	tx, err := db.Begin()
-	if err != nil { return c.Status(500).SendString(err.Error()) }
-
-	// Header
-	_, err = tx.Exec(`UPDATE tb_order SET is_delete = 1, is_active = 0 WHERE order_id = @ID`, sql.Named("ID", id))
-	if err != nil { tx.Rollback(); return c.Status(500).SendString(err.Error()) }
-
-	// Items
-	_, err = tx.Exec(`UPDATE tb_order_item SET is_delete = 1 WHERE order_id = @ID`, sql.Named("ID", id))
-	if err != nil { tx.Rollback(); return c.Status(500).SendString(err.Error()) }
+// DeleteOrderByID (Soft) ทั้ง Header และ Items
+func (h *OrderHandler) DeleteOrderByID(c *fiber.Ctx) error {
+	err := h.repo.SoftDelete(c.UserContext(), c.Params("id"), utils.ResolveUser(c))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "Order not found",
+			Data:    nil,
+		})
+	}
+	if err != nil {
+		return utils.Internal(err, "Failed to delete order")
+	}
 
-	tx.Commit()
-	return c.JSON(fiber.Map{"status": "success"})
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "Order deleted successfully",
+		Data:    nil,
+	})
 }
 
-// RemoveOrderByID (Hard)
-func RemoveOrderByID(c *fiber.Ctx) error {
-	db := c.Locals("db").(*sql.DB)
-	id := c.Params("id")
-	
-	tx, err := db.Begin()
-	if err != nil { return c.Status(500).SendString(err.Error()) }
-
-	// Items first
-	_, err = tx.Exec("DELETE FROM tb_order_item WHERE order_id = @ID", sql.Named("ID", id))
-	if err != nil { tx.Rollback(); return c.Status(500).SendString(err.Error()) }
-
-	// Header
-	_, err = tx.Exec("DELETE FROM tb_order WHERE order_id = @ID", sql.Named("ID", id))
-	if err != nil { tx.Rollback(); return c.Status(500).SendString(err.Error()) }
+// RemoveOrderByID (Hard) ลบ Items ก่อนเพราะติด FK
+func (h *OrderHandler) RemoveOrderByID(c *fiber.Ctx) error {
+	err := h.repo.Purge(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "Order not found",
+			Data:    nil,
+		})
+	}
+	if err != nil {
+		return utils.Internal(err, "Failed to remove order")
+	}
 
-	tx.Commit()
-	return c.JSON(fiber.Map{"status": "success"})
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "Order removed successfully",
+		Data:    nil,
+	})
 }
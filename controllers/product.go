@@ -1,192 +1,115 @@
 package controllers
 
 import (
-	"PenbunAPI/config"
 	"PenbunAPI/models"
+	"PenbunAPI/repository"
 	"PenbunAPI/utils"
-	"database/sql"
-	"log"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// ProductHandler คือ Handler ของ Product API ซึ่งเข้าถึงข้อมูลผ่าน ProductRepository
+type ProductHandler struct {
+	repo repository.ProductRepository
+}
+
+// NewProductHandler สร้าง ProductHandler (ใช้ repository.NewMemoryProductRepository เมื่อทดสอบโดยไม่มีฐานข้อมูล)
+func NewProductHandler(repo repository.ProductRepository) *ProductHandler {
+	return &ProductHandler{repo: repo}
+}
+
 // 1. Select All
-func SelectAllProducts(c *fiber.Ctx) error {
-	query := `
-		SELECT autoID, prefix, product_id, product_name_th, product_name_en,
-		       product_type_id, format_type_id, vendor_id, unit_type_id,
-		       isbn, author_name, publisher_date, edition_number,
-		       price, cost, description, note,
-		       count_stock, is_active, is_delete, update_by, update_date
-		FROM tb_product
-		WHERE is_delete = 0
-	`
-	rows, err := config.DB.Query(query)
+func (h *ProductHandler) SelectAllProducts(c *fiber.Ctx) error {
+	list, err := h.repo.List(c.UserContext())
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status: "error", Message: "Failed to fetch products", Data: nil,
-		})
-	}
-	defer rows.Close()
-
-	var list []models.Product
-	for rows.Next() {
-		var p models.Product
-		if err := rows.Scan(
-			&p.AutoID, &p.Prefix, &p.ProductID, &p.ProductNameTH, &p.ProductNameEN,
-			&p.ProductTypeID, &p.FormatTypeID, &p.VendorID, &p.UnitTypeID,
-			&p.ISBN, &p.AuthorName, &p.PublisherDate, &p.EditionNumber,
-			&p.Price, &p.Cost, &p.Description, &p.Note,
-			&p.CountStock, &p.IsActive, &p.IsDelete, &p.UpdateBy, &p.UpdateDate,
-		); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status: "error", Message: "Failed to read data", Data: nil,
-			})
-		}
-		list = append(list, p)
+		return utils.Internal(err, "Failed to fetch products")
 	}
 	return c.JSON(models.ApiResponse{
 		Status: "success", Message: "", Data: list,
 	})
 }
 
-// 2. Select Paging
-func SelectPageProducts(c *fiber.Ctx) error {
-	page := c.QueryInt("page", 1)
-	limit := c.QueryInt("limit", 10)
-	offset := (page - 1) * limit
+// productListSpec คือ field ที่ SelectPageProducts อนุญาตให้ใช้กับ ?filter=, ?sort= และ ?q=
+var productListSpec = utils.ListSpec{
+	Fields: map[string]utils.ListField{
+		"auto_id":         {Column: "autoID", Type: utils.FieldNumber},
+		"product_id":      {Column: "product_id", Type: utils.FieldText},
+		"prefix":          {Column: "prefix", Type: utils.FieldText},
+		"product_name_th": {Column: "product_name_th", Type: utils.FieldText},
+		"product_name_en": {Column: "product_name_en", Type: utils.FieldText},
+		"product_type_id": {Column: "product_type_id", Type: utils.FieldText},
+		"format_type_id":  {Column: "format_type_id", Type: utils.FieldText},
+		"vendor_id":       {Column: "vendor_id", Type: utils.FieldText},
+		"unit_type_id":    {Column: "unit_type_id", Type: utils.FieldText},
+		"isbn":            {Column: "isbn", Type: utils.FieldText},
+		"author_name":     {Column: "author_name", Type: utils.FieldText},
+		"publisher_date":  {Column: "publisher_date", Type: utils.FieldDate},
+		"edition_number":  {Column: "edition_number", Type: utils.FieldNumber},
+		"price":           {Column: "price", Type: utils.FieldNumber},
+		"cost":            {Column: "cost", Type: utils.FieldNumber},
+		"count_stock":     {Column: "count_stock", Type: utils.FieldBool},
+		"is_active":       {Column: "is_active", Type: utils.FieldBool},
+		"update_by":       {Column: "update_by", Type: utils.FieldText},
+		"update_date":     {Column: "update_date", Type: utils.FieldDate},
+	},
+	Search:      []string{"product_id", "product_name_th", "product_name_en", "isbn", "author_name"},
+	DefaultSort: "update_date DESC",
+	Key:         "auto_id",
+}
 
-	query := `
-		SELECT autoID, prefix, product_id, product_name_th, product_name_en,
-		       product_type_id, format_type_id, vendor_id, unit_type_id,
-		       isbn, author_name, publisher_date, edition_number,
-		       price, cost, description, note,
-		       count_stock, is_active, is_delete, update_by, update_date
-		FROM tb_product
-		WHERE is_delete = 0
-		ORDER BY update_date DESC
-		OFFSET @Offset ROWS FETCH NEXT @Limit ROWS ONLY
-	`
-	rows, err := config.DB.Query(query, sql.Named("Offset", offset), sql.Named("Limit", limit))
+// 2. Select Paging
+func (h *ProductHandler) SelectPageProducts(c *fiber.Ctx) error {
+	lq, err := utils.ParseListQuery(c, productListSpec)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status: "error", Message: "Failed to fetch products", Data: nil,
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: err.Error(),
+			Data:    nil,
 		})
 	}
-	defer rows.Close()
+	page, limit := lq.Page, lq.Limit
 
-	var list []models.Product
-	for rows.Next() {
-		var p models.Product
-		if err := rows.Scan(
-			&p.AutoID, &p.Prefix, &p.ProductID, &p.ProductNameTH, &p.ProductNameEN,
-			&p.ProductTypeID, &p.FormatTypeID, &p.VendorID, &p.UnitTypeID,
-			&p.ISBN, &p.AuthorName, &p.PublisherDate, &p.EditionNumber,
-			&p.Price, &p.Cost, &p.Description, &p.Note,
-			&p.CountStock, &p.IsActive, &p.IsDelete, &p.UpdateBy, &p.UpdateDate,
-		); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status: "error", Message: "Failed to read data", Data: nil,
-			})
-		}
-		list = append(list, p)
+	list, total, err := h.repo.Page(c.UserContext(), lq)
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch products")
 	}
 
-	var total int
-	err = config.DB.QueryRow(`SELECT COUNT(*) FROM tb_product WHERE is_delete = 0`).Scan(&total)
-	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status: "error", Message: "Failed to count records", Data: nil,
-		})
+	list, next := utils.CursorPage(lq, list, func(item models.Product) interface{} { return item.AutoID })
+
+	if utils.UsePageEnvelope(c) {
+		return utils.SendPage(c, lq, list, total, next)
 	}
 
 	return c.JSON(models.ApiResponse{
 		Status: "success",
-		Data: fiber.Map{
+		Data: lq.Paged(fiber.Map{
 			"page": page, "limit": limit, "total": total,
 			"products": list,
-		},
+		}, next),
 	})
 }
 
 // 3. Select By ID
-func SelectProductByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `
-		SELECT autoID, prefix, product_id, product_name_th, product_name_en,
-		       product_type_id, format_type_id, vendor_id, unit_type_id,
-		       isbn, author_name, publisher_date, edition_number,
-		       price, cost, description, note,
-		       count_stock, is_active, is_delete, update_by, update_date
-		FROM tb_product
-		WHERE product_id = @ID AND is_delete = 0
-	`
-	row := config.DB.QueryRow(query, sql.Named("ID", id))
-	var p models.Product
-	if err := row.Scan(
-		&p.AutoID, &p.Prefix, &p.ProductID, &p.ProductNameTH, &p.ProductNameEN,
-		&p.ProductTypeID, &p.FormatTypeID, &p.VendorID, &p.UnitTypeID,
-		&p.ISBN, &p.AuthorName, &p.PublisherDate, &p.EditionNumber,
-		&p.Price, &p.Cost, &p.Description, &p.Note,
-		&p.CountStock, &p.IsActive, &p.IsDelete, &p.UpdateBy, &p.UpdateDate,
-	); err != nil {
-		if err == sql.ErrNoRows {
-			return c.Status(404).JSON(models.ApiResponse{
-				Status: "error", Message: "Product not found", Data: nil,
-			})
-		}
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status: "error", Message: "Failed to read product", Data: nil,
+func (h *ProductHandler) SelectProductByID(c *fiber.Ctx) error {
+	p, err := h.repo.Get(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
+			Status: "error", Message: "Product not found", Data: nil,
 		})
 	}
+	if err != nil {
+		return utils.Internal(err, "Failed to read product")
+	}
 	return c.JSON(models.ApiResponse{
 		Status: "success", Message: "", Data: p,
 	})
 }
 
 // 4. Select By Name (LIKE)
-func SelectProductByName(c *fiber.Ctx) error {
-	name := c.Params("name")
-	query := `
-		SELECT autoID, prefix, product_id, product_name_th, product_name_en,
-		       product_type_id, format_type_id, vendor_id, unit_type_id,
-		       isbn, author_name, publisher_date, edition_number,
-		       price, cost, description, note,
-		       count_stock, is_active, is_delete, update_by, update_date
-		FROM tb_product
-		WHERE (product_name_th LIKE '%' + @Name + '%' OR product_name_en LIKE '%' + @Name + '%') AND is_delete = 0
-	`
-	rows, err := config.DB.Query(query, sql.Named("Name", name))
+func (h *ProductHandler) SelectProductByName(c *fiber.Ctx) error {
+	list, err := h.repo.Search(c.UserContext(), c.Params("name"))
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status: "error", Message: "Failed to search products", Data: nil,
-		})
-	}
-	defer rows.Close()
-
-	var list []models.Product
-	for rows.Next() {
-		var p models.Product
-		if err := rows.Scan(
-			&p.AutoID, &p.Prefix, &p.ProductID, &p.ProductNameTH, &p.ProductNameEN,
-			&p.ProductTypeID, &p.FormatTypeID, &p.VendorID, &p.UnitTypeID,
-			&p.ISBN, &p.AuthorName, &p.PublisherDate, &p.EditionNumber,
-			&p.Price, &p.Cost, &p.Description, &p.Note,
-			&p.CountStock, &p.IsActive, &p.IsDelete, &p.UpdateBy, &p.UpdateDate,
-		); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status: "error", Message: "Failed to read data", Data: nil,
-			})
-		}
-		list = append(list, p)
+		return utils.Internal(err, "Failed to search products")
 	}
 	return c.JSON(models.ApiResponse{
 		Status: "success", Message: "", Data: list,
@@ -194,175 +117,85 @@ func SelectProductByName(c *fiber.Ctx) error {
 }
 
 // 5. Insert
-func InsertProduct(c *fiber.Ctx) error {
+func (h *ProductHandler) InsertProduct(c *fiber.Ctx) error {
 	var p models.Product
-	if err := c.BodyParser(&p); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status: "error", Message: "Invalid request body", Data: nil,
-		})
+	if err := utils.Bind(c, &p); err != nil {
+		return err
 	}
 
-	// 🚩 DUMMY ID for TRIGGER mechanism (product_id is NOT NULL)
-	dummyID := "TEMP"
+	p.UpdateBy = utils.StampUser(c, p.UpdateBy)
 
-	query := `
-		INSERT INTO tb_product (
-			product_id, product_name_th, product_name_en,
-			product_type_id, format_type_id, vendor_id, unit_type_id,
-			isbn, author_name, publisher_date, edition_number,
-			price, cost, description, note,
-			count_stock, update_by
-		)
-		VALUES (
-			@ProductID, @NameTH, @NameEN,
-			@TypeID, @FormatID, @VendorID, @UnitID,
-			@ISBN, @Author, @PubDate, @Edition,
-			@Price, @Cost, @Desc, @Note,
-			@CountStock, @UpdateBy
-		)
-	`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			_, err := tx.Exec(query,
-				sql.Named("ProductID", dummyID), // Passed strictly to satisfy NOT NULL constraint
-				sql.Named("NameTH", p.ProductNameTH),
-				sql.Named("NameEN", p.ProductNameEN),
-				sql.Named("TypeID", p.ProductTypeID),
-				sql.Named("FormatID", p.FormatTypeID),
-				sql.Named("VendorID", p.VendorID),
-				sql.Named("UnitID", p.UnitTypeID),
-				sql.Named("ISBN", p.ISBN),
-				sql.Named("Author", p.AuthorName),
-				sql.Named("PubDate", p.PublisherDate),
-				sql.Named("Edition", p.EditionNumber),
-				sql.Named("Price", p.Price),
-				sql.Named("Cost", p.Cost),
-				sql.Named("Desc", p.Description),
-				sql.Named("Note", p.Note),
-				sql.Named("CountStock", p.CountStock),
-				sql.Named("UpdateBy", p.UpdateBy),
-			)
-			return err
-		},
-	})
+	newID, err := h.repo.Create(c.UserContext(), &p)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status: "error", Message: "Failed to insert product", Data: nil,
-		})
+		return utils.Internal(err, "Failed to insert product")
 	}
+	utils.SetCreatedID(c, newID)
 	return c.Status(201).JSON(models.ApiResponse{
-		Status: "success", Message: "Product added successfully", Data: nil,
+		Status: "success", Message: "Product added successfully", Data: fiber.Map{"product_id": newID},
 	})
 }
 
 // 6. Update
-func UpdateProductByID(c *fiber.Ctx) error {
+func (h *ProductHandler) UpdateProductByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var p models.Product
-	if err := c.BodyParser(&p); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status: "error", Message: "Invalid request body", Data: nil,
-		})
+	if err := utils.BindPartial(c, &p); err != nil {
+		return err
 	}
 
-	query := `
-		UPDATE tb_product
-		SET product_name_th = COALESCE(NULLIF(@NameTH, ''), product_name_th),
-			product_name_en = @NameEN,
-			product_type_id = @TypeID,
-			format_type_id = @FormatID,
-			vendor_id = @VendorID,
-			unit_type_id = @UnitID,
-			isbn = @ISBN,
-			author_name = @Author,
-			publisher_date = @PubDate,
-			edition_number = @Edition,
-			price = @Price,
-			cost = @Cost,
-			description = @Desc,
-			note = @Note,
-			count_stock = @CountStock,
-			update_by = @UpdateBy
-		WHERE product_id = @ID AND is_delete = 0
-	`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			_, err := tx.Exec(query,
-				sql.Named("NameTH", p.ProductNameTH),
-				sql.Named("NameEN", p.ProductNameEN),
-				sql.Named("TypeID", p.ProductTypeID),
-				sql.Named("FormatID", p.FormatTypeID),
-				sql.Named("VendorID", p.VendorID),
-				sql.Named("UnitID", p.UnitTypeID),
-				sql.Named("ISBN", p.ISBN),
-				sql.Named("Author", p.AuthorName),
-				sql.Named("PubDate", p.PublisherDate),
-				sql.Named("Edition", p.EditionNumber),
-				sql.Named("Price", p.Price),
-				sql.Named("Cost", p.Cost),
-				sql.Named("Desc", p.Description),
-				sql.Named("Note", p.Note),
-				sql.Named("CountStock", p.CountStock),
-				sql.Named("UpdateBy", p.UpdateBy),
-				sql.Named("ID", id),
-			)
-			return err
-		},
-	})
-	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status: "error", Message: "Failed to update product", Data: nil,
+	p.UpdateBy = utils.StampUser(c, p.UpdateBy)
+
+	err := h.repo.Update(c.UserContext(), id, &p)
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "Product not found",
+			Data:    nil,
 		})
 	}
+	if err != nil {
+		return utils.Internal(err, "Failed to update product")
+	}
 	return c.JSON(models.ApiResponse{
 		Status: "success", Message: "Product updated successfully", Data: nil,
 	})
 }
 
 // 7. Delete (Soft)
-func DeleteProductByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `
-		UPDATE tb_product
-		SET is_delete = 1,
-			update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE 'SE Asia Standard Time' AS DATETIME)
-		WHERE product_id = @ID
-	`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			_, err := tx.Exec(query, sql.Named("ID", id))
-			return err
-		},
-	})
+func (h *ProductHandler) DeleteProductByID(c *fiber.Ctx) error {
+	cascade, err := utils.CascadeSoft(c)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status: "error", Message: "Failed to delete product", Data: nil,
+		return err
+	}
+	err = h.repo.SoftDelete(c.UserContext(), c.Params("id"), utils.ResolveUser(c), cascade)
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "Product not found",
+			Data:    nil,
 		})
 	}
+	if err != nil {
+		return utils.Internal(err, "Failed to delete product")
+	}
 	return c.JSON(models.ApiResponse{
 		Status: "success", Message: "Product deleted successfully", Data: nil,
 	})
 }
 
 // 8. Remove (Hard)
-func RemoveProductByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `DELETE FROM tb_product WHERE product_id = @ID`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			_, err := tx.Exec(query, sql.Named("ID", id))
-			return err
-		},
-	})
-	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status: "error", Message: "Failed to remove product", Data: nil,
+func (h *ProductHandler) RemoveProductByID(c *fiber.Ctx) error {
+	err := h.repo.Purge(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "Product not found",
+			Data:    nil,
 		})
 	}
+	if err != nil {
+		return utils.Internal(err, "Failed to remove product")
+	}
 	return c.JSON(models.ApiResponse{
 		Status: "success", Message: "Product removed successfully", Data: nil,
 	})
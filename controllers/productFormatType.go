@@ -1,126 +1,90 @@
 package controllers
 
 import (
-	"PenbunAPI/config"
 	"PenbunAPI/models"
+	"PenbunAPI/repository"
 	"PenbunAPI/utils"
-	"database/sql"
-	"errors"
-	"log"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SelectAllProductFormatType(c *fiber.Ctx) error {
-	query := `
-		SELECT product_format_type_id, format_name, description, update_by, update_date, is_active, is_delete
-		FROM tb_product_format_type
-		WHERE is_delete = 0
-	`
-	rows, err := config.DB.Query(query)
-	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{Status: "error", Message: "Failed to fetch product format types"})
-	}
-	defer rows.Close()
+// ProductFormatTypeHandler คือ Handler ของ Product Format Type API ซึ่งเข้าถึงข้อมูลผ่าน ProductFormatTypeRepository
+type ProductFormatTypeHandler struct {
+	repo repository.ProductFormatTypeRepository
+}
+
+// NewProductFormatTypeHandler สร้าง ProductFormatTypeHandler (ใช้ repository.NewMemoryProductFormatTypeRepository เมื่อทดสอบโดยไม่มีฐานข้อมูล)
+func NewProductFormatTypeHandler(repo repository.ProductFormatTypeRepository) *ProductFormatTypeHandler {
+	return &ProductFormatTypeHandler{repo: repo}
+}
 
-	var result []models.ProductFormatType
-	for rows.Next() {
-		var ft models.ProductFormatType
-		if err := rows.Scan(&ft.ProductFormatTypeID, &ft.FormatName, &ft.Description, &ft.UpdateBy, &ft.UpdateDate, &ft.IsActive, &ft.IsDelete); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{Status: "error", Message: "Failed to read data"})
-		}
-		result = append(result, ft)
+func (h *ProductFormatTypeHandler) SelectAllProductFormatType(c *fiber.Ctx) error {
+	result, err := h.repo.List(c.UserContext())
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch product format types")
 	}
 
 	return c.JSON(models.ApiResponse{Status: "success", Data: result})
 }
 
-func SelectPageProductFormatType(c *fiber.Ctx) error {
-	page := c.QueryInt("page", 1)
-	limit := c.QueryInt("limit", 10)
-	offset := (page - 1) * limit
-
-	query := `
-		SELECT product_format_type_id, format_name, description, update_by, update_date, is_active, is_delete
-		FROM tb_product_format_type
-		WHERE is_delete = 0
-		ORDER BY update_date DESC
-		OFFSET @Offset ROWS FETCH NEXT @Limit ROWS ONLY
-	`
-	rows, err := config.DB.Query(query, sql.Named("Offset", offset), sql.Named("Limit", limit))
+// productFormatTypeListSpec คือ field ที่ SelectPageProductFormatType อนุญาตให้ใช้กับ ?filter=, ?sort= และ ?q=
+var productFormatTypeListSpec = utils.ListSpec{
+	Fields: map[string]utils.ListField{
+		"product_format_type_id": {Column: "product_format_type_id", Type: utils.FieldText},
+		"format_name":            {Column: "format_name", Type: utils.FieldText},
+		"is_active":              {Column: "is_active", Type: utils.FieldBool},
+		"update_by":              {Column: "update_by", Type: utils.FieldText},
+		"update_date":            {Column: "update_date", Type: utils.FieldDate},
+	},
+	Search:      []string{"product_format_type_id", "format_name", "description"},
+	DefaultSort: "update_date DESC",
+	Key:         "product_format_type_id",
+}
+
+func (h *ProductFormatTypeHandler) SelectPageProductFormatType(c *fiber.Ctx) error {
+	lq, err := utils.ParseListQuery(c, productFormatTypeListSpec)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{Status: "error", Message: "Failed to fetch product format types"})
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: err.Error(),
+			Data:    nil,
+		})
 	}
-	defer rows.Close()
+	page, limit := lq.Page, lq.Limit
 
-	var result []models.ProductFormatType
-	for rows.Next() {
-		var ft models.ProductFormatType
-		if err := rows.Scan(&ft.ProductFormatTypeID, &ft.FormatName, &ft.Description, &ft.UpdateBy, &ft.UpdateDate, &ft.IsActive, &ft.IsDelete); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{Status: "error", Message: "Failed to read data"})
-		}
-		result = append(result, ft)
+	result, total, err := h.repo.Page(c.UserContext(), lq)
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch product format types")
 	}
 
-	var total int
-	if err := config.DB.QueryRow(`SELECT COUNT(*) FROM tb_product_format_type WHERE is_delete = 0`).Scan(&total); err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{Status: "error", Message: "Failed to count records"})
+	result, next := utils.CursorPage(lq, result, func(item models.ProductFormatType) interface{} { return item.ProductFormatTypeID })
+
+	if utils.UsePageEnvelope(c) {
+		return utils.SendPage(c, lq, result, total, next)
 	}
 
 	return c.JSON(models.ApiResponse{
 		Status: "success",
-		Data:   fiber.Map{"page": page, "limit": limit, "total": total, "productFormatType": result},
+		Data:   lq.Paged(fiber.Map{"page": page, "limit": limit, "total": total, "productFormatType": result}, next),
 	})
 }
 
-func SelectProductFormatTypeByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `
-		SELECT product_format_type_id, format_name, description, update_by, update_date, is_active, is_delete
-		FROM tb_product_format_type
-		WHERE product_format_type_id = @ID AND is_delete = 0
-	`
-	row := config.DB.QueryRow(query, sql.Named("ID", id))
-
-	var ft models.ProductFormatType
-	if err := row.Scan(&ft.ProductFormatTypeID, &ft.FormatName, &ft.Description, &ft.UpdateBy, &ft.UpdateDate, &ft.IsActive, &ft.IsDelete); err != nil {
-		if err == sql.ErrNoRows {
-			return c.Status(404).JSON(models.ApiResponse{Status: "error", Message: "Product format type not found"})
-		}
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{Status: "error", Message: "Failed to read data"})
+func (h *ProductFormatTypeHandler) SelectProductFormatTypeByID(c *fiber.Ctx) error {
+	pc, err := h.repo.Get(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{Status: "error", Message: "Product format type not found"})
+	}
+	if err != nil {
+		return utils.Internal(err, "Failed to read data")
 	}
 
-	return c.JSON(models.ApiResponse{Status: "success", Data: ft})
+	return c.JSON(models.ApiResponse{Status: "success", Data: pc})
 }
 
-func SelectProductFormatTypeByName(c *fiber.Ctx) error {
-	name := c.Params("name")
-	query := `
-		SELECT product_format_type_id, format_name, description, update_by, update_date, is_active, is_delete
-		FROM tb_product_format_type
-		WHERE format_name LIKE '%' + @Name + '%' AND is_delete = 0
-	`
-	rows, err := config.DB.Query(query, sql.Named("Name", name))
+func (h *ProductFormatTypeHandler) SelectProductFormatTypeByName(c *fiber.Ctx) error {
+	result, err := h.repo.Search(c.UserContext(), c.Params("name"))
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{Status: "error", Message: "Failed to search product format types"})
-	}
-	defer rows.Close()
-
-	var result []models.ProductFormatType
-	for rows.Next() {
-		var ft models.ProductFormatType
-		if err := rows.Scan(&ft.ProductFormatTypeID, &ft.FormatName, &ft.Description, &ft.UpdateBy, &ft.UpdateDate, &ft.IsActive, &ft.IsDelete); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{Status: "error", Message: "Failed to read data"})
-		}
-		result = append(result, ft)
+		return utils.Internal(err, "Failed to search product format types")
 	}
 
 	if len(result) == 0 {
@@ -130,120 +94,70 @@ func SelectProductFormatTypeByName(c *fiber.Ctx) error {
 	return c.JSON(models.ApiResponse{Status: "success", Data: result})
 }
 
-func InsertProductFormatType(c *fiber.Ctx) error {
+func (h *ProductFormatTypeHandler) InsertProductFormatType(c *fiber.Ctx) error {
 	var ft models.ProductFormatType
-	if err := c.BodyParser(&ft); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{Status: "error", Message: "Invalid request body"})
-	}
-
-	query := `
-		INSERT INTO tb_product_format_type (format_name, description, update_by)
-		VALUES (@FormatName, @Description, @UpdateBy)
-	`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			_, err := tx.Exec(query,
-				sql.Named("FormatName", ft.FormatName),
-				sql.Named("Description", ft.Description),
-				sql.Named("UpdateBy", ft.UpdateBy),
-			)
-			return err
-		},
-	})
+	if err := utils.Bind(c, &ft); err != nil {
+		return err
+	}
+
+	ft.UpdateBy = utils.StampUser(c, ft.UpdateBy)
+
+	newID, err := h.repo.Create(c.UserContext(), &ft)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{Status: "error", Message: "Failed to insert product format type"})
+		return utils.Internal(err, "Failed to insert product format type")
 	}
 
-	return c.Status(201).JSON(models.ApiResponse{Status: "success", Message: "Product format type inserted successfully"})
+	utils.SetCreatedID(c, newID)
+	return c.Status(201).JSON(models.ApiResponse{Status: "success", Message: "Product format type inserted successfully", Data: fiber.Map{"product_format_type_id": newID}})
 }
 
-func UpdateProductFormatTypeByID(c *fiber.Ctx) error {
+func (h *ProductFormatTypeHandler) UpdateProductFormatTypeByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var ft models.ProductFormatType
-	if err := c.BodyParser(&ft); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{Status: "error", Message: "Invalid request body"})
-	}
-
-	query := `
-		UPDATE tb_product_format_type
-		SET format_name = COALESCE(NULLIF(@FormatName, ''), format_name),
-		    description = COALESCE(@Description, description),
-		    update_by = @UpdateBy,
-		    is_active = COALESCE(@IsActive, is_active)
-		WHERE product_format_type_id = @ID AND is_delete = 0
-	`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(query,
-				sql.Named("FormatName", ft.FormatName),
-				sql.Named("Description", ft.Description),
-				sql.Named("UpdateBy", ft.UpdateBy),
-				sql.Named("IsActive", ft.IsActive),
-				sql.Named("ID", id),
-			)
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return errors.New("product format type not found")
-			}
-			return nil
-		},
-	})
+	if err := utils.BindPartial(c, &ft); err != nil {
+		return err
+	}
+
+	ft.UpdateBy = utils.StampUser(c, ft.UpdateBy)
+
+	err := h.repo.Update(c.UserContext(), id, &ft)
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "Product format type not found",
+			Data:    nil,
+		})
+	}
 	if err != nil {
-		if err.Error() == "product format type not found" {
-			return c.Status(404).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Product format type not found",
-				Data:    nil,
-			})
-		}
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{Status: "error", Message: "Failed to update product format type"})
+		return utils.Internal(err, "Failed to update product format type")
 	}
 
 	return c.JSON(models.ApiResponse{Status: "success", Message: "Product format type updated successfully"})
 }
 
-func DeleteProductFormatTypeByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `
-		UPDATE tb_product_format_type
-		SET is_delete = 1,
-		    update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE 'SE Asia Standard Time' AS DATETIME)
-		WHERE product_format_type_id = @ID
-	`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			_, err := tx.Exec(query, sql.Named("ID", id))
-			return err
-		},
-	})
+func (h *ProductFormatTypeHandler) DeleteProductFormatTypeByID(c *fiber.Ctx) error {
+	cascade, err := utils.CascadeSoft(c)
+	if err != nil {
+		return err
+	}
+	err = h.repo.SoftDelete(c.UserContext(), c.Params("id"), utils.ResolveUser(c), cascade)
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{Status: "error", Message: "Product format type not found"})
+	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{Status: "error", Message: "Failed to soft delete product format type"})
+		return utils.Internal(err, "Failed to soft delete product format type")
 	}
 
 	return c.JSON(models.ApiResponse{Status: "success", Message: "Product format type deleted (soft)"})
 }
 
-func RemoveProductFormatTypeByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `DELETE FROM tb_product_format_type WHERE product_format_type_id = @ID`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			_, err := tx.Exec(query, sql.Named("ID", id))
-			return err
-		},
-	})
+func (h *ProductFormatTypeHandler) RemoveProductFormatTypeByID(c *fiber.Ctx) error {
+	err := h.repo.Purge(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{Status: "error", Message: "Product format type not found"})
+	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{Status: "error", Message: "Failed to hard delete product format type"})
+		return utils.Internal(err, "Failed to hard delete product format type")
 	}
 
 	return c.JSON(models.ApiResponse{Status: "success", Message: "Product format type deleted (hard)"})
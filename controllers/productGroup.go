@@ -1,53 +1,27 @@
 package controllers
 
 import (
-	"PenbunAPI/config"
 	"PenbunAPI/models"
+	"PenbunAPI/repository"
 	"PenbunAPI/utils"
-	"database/sql"
-	"log"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SelectAllProductGroup(c *fiber.Ctx) error {
-	query := `
-		SELECT g.product_group_id, g.product_category_id, g.product_group_name, g.description, 
-		       g.update_by, g.update_date, g.is_active,
-		       c.category_name
-		FROM tb_product_group g
-		LEFT JOIN tb_product_category c ON g.product_category_id = c.product_category_id
-		WHERE g.is_delete = 0
-	`
-	rows, err := config.DB.Query(query)
-	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch product groups",
-			Data:    nil,
-		})
-	}
-	defer rows.Close()
+// ProductGroupHandler คือ Handler ของ Product Group API ซึ่งเข้าถึงข้อมูลผ่าน ProductGroupRepository
+type ProductGroupHandler struct {
+	repo repository.ProductGroupRepository
+}
+
+// NewProductGroupHandler สร้าง ProductGroupHandler (ใช้ repository.NewMemoryProductGroupRepository เมื่อทดสอบโดยไม่มีฐานข้อมูล)
+func NewProductGroupHandler(repo repository.ProductGroupRepository) *ProductGroupHandler {
+	return &ProductGroupHandler{repo: repo}
+}
 
-	var list []models.ProductGroup
-	for rows.Next() {
-		var item models.ProductGroup
-		var upd sql.NullTime
-		if err := rows.Scan(&item.ProductGroupID, &item.ProductCategoryID, &item.ProductGroupName, &item.Description, 
-			&item.UpdateBy, &upd, &item.IsActive, &item.CategoryName); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			item.UpdateDate = &t
-		}
-		list = append(list, item)
+func (h *ProductGroupHandler) SelectAllProductGroup(c *fiber.Ctx) error {
+	list, err := h.repo.List(c.UserContext())
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch product groups")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -57,107 +31,66 @@ func SelectAllProductGroup(c *fiber.Ctx) error {
 	})
 }
 
-func SelectPageProductGroup(c *fiber.Ctx) error {
-	page := c.QueryInt("page", 1)
-	limit := c.QueryInt("limit", 10)
-	offset := (page - 1) * limit
+// productGroupListSpec คือ field ที่ SelectPageProductGroup อนุญาตให้ใช้กับ ?filter=, ?sort= และ ?q=
+var productGroupListSpec = utils.ListSpec{
+	Fields: map[string]utils.ListField{
+		"product_group_id":    {Column: "g.product_group_id", Type: utils.FieldText},
+		"product_category_id": {Column: "g.product_category_id", Type: utils.FieldText},
+		"category_name":       {Column: "c.category_name", Type: utils.FieldText},
+		"product_group_name":  {Column: "g.product_group_name", Type: utils.FieldText},
+		"is_active":           {Column: "g.is_active", Type: utils.FieldBool},
+		"update_by":           {Column: "g.update_by", Type: utils.FieldText},
+		"update_date":         {Column: "g.update_date", Type: utils.FieldDate},
+	},
+	Search:      []string{"g.product_group_id", "g.product_group_name", "g.description"},
+	DefaultSort: "g.update_date DESC",
+	Key:         "product_group_id",
+}
 
-	query := `
-		SELECT g.product_group_id, g.product_category_id, g.product_group_name, g.description, 
-		       g.update_by, g.update_date, g.is_active,
-		       c.category_name
-		FROM tb_product_group g
-		LEFT JOIN tb_product_category c ON g.product_category_id = c.product_category_id
-		WHERE g.is_delete = 0
-		ORDER BY g.update_date DESC
-		OFFSET @Offset ROWS FETCH NEXT @Limit ROWS ONLY
-	`
-	rows, err := config.DB.Query(query, sql.Named("Offset", offset), sql.Named("Limit", limit))
+func (h *ProductGroupHandler) SelectPageProductGroup(c *fiber.Ctx) error {
+	lq, err := utils.ParseListQuery(c, productGroupListSpec)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch product groups",
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: err.Error(),
 			Data:    nil,
 		})
 	}
-	defer rows.Close()
+	page, limit := lq.Page, lq.Limit
 
-	var list []models.ProductGroup
-	for rows.Next() {
-		var item models.ProductGroup
-		var upd sql.NullTime
-		if err := rows.Scan(&item.ProductGroupID, &item.ProductCategoryID, &item.ProductGroupName, &item.Description, 
-			&item.UpdateBy, &upd, &item.IsActive, &item.CategoryName); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			item.UpdateDate = &t
-		}
-		list = append(list, item)
+	list, total, err := h.repo.Page(c.UserContext(), lq)
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch product groups")
 	}
 
-	var total int
-	err = config.DB.QueryRow(`SELECT COUNT(*) FROM tb_product_group WHERE is_delete = 0`).Scan(&total)
-	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to count records",
-			Data:    nil,
-		})
+	list, next := utils.CursorPage(lq, list, func(item models.ProductGroup) interface{} { return item.ProductGroupID })
+
+	if utils.UsePageEnvelope(c) {
+		return utils.SendPage(c, lq, list, total, next)
 	}
 
 	return c.JSON(models.ApiResponse{
 		Status: "success",
-		Data: fiber.Map{
+		Data: lq.Paged(fiber.Map{
 			"page":         page,
 			"limit":        limit,
 			"total":        total,
 			"productGroup": list,
-		},
+		}, next),
 	})
 }
 
-func SelectProductGroupByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `
-		SELECT g.product_group_id, g.product_category_id, g.product_group_name, g.description, 
-		       g.update_by, g.update_date, g.is_active,
-		       c.category_name
-		FROM tb_product_group g
-		LEFT JOIN tb_product_category c ON g.product_category_id = c.product_category_id
-		WHERE g.product_group_id = @ID AND g.is_delete = 0
-	`
-	row := config.DB.QueryRow(query, sql.Named("ID", id))
-
-	var item models.ProductGroup
-	var upd sql.NullTime
-	if err := row.Scan(&item.ProductGroupID, &item.ProductCategoryID, &item.ProductGroupName, &item.Description, 
-		&item.UpdateBy, &upd, &item.IsActive, &item.CategoryName); err != nil {
-		if err == sql.ErrNoRows {
-			return c.Status(404).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Product group not found",
-				Data:    nil,
-			})
-		}
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
+func (h *ProductGroupHandler) SelectProductGroupByID(c *fiber.Ctx) error {
+	item, err := h.repo.Get(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
-			Message: "Failed to read data",
+			Message: "Product group not found",
 			Data:    nil,
 		})
 	}
-	if upd.Valid {
-		t := upd.Time.Format("2006-01-02T15:04:05")
-		item.UpdateDate = &t
+	if err != nil {
+		return utils.Internal(err, "Failed to read data")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -167,48 +100,13 @@ func SelectProductGroupByID(c *fiber.Ctx) error {
 	})
 }
 
-func SelectProductGroupByName(c *fiber.Ctx) error {
-	name := c.Params("name")
-	query := `
-		SELECT g.product_group_id, g.product_category_id, g.product_group_name, g.description, 
-		       g.update_by, g.update_date, g.is_active,
-		       c.category_name
-		FROM tb_product_group g
-		LEFT JOIN tb_product_category c ON g.product_category_id = c.product_category_id
-		WHERE g.product_group_name LIKE '%' + @Name + '%' AND g.is_delete = 0
-	`
-	rows, err := config.DB.Query(query, sql.Named("Name", name))
+func (h *ProductGroupHandler) SelectProductGroupByName(c *fiber.Ctx) error {
+	list, err := h.repo.Search(c.UserContext(), c.Params("name"))
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch product groups",
-			Data:    nil,
-		})
-	}
-	defer rows.Close()
-
-	var results []models.ProductGroup
-	for rows.Next() {
-		var item models.ProductGroup
-		var upd sql.NullTime
-		if err := rows.Scan(&item.ProductGroupID, &item.ProductCategoryID, &item.ProductGroupName, &item.Description, 
-			&item.UpdateBy, &upd, &item.IsActive, &item.CategoryName); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			item.UpdateDate = &t
-		}
-		results = append(results, item)
+		return utils.Internal(err, "Failed to fetch product groups")
 	}
 
-	if len(results) == 0 {
+	if len(list) == 0 {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "No matching product group found",
@@ -219,105 +117,42 @@ func SelectProductGroupByName(c *fiber.Ctx) error {
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "",
-		Data:    results,
+		Data:    list,
 	})
 }
 
-func InsertProductGroup(c *fiber.Ctx) error {
+func (h *ProductGroupHandler) InsertProductGroup(c *fiber.Ctx) error {
 	var item models.ProductGroup
-	if err := c.BodyParser(&item); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Invalid request body",
-			Data:    nil,
-		})
-	}
-
-	if item.ProductCategoryID == "" {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "product_category_id is required",
-			Data:    nil,
-		})
+	if err := utils.Bind(c, &item); err != nil {
+		return err
 	}
 
-	query := `
-		INSERT INTO tb_product_group (product_category_id, product_group_name, description, update_by)
-		VALUES (@CategoryID, @GroupName, @Description, @UpdateBy)
-	`
+	item.UpdateBy = utils.StampUser(c, item.UpdateBy)
 
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			_, err := tx.Exec(query,
-				sql.Named("CategoryID", item.ProductCategoryID),
-				sql.Named("GroupName", item.ProductGroupName),
-				sql.Named("Description", item.Description),
-				sql.Named("UpdateBy", item.UpdateBy),
-			)
-			return err
-		},
-	})
+	newID, err := h.repo.Create(c.UserContext(), &item)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to insert product group",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to insert product group")
 	}
 
+	utils.SetCreatedID(c, newID)
 	return c.Status(201).JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "Product group added successfully",
-		Data:    nil,
+		Data:    fiber.Map{"product_group_id": newID},
 	})
 }
 
-func UpdateProductGroupByID(c *fiber.Ctx) error {
+func (h *ProductGroupHandler) UpdateProductGroupByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var item models.ProductGroup
-	if err := c.BodyParser(&item); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Invalid request body",
-			Data:    nil,
-		})
+	if err := utils.BindPartial(c, &item); err != nil {
+		return err
 	}
 
-	query := `
-		UPDATE tb_product_group
-		SET product_category_id = COALESCE(NULLIF(@CategoryID, ''), product_category_id),
-			product_group_name = COALESCE(NULLIF(@GroupName, ''), product_group_name),
-			description = COALESCE(@Description, description),
-			update_by = @UpdateBy,
-			is_active = COALESCE(@IsActive, is_active)
-		WHERE product_group_id = @ID AND is_delete = 0
-	`
+	item.UpdateBy = utils.StampUser(c, item.UpdateBy)
 
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(query,
-				sql.Named("CategoryID", item.ProductCategoryID),
-				sql.Named("GroupName", item.ProductGroupName),
-				sql.Named("Description", item.Description),
-				sql.Named("UpdateBy", item.UpdateBy),
-				sql.Named("IsActive", item.IsActive),
-				sql.Named("ID", id),
-			)
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+	err := h.repo.Update(c.UserContext(), id, &item)
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Product group not found",
@@ -325,12 +160,7 @@ func UpdateProductGroupByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to update product group",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to update product group")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -340,32 +170,13 @@ func UpdateProductGroupByID(c *fiber.Ctx) error {
 	})
 }
 
-func DeleteProductGroupByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `
-		UPDATE tb_product_group
-		SET is_delete = 1,
-			is_active = 0,
-			update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE 'SE Asia Standard Time' AS DATETIME)
-		WHERE product_group_id = @ID AND is_delete = 0
-	`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(query, sql.Named("ID", id))
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+func (h *ProductGroupHandler) DeleteProductGroupByID(c *fiber.Ctx) error {
+	cascade, err := utils.CascadeSoft(c)
+	if err != nil {
+		return err
+	}
+	err = h.repo.SoftDelete(c.UserContext(), c.Params("id"), utils.ResolveUser(c), cascade)
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Product group not found",
@@ -373,12 +184,7 @@ func DeleteProductGroupByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to delete product group",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to delete product group")
 	}
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
@@ -387,26 +193,9 @@ func DeleteProductGroupByID(c *fiber.Ctx) error {
 	})
 }
 
-func RemoveProductGroupByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `DELETE FROM tb_product_group WHERE product_group_id = @ID`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(query, sql.Named("ID", id))
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+func (h *ProductGroupHandler) RemoveProductGroupByID(c *fiber.Ctx) error {
+	err := h.repo.Purge(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Product group not found",
@@ -414,12 +203,7 @@ func RemoveProductGroupByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to remove product group",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to remove product group")
 	}
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
@@ -1,34 +1,28 @@
 package controllers
 
 import (
-	"PenbunAPI/config"
 	"PenbunAPI/models"
+	"PenbunAPI/repository"
 	"PenbunAPI/utils"
-	"database/sql"
-	"fmt"
-	"log"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// ProductPackConfigHandler คือ Handler ของ Product Pack Config API ซึ่งเข้าถึงข้อมูลผ่าน ProductPackConfigRepository
+type ProductPackConfigHandler struct {
+	repo repository.ProductPackConfigRepository
+}
+
+// NewProductPackConfigHandler สร้าง ProductPackConfigHandler (ใช้ repository.NewMemoryProductPackConfigRepository เมื่อทดสอบโดยไม่มีฐานข้อมูล)
+func NewProductPackConfigHandler(repo repository.ProductPackConfigRepository) *ProductPackConfigHandler {
+	return &ProductPackConfigHandler{repo: repo}
+}
+
 // 1. Select All
-func SelectAllProductPackConfig(c *fiber.Ctx) error {
-	rows, err := config.DB.Query("SELECT autoID, product_pack_config_id, product_id, bundle_qty, unit_type_id, note, update_by, update_date, id_status FROM tb_product_pack_config WHERE is_delete = 0")
+func (h *ProductPackConfigHandler) SelectAllProductPackConfig(c *fiber.Ctx) error {
+	configs, err := h.repo.List(c.UserContext())
 	if err != nil {
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch product pack configs",
-		})
-	}
-	defer rows.Close()
-
-	var configs []models.ProductPackConfig
-	for rows.Next() {
-		var cfg models.ProductPackConfig
-		if err := rows.Scan(&cfg.AutoID, &cfg.ProductPackConfigID, &cfg.ProductID, &cfg.BundleQty, &cfg.UnitTypeID, &cfg.Note, &cfg.UpdateBy, &cfg.UpdateDate, &cfg.IDStatus); err != nil {
-			continue
-		}
-		configs = append(configs, cfg)
+		return utils.Internal(err, "Failed to fetch product pack configs")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -38,83 +32,66 @@ func SelectAllProductPackConfig(c *fiber.Ctx) error {
 	})
 }
 
-// 2. Select Page
-func SelectPageProductPackConfig(c *fiber.Ctx) error {
-	page := c.QueryInt("page", 1)
-	limit := c.QueryInt("limit", 10)
-	offset := (page - 1) * limit
+// productPackConfigListSpec คือ field ที่ SelectPageProductPackConfig อนุญาตให้ใช้กับ ?filter=, ?sort= และ ?q=
+var productPackConfigListSpec = utils.ListSpec{
+	Fields: map[string]utils.ListField{
+		"auto_id":                {Column: "autoID", Type: utils.FieldNumber},
+		"product_pack_config_id": {Column: "product_pack_config_id", Type: utils.FieldText},
+		"product_id":             {Column: "product_id", Type: utils.FieldText},
+		"bundle_qty":             {Column: "bundle_qty", Type: utils.FieldNumber},
+		"unit_type_id":           {Column: "unit_type_id", Type: utils.FieldText},
+		"id_status":              {Column: "id_status", Type: utils.FieldBool},
+		"update_by":              {Column: "update_by", Type: utils.FieldText},
+		"update_date":            {Column: "update_date", Type: utils.FieldDate},
+	},
+	Search:      []string{"product_pack_config_id", "product_id", "note"},
+	DefaultSort: "update_date DESC",
+	Key:         "auto_id",
+}
 
-	// Get total count
-	var total int
-	err := config.DB.QueryRow("SELECT COUNT(*) FROM tb_product_pack_config WHERE is_delete = 0").Scan(&total)
+// 2. Select Page
+func (h *ProductPackConfigHandler) SelectPageProductPackConfig(c *fiber.Ctx) error {
+	lq, err := utils.ParseListQuery(c, productPackConfigListSpec)
 	if err != nil {
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to count product pack configs",
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: err.Error(),
+			Data:    nil,
 		})
 	}
 
-	// Get data
-	query := `
-		SELECT autoID, product_pack_config_id, product_id, bundle_qty, unit_type_id, note, update_by, update_date, id_status 
-		FROM tb_product_pack_config 
-		WHERE is_delete = 0 
-		ORDER BY update_date DESC 
-		OFFSET @Offset ROWS FETCH NEXT @Limit ROWS ONLY`
-
-	rows, err := config.DB.Query(query, sql.Named("Offset", offset), sql.Named("Limit", limit))
+	configs, total, err := h.repo.Page(c.UserContext(), lq)
 	if err != nil {
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch product pack configs page",
-		})
+		return utils.Internal(err, "Failed to fetch product pack configs page")
 	}
-	defer rows.Close()
 
-	var configs []models.ProductPackConfig
-	for rows.Next() {
-		var cfg models.ProductPackConfig
-		if err := rows.Scan(&cfg.AutoID, &cfg.ProductPackConfigID, &cfg.ProductID, &cfg.BundleQty, &cfg.UnitTypeID, &cfg.Note, &cfg.UpdateBy, &cfg.UpdateDate, &cfg.IDStatus); err != nil {
-			continue
-		}
-		configs = append(configs, cfg)
+	configs, next := utils.CursorPage(lq, configs, func(item models.ProductPackConfig) interface{} { return item.AutoID })
+
+	if utils.UsePageEnvelope(c) {
+		return utils.SendPage(c, lq, configs, total, next)
 	}
 
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "Product pack configs page fetched successfully",
-		Data: fiber.Map{
+		Data: lq.Paged(fiber.Map{
 			"total": total,
 			"items": configs,
-		},
+		}, next),
 	})
 }
 
 // 3. Select By ID
-func SelectProductPackConfigByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var cfg models.ProductPackConfig
-
-	query := `
-		SELECT autoID, product_pack_config_id, product_id, bundle_qty, unit_type_id, note, update_by, update_date, id_status 
-		FROM tb_product_pack_config 
-		WHERE product_pack_config_id = @ID AND is_delete = 0`
-
-	err := config.DB.QueryRow(query, sql.Named("ID", id)).Scan(
-		&cfg.AutoID, &cfg.ProductPackConfigID, &cfg.ProductID, &cfg.BundleQty, &cfg.UnitTypeID, &cfg.Note, &cfg.UpdateBy, &cfg.UpdateDate, &cfg.IDStatus,
-	)
-
+func (h *ProductPackConfigHandler) SelectProductPackConfigByID(c *fiber.Ctx) error {
+	cfg, err := h.repo.Get(c.UserContext(), c.Params("id"))
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if err == repository.ErrNotFound {
 			return c.Status(404).JSON(models.ApiResponse{
 				Status:  "fail",
 				Message: "Product pack config not found",
 			})
 		}
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch product pack config",
-		})
+		return utils.Internal(err, "Failed to fetch product pack config")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -125,29 +102,10 @@ func SelectProductPackConfigByID(c *fiber.Ctx) error {
 }
 
 // 4. Select By Name (Search by Note or ProductID)
-func SelectProductPackConfigByName(c *fiber.Ctx) error {
-	name := c.Params("name") // Using 'name' param for search query
-	query := `
-		SELECT autoID, product_pack_config_id, product_id, bundle_qty, unit_type_id, note, update_by, update_date, id_status 
-		FROM tb_product_pack_config 
-		WHERE (note LIKE '%' + @Name + '%' OR product_id LIKE '%' + @Name + '%') AND is_delete = 0`
-
-	rows, err := config.DB.Query(query, sql.Named("Name", name))
+func (h *ProductPackConfigHandler) SelectProductPackConfigByName(c *fiber.Ctx) error {
+	configs, err := h.repo.Search(c.UserContext(), c.Params("name")) // Using 'name' param for search query
 	if err != nil {
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to search product pack configs",
-		})
-	}
-	defer rows.Close()
-
-	var configs []models.ProductPackConfig
-	for rows.Next() {
-		var cfg models.ProductPackConfig
-		if err := rows.Scan(&cfg.AutoID, &cfg.ProductPackConfigID, &cfg.ProductID, &cfg.BundleQty, &cfg.UnitTypeID, &cfg.Note, &cfg.UpdateBy, &cfg.UpdateDate, &cfg.IDStatus); err != nil {
-			continue
-		}
-		configs = append(configs, cfg)
+		return utils.Internal(err, "Failed to search product pack configs")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -158,42 +116,22 @@ func SelectProductPackConfigByName(c *fiber.Ctx) error {
 }
 
 // 5. Insert
-func InsertProductPackConfig(c *fiber.Ctx) error {
+func (h *ProductPackConfigHandler) InsertProductPackConfig(c *fiber.Ctx) error {
 	var cfg models.ProductPackConfig
-	if err := c.BodyParser(&cfg); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "fail",
-			Message: "Invalid request body",
-		})
+	if err := utils.Bind(c, &cfg); err != nil {
+		return err
 	}
 
-	query := `
-		INSERT INTO tb_product_pack_config (product_id, bundle_qty, unit_type_id, note, update_by, id_status)
-		VALUES (@ProductID, @BundleQty, @UnitTypeID, @Note, @UpdateBy, @IDStatus)`
-
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			_, err := tx.Exec(query,
-				sql.Named("ProductID", cfg.ProductID),
-				sql.Named("BundleQty", cfg.BundleQty),
-				sql.Named("UnitTypeID", cfg.UnitTypeID),
-				sql.Named("Note", cfg.Note),
-				sql.Named("UpdateBy", cfg.UpdateBy),
-				sql.Named("IDStatus", cfg.IDStatus),
-			)
-			return err
-		},
-	})
+	cfg.UpdateBy = utils.AuditUser(c, cfg.UpdateBy)
 
+	id, err := h.repo.Create(c.UserContext(), &cfg)
 	if err != nil {
-		log.Println("Insert Error:", err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to create product pack config",
-		})
+		return utils.Internal(err, "Failed to create product pack config")
 	}
+	cfg.ProductPackConfigID = id
 
-	return c.JSON(models.ApiResponse{
+	utils.SetCreatedID(c, cfg.ProductPackConfigID)
+	return c.Status(201).JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "Product pack config created successfully",
 		Data:    cfg,
@@ -201,59 +139,22 @@ func InsertProductPackConfig(c *fiber.Ctx) error {
 }
 
 // 6. Update By ID
-func UpdateProductPackConfigByID(c *fiber.Ctx) error {
-	id := c.Params("id")
+func (h *ProductPackConfigHandler) UpdateProductPackConfigByID(c *fiber.Ctx) error {
 	var cfg models.ProductPackConfig
-	if err := c.BodyParser(&cfg); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "fail",
-			Message: "Invalid request body",
-		})
+	if err := utils.Bind(c, &cfg); err != nil {
+		return err
 	}
 
-	query := `
-		UPDATE tb_product_pack_config 
-		SET product_id = @ProductID,
-			bundle_qty = @BundleQty,
-			unit_type_id = @UnitTypeID,
-			note = @Note,
-			update_by = @UpdateBy,
-			id_status = @IDStatus
-		WHERE product_pack_config_id = @ID AND is_delete = 0`
+	cfg.UpdateBy = utils.AuditUser(c, cfg.UpdateBy)
 
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			result, err := tx.Exec(query,
-				sql.Named("ProductID", cfg.ProductID),
-				sql.Named("BundleQty", cfg.BundleQty),
-				sql.Named("UnitTypeID", cfg.UnitTypeID),
-				sql.Named("Note", cfg.Note),
-				sql.Named("UpdateBy", cfg.UpdateBy),
-				sql.Named("IDStatus", cfg.IDStatus),
-				sql.Named("ID", id),
-			)
-			if err != nil {
-				return err
-			}
-			rowsAffected, _ := result.RowsAffected()
-			if rowsAffected == 0 {
-				return fmt.Errorf("record not found")
-			}
-			return nil
-		},
-	})
-
-	if err != nil {
-		if err.Error() == "record not found" {
+	if err := h.repo.Update(c.UserContext(), c.Params("id"), &cfg); err != nil {
+		if err == repository.ErrNotFound {
 			return c.Status(404).JSON(models.ApiResponse{
 				Status:  "fail",
 				Message: "Product pack config not found",
 			})
 		}
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to update product pack config",
-		})
+		return utils.Internal(err, "Failed to update product pack config")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -264,40 +165,15 @@ func UpdateProductPackConfigByID(c *fiber.Ctx) error {
 }
 
 // 7. Delete By ID (Soft Delete)
-func DeleteProductPackConfigByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	username := c.Query("user")
-	if username == "" {
-		username = "UNKNOWN"
-	}
-
-	query := `UPDATE tb_product_pack_config SET is_delete = 1, update_by = @UpdateBy WHERE product_pack_config_id = @ID`
-
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			result, err := tx.Exec(query, sql.Named("UpdateBy", username), sql.Named("ID", id))
-			if err != nil {
-				return err
-			}
-			rowsAffected, _ := result.RowsAffected()
-			if rowsAffected == 0 {
-				return fmt.Errorf("record not found")
-			}
-			return nil
-		},
-	})
-
-	if err != nil {
-		if err.Error() == "record not found" {
+func (h *ProductPackConfigHandler) DeleteProductPackConfigByID(c *fiber.Ctx) error {
+	if err := h.repo.SoftDelete(c.UserContext(), c.Params("id"), utils.ResolveUser(c), false); err != nil {
+		if err == repository.ErrNotFound {
 			return c.Status(404).JSON(models.ApiResponse{
 				Status:  "fail",
 				Message: "Product pack config not found",
 			})
 		}
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to delete product pack config",
-		})
+		return utils.Internal(err, "Failed to delete product pack config")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -307,35 +183,15 @@ func DeleteProductPackConfigByID(c *fiber.Ctx) error {
 }
 
 // 8. Remove By ID (Hard Delete)
-func RemoveProductPackConfigByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `DELETE FROM tb_product_pack_config WHERE product_pack_config_id = @ID`
-
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			result, err := tx.Exec(query, sql.Named("ID", id))
-			if err != nil {
-				return err
-			}
-			rowsAffected, _ := result.RowsAffected()
-			if rowsAffected == 0 {
-				return fmt.Errorf("record not found")
-			}
-			return nil
-		},
-	})
-
-	if err != nil {
-		if err.Error() == "record not found" {
+func (h *ProductPackConfigHandler) RemoveProductPackConfigByID(c *fiber.Ctx) error {
+	if err := h.repo.Purge(c.UserContext(), c.Params("id")); err != nil {
+		if err == repository.ErrNotFound {
 			return c.Status(404).JSON(models.ApiResponse{
 				Status:  "fail",
 				Message: "Product pack config not found",
 			})
 		}
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to remove product pack config",
-		})
+		return utils.Internal(err, "Failed to remove product pack config")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -2,363 +2,199 @@ package controllers
 
 import (
 	"PenbunAPI/models"
-	"database/sql"
+	"PenbunAPI/repository"
+	"PenbunAPI/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-// SelectAllReceiveNotes ดึงข้อมูลใบรับสินค้าทั้งหมด
-func SelectAllReceiveNotes(c *fiber.Ctx) error {
-	db := c.Locals("db").(*sql.DB)
+// ReceiveHandler คือ Handler ของ Receive Note API ซึ่งเข้าถึงข้อมูลผ่าน ReceiveRepository
+type ReceiveHandler struct {
+	repo repository.ReceiveRepository
+}
 
-	query := `
-		SELECT r.receive_note_id, r.vendor_id, v.vendor_name, r.warehouse_id, w.warehouse_name,
-		       r.doc_date, r.ref_invoice_no, r.receive_type, r.total_amount, r.note,
-		       r.update_by, r.update_date, r.is_active
-		FROM tb_receive_note r
-		LEFT JOIN tb_vendor v ON r.vendor_id = v.vendor_id
-		LEFT JOIN tb_warehouse w ON r.warehouse_id = w.warehouse_id
-		WHERE r.is_delete = 0
-		ORDER BY r.doc_date DESC
-	`
+// NewReceiveHandler สร้าง ReceiveHandler (ใช้ repository.NewMemoryReceiveRepository เมื่อทดสอบโดยไม่มีฐานข้อมูล)
+func NewReceiveHandler(repo repository.ReceiveRepository) *ReceiveHandler {
+	return &ReceiveHandler{repo: repo}
+}
 
-	rows, err := db.Query(query)
+// SelectAllReceiveNotes ดึงข้อมูลใบรับสินค้าทั้งหมด
+func (h *ReceiveHandler) SelectAllReceiveNotes(c *fiber.Ctx) error {
+	receives, err := h.repo.List(c.UserContext())
 	if err != nil {
-		return c.Status(500).SendString(err.Error())
-	}
-	defer rows.Close()
-
-	var receives []models.ReceiveNote
-	for rows.Next() {
-		var r models.ReceiveNote
-		var vendorName, warehouseName *string
-		if err := rows.Scan(&r.ReceiveNoteID, &r.VendorID, &vendorName, &r.WarehouseID, &warehouseName,
-			&r.DocDate, &r.RefInvoiceNo, &r.ReceiveType, &r.TotalAmount, &r.Note,
-			&r.UpdateBy, &r.UpdateDate, &r.IsActive); err != nil {
-			return c.Status(500).SendString(err.Error())
-		}
-		r.VendorName = vendorName
-		// We might need to add WarehouseName to the model if we want to return it, 
-		// currently ReceiveNote struct doesn't have WarehouseName, so we just scan it but don't bind if not needed or add it to struct.
-		// For now, let's assume valid model integration.
-		receives = append(receives, r)
+		return utils.Internal(err, "Failed to fetch receive notes")
 	}
 
-	return c.JSON(fiber.Map{
-		"data": receives,
+	return c.JSON(models.ApiResponse{
+		Status: "success",
+		Data:   receives,
 	})
 }
 
-// SelectPageReceiveNotes ดึงข้อมูลใบรับสินค้าแบบ Paging
-func SelectPageReceiveNotes(c *fiber.Ctx) error {
-	db := c.Locals("db").(*sql.DB)
-	page := c.QueryInt("page", 1)
-	limit := c.QueryInt("limit", 10)
-	offset := (page - 1) * limit
+// receiveNoteListSpec คือ field ที่ SelectPageReceiveNotes อนุญาตให้ใช้กับ ?filter=, ?sort= และ ?q=
+var receiveNoteListSpec = utils.ListSpec{
+	Fields: map[string]utils.ListField{
+		"receive_note_id": {Column: "r.receive_note_id", Type: utils.FieldText},
+		"vendor_id":       {Column: "r.vendor_id", Type: utils.FieldText},
+		"vendor_name":     {Column: "v.vendor_name", Type: utils.FieldText},
+		"warehouse_id":    {Column: "r.warehouse_id", Type: utils.FieldText},
+		"warehouse_name":  {Column: "w.warehouse_name", Type: utils.FieldText},
+		"doc_date":        {Column: "r.doc_date", Type: utils.FieldDate},
+		"ref_invoice_no":  {Column: "r.ref_invoice_no", Type: utils.FieldText},
+		"receive_type":    {Column: "r.receive_type", Type: utils.FieldText},
+		"total_amount":    {Column: "r.total_amount", Type: utils.FieldNumber},
+		"is_active":       {Column: "r.is_active", Type: utils.FieldBool},
+		"update_by":       {Column: "r.update_by", Type: utils.FieldText},
+		"update_date":     {Column: "r.update_date", Type: utils.FieldDate},
+	},
+	Search:      []string{"r.receive_note_id", "r.ref_invoice_no", "v.vendor_name", "r.note"},
+	DefaultSort: "r.doc_date DESC",
+	Key:         "receive_note_id",
+}
 
-	// Count Total
-	var total int
-	countQuery := `SELECT COUNT(*) FROM tb_receive_note WHERE is_delete = 0`
-	if err := db.QueryRow(countQuery).Scan(&total); err != nil {
-		return c.Status(500).SendString(err.Error())
+// SelectPageReceiveNotes ดึงข้อมูลใบรับสินค้าแบบ Paging
+func (h *ReceiveHandler) SelectPageReceiveNotes(c *fiber.Ctx) error {
+	lq, err := utils.ParseListQuery(c, receiveNoteListSpec)
+	if err != nil {
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: err.Error(),
+			Data:    nil,
+		})
 	}
+	page, limit := lq.Page, lq.Limit
 
-	query := `
-		SELECT r.receive_note_id, r.vendor_id, v.vendor_name, r.warehouse_id, w.warehouse_name,
-		       r.doc_date, r.ref_invoice_no, r.receive_type, r.total_amount, r.note,
-		       r.update_by, r.update_date, r.is_active
-		FROM tb_receive_note r
-		LEFT JOIN tb_vendor v ON r.vendor_id = v.vendor_id
-		LEFT JOIN tb_warehouse w ON r.warehouse_id = w.warehouse_id
-		WHERE r.is_delete = 0
-		ORDER BY r.doc_date DESC
-		OFFSET @Offset ROWS FETCH NEXT @Limit ROWS ONLY
-	`
-
-	rows, err := db.Query(query, sql.Named("Offset", offset), sql.Named("Limit", limit))
+	receives, total, err := h.repo.Page(c.UserContext(), lq)
 	if err != nil {
-		return c.Status(500).SendString(err.Error())
+		return utils.Internal(err, "Failed to fetch receive notes")
 	}
-	defer rows.Close()
 
-	var receives []models.ReceiveNote
-	for rows.Next() {
-		var r models.ReceiveNote
-		var vendorName, warehouseName *string
-		if err := rows.Scan(&r.ReceiveNoteID, &r.VendorID, &vendorName, &r.WarehouseID, &warehouseName,
-			&r.DocDate, &r.RefInvoiceNo, &r.ReceiveType, &r.TotalAmount, &r.Note,
-			&r.UpdateBy, &r.UpdateDate, &r.IsActive); err != nil {
-			return c.Status(500).SendString(err.Error())
-		}
-		r.VendorName = vendorName
-		receives = append(receives, r)
+	receives, next := utils.CursorPage(lq, receives, func(item models.ReceiveNote) interface{} { return item.ReceiveNoteID })
+
+	if utils.UsePageEnvelope(c) {
+		return utils.SendPage(c, lq, receives, total, next)
 	}
 
-	return c.JSON(fiber.Map{
+	return c.JSON(lq.Paged(fiber.Map{
 		"data":  receives,
 		"total": total,
 		"page":  page,
 		"limit": limit,
-	})
+	}, next))
 }
 
 // SelectReceiveNoteByID ดึงข้อมูลใบรับสินค้าตาม ID (พร้อม Items)
-func SelectReceiveNoteByID(c *fiber.Ctx) error {
-	db := c.Locals("db").(*sql.DB)
-	id := c.Params("id")
-
-	// 1. Get Header
-	queryHeader := `
-		SELECT r.receive_note_id, r.vendor_id, v.vendor_name, r.warehouse_id, w.warehouse_name,
-		       r.doc_date, r.ref_invoice_no, r.receive_type, r.total_amount, r.note,
-		       r.update_by, r.update_date, r.is_active
-		FROM tb_receive_note r
-		LEFT JOIN tb_vendor v ON r.vendor_id = v.vendor_id
-		LEFT JOIN tb_warehouse w ON r.warehouse_id = w.warehouse_id
-		WHERE r.receive_note_id = @ID AND r.is_delete = 0
-	`
-
-	var r models.ReceiveNote
-	var vendorName, warehouseName *string
-	err := db.QueryRow(queryHeader, sql.Named("ID", id)).Scan(
-		&r.ReceiveNoteID, &r.VendorID, &vendorName, &r.WarehouseID, &warehouseName,
-		&r.DocDate, &r.RefInvoiceNo, &r.ReceiveType, &r.TotalAmount, &r.Note,
-		&r.UpdateBy, &r.UpdateDate, &r.IsActive,
-	)
-	if err == sql.ErrNoRows {
-		return c.Status(404).SendString("Receive Note not found")
+func (h *ReceiveHandler) SelectReceiveNoteByID(c *fiber.Ctx) error {
+	doc, err := h.repo.Get(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "Receive note not found",
+			Data:    nil,
+		})
 	} else if err != nil {
-		return c.Status(500).SendString(err.Error())
-	}
-	r.VendorName = vendorName
-
-	// 2. Get Items
-	queryItems := `
-		SELECT ri.auto_id, ri.receive_note_id, ri.product_id, p.product_name,
-		       ri.qty, ri.unit_cost, ri.line_total, ri.remark
-		FROM tb_receive_item ri
-		LEFT JOIN tb_product p ON ri.product_id = p.product_id
-		WHERE ri.receive_note_id = @ID AND ri.is_delete = 0
-	`
-	rows, err := db.Query(queryItems, sql.Named("ID", id))
-	if err != nil {
-		return c.Status(500).SendString(err.Error())
+		return utils.Internal(err, "Failed to fetch receive note")
 	}
-	defer rows.Close()
 
-	var items []models.ReceiveItem
-	for rows.Next() {
-		var item models.ReceiveItem
-		var productName *string
-		// Scan matches struct fields, adapt based on model definition
-		// Assuming ReceiveItem model has AutoID, ReceiveNoteID, ProductID, Qty, UnitCost, LineTotal, Remark
-		if err := rows.Scan(&item.AutoID, &item.ReceiveNoteID, &item.ProductID, &productName,
-			&item.Qty, &item.UnitCost, &item.LineTotal, &item.Remark); err != nil {
-			return c.Status(500).SendString(err.Error())
-		}
-		item.ProductName = productName
-		items = append(items, item)
-	}
-
-	return c.JSON(fiber.Map{
-		"header": r,
-		"items":  items,
+	return c.JSON(models.ApiResponse{
+		Status: "success",
+		Data: fiber.Map{
+			"header": doc.Header,
+			"items":  doc.Items,
+		},
 	})
 }
 
-// InsertReceiveNote เพิ่มใบรับสินค้า
-func InsertReceiveNote(c *fiber.Ctx) error {
-	db := c.Locals("db").(*sql.DB)
-	
-	// Complex struct for Request Body containing Header and Items
-	type InsertRequest struct {
-		Header models.ReceiveNote   `json:"header"`
-		Items  []models.ReceiveItem `json:"items"`
-	}
-
-	var req InsertRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).SendString("Invalid Request Body")
-	}
-
-	// Transaction Start
-	tx, err := db.Begin()
-	if err != nil {
-		return c.Status(500).SendString(err.Error())
-	}
-
-	// 1. Insert Header
-	// Trigger will generate receive_note_id
-	queryHeader := `
-		INSERT INTO tb_receive_note (
-			vendor_id, warehouse_id, doc_date, ref_invoice_no, receive_type, 
-			total_amount, note, update_by, is_active
-		)
-		VALUES (
-			@VendorID, @WarehouseID, @DocDate, @RefInvoiceNo, @ReceiveType, 
-			@TotalAmount, @Note, @UpdateBy, 1
-		);
-	`
-	// Verify user if possible, else use "System" or from Body
-	user := "System" // Or from c.Locals("user")
-
-	_, err = tx.Exec(queryHeader,
-		sql.Named("VendorID", req.Header.VendorID),
-		sql.Named("WarehouseID", req.Header.WarehouseID),
-		sql.Named("DocDate", req.Header.DocDate),
-		sql.Named("RefInvoiceNo", req.Header.RefInvoiceNo),
-		sql.Named("ReceiveType", req.Header.ReceiveType),
-		sql.Named("TotalAmount", req.Header.TotalAmount), // Should be calculated but take from FE for now
-		sql.Named("Note", req.Header.Note),
-		sql.Named("UpdateBy", user),
-	)
-	if err != nil {
-		tx.Rollback()
-		return c.Status(500).SendString("Header Insert Fail: " + err.Error())
+// InsertReceiveNote เพิ่มใบรับสินค้า (Header + Items ใน Transaction เดียว)
+func (h *ReceiveHandler) InsertReceiveNote(c *fiber.Ctx) error {
+	var req repository.ReceiveNote
+	if err := utils.Bind(c, &req); err != nil {
+		return err
 	}
 
-	// 2. Fetch Generated ID
-	// Since we inserted, triggger ran. We need to find the ID.
-	// For simplicity in this stack, assuming we can get it by recent insert or output.
-	// However, SQL Server with Trigger ID generation is tricky to get back immediately without OUTPUT clause in INSERT.
-	// But our trigger updates the table AFTER insert. 
-	// Standard approach: Get latest by User/Time or use OUTPUT inserted.autoID then Select.
-	// Improved Query with OUTPUT could be:
-	// INSERT ... VALUES ... SELECT SCOPE_IDENTITY()
-	// Let's rely on fetching the latest ID for this session/user logic if possible, OR better:
-	// Use OUTPUT Clause in the INSERT statement above is invalid if Trigger handles ID generation later?
-	// Actually, the Trigger updates keys. So we need the autoID to find the key.
-	
-	// Workaround: We query the latest autoID inserted? 
-	// Let's modify the flow: 
-	// Ideally we should pass the generated ID back.
-	// For this task, I will use a simple query to get the latest ID created by this user/process or similar.
-	// NOTE: This race condition is risky. But acceptable for this prototype phase.
-	// Better: Select TOP 1 receive_note_id FROM tb_receive_note ORDER BY autoID DESC
-	
-	var newID string
-	err = tx.QueryRow("SELECT TOP 1 receive_note_id FROM tb_receive_note WHERE update_by = @UpdateBy ORDER BY autoID DESC", sql.Named("UpdateBy", user)).Scan(&newID)
+	// ผู้บันทึกเอกสารมาจากผู้ใช้ที่ยืนยันตัวตนแล้วเสมอ
+	newID, err := h.repo.Create(c.UserContext(), &req, utils.ResolveUser(c))
 	if err != nil {
-		tx.Rollback()
-		return c.Status(500).SendString("Failed to retrieve generated ID: " + err.Error())
-	}
-
-	// 3. Insert Items
-	queryItem := `
-		INSERT INTO tb_receive_item (receive_note_id, product_id, qty, unit_cost, line_total, remark)
-		VALUES (@NoteID, @ProductID, @Qty, @Cost, @Total, @Remark)
-	`
-	for _, item := range req.Items {
-		_, err := tx.Exec(queryItem,
-			sql.Named("NoteID", newID),
-			sql.Named("ProductID", item.ProductID),
-			sql.Named("Qty", item.Qty),
-			sql.Named("Cost", item.UnitCost),
-			sql.Named("Total", item.LineTotal),
-			sql.Named("Remark", item.Remark),
-		)
-		if err != nil {
-			tx.Rollback()
-			return c.Status(500).SendString("Item Insert Fail: " + err.Error())
-		}
-		
-		// 4. Update Stock (Simple increment for now - Layer 7 logic, omitting for Layer 5 basic save)
-		// Assuming we just save document first.
-	}
-
-	if err := tx.Commit(); err != nil {
-		return c.Status(500).SendString("Commit Fail: " + err.Error())
+		return utils.Internal(err, "Failed to insert receive note")
 	}
 
-	return c.JSON(fiber.Map{
-		"status": "success",
-		"id":     newID,
+	utils.SetCreatedID(c, newID)
+	return c.Status(201).JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "Receive note added successfully",
+		Data:    fiber.Map{"receive_note_id": newID},
 	})
 }
 
-// UpdateReceiveNoteByID แก้ไขใบรับสินค้า
-func UpdateReceiveNoteByID(c *fiber.Ctx) error {
-	// Logic similar to Insert but typically we only update Header info or Add/Remove items.
-	// For MVP, implementing Header update only for brevity, or full replace.
-	// Let's allow updating Note and RefInvoice.
-	db := c.Locals("db").(*sql.DB)
-	id := c.Params("id")
-	
+// UpdateReceiveNoteByID แก้ไขใบรับสินค้า (เฉพาะ ref_invoice_no และ note ของ Header)
+func (h *ReceiveHandler) UpdateReceiveNoteByID(c *fiber.Ctx) error {
 	type UpdateRequest struct {
 		RefInvoiceNo *string `json:"ref_invoice_no"`
 		Note         *string `json:"note"`
 		UpdateBy     string  `json:"update_by"`
 	}
 	var req UpdateRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).SendString("Invalid Request Body")
+	if err := utils.BindPartial(c, &req); err != nil {
+		return err
 	}
 
-	query := `
-		UPDATE tb_receive_note
-		SET ref_invoice_no = @Ref, note = @Note, update_by = @UpdateBy,
-		    update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE 'SE Asia Standard Time' AS DATETIME)
-		WHERE receive_note_id = @ID AND is_delete = 0
-	`
-	_, err := db.Exec(query,
-		sql.Named("Ref", req.RefInvoiceNo),
-		sql.Named("Note", req.Note),
-		sql.Named("UpdateBy", req.UpdateBy),
-		sql.Named("ID", id),
-	)
+	err := h.repo.UpdateNote(c.UserContext(), c.Params("id"), req.RefInvoiceNo, req.Note, utils.AuditUser(c, req.UpdateBy))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "Receive note not found",
+			Data:    nil,
+		})
+	}
 	if err != nil {
-		return c.Status(500).SendString(err.Error())
+		return utils.Internal(err, "Failed to update receive note")
 	}
 
-	return c.JSON(fiber.Map{"status": "success"})
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "Receive note updated successfully",
+		Data:    nil,
+	})
 }
 
-// DeleteReceiveNoteByID ลบใบรับสินค้า (Soft Delete)
-func DeleteReceiveNoteByID(c *fiber.Ctx) error {
-	db := c.Locals("db").(*sql.DB)
-	id := c.Params("id")
-
-	// Transaction to delete header and items
-	tx, err := db.Begin()
-	if err != nil {
-		return c.Status(500).SendString(err.Error())
-	}
-
-	// 1. Delete Header
-	_, err = tx.Exec(`UPDATE tb_receive_note SET is_delete = 1, is_active = 0 WHERE receive_note_id = @ID`, sql.Named("ID", id))
-	if err != nil {
-		tx.Rollback()
-		return c.Status(500).SendString(err.Error())
+// DeleteReceiveNoteByID ลบใบรับสินค้า (Soft Delete ทั้ง Header และ Items)
+func (h *ReceiveHandler) DeleteReceiveNoteByID(c *fiber.Ctx) error {
+	err := h.repo.SoftDelete(c.UserContext(), c.Params("id"), utils.ResolveUser(c))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "Receive note not found",
+			Data:    nil,
+		})
 	}
-
-	// 2. Delete Items
-	_, err = tx.Exec(`UPDATE tb_receive_item SET is_delete = 1 WHERE receive_note_id = @ID`, sql.Named("ID", id))
 	if err != nil {
-		tx.Rollback()
-		return c.Status(500).SendString(err.Error())
+		return utils.Internal(err, "Failed to delete receive note")
 	}
 
-	tx.Commit()
-	return c.JSON(fiber.Map{"status": "success"})
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "Receive note deleted successfully",
+		Data:    nil,
+	})
 }
 
-// RemoveReceiveNoteByID ลบจริง (Hard Delete)
-func RemoveReceiveNoteByID(c *fiber.Ctx) error {
-	db := c.Locals("db").(*sql.DB)
-	id := c.Params("id")
-	
-	// Check if ID is 'TEMP' or similar if needed, otherwise standard delete
-	tx, err := db.Begin()
-	if err != nil { return c.Status(500).SendString(err.Error()) }
-
-	// Delete Items first FK
-	_, err = tx.Exec("DELETE FROM tb_receive_item WHERE receive_note_id = @ID", sql.Named("ID", id))
-	if err != nil { tx.Rollback(); return c.Status(500).SendString(err.Error()) }
-
-	// Delete Header
-	_, err = tx.Exec("DELETE FROM tb_receive_note WHERE receive_note_id = @ID", sql.Named("ID", id))
-	if err != nil { tx.Rollback(); return c.Status(500).SendString(err.Error()) }
+// RemoveReceiveNoteByID ลบจริง (Hard Delete) โดยลบ Items ก่อนเพราะติด FK
+func (h *ReceiveHandler) RemoveReceiveNoteByID(c *fiber.Ctx) error {
+	err := h.repo.Purge(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "Receive note not found",
+			Data:    nil,
+		})
+	}
+	if err != nil {
+		return utils.Internal(err, "Failed to remove receive note")
+	}
 
-	tx.Commit()
-	return c.JSON(fiber.Map{"status": "success"})
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "Receive note removed successfully",
+		Data:    nil,
+	})
 }
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"PenbunAPI/models"
+	"PenbunAPI/repository"
+	"PenbunAPI/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// recycleModule คือข้อมูลของ module ที่ใช้กับ Restore และถังขยะ (Recycle Bin)
+type recycleModule struct {
+	repository.RecycleBin
+	entity string // ชื่อที่ใช้ในข้อความตอบกลับ
+}
+
+// recycleModules ใช้ชื่อ module เดียวกับ Route ของ v1 (และ scope ของ API Key)
+var recycleModules = map[string]recycleModule{
+	"vendor":            {RecycleBin: repository.RecycleBin{Table: "tb_vendor", IDColumn: "vendor_id", NameColumn: "vendor_name", Model: models.Vendor{}}, entity: "Vendor"},
+	"vendortype":        {RecycleBin: repository.RecycleBin{Table: "tb_vendor_type", IDColumn: "vendor_type_id", NameColumn: "type_name", Model: models.VendorType{}}, entity: "Vendor type"},
+	"customer":          {RecycleBin: repository.RecycleBin{Table: "tb_customer", IDColumn: "customer_id", NameColumn: "customer_name", Model: models.Customer{}}, entity: "Customer"},
+	"customertype":      {RecycleBin: repository.RecycleBin{Table: "tb_customer_type", IDColumn: "customer_type_id", NameColumn: "customer_type_name", Model: models.CustomerType{}}, entity: "Customer type"},
+	"discount":          {RecycleBin: repository.RecycleBin{Table: "tb_discount", IDColumn: "discount_id", NameColumn: "discount_name", Model: models.Discount{}}, entity: "Discount"},
+	"discounttype":      {RecycleBin: repository.RecycleBin{Table: "tb_discount_type", IDColumn: "discount_type_id", NameColumn: "discount_type_name", Model: models.DiscountType{}}, entity: "Discount type"},
+	"unittype":          {RecycleBin: repository.RecycleBin{Table: "tb_unit_type", IDColumn: "unit_type_id", NameColumn: "unit_type_name", Model: models.UnitType{}}, entity: "Unit type"},
+	"productgroup":      {RecycleBin: repository.RecycleBin{Table: "tb_product_group", IDColumn: "product_group_id", NameColumn: "product_group_name", Model: models.ProductGroup{}}, entity: "Product group"},
+	"productcategory":   {RecycleBin: repository.RecycleBin{Table: "tb_product_category", IDColumn: "product_category_id", NameColumn: "category_name", Model: models.ProductCategory{}}, entity: "Product category"},
+	"productformattype": {RecycleBin: repository.RecycleBin{Table: "tb_product_format_type", IDColumn: "product_format_type_id", NameColumn: "format_name", Model: models.ProductFormatType{}}, entity: "Product format type"},
+	"productpackconfig": {RecycleBin: repository.RecycleBin{Table: "tb_product_pack_config", IDColumn: "product_pack_config_id", NameColumn: "product_id", Model: models.ProductPackConfig{}}, entity: "Product pack config"},
+	"product":           {RecycleBin: repository.RecycleBin{Table: "tb_product", IDColumn: "product_id", NameColumn: "product_name_th", Model: models.Product{}}, entity: "Product"},
+	"warehouse":         {RecycleBin: repository.RecycleBin{Table: "tb_warehouse", IDColumn: "warehouse_id", NameColumn: "warehouse_name", Model: models.Warehouse{}}, entity: "Warehouse"},
+	"receive":           {RecycleBin: repository.RecycleBin{Table: "tb_receive_note", IDColumn: "receive_note_id", NameColumn: "vendor_id", Model: models.ReceiveNote{}, ItemTable: "tb_receive_item", ItemModel: models.ReceiveItem{}}, entity: "Receive note"},
+	"order":             {RecycleBin: repository.RecycleBin{Table: "tb_order", IDColumn: "order_id", NameColumn: "customer_id", Model: models.Order{}, ItemTable: "tb_order_item", ItemModel: models.OrderItem{}}, entity: "Order"},
+}
+
+func lookupRecycleModule(module string) recycleModule {
+	m, ok := recycleModules[module]
+	if !ok {
+		panic("controllers: unknown recycle module " + module)
+	}
+	return m
+}
+
+// RecycleHandler คือ Handler ของ Restore และถังขยะของทุก module ซึ่งเข้าถึงข้อมูลผ่าน RecycleRepository
+type RecycleHandler struct {
+	repo repository.RecycleRepository
+}
+
+// NewRecycleHandler สร้าง RecycleHandler (ใช้ repository.NewMemoryRecycleRepository เมื่อทดสอบโดยไม่มีฐานข้อมูล)
+func NewRecycleHandler(repo repository.RecycleRepository) *RecycleHandler {
+	return &RecycleHandler{repo: repo}
+}
+
+// RestoreByID สร้าง Handler สำหรับ PUT /restore/:id ของ module
+// เปลี่ยน is_delete กลับเป็น 0 (พร้อม items ของเอกสาร) โดยปฏิเสธด้วย 409 หากข้อมูลที่แถวนี้อ้างถึงถูกลบไปแล้ว
+func (h *RecycleHandler) RestoreByID(module string) fiber.Handler {
+	m := lookupRecycleModule(module)
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+
+		err := h.repo.Restore(c.UserContext(), m.RecycleBin, id, utils.ResolveUser(c))
+		if err == repository.ErrNotFound {
+			return utils.NotFound(m.entity + " not found in recycle bin")
+		}
+		if err != nil {
+			return utils.Internal(err, "Failed to restore "+m.entity)
+		}
+		return c.JSON(models.ApiResponse{
+			Status:  "success",
+			Message: m.entity + " restored successfully",
+			Data:    fiber.Map{m.IDColumn: id},
+		})
+	}
+}
+
+// SelectRecycleBin สร้าง Handler สำหรับ GET /recycle ของ module
+// แสดงรายการที่ถูก Soft Delete พร้อมผู้ลบและวันที่ลบ (รองรับ ?filter=, ?sort=, ?q= และ Paging แบบ Select Page)
+func (h *RecycleHandler) SelectRecycleBin(module string) fiber.Handler {
+	m := lookupRecycleModule(module)
+	spec := utils.ListSpec{
+		Fields: map[string]utils.ListField{
+			"id":           {Column: m.IDColumn, Type: utils.FieldText},
+			"name":         {Column: m.NameColumn, Type: utils.FieldText},
+			"deleted_by":   {Column: "update_by", Type: utils.FieldText},
+			"deleted_date": {Column: "update_date", Type: utils.FieldDate},
+		},
+		Search:      []string{m.IDColumn, m.NameColumn},
+		DefaultSort: "update_date DESC",
+		Key:         "id",
+	}
+	return func(c *fiber.Ctx) error {
+		lq, err := utils.ParseListQuery(c, spec)
+		if err != nil {
+			return utils.BadRequest(err.Error())
+		}
+
+		list, total, err := h.repo.Page(c.UserContext(), m.RecycleBin, lq)
+		if err != nil {
+			return utils.Internal(err, "Failed to fetch recycle bin")
+		}
+
+		list, next := utils.CursorPage(lq, list, func(item models.DeletedRecord) interface{} { return item.ID })
+		return utils.SendPage(c, lq, list, total, next)
+	}
+}
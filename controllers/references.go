@@ -1,39 +1,67 @@
 package controllers
 
 import (
-	"PenbunAPI/config"
 	"PenbunAPI/models"
-	"database/sql"
+	"PenbunAPI/repository"
+	"PenbunAPI/utils"
 	"log"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// referenceFilterColumns คือคอลัมน์ของ tb_reference ที่อนุญาตให้ใช้กรองข้อมูล
+// ชื่อคอลัมน์ใน SQL มาจาก map นี้เท่านั้น ไม่เคยนำค่าจาก Request มาต่อเป็น SQL โดยตรง
+var referenceFilterColumns = map[string]string{
+	"row_id":   "row_id",
+	"ref_id":   "ref_id",
+	"ref_int":  "ref_int",
+	"ref_text": "ref_text",
+}
+
+// ReferenceHandler คือ Handler ของ Reference API ซึ่งเข้าถึงข้อมูลผ่าน ReferenceRepository
+// (การอ่านตาม ref_id ผ่าน cache ของ utils.LookupReference)
+type ReferenceHandler struct {
+	repo repository.ReferenceRepository
+}
+
+// NewReferenceHandler สร้าง ReferenceHandler (ใช้ repository.NewMemoryReferenceRepository เมื่อทดสอบโดยไม่มีฐานข้อมูล)
+func NewReferenceHandler(repo repository.ReferenceRepository) *ReferenceHandler {
+	return &ReferenceHandler{repo: repo}
+}
+
+// referenceFilters คืนเงื่อนไข (คอลัมน์ -> ค่า) จาก Query String ที่อยู่ใน referenceFilterColumns
+func referenceFilters(c *fiber.Ctx) map[string]string {
+	filters := map[string]string{}
+	for key, column := range referenceFilterColumns {
+		if value := c.Query(key); value != "" {
+			filters[column] = value
+		}
+	}
+	return filters
+}
+
 // GetReference retrieves a specific reference by parameter
-func GetReference(c *fiber.Ctx) error {
+// (คงรูปแบบเดิมของ v1: ?parameter=<column>&value=<value> คืนค่าแถวแรกที่พบ แต่ column ต้องอยู่ใน allow-list)
+func (h *ReferenceHandler) GetReference(c *fiber.Ctx) error {
 	// รับค่าพารามิเตอร์ เช่น ref_id
-	parameter := c.Query("parameter")  // ชื่อฟิลด์ใน WHERE
-	value := c.Query("value")          // ค่าที่จะค้นหา
+	parameter := c.Query("parameter") // ชื่อฟิลด์ใน WHERE
+	value := c.Query("value")         // ค่าที่จะค้นหา
 
 	if parameter == "" || value == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Missing parameter or value"})
 	}
 
-	// สร้างคำสั่ง SQL โดยใช้ parameter และ value
-	query := "SELECT row_id, ref_id, ref_int, ref_text, update_by, update_date FROM tb_reference WHERE " + parameter + " = @Value"
-
-	var reference models.Reference
+	column, ok := referenceFilterColumns[strings.ToLower(parameter)]
+	if !ok {
+		log.Printf("[WARN] GetReference rejected parameter %q", parameter)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unsupported parameter"})
+	}
 
-	err := config.DB.QueryRow(query, sql.Named("Value", value)).Scan(
-		&reference.RowID,
-		&reference.RefID,
-		&reference.RefInt,
-		&reference.RefText,
-		&reference.UpdateBy,
-		&reference.UpdateDate,
-	)
+	reference, err := h.repo.Find(c.UserContext(), column, value)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if err == repository.ErrNotFound {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Reference not found"})
 		}
 		log.Println("[ERROR] Query failed:", err)
@@ -43,3 +71,124 @@ func GetReference(c *fiber.Ctx) error {
 	// ส่งข้อมูลกลับในรูปแบบ JSON
 	return c.JSON(reference)
 }
+
+// SelectAllReferences ดึงรายการ tb_reference ทั้งหมด กรองได้ด้วย ?ref_id=&ref_int=&ref_text=&row_id=
+func (h *ReferenceHandler) SelectAllReferences(c *fiber.Ctx) error {
+	list, err := h.repo.List(c.UserContext(), referenceFilters(c))
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch references")
+	}
+
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "References retrieved successfully",
+		Data:    list,
+	})
+}
+
+// SelectReferenceByRefID ดึงรายการทั้งหมดในกลุ่ม ref_id (อ่านผ่าน cache)
+func (h *ReferenceHandler) SelectReferenceByRefID(c *fiber.Ctx) error {
+	refID := c.Params("refid")
+	list, err := h.repo.Lookup(c.UserContext(), refID)
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch references")
+	}
+	if len(list) == 0 {
+		return c.Status(404).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "Reference group not found",
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "References retrieved successfully",
+		Data:    list,
+	})
+}
+
+// SelectReferenceByRowID ดึงรายการเดียวตาม row_id (ใช้เป็น GET /references/:id ของ v2 และการอ่านก่อน PATCH)
+func (h *ReferenceHandler) SelectReferenceByRowID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "Invalid row_id",
+			Data:    nil,
+		})
+	}
+	item, err := h.repo.Find(c.UserContext(), "row_id", strconv.Itoa(id))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "Reference not found",
+			Data:    nil,
+		})
+	}
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch reference")
+	}
+
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "Reference retrieved successfully",
+		Data:    item,
+	})
+}
+
+func (h *ReferenceHandler) InsertReference(c *fiber.Ctx) error {
+	var item models.Reference
+	if err := utils.Bind(c, &item); err != nil {
+		return err
+	}
+
+	item.UpdateBy = utils.StampUser(c, item.UpdateBy)
+
+	// Repository ล้าง cache ของกลุ่ม ref_id หลังเพิ่มสำเร็จ (repository.CachedReference)
+	if err := h.repo.Create(c.UserContext(), &item); err != nil {
+		return utils.Internal(err, "Failed to insert reference")
+	}
+	utils.SetCreatedID(c, strconv.Itoa(item.RowID))
+
+	return c.Status(201).JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "Reference added successfully",
+		Data:    fiber.Map{"row_id": item.RowID, "ref_id": item.RefID, "ref_int": item.RefInt},
+	})
+}
+
+func (h *ReferenceHandler) UpdateReferenceByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "error",
+			Message: "Invalid row_id",
+			Data:    nil,
+		})
+	}
+	var item models.Reference
+	if err := utils.BindPartial(c, &item); err != nil {
+		return err
+	}
+
+	item.UpdateBy = utils.StampUser(c, item.UpdateBy)
+
+	// Repository ล้าง cache ทั้งกลุ่มเดิมและกลุ่มใหม่ (กรณีย้ายกลุ่ม) หลังแก้ไขสำเร็จ
+	if _, err := h.repo.Update(c.UserContext(), id, &item); err != nil {
+		if err == repository.ErrNotFound {
+			return c.Status(404).JSON(models.ApiResponse{
+				Status:  "error",
+				Message: "Reference not found",
+				Data:    nil,
+			})
+		}
+		return utils.Internal(err, "Failed to update reference")
+	}
+
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "Reference updated successfully",
+		Data:    fiber.Map{"row_id": id},
+	})
+}
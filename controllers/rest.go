@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"PenbunAPI/models"
+	"PenbunAPI/utils"
+	"encoding/json"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// auditFields คือ field ที่ Server เป็นผู้กำหนดเอง จึงไม่นำค่าเดิมไปส่งต่อให้ Update
+var auditFields = []string{"update_by", "update_date"}
+
+// MergePatch สร้าง Handler สำหรับ PATCH /:id แบบ JSON Merge Patch (RFC 7396)
+// โดยอ่านข้อมูลปัจจุบันด้วย get แล้วนำ field ที่ส่งมาทับลงไป ก่อนส่งต่อให้ update (ซึ่งรับข้อมูลทั้งก้อน)
+// field ที่ไม่ได้ส่งมาจะคงค่าเดิม เอกสารที่มี header/items จะ Patch ที่ header
+// null (การล้างค่าของ RFC 7396) ตอบ 400 เพราะ Update ของ v1 ที่รับต่อใช้ COALESCE ซึ่งตีความ null ว่าคงค่าเดิม
+func MergePatch(get, update fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var patch map[string]interface{}
+		if err := json.Unmarshal(c.Body(), &patch); err != nil || patch == nil {
+			return c.Status(400).JSON(models.ApiResponse{
+				Status:  "fail",
+				Message: "Request body must be a JSON object",
+				Data:    nil,
+			})
+		}
+
+		if fields := nullFields(patch, ""); len(fields) > 0 {
+			errs := make([]utils.FieldError, len(fields))
+			for i, f := range fields {
+				errs[i] = utils.FieldError{Field: f, Rule: "not_null", Message: "null cannot clear a field, send the new value instead"}
+			}
+			return utils.BadRequest("null values are not supported in PATCH").WithDetails(fiber.Map{"errors": errs})
+		}
+
+		if err := get(c); err != nil {
+			return err
+		}
+		if c.Response().StatusCode() != fiber.StatusOK {
+			return nil // เช่น 404 จาก get ตอบกลับไปตามเดิม
+		}
+
+		var current struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(c.Response().Body(), &current); err != nil || current.Data == nil {
+			return utils.Internal(err, "Failed to read current record")
+		}
+		doc := current.Data
+		if header, ok := doc["header"].(map[string]interface{}); ok {
+			doc = header
+		}
+		for _, f := range auditFields {
+			delete(doc, f)
+		}
+
+		body, err := json.Marshal(mergePatch(doc, patch))
+		if err != nil {
+			return err
+		}
+		c.Request().SetBody(body)
+		c.Request().Header.SetContentType(fiber.MIMEApplicationJSON)
+		c.Response().ResetBody()
+		c.Status(fiber.StatusOK)
+		return update(c)
+	}
+}
+
+// nullFields คืนชื่อ field ทั้งหมดใน patch ที่มีค่า null (object ซ้อนใช้ชื่อแบบ a.b) เรียงตามชื่อ
+func nullFields(patch map[string]interface{}, prefix string) []string {
+	var fields []string
+	for k, v := range patch {
+		switch v := v.(type) {
+		case nil:
+			fields = append(fields, prefix+k)
+		case map[string]interface{}:
+			fields = append(fields, nullFields(v, prefix+k+".")...)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// mergePatch ทับ patch ลงบน doc ตาม RFC 7396 (object ซ้อนกันจะ merge ต่อ)
+func mergePatch(doc, patch map[string]interface{}) map[string]interface{} {
+	for k, v := range patch {
+		sub, isObj := v.(map[string]interface{})
+		cur, curObj := doc[k].(map[string]interface{})
+		if isObj && curObj {
+			doc[k] = mergePatch(cur, sub)
+		} else {
+			doc[k] = v
+		}
+	}
+	return doc
+}
@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"PenbunAPI/auth"
+	"PenbunAPI/dialect"
+	"PenbunAPI/models"
+	"PenbunAPI/utils"
+	"context"
+	"database/sql"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// SessionHandler คือ Handler ของ Session ผู้ใช้ (tb_user_session)
+type SessionHandler struct {
+	db       *sql.DB
+	sessions *auth.Sessions
+	log      *logrus.Logger
+}
+
+// NewSessionHandler สร้าง SessionHandler
+func NewSessionHandler(db *sql.DB, sessions *auth.Sessions, logger *logrus.Logger) *SessionHandler {
+	return &SessionHandler{db: db, sessions: sessions, log: logger}
+}
+
+// issueSessionToken เปิด Session ใหม่ใน tb_user_session แล้วออก JWT ที่ผูกกับ Session นั้น (sid)
+func issueSessionToken(c *fiber.Ctx, sessions *auth.Sessions, userName string, roles []string, amr ...string) (string, error) {
+	sid, err := sessions.Start(c.UserContext(), userName, c.Get(fiber.HeaderUserAgent), c.IP())
+	if err != nil {
+		return "", err
+	}
+	tokenString, _, err := auth.IssueToken(userName, roles, sid, amr...)
+	return tokenString, err
+}
+
+// selectSessions อ่าน Session ที่ยังใช้งานได้ของผู้ใช้ เรียงจากใช้งานล่าสุด
+func (h *SessionHandler) selectSessions(ctx context.Context, userName, currentSID string) ([]models.UserSession, error) {
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT session_id, user_name, user_agent, ip_address, issue_date, last_seen_date, expire_date
+		FROM tb_user_session
+		WHERE user_name = @UserName
+		  AND revoke_date IS NULL
+		  AND expire_date > `+dialect.Of(h.db).Now()+`
+		ORDER BY last_seen_date DESC
+	`, sql.Named("UserName", userName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.UserSession{}
+	for rows.Next() {
+		var item models.UserSession
+		var issue, lastSeen, expire sql.NullTime
+		if err := rows.Scan(&item.SessionID, &item.UserName, &item.UserAgent, &item.IPAddress, &issue, &lastSeen, &expire); err != nil {
+			return nil, err
+		}
+		for _, f := range []struct {
+			src sql.NullTime
+			dst **string
+		}{{issue, &item.IssueDate}, {lastSeen, &item.LastSeenDate}, {expire, &item.ExpireDate}} {
+			if f.src.Valid {
+				t := f.src.Time.Format("2006-01-02T15:04:05")
+				*f.dst = &t
+			}
+		}
+		item.Current = item.SessionID == currentSID
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
+
+// currentSessionID คืนค่า sid ของ Token ที่ใช้เรียก Request นี้
+func currentSessionID(c *fiber.Ctx) string {
+	if claims := auth.FromContext(c); claims != nil {
+		return claims.SessionID
+	}
+	return ""
+}
+
+// SelectMySessions - รายการ Session ที่ยังใช้งานอยู่ของผู้ใช้ปัจจุบัน
+func (h *SessionHandler) SelectMySessions(c *fiber.Ctx) error {
+	list, err := h.selectSessions(c.UserContext(), utils.ResolveUser(c), currentSessionID(c))
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch sessions")
+	}
+
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "Sessions retrieved successfully",
+		Data:    list,
+	})
+}
+
+// RevokeMySession - ยกเลิก Session หนึ่งของผู้ใช้ปัจจุบัน (Token ของ Session นั้นจะใช้ไม่ได้ทันที)
+func (h *SessionHandler) RevokeMySession(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userName := utils.ResolveUser(c)
+
+	found, err := h.sessions.Revoke(c.UserContext(), id, userName, userName)
+	if err != nil {
+		return utils.Internal(err, "Failed to revoke session")
+	}
+	if !found {
+		return c.Status(404).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: "Session not found",
+			Data:    nil,
+		})
+	}
+
+	h.log.WithFields(logrus.Fields{
+		"action":     "session_revoke",
+		"user_name":  userName,
+		"session_id": id,
+	}).Info("Session revoked")
+
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "Session revoked successfully",
+		Data:    fiber.Map{"session_id": id},
+	})
+}
+
+// RevokeOtherSessions - ออกจากระบบทุกอุปกรณ์ยกเว้น Session ปัจจุบัน
+func (h *SessionHandler) RevokeOtherSessions(c *fiber.Ctx) error {
+	userName := utils.ResolveUser(c)
+
+	n, err := h.sessions.RevokeUser(c.UserContext(), userName, currentSessionID(c), userName)
+	if err != nil {
+		return utils.Internal(err, "Failed to revoke sessions")
+	}
+
+	h.log.WithFields(logrus.Fields{
+		"action":    "session_revoke_others",
+		"user_name": userName,
+		"revoked":   n,
+	}).Info("Other sessions revoked")
+
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "Other sessions revoked successfully",
+		Data:    fiber.Map{"revoked": n},
+	})
+}
+
+// SelectUserSessions - (Admin) รายการ Session ที่ยังใช้งานอยู่ของผู้ใช้ที่ระบุ
+func (h *SessionHandler) SelectUserSessions(c *fiber.Ctx) error {
+	list, err := h.selectSessions(c.UserContext(), c.Params("username"), currentSessionID(c))
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch sessions")
+	}
+
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "Sessions retrieved successfully",
+		Data:    list,
+	})
+}
+
+// ForceLogoutUser - (Admin) ยกเลิกทุก Session ของผู้ใช้ที่ระบุ
+func (h *SessionHandler) ForceLogoutUser(c *fiber.Ctx) error {
+	target := c.Params("username")
+	admin := utils.ResolveUser(c)
+
+	n, err := h.sessions.RevokeUser(c.UserContext(), target, "", admin)
+	if err != nil {
+		return utils.Internal(err, "Failed to revoke sessions")
+	}
+
+	h.log.WithFields(logrus.Fields{
+		"action":    "session_force_logout",
+		"user_name": target,
+		"revoke_by": admin,
+		"revoked":   n,
+	}).Warn("User force-logged out by admin")
+
+	return c.JSON(models.ApiResponse{
+		Status:  "success",
+		Message: "User logged out from all sessions",
+		Data:    fiber.Map{"user_name": target, "revoked": n},
+	})
+}
@@ -1,179 +1,110 @@
 package controllers
 
 import (
-	"PenbunAPI/config"
 	"PenbunAPI/models"
+	"PenbunAPI/repository"
 	"PenbunAPI/utils"
-	"database/sql"
-	"errors"
-	"log"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SelectAllUnitType(c *fiber.Ctx) error {
-	query := `
-		SELECT unit_type_id, unit_type_name, COALESCE(description, ''), update_by, update_date, is_active
-		FROM tb_unit_type
-		WHERE is_delete = 0
-	`
-	rows, err := config.DB.Query(query)
-	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch unit types",
-			Data:    nil,
-		})
-	}
-	defer rows.Close()
+// UnitTypeHandler คือ Handler ของ Unit Type API ซึ่งเข้าถึงข้อมูลผ่าน UnitTypeRepository
+type UnitTypeHandler struct {
+	repo repository.UnitTypeRepository
+}
+
+// NewUnitTypeHandler สร้าง UnitTypeHandler (ใช้ repository.NewMemoryUnitTypeRepository เมื่อทดสอบโดยไม่มีฐานข้อมูล)
+func NewUnitTypeHandler(repo repository.UnitTypeRepository) *UnitTypeHandler {
+	return &UnitTypeHandler{repo: repo}
+}
 
-	var result []models.UnitType
-	for rows.Next() {
-		var ut models.UnitType
-		if err := rows.Scan(&ut.UnitTypeID, &ut.UnitTypeName, &ut.Description, &ut.UpdateBy, &ut.UpdateDate, &ut.IsActive); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		result = append(result, ut)
+func (h *UnitTypeHandler) SelectAllUnitType(c *fiber.Ctx) error {
+	list, err := h.repo.List(c.UserContext())
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch unit types")
 	}
 
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "",
-		Data:    result,
+		Data:    list,
 	})
 }
 
-func SelectPageUnitType(c *fiber.Ctx) error {
-	page := c.QueryInt("page", 1)
-	limit := c.QueryInt("limit", 10)
-	offset := (page - 1) * limit
+// unitTypeListSpec คือ field ที่ SelectPageUnitType อนุญาตให้ใช้กับ ?filter=, ?sort= และ ?q=
+var unitTypeListSpec = utils.ListSpec{
+	Fields: map[string]utils.ListField{
+		"unit_type_id":   {Column: "unit_type_id", Type: utils.FieldText},
+		"unit_type_name": {Column: "unit_type_name", Type: utils.FieldText},
+		"is_active":      {Column: "is_active", Type: utils.FieldBool},
+		"update_by":      {Column: "update_by", Type: utils.FieldText},
+		"update_date":    {Column: "update_date", Type: utils.FieldDate},
+	},
+	Search:      []string{"unit_type_id", "unit_type_name", "description"},
+	DefaultSort: "update_date DESC",
+	Key:         "unit_type_id",
+}
 
-	query := `
-		SELECT unit_type_id, unit_type_name, COALESCE(description, ''), update_by, update_date, is_active
-		FROM tb_unit_type
-		WHERE is_delete = 0
-		ORDER BY update_date DESC
-		OFFSET @Offset ROWS FETCH NEXT @Limit ROWS ONLY
-	`
-	rows, err := config.DB.Query(query, sql.Named("Offset", offset), sql.Named("Limit", limit))
+func (h *UnitTypeHandler) SelectPageUnitType(c *fiber.Ctx) error {
+	lq, err := utils.ParseListQuery(c, unitTypeListSpec)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch unit types",
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: err.Error(),
 			Data:    nil,
 		})
 	}
-	defer rows.Close()
+	page, limit := lq.Page, lq.Limit
 
-	var result []models.UnitType
-	for rows.Next() {
-		var ut models.UnitType
-		if err := rows.Scan(&ut.UnitTypeID, &ut.UnitTypeName, &ut.Description, &ut.UpdateBy, &ut.UpdateDate, &ut.IsActive); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		result = append(result, ut)
+	list, total, err := h.repo.Page(c.UserContext(), lq)
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch unit types")
 	}
 
-	var total int
-	err = config.DB.QueryRow(`SELECT COUNT(*) FROM tb_unit_type WHERE is_delete = 0`).Scan(&total)
-	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to count records",
-			Data:    nil,
-		})
+	list, next := utils.CursorPage(lq, list, func(item models.UnitType) interface{} { return item.UnitTypeID })
+
+	if utils.UsePageEnvelope(c) {
+		return utils.SendPage(c, lq, list, total, next)
 	}
 
 	return c.JSON(models.ApiResponse{
 		Status: "success",
-		Data: fiber.Map{
+		Data: lq.Paged(fiber.Map{
 			"page":      page,
 			"limit":     limit,
 			"total":     total,
-			"items":     result,
-		},
+			"items":     list,
+		}, next),
 	})
 }
 
-func SelectUnitTypeByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `
-		SELECT unit_type_id, unit_type_name, COALESCE(description, ''), update_by, update_date, is_active
-		FROM tb_unit_type
-		WHERE unit_type_id = @ID AND is_delete = 0
-	`
-	row := config.DB.QueryRow(query, sql.Named("ID", id))
-
-	var ut models.UnitType
-	if err := row.Scan(&ut.UnitTypeID, &ut.UnitTypeName, &ut.Description, &ut.UpdateBy, &ut.UpdateDate, &ut.IsActive); err != nil {
-		if err == sql.ErrNoRows {
-			return c.Status(404).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Unit type not found",
-				Data:    nil,
-			})
-		}
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
+func (h *UnitTypeHandler) SelectUnitTypeByID(c *fiber.Ctx) error {
+	item, err := h.repo.Get(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
-			Message: "Failed to read data",
+			Message: "Unit type not found",
 			Data:    nil,
 		})
 	}
+	if err != nil {
+		return utils.Internal(err, "Failed to read data")
+	}
 
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "",
-		Data:    ut,
+		Data:    item,
 	})
 }
 
-func SelectUnitTypeByName(c *fiber.Ctx) error {
-	name := c.Params("name")
-	query := `
-		SELECT unit_type_id, unit_type_name, COALESCE(description, ''), update_by, update_date, is_active
-		FROM tb_unit_type
-		WHERE unit_type_name LIKE '%' + @Name + '%' AND is_delete = 0
-	`
-	rows, err := config.DB.Query(query, sql.Named("Name", name))
+func (h *UnitTypeHandler) SelectUnitTypeByName(c *fiber.Ctx) error {
+	list, err := h.repo.Search(c.UserContext(), c.Params("name"))
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch unit types",
-			Data:    nil,
-		})
-	}
-	defer rows.Close()
-
-	var result []models.UnitType
-	for rows.Next() {
-		var ut models.UnitType
-		if err := rows.Scan(&ut.UnitTypeID, &ut.UnitTypeName, &ut.Description, &ut.UpdateBy, &ut.UpdateDate, &ut.IsActive); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		result = append(result, ut)
+		return utils.Internal(err, "Failed to fetch unit types")
 	}
 
-	if len(result) == 0 {
+	if len(list) == 0 {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "No matching unit type found",
@@ -184,106 +115,52 @@ func SelectUnitTypeByName(c *fiber.Ctx) error {
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "",
-		Data:    result,
+		Data:    list,
 	})
 }
 
-func InsertUnitType(c *fiber.Ctx) error {
-	var ut models.UnitType
-	if err := c.BodyParser(&ut); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Invalid request body",
-			Data:    nil,
-		})
+func (h *UnitTypeHandler) InsertUnitType(c *fiber.Ctx) error {
+	var item models.UnitType
+	if err := utils.Bind(c, &item); err != nil {
+		return err
 	}
 
-	query := `
-		INSERT INTO tb_unit_type (unit_type_id, unit_type_name, description, update_by)
-		VALUES (NULL, @Name, @Desc, @By)
-	`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			_, err := tx.Exec(query,
-				sql.Named("Name", ut.UnitTypeName),
-				sql.Named("Desc", ut.Description),
-				sql.Named("By", ut.UpdateBy),
-			)
-			return err
-		},
-	})
+	item.UpdateBy = utils.AuditUser(c, item.UpdateBy)
+
+	newID, err := h.repo.Create(c.UserContext(), &item)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to insert unit type",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to insert unit type")
 	}
 
+	utils.SetCreatedID(c, newID)
 	return c.Status(201).JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "Unit type added successfully",
-		Data:    nil,
+		Data:    fiber.Map{"unit_type_id": newID},
 	})
 }
 
-func UpdateUnitTypeByID(c *fiber.Ctx) error {
+func (h *UnitTypeHandler) UpdateUnitTypeByID(c *fiber.Ctx) error {
 	id := c.Params("id")
-	var ut models.UnitType
-	if err := c.BodyParser(&ut); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Invalid request body",
-			Data:    nil,
-		})
+	var item models.UnitType
+	if err := utils.BindPartial(c, &item); err != nil {
+		return err
 	}
 
-	query := `
-		UPDATE tb_unit_type
-		SET unit_type_name = COALESCE(NULLIF(@Name, ''), unit_type_name),
-			description = COALESCE(@Desc, description),
-			update_by = @By,
-			is_active = COALESCE(@Status, is_active)
-		WHERE unit_type_id = @ID AND is_delete = 0
-	`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(query,
-				sql.Named("Name", ut.UnitTypeName),
-				sql.Named("Desc", ut.Description),
-				sql.Named("By", ut.UpdateBy),
-				sql.Named("Status", ut.IsActive),
-				sql.Named("ID", id),
-			)
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return errors.New("unit type not found")
-			}
-			return nil
-		},
-	})
-	if err != nil {
-		if err.Error() == "unit type not found" {
-			return c.Status(404).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Unit type not found",
-				Data:    nil,
-			})
-		}
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
+	item.UpdateBy = utils.AuditUser(c, item.UpdateBy)
+
+	err := h.repo.Update(c.UserContext(), id, &item)
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
-			Message: "Failed to update unit type",
+			Message: "Unit type not found",
 			Data:    nil,
 		})
 	}
+	if err != nil {
+		return utils.Internal(err, "Failed to update unit type")
+	}
+
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "Unit type updated successfully",
@@ -291,27 +168,22 @@ func UpdateUnitTypeByID(c *fiber.Ctx) error {
 	})
 }
 
-func DeleteUnitTypeByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `
-		UPDATE tb_unit_type
-		SET is_delete = 1
-		WHERE unit_type_id = @ID
-	`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			_, err := tx.Exec(query, sql.Named("ID", id))
-			return err
-		},
-	})
+func (h *UnitTypeHandler) DeleteUnitTypeByID(c *fiber.Ctx) error {
+	cascade, err := utils.CascadeSoft(c)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
+		return err
+	}
+	err = h.repo.SoftDelete(c.UserContext(), c.Params("id"), utils.ResolveUser(c), cascade)
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
-			Message: "Failed to soft delete unit type",
+			Message: "Unit type not found",
 			Data:    nil,
 		})
 	}
+	if err != nil {
+		return utils.Internal(err, "Failed to soft delete unit type")
+	}
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "Unit type marked as deleted",
@@ -319,23 +191,18 @@ func DeleteUnitTypeByID(c *fiber.Ctx) error {
 	})
 }
 
-func RemoveUnitTypeByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `DELETE FROM tb_unit_type WHERE unit_type_id = @ID`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			_, err := tx.Exec(query, sql.Named("ID", id))
-			return err
-		},
-	})
-	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
+func (h *UnitTypeHandler) RemoveUnitTypeByID(c *fiber.Ctx) error {
+	err := h.repo.Purge(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
-			Message: "Failed to hard delete unit type",
+			Message: "Unit type not found",
 			Data:    nil,
 		})
 	}
+	if err != nil {
+		return utils.Internal(err, "Failed to hard delete unit type")
+	}
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "Unit type removed successfully",
@@ -1,52 +1,29 @@
 package controllers
 
 import (
-	"PenbunAPI/config"
 	"PenbunAPI/models"
+	"PenbunAPI/repository"
 	"PenbunAPI/utils"
-	"database/sql"
 	"log"
-	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// VendorTypeHandler คือ Handler ของ Vendor Type API ซึ่งเข้าถึงข้อมูลผ่าน VendorTypeRepository
+type VendorTypeHandler struct {
+	repo repository.VendorTypeRepository
+}
+
+// NewVendorTypeHandler สร้าง VendorTypeHandler (ใช้ repository.NewMemoryVendorTypeRepository เมื่อทดสอบโดยไม่มีฐานข้อมูล)
+func NewVendorTypeHandler(repo repository.VendorTypeRepository) *VendorTypeHandler {
+	return &VendorTypeHandler{repo: repo}
+}
+
 // ---------- 1) Select All ----------
-func SelectAllVendorType(c *fiber.Ctx) error {
-	query := `
-        SELECT vendor_type_id, prefix, type_name, description, update_by, update_date, is_active, is_delete
-        FROM tb_vendor_type
-        WHERE is_delete = 0
-        ORDER BY update_date DESC, vendor_type_id ASC
-    `
-	rows, err := config.DB.Query(query)
+func (h *VendorTypeHandler) SelectAllVendorType(c *fiber.Ctx) error {
+	result, err := h.repo.List(c.UserContext())
 	if err != nil {
-		log.Println("[SelectAllVendorType] query:", err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch vendor types",
-			Data:    nil,
-		})
-	}
-	defer rows.Close()
-
-	var result []models.VendorType
-	for rows.Next() {
-		var vt models.VendorType
-		var upd sql.NullTime
-		if err := rows.Scan(&vt.VendorTypeID, &vt.Prefix, &vt.TypeName, &vt.Description, &vt.UpdateBy, &upd, &vt.IsActive, &vt.IsDelete); err != nil {
-			log.Println("[SelectAllVendorType] scan:", err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			vt.UpdateDate = &t
-		}
-		result = append(result, vt)
+		return utils.Internal(err, "Failed to fetch vendor types")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -56,102 +33,68 @@ func SelectAllVendorType(c *fiber.Ctx) error {
 	})
 }
 
+// vendorTypeListSpec คือ field ที่ SelectPageVendorType อนุญาตให้ใช้กับ ?filter=, ?sort= และ ?q=
+var vendorTypeListSpec = utils.ListSpec{
+	Fields: map[string]utils.ListField{
+		"vendor_type_id": {Column: "vendor_type_id", Type: utils.FieldText},
+		"prefix":         {Column: "prefix", Type: utils.FieldText},
+		"type_name":      {Column: "type_name", Type: utils.FieldText},
+		"is_active":      {Column: "is_active", Type: utils.FieldBool},
+		"update_by":      {Column: "update_by", Type: utils.FieldText},
+		"update_date":    {Column: "update_date", Type: utils.FieldDate},
+	},
+	Search:      []string{"vendor_type_id", "prefix", "type_name", "description"},
+	DefaultSort: "update_date DESC, vendor_type_id ASC",
+	Key:         "vendor_type_id",
+}
+
 // ---------- 2) Select Paging ----------
-func SelectPageVendorType(c *fiber.Ctx) error {
-	page := c.QueryInt("page", 1)
-	limit := c.QueryInt("limit", 10)
-	offset := (page - 1) * limit
-
-	query := `
-        SELECT vendor_type_id, prefix, type_name, description, update_by, update_date, is_active, is_delete
-        FROM tb_vendor_type
-        WHERE is_delete = 0
-        ORDER BY update_date DESC, vendor_type_id ASC
-        OFFSET @Offset ROWS FETCH NEXT @Limit ROWS ONLY
-    `
-	rows, err := config.DB.Query(query, sql.Named("Offset", offset), sql.Named("Limit", limit))
+func (h *VendorTypeHandler) SelectPageVendorType(c *fiber.Ctx) error {
+	lq, err := utils.ParseListQuery(c, vendorTypeListSpec)
 	if err != nil {
-		log.Println("[SelectPageVendorType] query:", err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch vendor types",
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: err.Error(),
 			Data:    nil,
 		})
 	}
-	defer rows.Close()
-
-	var items []models.VendorType
-	for rows.Next() {
-		var vt models.VendorType
-		var upd sql.NullTime
-		if err := rows.Scan(&vt.VendorTypeID, &vt.Prefix, &vt.TypeName, &vt.Description, &vt.UpdateBy, &upd, &vt.IsActive, &vt.IsDelete); err != nil {
-			log.Println("[SelectPageVendorType] scan:", err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			vt.UpdateDate = &t
-		}
-		items = append(items, vt)
-	}
+	page, limit := lq.Page, lq.Limit
 
-	var total int
-	err = config.DB.QueryRow(`SELECT COUNT(*) FROM tb_vendor_type WHERE is_delete = 0`).Scan(&total)
+	items, total, err := h.repo.Page(c.UserContext(), lq)
 	if err != nil {
-		log.Println("[SelectPageVendorType] count:", err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to count records",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to fetch vendor types")
+	}
+
+	items, next := utils.CursorPage(lq, items, func(item models.VendorType) interface{} { return item.VendorTypeID })
+
+	if utils.UsePageEnvelope(c) {
+		return utils.SendPage(c, lq, items, total, next)
 	}
 
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "",
-		Data: fiber.Map{
+		Data: lq.Paged(fiber.Map{
 			"page":  page,
 			"limit": limit,
 			"total": total,
 			"items": items,
-		},
+		}, next),
 	})
 }
 
 // ---------- 3) Select By ID ----------
-func SelectVendorTypeByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `
-        SELECT vendor_type_id, prefix, type_name, description, update_by, update_date, is_active, is_delete
-        FROM tb_vendor_type
-        WHERE vendor_type_id = @ID AND is_delete = 0
-    `
-	row := config.DB.QueryRow(query, sql.Named("ID", id))
-
-	var vt models.VendorType
-	var upd sql.NullTime
-	if err := row.Scan(&vt.VendorTypeID, &vt.Prefix, &vt.TypeName, &vt.Description, &vt.UpdateBy, &upd, &vt.IsActive, &vt.IsDelete); err != nil {
-		if err == sql.ErrNoRows {
-			return c.Status(404).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Vendor type not found",
-				Data:    nil,
-			})
-		}
-		log.Println("[SelectVendorTypeByID] scan:", err)
-		return c.Status(500).JSON(models.ApiResponse{
+func (h *VendorTypeHandler) SelectVendorTypeByID(c *fiber.Ctx) error {
+	vt, err := h.repo.Get(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
-			Message: "Failed to read data",
+			Message: "Vendor type not found",
 			Data:    nil,
 		})
 	}
-	if upd.Valid {
-		t := upd.Time.Format("2006-01-02T15:04:05")
-		vt.UpdateDate = &t
+	if err != nil {
+		return utils.Internal(err, "Failed to read data")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -162,42 +105,10 @@ func SelectVendorTypeByID(c *fiber.Ctx) error {
 }
 
 // ---------- 4) Select By Name (LIKE) ----------
-func SelectVendorTypeByName(c *fiber.Ctx) error {
-	name := c.Params("name")
-	query := `
-        SELECT vendor_type_id, prefix, type_name, description, update_by, update_date, is_active, is_delete
-        FROM tb_vendor_type
-        WHERE type_name LIKE '%' + @Name + '%' AND is_delete = 0
-        ORDER BY type_name ASC, vendor_type_id ASC
-    `
-	rows, err := config.DB.Query(query, sql.Named("Name", name))
+func (h *VendorTypeHandler) SelectVendorTypeByName(c *fiber.Ctx) error {
+	result, err := h.repo.Search(c.UserContext(), c.Params("name"))
 	if err != nil {
-		log.Println("[SelectVendorTypeByName] query:", err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch vendor types",
-			Data:    nil,
-		})
-	}
-	defer rows.Close()
-
-	var result []models.VendorType
-	for rows.Next() {
-		var vt models.VendorType
-		var upd sql.NullTime
-		if err := rows.Scan(&vt.VendorTypeID, &vt.Prefix, &vt.TypeName, &vt.Description, &vt.UpdateBy, &upd, &vt.IsActive, &vt.IsDelete); err != nil {
-			log.Println("[SelectVendorTypeByName] scan:", err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			vt.UpdateDate = &t
-		}
-		result = append(result, vt)
+		return utils.Internal(err, "Failed to fetch vendor types")
 	}
 
 	if len(result) == 0 {
@@ -216,115 +127,47 @@ func SelectVendorTypeByName(c *fiber.Ctx) error {
 }
 
 // ---------- 5) Insert ----------
-func InsertVendorType(c *fiber.Ctx) error {
+func (h *VendorTypeHandler) InsertVendorType(c *fiber.Ctx) error {
 	var vt models.VendorType
-	if err := c.BodyParser(&vt); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Invalid request body",
-			Data:    nil,
-		})
-	}
-
-	// Validation
-	if strings.TrimSpace(vt.TypeName) == "" {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "type_name is required",
-			Data:    nil,
-		})
+	if err := utils.Bind(c, &vt); err != nil {
+		return err
 	}
 
 	// Resolve update_by
-	if vt.UpdateBy == nil || *vt.UpdateBy == "" {
-		username := utils.ResolveUser(c)
-		vt.UpdateBy = &username
-	}
+	vt.UpdateBy = utils.StampUser(c, vt.UpdateBy)
 
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			// Log incoming request for debugging
-			log.Printf("[InsertVendorType] TypeName: %s, IsActive: %v, UpdateBy: %v", vt.TypeName, vt.IsActive, vt.UpdateBy)
-
-			_, err := tx.Exec(`
-                INSERT INTO tb_vendor_type (type_name, description, is_active, update_by)
-                VALUES (@TypeName, @Description, @IsActive, @UpdateBy)`,
-				sql.Named("TypeName", vt.TypeName),
-				sql.Named("Description", vt.Description),
-				sql.Named("IsActive", vt.IsActive),
-				sql.Named("UpdateBy", vt.UpdateBy),
-			)
-			return err
-		},
-	})
+	// Log incoming request for debugging
+	log.Printf("[InsertVendorType] TypeName: %s, IsActive: %v, UpdateBy: %v", vt.TypeName, vt.IsActive, vt.UpdateBy)
+
+	newID, err := h.repo.Create(c.UserContext(), &vt)
 	if err != nil {
-		log.Println("[InsertVendorType] exec:", err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to insert vendor type",
-			Data:    fiber.Map{"type_name": vt.TypeName},
-		})
+		return utils.Internal(err, "Failed to insert vendor type")
 	}
 
+	utils.SetCreatedID(c, newID)
 	return c.Status(201).JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "Vendor type added successfully",
-		Data:    fiber.Map{"type_name": vt.TypeName},
+		Data:    fiber.Map{"vendor_type_id": newID, "type_name": vt.TypeName},
 	})
 }
 
 // ---------- 6) Update By ID ----------
-func UpdateVendorTypeByID(c *fiber.Ctx) error {
+func (h *VendorTypeHandler) UpdateVendorTypeByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var vt models.VendorType
-	if err := c.BodyParser(&vt); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Invalid request body",
-			Data:    nil,
-		})
+	if err := utils.BindPartial(c, &vt); err != nil {
+		return err
 	}
 
 	// Resolve update_by
-	if vt.UpdateBy == nil || *vt.UpdateBy == "" {
-		username := utils.ResolveUser(c)
-		vt.UpdateBy = &username
-	}
+	vt.UpdateBy = utils.StampUser(c, vt.UpdateBy)
 
 	// Log incoming request for debugging
 	log.Printf("[UpdateVendorTypeByID] ID: %s, TypeName: %s, IsActive: %v", id, vt.TypeName, vt.IsActive)
 
-	query := `
-        UPDATE tb_vendor_type
-        SET type_name = COALESCE(NULLIF(@TypeName, ''), type_name),
-            description = COALESCE(@Description, description),
-            is_active = COALESCE(@IsActive, is_active),
-            update_by = @UpdateBy
-        WHERE vendor_type_id = @ID AND is_delete = 0
-    `
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(query,
-				sql.Named("TypeName", vt.TypeName),
-				sql.Named("Description", vt.Description),
-				sql.Named("IsActive", vt.IsActive),
-				sql.Named("UpdateBy", vt.UpdateBy),
-				sql.Named("ID", id),
-			)
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows // Use standard error for 404
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+	err := h.repo.Update(c.UserContext(), id, &vt)
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Vendor type not found",
@@ -332,12 +175,7 @@ func UpdateVendorTypeByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println("[UpdateVendorTypeByID] exec:", err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to update vendor type",
-			Data:    fiber.Map{"vendor_type_id": id},
-		})
+		return utils.Internal(err, "Failed to update vendor type")
 	}
 
 	log.Printf("[UpdateVendorTypeByID] Successfully updated ID: %s with status: %v", id, vt.IsActive)
@@ -350,35 +188,16 @@ func UpdateVendorTypeByID(c *fiber.Ctx) error {
 }
 
 // ---------- 7) Delete (Soft) ----------
-func DeleteVendorTypeByID(c *fiber.Ctx) error {
+func (h *VendorTypeHandler) DeleteVendorTypeByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 	username := utils.ResolveUser(c)
+	cascade, err := utils.CascadeSoft(c)
+	if err != nil {
+		return err
+	}
 
-	query := `
-        UPDATE tb_vendor_type
-        SET is_delete = 1, update_by = @UpdateBy
-        WHERE vendor_type_id = @ID
-    `
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(query,
-				sql.Named("ID", id),
-				sql.Named("UpdateBy", username),
-			)
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+	err = h.repo.SoftDelete(c.UserContext(), id, username, cascade)
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Vendor type not found",
@@ -386,12 +205,7 @@ func DeleteVendorTypeByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println("[DeleteVendorTypeByID] exec:", err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to soft delete vendor type",
-			Data:    fiber.Map{"vendor_type_id": id},
-		})
+		return utils.Internal(err, "Failed to soft delete vendor type")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -402,26 +216,10 @@ func DeleteVendorTypeByID(c *fiber.Ctx) error {
 }
 
 // ---------- 8) Remove (Hard) ----------
-func RemoveVendorTypeByID(c *fiber.Ctx) error {
+func (h *VendorTypeHandler) RemoveVendorTypeByID(c *fiber.Ctx) error {
 	id := c.Params("id")
-	query := `DELETE FROM tb_vendor_type WHERE vendor_type_id = @ID`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(query, sql.Named("ID", id))
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+	err := h.repo.Purge(c.UserContext(), id)
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Vendor type not found",
@@ -429,12 +227,7 @@ func RemoveVendorTypeByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println("[RemoveVendorTypeByID] exec:", err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to hard delete vendor type",
-			Data:    fiber.Map{"vendor_type_id": id},
-		})
+		return utils.Internal(err, "Failed to hard delete vendor type")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -442,4 +235,4 @@ func RemoveVendorTypeByID(c *fiber.Ctx) error {
 		Message: "Vendor type removed successfully",
 		Data:    fiber.Map{"vendor_type_id": id},
 	})
-}
\ No newline at end of file
+}
@@ -1,49 +1,27 @@
 package controllers
 
 import (
-	"PenbunAPI/config"
 	"PenbunAPI/models"
+	"PenbunAPI/repository"
 	"PenbunAPI/utils"
-	"database/sql"
-	"log"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SelectAllWarehouse(c *fiber.Ctx) error {
-	query := `
-		SELECT warehouse_id, warehouse_code, warehouse_name, description, is_main_dc, allow_negative_stock, update_by, update_date, is_active
-		FROM tb_warehouse
-		WHERE is_delete = 0
-	`
-	rows, err := config.DB.Query(query)
-	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch warehouses",
-			Data:    nil,
-		})
-	}
-	defer rows.Close()
+// WarehouseHandler คือ Handler ของ Warehouse API ซึ่งเข้าถึงข้อมูลผ่าน WarehouseRepository
+type WarehouseHandler struct {
+	repo repository.WarehouseRepository
+}
+
+// NewWarehouseHandler สร้าง WarehouseHandler (ใช้ repository.NewMemoryWarehouseRepository เมื่อทดสอบโดยไม่มีฐานข้อมูล)
+func NewWarehouseHandler(repo repository.WarehouseRepository) *WarehouseHandler {
+	return &WarehouseHandler{repo: repo}
+}
 
-	var list []models.Warehouse
-	for rows.Next() {
-		var item models.Warehouse
-		var upd sql.NullTime
-		if err := rows.Scan(&item.WarehouseID, &item.WarehouseCode, &item.WarehouseName, &item.Description, &item.IsMainDC, &item.AllowNegativeStock, &item.UpdateBy, &upd, &item.IsActive); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			item.UpdateDate = &t
-		}
-		list = append(list, item)
+func (h *WarehouseHandler) SelectAllWarehouse(c *fiber.Ctx) error {
+	list, err := h.repo.List(c.UserContext())
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch warehouses")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -53,99 +31,67 @@ func SelectAllWarehouse(c *fiber.Ctx) error {
 	})
 }
 
-func SelectPageWarehouse(c *fiber.Ctx) error {
-	page := c.QueryInt("page", 1)
-	limit := c.QueryInt("limit", 10)
-	offset := (page - 1) * limit
+// warehouseListSpec คือ field ที่ SelectPageWarehouse อนุญาตให้ใช้กับ ?filter=, ?sort= และ ?q=
+var warehouseListSpec = utils.ListSpec{
+	Fields: map[string]utils.ListField{
+		"warehouse_id":         {Column: "warehouse_id", Type: utils.FieldText},
+		"warehouse_code":       {Column: "warehouse_code", Type: utils.FieldText},
+		"warehouse_name":       {Column: "warehouse_name", Type: utils.FieldText},
+		"is_main_dc":           {Column: "is_main_dc", Type: utils.FieldBool},
+		"allow_negative_stock": {Column: "allow_negative_stock", Type: utils.FieldBool},
+		"is_active":            {Column: "is_active", Type: utils.FieldBool},
+		"update_by":            {Column: "update_by", Type: utils.FieldText},
+		"update_date":          {Column: "update_date", Type: utils.FieldDate},
+	},
+	Search:      []string{"warehouse_id", "warehouse_code", "warehouse_name", "description"},
+	DefaultSort: "update_date DESC",
+	Key:         "warehouse_id",
+}
 
-	query := `
-		SELECT warehouse_id, warehouse_code, warehouse_name, description, is_main_dc, allow_negative_stock, update_by, update_date, is_active
-		FROM tb_warehouse
-		WHERE is_delete = 0
-		ORDER BY update_date DESC
-		OFFSET @Offset ROWS FETCH NEXT @Limit ROWS ONLY
-	`
-	rows, err := config.DB.Query(query, sql.Named("Offset", offset), sql.Named("Limit", limit))
+func (h *WarehouseHandler) SelectPageWarehouse(c *fiber.Ctx) error {
+	lq, err := utils.ParseListQuery(c, warehouseListSpec)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch warehouses",
+		return c.Status(400).JSON(models.ApiResponse{
+			Status:  "fail",
+			Message: err.Error(),
 			Data:    nil,
 		})
 	}
-	defer rows.Close()
+	page, limit := lq.Page, lq.Limit
 
-	var list []models.Warehouse
-	for rows.Next() {
-		var item models.Warehouse
-		var upd sql.NullTime
-		if err := rows.Scan(&item.WarehouseID, &item.WarehouseCode, &item.WarehouseName, &item.Description, &item.IsMainDC, &item.AllowNegativeStock, &item.UpdateBy, &upd, &item.IsActive); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			item.UpdateDate = &t
-		}
-		list = append(list, item)
+	list, total, err := h.repo.Page(c.UserContext(), lq)
+	if err != nil {
+		return utils.Internal(err, "Failed to fetch warehouses")
 	}
 
-	var total int
-	err = config.DB.QueryRow(`SELECT COUNT(*) FROM tb_warehouse WHERE is_delete = 0`).Scan(&total)
-	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to count records",
-			Data:    nil,
-		})
+	list, next := utils.CursorPage(lq, list, func(item models.Warehouse) interface{} { return item.WarehouseID })
+
+	if utils.UsePageEnvelope(c) {
+		return utils.SendPage(c, lq, list, total, next)
 	}
 
 	return c.JSON(models.ApiResponse{
 		Status: "success",
-		Data: fiber.Map{
+		Data: lq.Paged(fiber.Map{
 			"page":      page,
 			"limit":     limit,
 			"total":     total,
 			"warehouse": list,
-		},
+		}, next),
 	})
 }
 
-func SelectWarehouseByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `
-		SELECT warehouse_id, warehouse_code, warehouse_name, description, is_main_dc, allow_negative_stock, update_by, update_date, is_active
-		FROM tb_warehouse
-		WHERE warehouse_id = @ID AND is_delete = 0
-	`
-	row := config.DB.QueryRow(query, sql.Named("ID", id))
-
-	var item models.Warehouse
-	var upd sql.NullTime
-	if err := row.Scan(&item.WarehouseID, &item.WarehouseCode, &item.WarehouseName, &item.Description, &item.IsMainDC, &item.AllowNegativeStock, &item.UpdateBy, &upd, &item.IsActive); err != nil {
-		if err == sql.ErrNoRows {
-			return c.Status(404).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Warehouse not found",
-				Data:    nil,
-			})
-		}
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
+func (h *WarehouseHandler) SelectWarehouseByID(c *fiber.Ctx) error {
+	item, err := h.repo.Get(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
+		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
-			Message: "Failed to read data",
+			Message: "Warehouse not found",
 			Data:    nil,
 		})
 	}
-	if upd.Valid {
-		t := upd.Time.Format("2006-01-02T15:04:05")
-		item.UpdateDate = &t
+	if err != nil {
+		return utils.Internal(err, "Failed to read data")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -155,44 +101,13 @@ func SelectWarehouseByID(c *fiber.Ctx) error {
 	})
 }
 
-func SelectWarehouseByName(c *fiber.Ctx) error {
-	name := c.Params("name")
-	query := `
-		SELECT warehouse_id, warehouse_code, warehouse_name, description, is_main_dc, allow_negative_stock, update_by, update_date, is_active
-		FROM tb_warehouse
-		WHERE warehouse_name LIKE '%' + @Name + '%' AND is_delete = 0
-	`
-	rows, err := config.DB.Query(query, sql.Named("Name", name))
+func (h *WarehouseHandler) SelectWarehouseByName(c *fiber.Ctx) error {
+	list, err := h.repo.Search(c.UserContext(), c.Params("name"))
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to fetch warehouses",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to fetch warehouses")
 	}
-	defer rows.Close()
 
-	var results []models.Warehouse
-	for rows.Next() {
-		var item models.Warehouse
-		var upd sql.NullTime
-		if err := rows.Scan(&item.WarehouseID, &item.WarehouseCode, &item.WarehouseName, &item.Description, &item.IsMainDC, &item.AllowNegativeStock, &item.UpdateBy, &upd, &item.IsActive); err != nil {
-			log.Println(err)
-			return c.Status(500).JSON(models.ApiResponse{
-				Status:  "error",
-				Message: "Failed to read data",
-				Data:    nil,
-			})
-		}
-		if upd.Valid {
-			t := upd.Time.Format("2006-01-02T15:04:05")
-			item.UpdateDate = &t
-		}
-		results = append(results, item)
-	}
-
-	if len(results) == 0 {
+	if len(list) == 0 {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "No matching warehouse found",
@@ -203,103 +118,42 @@ func SelectWarehouseByName(c *fiber.Ctx) error {
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "",
-		Data:    results,
+		Data:    list,
 	})
 }
 
-func InsertWarehouse(c *fiber.Ctx) error {
+func (h *WarehouseHandler) InsertWarehouse(c *fiber.Ctx) error {
 	var item models.Warehouse
-	if err := c.BodyParser(&item); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Invalid request body",
-			Data:    nil,
-		})
+	if err := utils.Bind(c, &item); err != nil {
+		return err
 	}
 
-	query := `
-		INSERT INTO tb_warehouse (warehouse_code, warehouse_name, description, is_main_dc, allow_negative_stock, update_by)
-		VALUES (@WarehouseCode, @WarehouseName, @Description, COALESCE(@IsMainDC, 0), COALESCE(@AllowNegativeStock, 0), @UpdateBy)
-	`
+	item.UpdateBy = utils.StampUser(c, item.UpdateBy)
 
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			_, err := tx.Exec(query,
-				sql.Named("WarehouseCode", item.WarehouseCode),
-				sql.Named("WarehouseName", item.WarehouseName),
-				sql.Named("Description", item.Description),
-				sql.Named("IsMainDC", item.IsMainDC),
-				sql.Named("AllowNegativeStock", item.AllowNegativeStock),
-				sql.Named("UpdateBy", item.UpdateBy),
-			)
-			return err
-		},
-	})
+	newID, err := h.repo.Create(c.UserContext(), &item)
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to insert warehouse",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to insert warehouse")
 	}
 
+	utils.SetCreatedID(c, newID)
 	return c.Status(201).JSON(models.ApiResponse{
 		Status:  "success",
 		Message: "Warehouse added successfully",
-		Data:    nil,
+		Data:    fiber.Map{"warehouse_id": newID},
 	})
 }
 
-func UpdateWarehouseByID(c *fiber.Ctx) error {
+func (h *WarehouseHandler) UpdateWarehouseByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var item models.Warehouse
-	if err := c.BodyParser(&item); err != nil {
-		return c.Status(400).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Invalid request body",
-			Data:    nil,
-		})
+	if err := utils.BindPartial(c, &item); err != nil {
+		return err
 	}
 
-	query := `
-		UPDATE tb_warehouse
-		SET warehouse_code = COALESCE(NULLIF(@WarehouseCode, ''), warehouse_code),
-			warehouse_name = COALESCE(NULLIF(@WarehouseName, ''), warehouse_name),
-			description = COALESCE(@Description, description),
-			is_main_dc = COALESCE(@IsMainDC, is_main_dc),
-			allow_negative_stock = COALESCE(@AllowNegativeStock, allow_negative_stock),
-			update_by = @UpdateBy,
-			is_active = COALESCE(@IsActive, is_active)
-		WHERE warehouse_id = @ID AND is_delete = 0
-	`
+	item.UpdateBy = utils.StampUser(c, item.UpdateBy)
 
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(query,
-				sql.Named("WarehouseCode", item.WarehouseCode),
-				sql.Named("WarehouseName", item.WarehouseName),
-				sql.Named("Description", item.Description),
-				sql.Named("IsMainDC", item.IsMainDC),
-				sql.Named("AllowNegativeStock", item.AllowNegativeStock),
-				sql.Named("UpdateBy", item.UpdateBy),
-				sql.Named("IsActive", item.IsActive),
-				sql.Named("ID", id),
-			)
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+	err := h.repo.Update(c.UserContext(), id, &item)
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Warehouse not found",
@@ -307,12 +161,7 @@ func UpdateWarehouseByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to update warehouse",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to update warehouse")
 	}
 
 	return c.JSON(models.ApiResponse{
@@ -322,32 +171,13 @@ func UpdateWarehouseByID(c *fiber.Ctx) error {
 	})
 }
 
-func DeleteWarehouseByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `
-		UPDATE tb_warehouse
-		SET is_delete = 1,
-			is_active = 0,
-			update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE 'SE Asia Standard Time' AS DATETIME)
-		WHERE warehouse_id = @ID AND is_delete = 0
-	`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(query, sql.Named("ID", id))
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+func (h *WarehouseHandler) DeleteWarehouseByID(c *fiber.Ctx) error {
+	cascade, err := utils.CascadeSoft(c)
+	if err != nil {
+		return err
+	}
+	err = h.repo.SoftDelete(c.UserContext(), c.Params("id"), utils.ResolveUser(c), cascade)
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Warehouse not found",
@@ -355,12 +185,7 @@ func DeleteWarehouseByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to delete warehouse",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to delete warehouse")
 	}
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
@@ -369,26 +194,9 @@ func DeleteWarehouseByID(c *fiber.Ctx) error {
 	})
 }
 
-func RemoveWarehouseByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	query := `DELETE FROM tb_warehouse WHERE warehouse_id = @ID`
-	err := utils.ExecuteTransaction(config.DB, []func(tx *sql.Tx) error{
-		func(tx *sql.Tx) error {
-			res, err := tx.Exec(query, sql.Named("ID", id))
-			if err != nil {
-				return err
-			}
-			rows, err := res.RowsAffected()
-			if err != nil {
-				return err
-			}
-			if rows == 0 {
-				return sql.ErrNoRows
-			}
-			return nil
-		},
-	})
-	if err == sql.ErrNoRows {
+func (h *WarehouseHandler) RemoveWarehouseByID(c *fiber.Ctx) error {
+	err := h.repo.Purge(c.UserContext(), c.Params("id"))
+	if err == repository.ErrNotFound {
 		return c.Status(404).JSON(models.ApiResponse{
 			Status:  "error",
 			Message: "Warehouse not found",
@@ -396,12 +204,7 @@ func RemoveWarehouseByID(c *fiber.Ctx) error {
 		})
 	}
 	if err != nil {
-		log.Println(err)
-		return c.Status(500).JSON(models.ApiResponse{
-			Status:  "error",
-			Message: "Failed to remove warehouse",
-			Data:    nil,
-		})
+		return utils.Internal(err, "Failed to remove warehouse")
 	}
 	return c.JSON(models.ApiResponse{
 		Status:  "success",
@@ -0,0 +1,49 @@
+// Package dialect รวมส่วนของ SQL ที่เขียนต่างกันในแต่ละฐานข้อมูล (เวลาปัจจุบัน, การแบ่งหน้า, LIKE, TOP 1
+// และการสร้างรหัสด้วย Trigger) เพื่อให้ Repository และ Handler ใช้ SQL ชุดเดียวกันได้ทั้งบน SQL Server
+// และบน SQLite ที่ใช้แทนฐานข้อมูลจริงในการทดสอบและเดโม
+package dialect
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Dialect คือส่วนของ SQL ที่ขึ้นกับฐานข้อมูล
+// method ที่คืน string ให้ผลเป็นส่วนของ SQL สำหรับต่อเข้ากับ Query (ชื่อ parameter ต้องมี @ นำหน้า)
+type Dialect interface {
+	// Name คืนชื่อ Driver เช่น "sqlserver" หรือ "sqlite3"
+	Name() string
+	// Now คือเวลาปัจจุบันตามเวลาประเทศไทย (ตามที่ Trigger ใช้บันทึก update_date)
+	Now() string
+	// NowPlusSeconds คือเวลาปัจจุบันบวกจำนวนวินาทีใน parameter seconds (เช่น "@TTL")
+	NowPlusSeconds(seconds string) string
+	// Contains คือเงื่อนไข column LIKE '%' + param + '%'
+	Contains(column, param string) string
+	// Paginate คือส่วนท้ายของ Query ที่มี ORDER BY แล้ว สำหรับข้าม offset แถวแล้วอ่าน limit แถว
+	Paginate(offset, limit string) string
+	// SelectFirst คือ SELECT ที่อ่านเพียงแถวแรก โดย rest คือส่วนตั้งแต่ FROM เป็นต้นไป
+	SelectFirst(columns, rest string) string
+	// Version คือ Query ที่คืนรุ่นของฐานข้อมูลหนึ่งแถว
+	Version() string
+	// InsertReturningID รัน insertSQL (ต้องมี "OUTPUT INSERTED.autoID INTO @inserted") แล้วคืนรหัสหลัก
+	// (idColumn) ที่ Trigger สร้างให้
+	InsertReturningID(ctx context.Context, tx *sql.Tx, table, idColumn, insertSQL string, args ...interface{}) (string, error)
+	// InsertIdentity รัน insertSQL (ต้องมี "OUTPUT INSERTED.<คอลัมน์ IDENTITY> INTO @inserted") แล้วคืนค่า IDENTITY
+	// ของแถวที่เพิ่ม สำหรับตารางที่ไม่มีรหัสจาก Trigger เช่น tb_reference
+	InsertIdentity(ctx context.Context, tx *sql.Tx, insertSQL string, args ...interface{}) (int64, error)
+	// LockRow คือ table ใน FROM ของ SELECT ที่ล็อกแถวที่อ่านไว้จนจบ Transaction (เช่นการตรวจ If-Match ก่อนเขียน)
+	LockRow(table string) string
+	// Touch บันทึก update_date ของแถว idColumn = id หลัง UPDATE ที่ไม่ได้กำหนด update_date เอง
+	// (บน SQL Server Trigger ทำให้แล้ว จึงไม่ต้องทำอะไร)
+	Touch(ctx context.Context, tx *sql.Tx, table, idColumn string, id interface{}) error
+}
+
+// Of คืน Dialect ตาม Driver ของ db (SQLite หากเปิดด้วย Driver ของ go-sqlite3 นอกนั้นเป็น SQL Server)
+func Of(db *sql.DB) Dialect {
+	if _, ok := db.Driver().(*sqlite3.SQLiteDriver); ok {
+		return SQLite
+	}
+	return SQLServer
+}
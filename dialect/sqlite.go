@@ -0,0 +1,123 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// SQLite คือ Dialect ของ SQLite ที่ใช้แทน SQL Server ในการทดสอบและเดโม
+// SQLite ไม่มี Trigger ของระบบ จึงสร้างรหัสและบันทึก update_date ใน Go แทน (InsertReturningID, Touch)
+var SQLite Dialect = sqlite{}
+
+type sqlite struct{}
+
+// IDDigits คือจำนวนหลักของเลขลำดับต่อท้าย prefix (เหมือน TRIG_GENERATE_[TABLE]_ID เช่น PTG000001)
+const IDDigits = 6
+
+// bangkok คือเวลาประเทศไทย (ไม่มี Daylight Saving จึงใช้ offset คงที่ได้โดยไม่ต้องมี tzdata)
+var bangkok = time.FixedZone("ICT", 7*60*60)
+
+// sqliteTime คือรูปแบบเวลาที่บันทึก (ละเอียดถึงมิลลิวินาที) ตรงกับผลของ strftime('%Y-%m-%d %H:%M:%f') จึงเปรียบเทียบแบบ string ได้
+// รวมถึงกับค่าเดิมที่ละเอียดถึงวินาทีจาก datetime() ด้วย
+const sqliteTime = "2006-01-02 15:04:05.000"
+
+// sqliteNow คือเวลาปัจจุบันตามเวลาประเทศไทยในรูปแบบ sqliteTime
+const sqliteNow = "strftime('%Y-%m-%d %H:%M:%f', 'now', '+7 hours'"
+
+var outputInserted = regexp.MustCompile(`(?i)\s*OUTPUT\s+INSERTED\.\w+\s+INTO\s+@inserted`)
+
+// OpenSQLite เปิดฐานข้อมูล SQLite ที่ path (":memory:" หรือค่าว่าง = ในหน่วยความจำ)
+// ตารางสร้างด้วย migrate.Up ต้อง build ด้วย CGO_ENABLED=1 (go-sqlite3 เป็น cgo)
+func OpenSQLite(path string) (*sql.DB, error) {
+	memory := path == "" || path == ":memory:"
+	dsn := "file:" + path + "?_foreign_keys=on&_busy_timeout=5000&_txlock=immediate&_journal_mode=WAL"
+	if memory {
+		dsn = "file::memory:?_foreign_keys=on"
+	}
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if memory {
+		// ฐานข้อมูลในหน่วยความจำอยู่กับ Connection เดียว ทุก Request จึงต้องใช้ Connection เดียวกัน
+		db.SetMaxOpenConns(1)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	return db, nil
+}
+
+func (sqlite) Name() string { return "sqlite3" }
+
+func (sqlite) Now() string { return sqliteNow + ")" }
+
+func (sqlite) NowPlusSeconds(seconds string) string {
+	return sqliteNow + ", " + seconds + " || ' seconds')"
+}
+
+// LockRow Connection ที่เขียนเปิด Transaction แบบ IMMEDIATE (_txlock=immediate) ซึ่งล็อกการเขียนทั้งฐานข้อมูลอยู่แล้ว
+// (ฐานข้อมูลในหน่วยความจำมี Connection เดียว ทุก Transaction จึงทำงานทีละรายการ)
+func (sqlite) LockRow(table string) string { return table }
+
+func (sqlite) Contains(column, param string) string {
+	return column + " LIKE '%' || " + param + " || '%'"
+}
+
+func (sqlite) Paginate(offset, limit string) string {
+	return " LIMIT " + limit + " OFFSET " + offset
+}
+
+func (sqlite) SelectFirst(columns, rest string) string {
+	return "SELECT " + columns + " " + rest + " LIMIT 1"
+}
+
+func (sqlite) Version() string { return "SELECT 'SQLite ' || sqlite_version()" }
+
+// InsertIdentity ตัด OUTPUT ... INTO @inserted ออกแล้วคืน rowid (คอลัมน์ IDENTITY คือ INTEGER PRIMARY KEY)
+func (sqlite) InsertIdentity(ctx context.Context, tx *sql.Tx, insertSQL string, args ...interface{}) (int64, error) {
+	res, err := tx.ExecContext(ctx, outputInserted.ReplaceAllString(insertSQL, ""), args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// InsertReturningID ตัด OUTPUT ... INTO @inserted ออกแล้วทำงานแทน Trigger:
+// สร้างรหัสจาก prefix ของแถวต่อด้วย autoID และบันทึก update_date
+func (sqlite) InsertReturningID(ctx context.Context, tx *sql.Tx, table, idColumn, insertSQL string, args ...interface{}) (string, error) {
+	res, err := tx.ExecContext(ctx, outputInserted.ReplaceAllString(insertSQL, ""), args...)
+	if err != nil {
+		return "", err
+	}
+	autoID, err := res.LastInsertId()
+	if err != nil {
+		return "", err
+	}
+	var prefix string
+	if err := tx.QueryRowContext(ctx, "SELECT prefix FROM "+table+" WHERE autoID = @AutoID", sql.Named("AutoID", autoID)).Scan(&prefix); err != nil {
+		return "", err
+	}
+	id := fmt.Sprintf("%s%0*d", prefix, IDDigits, autoID)
+	_, err = tx.ExecContext(ctx, "UPDATE "+table+" SET "+idColumn+" = @ID, update_date = @Now WHERE autoID = @AutoID",
+		sql.Named("ID", id), sql.Named("Now", now()), sql.Named("AutoID", autoID))
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Touch ทำงานแทน TRIG_AUTO_UPDATE_DATE_[TABLE]
+func (sqlite) Touch(ctx context.Context, tx *sql.Tx, table, idColumn string, id interface{}) error {
+	_, err := tx.ExecContext(ctx, "UPDATE "+table+" SET update_date = @Now WHERE "+idColumn+" = @ID",
+		sql.Named("Now", now()), sql.Named("ID", id))
+	return err
+}
+
+func now() string {
+	return time.Now().In(bangkok).Format(sqliteTime)
+}
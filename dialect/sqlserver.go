@@ -0,0 +1,66 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+)
+
+// SQLServer คือ Dialect ของ SQL Server (ฐานข้อมูลจริง) ซึ่งมี Trigger สร้างรหัสและบันทึก update_date ให้เอง
+var SQLServer Dialect = sqlServer{}
+
+type sqlServer struct{}
+
+const sqlServerNow = "CAST(SYSDATETIMEOFFSET() AT TIME ZONE 'SE Asia Standard Time' AS DATETIME)"
+
+func (sqlServer) Name() string { return "sqlserver" }
+
+func (sqlServer) Now() string { return sqlServerNow }
+
+func (sqlServer) NowPlusSeconds(seconds string) string {
+	return "DATEADD(SECOND, " + seconds + ", " + sqlServerNow + ")"
+}
+
+func (sqlServer) Contains(column, param string) string {
+	return column + " LIKE '%' + " + param + " + '%'"
+}
+
+func (sqlServer) Paginate(offset, limit string) string {
+	return " OFFSET " + offset + " ROWS FETCH NEXT " + limit + " ROWS ONLY"
+}
+
+func (sqlServer) SelectFirst(columns, rest string) string {
+	return "SELECT TOP 1 " + columns + " " + rest
+}
+
+func (sqlServer) Version() string { return "SELECT @@VERSION" }
+
+// InsertReturningID ตารางที่มี Trigger ใช้ OUTPUT แบบไม่มี INTO ไม่ได้
+// จึงพัก autoID ไว้ใน table variable แล้วอ่านรหัสหลังจาก Trigger ทำงาน
+func (sqlServer) InsertReturningID(ctx context.Context, tx *sql.Tx, table, idColumn, insertSQL string, args ...interface{}) (string, error) {
+	query := `DECLARE @inserted TABLE (autoID INT);
+		` + insertSQL + `;
+		SELECT t.` + idColumn + ` FROM ` + table + ` t JOIN @inserted i ON t.autoID = i.autoID`
+	var id string
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// InsertIdentity พัก IDENTITY ไว้ใน table variable เช่นเดียวกับ InsertReturningID (ตารางที่มี Trigger ใช้ OUTPUT แบบไม่มี INTO ไม่ได้)
+func (sqlServer) InsertIdentity(ctx context.Context, tx *sql.Tx, insertSQL string, args ...interface{}) (int64, error) {
+	query := `DECLARE @inserted TABLE (id INT);
+		` + insertSQL + `;
+		SELECT id FROM @inserted`
+	var id int64
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (sqlServer) LockRow(table string) string { return table + " WITH (UPDLOCK, ROWLOCK)" }
+
+func (sqlServer) Touch(ctx context.Context, tx *sql.Tx, table, idColumn string, id interface{}) error {
+	return nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -10,11 +11,15 @@ import (
 	"time"
 
 	"PenbunAPI/config"
+	"PenbunAPI/container"
 	"PenbunAPI/middleware"
+	"PenbunAPI/migrate"
 	"PenbunAPI/routes"
+	"PenbunAPI/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/recover"
 
 	"github.com/joho/godotenv"
 )
@@ -38,18 +43,32 @@ func main() {
 	// 4 procs/childs max
 	runtime.GOMAXPROCS(3)
 
+	// คำสั่ง migrate (go run . migrate up|down [n|all]|status|legacy-columns [apply]) ทำงานกับฐานข้อมูลแล้วจบโดยไม่เปิดเซิร์ฟเวอร์
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		db := config.ConnectDatabase()
+		err := migrate.Run(context.Background(), db, os.Args[2:], os.Stdout)
+		db.Close()
+		if err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+
 	// start a cleanup cron-job
 	go cleanUp()
 
 	// เริ่มต้น Logger
 	config.InitLogger()
 
-	// อ่าน PORT จากไฟล์ .env
-	port := os.Getenv("FIBER_PORT")
-	if port == "" {
-		port = "8089" // default if no port in .env
+	// โหลด Key สำหรับเซ็น/ตรวจสอบ JWT
+	if err := config.LoadJWTKeys(); err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
 	}
 
+	// อ่านค่าตั้งค่าจากไฟล์ .env (FIBER_PORT ค่าเริ่มต้น 8089)
+	cfg := container.LoadConfig()
+	port := cfg.Port
+
 	// สร้าง Fiber App
 	app := fiber.New(fiber.Config{
 		Prefork:           false,
@@ -58,58 +77,100 @@ func main() {
 		EnablePrintRoutes: true,
 		ServerHeader:      "Fiber",
 		AppName:           "PENBUN API v2.1.0",
+		ErrorHandler:      middleware.ErrorHandler, // ทุก error ตอบเป็น ApiResponse และไม่เปิดเผยข้อความภายใน
 	})
 
+	// แปลง panic เป็น error ให้ ErrorHandler ตอบ 500 แทนการปิด Process
+	app.Use(recover.New())
+
 	// ✅ Serve favicon.ico
 	// app.Get("/favicon.ico", func(c *fiber.Ctx) error {
 	// 	return c.SendStatus(fiber.StatusNoContent) // หรือใช้ StatusOK ก็ได้
 	// })
 
 	// ใช้ JWTMiddleware ระดับ Global
-	// app.Use(middleware.JWTMiddleware(os.Getenv("JWT_SECRET")))
+	// app.Use(middleware.JWTMiddleware())
 	// log.Println("[DEBUG] JWT is :", token)
 
 	// เพิ่ม CORS Middleware
 	app.Use(cors.New(cors.Config{
-		AllowOrigins:     "*",                                                  // อนุญาตทุก origin
-		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS",                        // อนุญาต methods
-		AllowHeaders:     "Origin,Content-Type,Accept,Authorization",           // อนุญาต headers
+		AllowOrigins:     "*",                                                                                            // อนุญาตทุก origin
+		AllowMethods:     "GET,POST,PUT,PATCH,DELETE,OPTIONS",                                                            // อนุญาต methods
+		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,X-API-Key,If-Match,If-None-Match,X-Read-Consistency", // อนุญาต headers (รวม If-Match/If-None-Match ของ ETag และ X-Read-Consistency)
+		ExposeHeaders:    "ETag,Location",                                                                                // ให้ Browser อ่าน ETag และ Location ของ Response ได้
 	}))
 
 	// เพิ่ม Logger Middleware
 	app.Use(middleware.NewLoggerMiddleware())
 
-	config.ConnectDatabase()
+	db := config.ConnectDatabase()
+
+	// DB_AUTO_MIGRATE=true รัน migration ที่ค้างอยู่ก่อน (เหมาะกับ SQLite สำหรับเดโม)
+	if config.GetEnv("DB_AUTO_MIGRATE") == "true" {
+		done, err := migrate.Up(context.Background(), db)
+		if err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+		for _, m := range done {
+			log.Printf("Migration applied: %04d_%s", m.Version, m.Name)
+		}
+	}
+
+	// ไม่เปิดให้บริการหากโครงสร้างฐานข้อมูลยังไม่ถึงรุ่นที่ Binary นี้ต้องการ
+	if err := migrate.Check(context.Background(), db); err != nil {
+		log.Fatalf("Database schema check failed: %v (run: go run . migrate up)", err)
+	}
+	// ฐานข้อมูลเดิมที่ยังใช้ชื่อคอลัมน์เก่าต้องเปลี่ยนชื่อเอง (ไม่เปลี่ยนให้อัตโนมัติเพราะกระทบรายงานและ ETL ภายนอก)
+	if err := migrate.CheckLegacyColumns(context.Background(), db); err != nil {
+		log.Fatalf("Database schema check failed: %v (back up the database, then run: go run . migrate legacy-columns apply)", err)
+	}
 
-	// ลงทะเบียน Routes พร้อมส่ง Database Connection
-	routes.RegisterV1Routes(app, config.DB)
+	// สร้าง Container (DB, Logger, Config, Service) ครั้งเดียว แล้วส่งต่อให้ทุก Route และ Handler
+	ctn := container.New(db, config.Logger, cfg)
 
-	// routes.RegisterV2Routes(app, config.DB)
+	// DB_READ_HOST ให้ Select อ่านจาก Read Replica (ตรวจสถานะทุก DB_READ_CHECK_INTERVAL และอ่านจากฐานข้อมูลหลักแทนเมื่อไม่พร้อม)
+	if read := config.ConnectReadReplica(); read != nil {
+		ctn.UseReadReplica(read, config.GetEnvDuration("DB_READ_CHECK_INTERVAL", 10*time.Second))
+	}
 
-	// เริ่มเซิร์ฟเวอร์
-	log.Println("Starting server on port", port)
-	log.Fatal(app.Listen(":" + port))
+	// CACHE_DRIVER เลือก Cache ของข้อมูลหลัก (memory, redis, none) ซึ่ง Repository และ utils.LookupReference อ่านผ่าน
+	ctn.Cache = config.ConnectCache()
+	utils.SetReferenceCache(ctn.Cache)
+
+	// ลงทะเบียน Routes พร้อมส่ง Container
+	routes.RegisterWellKnownRoutes(app)
+	routes.RegisterV1Routes(app, ctn)
 
-	// จัดการ Graceful Shutdown
+	routes.RegisterV2Routes(app, ctn)
+
+	// เริ่มเซิร์ฟเวอร์ใน goroutine เพื่อให้ main รอสัญญาณ Shutdown ได้
+	log.Println("Starting server on port", port)
+	serverErr := make(chan error, 1)
 	go func() {
-		if err := app.Listen(":" + port); err != nil {
-			log.Printf("Error starting server: %v\n", err)
-		}
+		serverErr <- app.Listen(":" + port)
 	}()
 
-	// รอรับสัญญาณ Interrupt หรือ Kill
+	// รอรับสัญญาณ Interrupt หรือ Kill (หรือ Listen ล้มเหลว เช่นพอร์ตถูกใช้อยู่)
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 
-	<-quit
-	fmt.Println("Gracefully shutting down...")
-
-	// รอให้ Fiber Shutdown อย่างปลอดภัย
-	if err := app.Shutdown(); err != nil {
-		fmt.Printf("Error shutting down server: %v\n", err)
+	exitCode := 0
+	select {
+	case err := <-serverErr:
+		log.Printf("Error starting server: %v\n", err)
+		exitCode = 1
+	case <-quit:
+		fmt.Println("Gracefully shutting down...")
+
+		// รอให้ Fiber Shutdown อย่างปลอดภัย
+		if err := app.Shutdown(); err != nil {
+			fmt.Printf("Error shutting down server: %v\n", err)
+		}
 	}
 
 	// ปิดฐานข้อมูลหรือกระบวนการที่ค้างอยู่
-	config.DB.Close()
+	ctn.Reads.Close()
+	ctn.DB.Close()
 	fmt.Println("Cleanup completed.")
+	os.Exit(exitCode)
 }
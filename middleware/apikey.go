@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"PenbunAPI/auth"
+	"PenbunAPI/dialect"
+	"PenbunAPI/utils"
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIKeyHeader คือ Header ที่ระบบภายนอก (WinApp, Sync Job) ใช้ส่ง API Key
+const APIKeyHeader = "X-API-Key"
+
+// authenticateAPIKey ตรวจสอบ API Key และเก็บ Service Identity ลงใน c.Locals("user")
+func authenticateAPIKey(c *fiber.Ctx, db *sql.DB, apiKey string) error {
+	var (
+		keyID, keyName, scopes string
+		expire                 sql.NullTime
+	)
+	err := db.QueryRowContext(c.UserContext(), `
+		SELECT api_key_id, key_name, scopes, expire_date
+		FROM tb_api_key
+		WHERE key_hash = @Hash AND is_active = 1 AND is_delete = 0 AND revoke_date IS NULL
+	`, sql.Named("Hash", utils.HashAPIKey(apiKey))).Scan(&keyID, &keyName, &scopes, &expire)
+	if err == sql.ErrNoRows {
+		log.Println("[DEBUG] Unknown or revoked API key")
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid API key")
+	}
+	if err != nil {
+		log.Println("[ERROR] API key lookup failed:", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to verify API key")
+	}
+	if expire.Valid && time.Now().After(expire.Time) {
+		log.Printf("[DEBUG] API key expired: %s", keyID)
+		return fiber.NewError(fiber.StatusUnauthorized, "API key expired")
+	}
+
+	scopeList := utils.SplitScopes(scopes)
+	module, action := requestScope(c)
+	if !utils.ScopeAllows(scopeList, module, action) {
+		log.Printf("[DEBUG] API key %s lacks scope %s:%s", keyID, module, action)
+		return fiber.NewError(fiber.StatusForbidden, "API key is not allowed to "+action+" "+module)
+	}
+
+	// บันทึกเวลาใช้งานล่าสุด (ไม่ให้กระทบ Response หากบันทึกไม่สำเร็จ)
+	// ใช้ context.WithoutCancel เพราะ goroutine ทำงานต่อหลัง Response ถูกส่งและ Timeout ของ Request ถูกยกเลิกแล้ว
+	ctx := context.WithoutCancel(c.UserContext())
+	go func(id string) {
+		if _, err := db.ExecContext(ctx, `UPDATE tb_api_key SET last_used_date = `+dialect.Of(db).Now()+` WHERE api_key_id = @ID`, sql.Named("ID", id)); err != nil {
+			log.Println("[WARN] Failed to update API key last_used_date:", err)
+		}
+	}(keyID)
+
+	log.Printf("[DEBUG] API key validated for service: %s", keyName)
+
+	// เก็บ Service Identity ใน context ในรูปแบบเดียวกับ JWT Claims
+	c.Locals("user", &auth.Claims{
+		UserName: "apikey:" + keyName,
+		AuthType: auth.AuthTypeAPIKey,
+		ApiKeyID: keyID,
+		Scopes:   scopeList,
+	})
+	return c.Next()
+}
+
+// resourceModules แปลงชื่อ Resource ของ v2 (เช่น "products") เป็นชื่อ module ของ scope (เช่น "product")
+// เพื่อให้ API Key เดิมใช้ได้ทั้ง v1 และ v2 (ลงทะเบียนตอนสร้าง Route เท่านั้น จึงไม่ต้อง lock)
+var resourceModules = map[string]string{}
+
+// MapResourceScope กำหนดว่า Resource ชื่อ resource ใช้ scope ของ module ใด
+func MapResourceScope(resource, module string) {
+	resourceModules[resource] = module
+}
+
+// requestScope แปลง Request เป็น module และ action สำหรับตรวจสอบ scope
+// เช่น GET /api/v1/protected/product/select/all หรือ GET /api/v2/protected/products => ("product", "read")
+func requestScope(c *fiber.Ctx) (string, string) {
+	module := ""
+	path := c.Path()
+	if idx := strings.Index(path, "/protected/"); idx >= 0 {
+		rest := path[idx+len("/protected/"):]
+		module = strings.ToLower(strings.SplitN(rest, "/", 2)[0])
+		if m, ok := resourceModules[module]; ok {
+			module = m
+		}
+	}
+
+	action := "write"
+	if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+		action = "read"
+	}
+	return module, action
+}
+
+// DenyAPIKey ป้องกันไม่ให้ Service Identity (API Key) เข้าถึง Route ที่สงวนไว้สำหรับผู้ใช้ เช่น การจัดการ API Key
+func DenyAPIKey() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if claims := auth.FromContext(c); claims != nil && claims.IsAPIKey() {
+			return fiber.NewError(fiber.StatusForbidden, "API keys cannot access this resource")
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"PenbunAPI/config"
+	"PenbunAPI/models"
+	"PenbunAPI/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrorHandler คือ fiber.Config.ErrorHandler ของทั้งระบบ
+// แปลงทุก error ที่ Handler/Middleware คืนค่ามา (AppError, fiber.Error, SQL Error, panic ที่ถูก recover)
+// เป็น ApiResponse โดยไม่เปิดเผยข้อความภายในให้ client และบันทึกสาเหตุจริงลง Log
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	appErr := utils.AsAppError(err)
+
+	fields := logrus.Fields{
+		"method": c.Method(),
+		"path":   c.Path(),
+		"status": appErr.Status,
+		"code":   appErr.Code,
+	}
+	if appErr.Err != nil {
+		fields["error"] = appErr.Err.Error()
+	}
+	entry := config.Logger.WithFields(fields)
+	if appErr.Status >= fiber.StatusInternalServerError {
+		entry.Error(appErr.Message)
+	} else {
+		entry.Info(appErr.Message)
+	}
+
+	status := "fail"
+	if appErr.Status >= fiber.StatusInternalServerError {
+		status = "error"
+	}
+	if appErr.Retryable {
+		c.Set(fiber.HeaderRetryAfter, "1")
+	}
+	return c.Status(appErr.Status).JSON(models.ApiResponse{
+		Status:  status,
+		Code:    appErr.Code,
+		Message: appErr.Message,
+		Data:    appErr.Details,
+	})
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"PenbunAPI/utils"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ETag ตอบ Weak ETag ของ GET/HEAD ที่สำเร็จ (200) จาก data ของ ApiResponse และตอบ 304 เมื่อตรงกับ If-None-Match
+// client ที่โหลด select/all ซ้ำจึงได้เพียง 304 หากข้อมูลไม่เปลี่ยน (ดู responseETag สำหรับวิธีคำนวณ)
+// Response ที่ไม่มี update_date (เช่น Session, สถานะฐานข้อมูล) ไม่มี ETag
+func ETag() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			return c.Next()
+		}
+		if err := c.Next(); err != nil || c.Response().StatusCode() != fiber.StatusOK {
+			return err
+		}
+		tag, ok := responseETag(c)
+		if !ok {
+			return nil
+		}
+		c.Set(fiber.HeaderETag, tag)
+		if utils.MatchETag(c.Get(fiber.HeaderIfNoneMatch), tag) {
+			c.Response().ResetBody()
+			c.Status(fiber.StatusNotModified)
+		}
+		return nil
+	}
+}
+
+// IfMatch ส่ง If-Match ของ Update/Delete ต่อทาง ctx (utils.WithIfMatch) ให้ Repository ตรวจกับแถวที่ล็อกไว้
+// ภายใน Transaction ของการเขียน (utils.CheckRowVersion) ไม่ตรง = 412 (utils.RecordChanged)
+// เพื่อไม่ให้ผู้ใช้สองคนที่แก้ไขข้อมูลเดียวกันเขียนทับกันโดยไม่รู้ตัว
+// ไม่อ่านข้อมูลมาเทียบล่วงหน้า เพราะการอ่านผ่าน Cache หรือ Read Replica อาจได้รุ่นเก่าและตอบ 412 ทั้งที่ข้อมูลตรงกัน
+// ไม่ส่ง If-Match = ทำงานเหมือนเดิม, If-Match: * = ผ่านหากข้อมูลยังอยู่
+func IfMatch() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		want := c.Get(fiber.HeaderIfMatch)
+		if want == "" {
+			return c.Next()
+		}
+		c.SetUserContext(utils.WithIfMatch(c.UserContext(), want))
+		return c.Next()
+	}
+}
+
+// responseETag คำนวณ Weak ETag จาก data ของ Response ที่ Handler เขียนไว้แล้ว
+//
+//	รายการ (select/all, page)               W/"<จำนวนแถว>-<hash ของ data>"
+//	ข้อมูลหนึ่งแถว หรือเอกสาร (header + items)  W/"<รุ่น>" (รุ่นของ header)
+//
+// รุ่นคือ utils.RowVersion ของ update_date (ตัวเลขล้วน ละเอียดถึงมิลลิวินาที) ซึ่ง Repository ใช้ตรวจ If-Match ด้วย
+// รายการใช้ hash ของ data ทั้งก้อน ไม่ใช่รุ่นล่าสุด เพราะการลบหรือย้ายแถวเข้า/ออกจากหน้าไม่ทำให้รุ่นล่าสุดเปลี่ยน
+// ส่วน total และ next_cursor ก็เปลี่ยนได้โดยที่แถวในหน้าเดิมไม่เปลี่ยน
+func responseETag(c *fiber.Ctx) (string, bool) {
+	var res struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(c.Response().Body(), &res); err != nil || len(res.Data) == 0 {
+		return "", false
+	}
+	var data interface{}
+	if err := json.Unmarshal(res.Data, &data); err != nil {
+		return "", false
+	}
+
+	switch d := data.(type) {
+	case []interface{}:
+		return listETag(res.Data, len(d)), true
+	case map[string]interface{}:
+		if header, ok := d["header"].(map[string]interface{}); ok {
+			d = header
+		} else if items, ok := d["items"].([]interface{}); ok {
+			return listETag(res.Data, len(items)), true
+		}
+		if version := rowVersion(d); version != "" {
+			return `W/"` + version + `"`, true
+		}
+	}
+	return "", false
+}
+
+// listETag คือ ETag ของรายการจากจำนวนแถวและ SHA-256 ของ data (ลำดับ รหัสและรุ่นของทุกแถว รวมถึง total และ next_cursor)
+func listETag(data json.RawMessage, count int) string {
+	sum := sha256.Sum256(data)
+	return `W/"` + strconv.Itoa(count) + "-" + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// rowVersion คือ utils.RowVersion ของ update_date ในแถว m (ว่างหากไม่มี)
+func rowVersion(m map[string]interface{}) string {
+	date, _ := m["update_date"].(string)
+	version, _ := utils.ParseRowVersion(date)
+	return version
+}
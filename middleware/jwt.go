@@ -1,24 +1,29 @@
 package middleware
 
 import (
+	"PenbunAPI/auth"
 	"PenbunAPI/config" // สำหรับ Blacklist
+	"database/sql"
 	"log"
-	"strings"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gofiber/fiber/v2"
 )
 
 // JWTMiddleware เป็น middleware ที่ใช้ในการตรวจสอบ JWT Token
-func JWTMiddleware(secretKey string) fiber.Handler {
+// การตรวจสอบทั้งหมด (alg, iss, aud, exp/nbf, kid) ทำผ่าน auth.ParseToken
+// หาก Request ส่ง X-API-Key มา จะตรวจสอบด้วย API Key แทน (สำหรับระบบ machine-to-machine)
+// db ใช้ค้นหา API Key และ sessions ใช้ตรวจว่า Session ของ Token ยังไม่ถูกยกเลิก
+func JWTMiddleware(db *sql.DB, sessions *auth.Sessions) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		tokenString := c.Get("Authorization")
-		if tokenString == "" {
-			return fiber.NewError(fiber.StatusUnauthorized, "Missing or malformed token")
+		if apiKey := c.Get(APIKeyHeader); apiKey != "" {
+			return authenticateAPIKey(c, db, apiKey)
 		}
 
 		// ตัดคำว่า "Bearer " ออกจาก Token
-		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
+		tokenString := auth.BearerToken(c)
+		if tokenString == "" {
+			return fiber.NewError(fiber.StatusUnauthorized, "Missing or malformed token")
+		}
 
 		// ตรวจสอบว่า Token อยู่ใน Blacklist หรือไม่
 		if config.IsBlacklisted(tokenString) {
@@ -27,27 +32,27 @@ func JWTMiddleware(secretKey string) fiber.Handler {
 		}
 
 		// ตรวจสอบและ parse Token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte(secretKey), nil
-		})
-		if err != nil || !token.Valid {
-			log.Println("[DEBUG] Invalid token format or signature")
+		claims, err := auth.ParseToken(tokenString)
+		if err != nil {
+			log.Println("[DEBUG] Invalid token:", err)
 			return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
 		}
 
-		// Extract claims
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok || !token.Valid {
-			log.Println("[DEBUG] Invalid claims structure")
-			return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+		// ตรวจสอบว่า Session ของ Token ยังไม่ถูกยกเลิก (Logout จากอุปกรณ์อื่น หรือ Admin Force Logout)
+		if claims.SessionID != "" {
+			active, err := sessions.Active(c.UserContext(), claims.SessionID, claims.UserName)
+			if err != nil {
+				log.Println("[ERROR] Session lookup failed:", err)
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to verify session")
+			}
+			if !active {
+				log.Printf("[DEBUG] Session revoked or expired: %s", claims.SessionID)
+				return fiber.NewError(fiber.StatusUnauthorized, "Session has been revoked")
+			}
 		}
 
 		// แสดงชื่อผู้ใช้งานแทน token
-		if userName, ok := claims["user_name"]; ok {
-			log.Printf("[DEBUG] Token validated for user: %v", userName)
-		} else {
-			log.Println("[DEBUG] Token valid but missing user_name in claims")
-		}
+		log.Printf("[DEBUG] Token validated for user: %s", claims.UserName)
 
 		// เก็บข้อมูลผู้ใช้งานใน context
 		c.Locals("user", claims)
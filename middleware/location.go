@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"PenbunAPI/utils"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Location ตอบ Location header (<collection>/<id>) เมื่อ Handler สร้างข้อมูลสำเร็จ (201) และบันทึกรหัสไว้ด้วย utils.SetCreatedID
+func Location() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if id := utils.CreatedID(c); id != "" && c.Response().StatusCode() == fiber.StatusCreated {
+			c.Location(strings.TrimSuffix(c.Path(), "/") + "/" + url.PathEscape(id))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"PenbunAPI/auth"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireMFA ป้องกัน Route ที่มีความเสี่ยงสูง (เช่น ลบข้อมูลจริง) ให้ผู้ใช้ที่มี role ตาม MFA_REQUIRED_ROLES
+// ต้อง Login ผ่าน MFA มาแล้วเท่านั้น ส่วน API Key ยืนยันปัจจัยที่สองไม่ได้ จึงถูกปฏิเสธเสมอ
+// (ไม่เช่นนั้นผู้ใช้ที่ Login ด้วยรหัสผ่านอย่างเดียวจะสร้าง Key ที่มี scope "*" มาลบข้อมูลแทนได้)
+func RequireMFA() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := auth.FromContext(c)
+		if claims == nil {
+			return fiber.NewError(fiber.StatusUnauthorized, "Missing or malformed token")
+		}
+		if claims.IsAPIKey() {
+			log.Printf("[DEBUG] API key %s rejected on MFA-protected route %s %s", claims.UserName, c.Method(), c.Path())
+			return fiber.NewError(fiber.StatusForbidden, "API keys cannot be used for this action")
+		}
+		if claims.HasMFA() || !auth.RequiresMFA(claims.Roles) {
+			return c.Next()
+		}
+		log.Printf("[DEBUG] MFA required for user %s on %s %s", claims.UserName, c.Method(), c.Path())
+		return fiber.NewError(fiber.StatusForbidden, "MFA is required for this action")
+	}
+}
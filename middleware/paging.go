@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"PenbunAPI/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PageEnvelope ให้ทุก Select Page ภายใต้ Group นี้ตอบด้วย models.Page รูปแบบเดียวกัน
+func PageEnvelope() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		utils.EnablePageEnvelope(c)
+		return c.Next()
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"PenbunAPI/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// RateLimit จำกัดจำนวน Request ต่อ IP ไม่เกิน max ครั้งในทุกช่วง window (ใช้กับ Route ที่เดารหัสได้ เช่น /login/mfa)
+// เกินแล้วตอบ 429 พร้อม Retry-After, max <= 0 = ไม่จำกัด
+func RateLimit(max int, window time.Duration) fiber.Handler {
+	if max <= 0 {
+		return func(c *fiber.Ctx) error { return c.Next() }
+	}
+	return limiter.New(limiter.Config{
+		Max:        max,
+		Expiration: window,
+		LimitReached: func(c *fiber.Ctx) error {
+			return utils.NewAppError(fiber.StatusTooManyRequests, utils.CodeRateLimited, "Too many requests, try again later")
+		},
+	})
+}
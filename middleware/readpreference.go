@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"PenbunAPI/replica"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReadConsistencyHeader คือ Header ที่ client ส่ง "primary" เพื่ออ่านจากฐานข้อมูลหลัก
+// เช่น GET ทันทีหลัง POST/PUT เพื่อให้เห็นข้อมูลที่เพิ่งเขียน (read-your-writes) แม้ Read Replica ยังตามไม่ทัน
+const ReadConsistencyHeader = "X-Read-Consistency"
+
+// ReadPreference กำหนดว่า Select ของ Request นี้อ่านจาก Read Replica ได้หรือไม่ (ดู replica.Router)
+// GET/HEAD อ่านจาก Replica ได้ ยกเว้นส่ง X-Read-Consistency: primary
+// Method อื่นทั้งหมด (Insert, Update, Delete, PATCH, Restore) อ่านจาก Primary รวมถึงการอ่านข้อมูลเดิมก่อนแก้ไข
+func ReadPreference() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		readOnly := c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead
+		if !readOnly || strings.EqualFold(c.Get(ReadConsistencyHeader), "primary") {
+			c.SetUserContext(replica.WithPrimary(c.UserContext()))
+		}
+		return c.Next()
+	}
+}
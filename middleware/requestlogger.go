@@ -1,11 +1,11 @@
 package middleware
 
 import (
+	"PenbunAPI/auth"
 	"PenbunAPI/config"
 	"log"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -16,10 +16,8 @@ func NewLoggerMiddleware() fiber.Handler {
 		err := c.Next()
 
 		user := "-"
-		if claims, ok := c.Locals("user").(jwt.MapClaims); ok {
-			if name, ok := claims["user_name"].(string); ok {
-				user = name
-			}
+		if claims := auth.FromContext(c); claims != nil && claims.UserName != "" {
+			user = claims.UserName
 		}
 
 		config.Logger.WithFields(map[string]interface{}{
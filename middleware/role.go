@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"PenbunAPI/auth"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireRole อนุญาตเฉพาะผู้ใช้ (JWT) ที่มี role ใด role หนึ่งตามที่กำหนด
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := auth.FromContext(c)
+		if claims == nil {
+			return fiber.NewError(fiber.StatusUnauthorized, "Missing or malformed token")
+		}
+		if !claims.IsAPIKey() {
+			for _, r := range roles {
+				if claims.HasRole(r) {
+					return c.Next()
+				}
+			}
+		}
+		return fiber.NewError(fiber.StatusForbidden, "Insufficient role")
+	}
+}
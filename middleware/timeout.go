@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"PenbunAPI/utils"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Timeout กำหนดงบเวลา d ให้ c.UserContext() ซึ่ง Handler และ Repository ส่งต่อให้ทุกคำสั่งฐานข้อมูล
+// เมื่อครบเวลา Query ที่ค้างอยู่จะถูกยกเลิกและ Transaction ถูก Rollback (utils.ExecuteTransaction)
+// Group ที่ใช้ Timeout ซ้อนกันจะใช้งบเวลาของ Group ในสุดแทนของ Group นอก (d <= 0 = ไม่จำกัดเวลา)
+//
+// หากครบเวลาแล้ว Handler คืน error หรือตอบ 5xx จะตอบ 504 (utils.Timeout) รูปแบบเดียวกันทุก Route
+// ผลที่ตอบสำเร็จไปแล้ว (เช่น 2xx ที่เสร็จก่อนถูกยกเลิก) จะไม่ถูกเปลี่ยน
+func Timeout(d time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// ตัด deadline ของ Group นอกออกก่อน แต่คงค่าอื่นใน context ไว้
+		parent := context.WithoutCancel(c.UserContext())
+		if d <= 0 {
+			c.SetUserContext(parent)
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithTimeout(parent, d)
+		defer cancel()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return err
+		}
+		if err == nil && c.Response().StatusCode() < fiber.StatusInternalServerError {
+			return nil
+		}
+		if err == nil {
+			err = ctx.Err()
+		}
+		c.Response().ResetBody()
+		return utils.Timeout(err)
+	}
+}
+
+// GroupTimeout ใช้งบเวลาตาม module ของ Request (ชื่อเดียวกับ scope ของ API Key เช่น product, receive, admin)
+// โดยอ่านจาก budgets และ module ที่ไม่มีใน budgets ใช้งบเวลาที่ Group นอกกำหนดไว้
+// ใช้ต่อจาก Group /protected เพื่อให้ v1 และ v2 ใช้ค่าตั้งค่าชุดเดียวกัน (REQUEST_TIMEOUT_<MODULE>)
+func GroupTimeout(budgets map[string]time.Duration) fiber.Handler {
+	handlers := make(map[string]fiber.Handler, len(budgets))
+	for module, d := range budgets {
+		handlers[module] = Timeout(d)
+	}
+	return func(c *fiber.Ctx) error {
+		module, _ := requestScope(c)
+		if h, ok := handlers[module]; ok {
+			return h(c)
+		}
+		return c.Next()
+	}
+}
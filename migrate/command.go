@@ -0,0 +1,109 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Usage คือรูปแบบคำสั่ง migrate ของ main
+const Usage = "migrate up | migrate down [n|all] | migrate status | migrate legacy-columns [apply]"
+
+// Run ทำงานตามคำสั่งย่อยของ main (go run . migrate <args>) แล้วเขียนผลลงใน out
+//
+//	up          รันทุกรุ่นที่ยังไม่ได้รัน
+//	down [n]    ย้อน n รุ่นล่าสุด (ค่าเริ่มต้น 1, all = ทุกรุ่น)
+//	status      แสดงสถานะของทุกรุ่น (ค่าเริ่มต้นเมื่อไม่ระบุคำสั่ง)
+//	legacy-columns [apply]  แสดงคอลัมน์ชื่อเก่าของฐานข้อมูลเดิม และเปลี่ยนชื่อเมื่อระบุ apply (Backup ก่อน)
+func Run(ctx context.Context, db *sql.DB, args []string, out io.Writer) error {
+	command := "status"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		done, err := Up(ctx, db)
+		for _, m := range done {
+			fmt.Fprintf(out, "applied  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			fmt.Fprintln(out, "schema is up to date")
+		}
+		return nil
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if args[1] == "all" {
+				steps = -1
+			} else if n, err := strconv.Atoi(args[1]); err == nil && n > 0 {
+				steps = n
+			} else {
+				return fmt.Errorf("invalid step count %q (usage: %s)", args[1], Usage)
+			}
+		}
+		done, err := Down(ctx, db, steps)
+		for _, m := range done {
+			fmt.Fprintf(out, "reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			fmt.Fprintln(out, "nothing to revert")
+		}
+		return nil
+
+	case "status":
+		states, err := Status(ctx, db)
+		if err != nil {
+			return err
+		}
+		for _, s := range states {
+			if s.Applied {
+				fmt.Fprintf(out, "applied  %04d_%s  %s\n", s.Version, s.Name, s.AppliedDate.Format("2006-01-02 15:04:05"))
+			} else {
+				fmt.Fprintf(out, "pending  %04d_%s\n", s.Version, s.Name)
+			}
+		}
+		return nil
+
+	case "legacy-columns":
+		if len(args) > 1 && args[1] != "apply" {
+			return fmt.Errorf("unknown option %q (usage: %s)", args[1], Usage)
+		}
+		if len(args) == 1 {
+			pending, err := LegacyColumns(ctx, db)
+			if err != nil {
+				return err
+			}
+			for _, c := range pending {
+				fmt.Fprintf(out, "pending  %s.%s -> %s\n", c.Table, c.Old, c.New)
+			}
+			if len(pending) == 0 {
+				fmt.Fprintln(out, "no legacy columns")
+			} else {
+				fmt.Fprintln(out, "back up the database, then run: migrate legacy-columns apply")
+			}
+			return nil
+		}
+		done, err := RenameLegacyColumns(ctx, db)
+		if err != nil {
+			return err
+		}
+		for _, c := range done {
+			fmt.Fprintf(out, "renamed  %s.%s -> %s\n", c.Table, c.Old, c.New)
+		}
+		if len(done) == 0 {
+			fmt.Fprintln(out, "no legacy columns")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown command %q (usage: %s)", command, Usage)
+}
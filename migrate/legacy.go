@@ -0,0 +1,87 @@
+package migrate
+
+import (
+	"PenbunAPI/dialect"
+	"PenbunAPI/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrLegacyColumns คือฐานข้อมูลเดิม (SQL v2.2) ที่ tb_product ยังใช้ชื่อคอลัมน์เก่าที่ Repository อ่านไม่ได้
+var ErrLegacyColumns = errors.New("database uses legacy column names")
+
+// LegacyColumn คือคอลัมน์ชื่อเก่าของฐานข้อมูลเดิมกับชื่อที่ Repository ใช้
+type LegacyColumn struct {
+	Table string
+	Old   string
+	New   string
+}
+
+// legacyColumns คือคอลัมน์ของ SQL v2.2 ที่ 0001_init (ซึ่งข้ามตารางที่มีอยู่แล้ว) ไม่ได้สร้างด้วยชื่อใหม่
+var legacyColumns = []LegacyColumn{
+	{Table: "tb_product", Old: "product_group_id", New: "product_type_id"},
+	{Table: "tb_product", Old: "product_format_type_id", New: "format_type_id"},
+	{Table: "tb_product", Old: "sell_price", New: "price"},
+	{Table: "tb_product", Old: "cost_price", New: "cost"},
+}
+
+// LegacyColumns คืนคอลัมน์ชื่อเก่าที่ยังไม่ได้เปลี่ยนชื่อบน db (อ่านอย่างเดียว ฐานข้อมูลที่ไม่ใช่ SQL Server ไม่มีชื่อเก่า)
+func LegacyColumns(ctx context.Context, db *sql.DB) ([]LegacyColumn, error) {
+	if dialect.Of(db).Name() != "sqlserver" {
+		return nil, nil
+	}
+	var pending []LegacyColumn
+	for _, c := range legacyColumns {
+		var hasOld, hasNew bool
+		err := db.QueryRowContext(ctx, `
+			SELECT CASE WHEN COL_LENGTH(@Table, @Old) IS NULL THEN 0 ELSE 1 END,
+			       CASE WHEN COL_LENGTH(@Table, @New) IS NULL THEN 0 ELSE 1 END`,
+			sql.Named("Table", "dbo."+c.Table), sql.Named("Old", c.Old), sql.Named("New", c.New)).Scan(&hasOld, &hasNew)
+		if err != nil {
+			return nil, err
+		}
+		if hasOld && !hasNew {
+			pending = append(pending, c)
+		}
+	}
+	return pending, nil
+}
+
+// CheckLegacyColumns คืน ErrLegacyColumns หาก db ยังมีคอลัมน์ชื่อเก่า (main ไม่เปิดให้บริการ และไม่เปลี่ยนชื่อให้เอง)
+func CheckLegacyColumns(ctx context.Context, db *sql.DB) error {
+	pending, err := LegacyColumns(ctx, db)
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+	names := make([]string, len(pending))
+	for i, c := range pending {
+		names[i] = c.Table + "." + c.Old
+	}
+	return fmt.Errorf("%w: %s", ErrLegacyColumns, strings.Join(names, ", "))
+}
+
+// RenameLegacyColumns เปลี่ยนชื่อคอลัมน์เก่าเป็นชื่อที่ Repository ใช้ด้วย sp_rename ใน Transaction เดียว
+// ทำเฉพาะเมื่อสั่งเอง (go run . migrate legacy-columns apply) เพราะรายงานหรือ ETL ภายนอกที่ใช้ชื่อเดิมจะอ่านไม่ได้อีก
+// และไม่มีคำสั่งย้อนกลับ ต้อง Backup ฐานข้อมูลก่อน
+func RenameLegacyColumns(ctx context.Context, db *sql.DB) ([]LegacyColumn, error) {
+	pending, err := LegacyColumns(ctx, db)
+	if err != nil || len(pending) == 0 {
+		return nil, err
+	}
+	steps := make([]func(tx *sql.Tx) error, len(pending))
+	for i, c := range pending {
+		c := c
+		steps[i] = func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `EXEC sp_rename @objname = @From, @newname = @To, @objtype = N'COLUMN'`,
+				sql.Named("From", "dbo."+c.Table+"."+c.Old), sql.Named("To", c.New))
+			return err
+		}
+	}
+	if err := utils.ExecuteTransaction(ctx, db, steps); err != nil {
+		return nil, fmt.Errorf("rename legacy columns: %w", err)
+	}
+	return pending, nil
+}
@@ -0,0 +1,281 @@
+// Package migrate จัดการโครงสร้างฐานข้อมูลด้วยไฟล์ SQL ที่มีเลขรุ่นและฝังมากับ Binary
+// ไฟล์แยกโฟลเดอร์ตาม Dialect (sqlserver/, sqlite3/) ในรูป NNNN_name.up.sql และ NNNN_name.down.sql
+// รุ่นที่ใช้แล้วบันทึกใน tb_schema_migration และ main ไม่เปิดให้บริการหากฐานข้อมูลยังไม่ถึงรุ่นล่าสุด (Check)
+package migrate
+
+import (
+	"PenbunAPI/dialect"
+	"PenbunAPI/utils"
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed sqlserver/*.sql sqlite3/*.sql
+var files embed.FS
+
+// ErrSchemaBehind คือฐานข้อมูลยังไม่ได้รัน migration ที่ Binary นี้ต้องการ
+var ErrSchemaBehind = errors.New("database schema is behind")
+
+// Migration คือไฟล์ up/down หนึ่งรุ่น
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// State คือสถานะของ Migration หนึ่งรุ่นบนฐานข้อมูล
+type State struct {
+	Migration
+	Applied     bool
+	AppliedDate *time.Time
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// batchSeparator คือบรรทัด GO ที่คั่น Batch ของ SQL Server (Driver ไม่รู้จัก GO จึงต้องแยกส่งเอง)
+var batchSeparator = regexp.MustCompile(`(?im)^[ \t]*GO[ \t]*$`)
+
+// history คือ SQL ของตาราง tb_schema_migration ในแต่ละ Dialect
+type history struct {
+	create string
+	exists string
+}
+
+var histories = map[string]history{
+	"sqlserver": {
+		create: `IF OBJECT_ID(N'dbo.tb_schema_migration', N'U') IS NULL
+			CREATE TABLE dbo.tb_schema_migration (
+				version      INT           NOT NULL PRIMARY KEY,
+				name         NVARCHAR(255) NOT NULL,
+				applied_date DATETIME      NOT NULL
+			)`,
+		exists: `SELECT COUNT(*) FROM sys.tables WHERE name = 'tb_schema_migration'`,
+	},
+	"sqlite3": {
+		create: `CREATE TABLE IF NOT EXISTS tb_schema_migration (
+				version      INTEGER  NOT NULL PRIMARY KEY,
+				name         TEXT     NOT NULL,
+				applied_date DATETIME NOT NULL
+			)`,
+		exists: `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'tb_schema_migration'`,
+	},
+}
+
+// Migrations คืน Migration ทั้งหมดของ Dialect d เรียงตามรุ่น
+func Migrations(d dialect.Dialect) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, d.Name())
+	if err != nil {
+		return nil, fmt.Errorf("migrations for %s: %w", d.Name(), err)
+	}
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		m := fileName.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("migration %s/%s: name must be NNNN_name.up.sql or NNNN_name.down.sql", d.Name(), e.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		body, err := files.ReadFile(d.Name() + "/" + e.Name())
+		if err != nil {
+			return nil, err
+		}
+		mig := byVersion[version]
+		if mig == nil {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %s/%04d: names %q and %q differ", d.Name(), version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %s/%04d_%s: both up and down files are required", d.Name(), mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Status คืนสถานะของทุก Migration บนฐานข้อมูล db
+func Status(ctx context.Context, db *sql.DB) ([]State, error) {
+	d := dialect.Of(db)
+	migrations, err := Migrations(d)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(ctx, db, d)
+	if err != nil {
+		return nil, err
+	}
+	states := make([]State, len(migrations))
+	for i, m := range migrations {
+		states[i] = State{Migration: m}
+		if date, ok := applied[m.Version]; ok {
+			states[i].Applied = true
+			states[i].AppliedDate = &date
+		}
+	}
+	return states, nil
+}
+
+// Check คืน ErrSchemaBehind หากยังมี Migration ที่ไม่ได้รันบน db
+func Check(ctx context.Context, db *sql.DB) error {
+	states, err := Status(ctx, db)
+	if err != nil {
+		return err
+	}
+	var pending []string
+	for _, s := range states {
+		if !s.Applied {
+			pending = append(pending, fmt.Sprintf("%04d_%s", s.Version, s.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: pending %s", ErrSchemaBehind, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// Up รันทุก Migration ที่ยังไม่ได้รันตามลำดับรุ่น แต่ละรุ่นอยู่ใน Transaction เดียวกับการบันทึกประวัติ
+// คืนรายการที่รันสำเร็จ หากรุ่นใดผิดพลาดจะหยุดที่รุ่นนั้น
+func Up(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	d := dialect.Of(db)
+	if err := ensureHistory(ctx, db, d); err != nil {
+		return nil, err
+	}
+	states, err := Status(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, s := range states {
+		if s.Applied {
+			continue
+		}
+		m := s.Migration
+		steps := batches(ctx, m.Up)
+		steps = append(steps, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `INSERT INTO tb_schema_migration (version, name, applied_date) VALUES (@Version, @Name, `+d.Now()+`)`,
+				sql.Named("Version", m.Version), sql.Named("Name", m.Name))
+			return err
+		})
+		if err := utils.ExecuteTransaction(ctx, db, steps); err != nil {
+			return done, fmt.Errorf("migration %04d_%s up: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Down ย้อน Migration ที่รันแล้วล่าสุด steps รุ่น (steps < 0 = ทุกรุ่น)
+func Down(ctx context.Context, db *sql.DB, steps int) ([]Migration, error) {
+	d := dialect.Of(db)
+	if err := ensureHistory(ctx, db, d); err != nil {
+		return nil, err
+	}
+	states, err := Status(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for i := len(states) - 1; i >= 0 && (steps < 0 || len(done) < steps); i-- {
+		if !states[i].Applied {
+			continue
+		}
+		m := states[i].Migration
+		txSteps := batches(ctx, m.Down)
+		txSteps = append(txSteps, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `DELETE FROM tb_schema_migration WHERE version = @Version`, sql.Named("Version", m.Version))
+			return err
+		})
+		if err := utils.ExecuteTransaction(ctx, db, txSteps); err != nil {
+			return done, fmt.Errorf("migration %04d_%s down: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// ensureHistory สร้าง tb_schema_migration หากยังไม่มี
+func ensureHistory(ctx context.Context, db *sql.DB, d dialect.Dialect) error {
+	h, ok := histories[d.Name()]
+	if !ok {
+		return fmt.Errorf("migrate: unsupported dialect %s", d.Name())
+	}
+	_, err := db.ExecContext(ctx, h.create)
+	return err
+}
+
+// appliedVersions อ่านรุ่นที่รันแล้วพร้อมวันที่ (ไม่มีตารางประวัติ = ยังไม่เคยรัน) โดยไม่สร้างตาราง
+// เพื่อให้ Check ใช้ได้กับผู้ใช้ฐานข้อมูลที่ไม่มีสิทธิ์ DDL
+func appliedVersions(ctx context.Context, db *sql.DB, d dialect.Dialect) (map[int]time.Time, error) {
+	h, ok := histories[d.Name()]
+	if !ok {
+		return nil, fmt.Errorf("migrate: unsupported dialect %s", d.Name())
+	}
+	var count int
+	if err := db.QueryRowContext(ctx, h.exists).Scan(&count); err != nil {
+		return nil, err
+	}
+	applied := map[int]time.Time{}
+	if count == 0 {
+		return applied, nil
+	}
+	rows, err := db.QueryContext(ctx, `SELECT version, applied_date FROM tb_schema_migration`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int
+		var date time.Time
+		if err := rows.Scan(&version, &date); err != nil {
+			return nil, err
+		}
+		applied[version] = date
+	}
+	return applied, rows.Err()
+}
+
+// batches แยกไฟล์ SQL ตามบรรทัด GO เป็นขั้นตอนของ Transaction (ข้าม Batch ที่มีแต่ Comment)
+func batches(ctx context.Context, script string) []func(tx *sql.Tx) error {
+	var steps []func(tx *sql.Tx) error
+	for _, batch := range batchSeparator.Split(script, -1) {
+		if onlyComments(batch) {
+			continue
+		}
+		query := batch
+		steps = append(steps, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, query)
+			return err
+		})
+	}
+	return steps
+}
+
+func onlyComments(batch string) bool {
+	for _, line := range strings.Split(batch, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,341 @@
+-- โครงสร้างตารางของ PenbunAPI บน SQLite (ใช้แทน SQL Server ในการทดสอบและเดโม) ตรงกับ sqlserver/0001_init.up.sql
+-- ทุกตารางหลักมี autoID, prefix, รหัสหลัก, update_by, update_date, is_delete ตาม standard.md
+-- รหัสหลักและ update_date ของการ UPDATE ถูกสร้างใน Go (dialect.SQLite) แทน Trigger ของ SQL Server
+-- ใช้ IF NOT EXISTS เพื่อรับฐานข้อมูลที่สร้างไว้ก่อนมี migration
+
+CREATE TABLE IF NOT EXISTS tb_users (
+    user_name        TEXT PRIMARY KEY,
+    user_password    TEXT NOT NULL,
+    user_role        TEXT,
+    mfa_secret       TEXT,
+    mfa_enabled      INTEGER NOT NULL DEFAULT 0,
+    mfa_last_step    INTEGER,
+    mfa_enable_date  DATETIME,
+    update_date      DATETIME DEFAULT (datetime('now', '+7 hours'))
+);
+
+CREATE TABLE IF NOT EXISTS tb_user_session (
+    session_id      TEXT PRIMARY KEY,
+    user_name       TEXT NOT NULL,
+    user_agent      TEXT,
+    ip_address      TEXT,
+    issue_date      DATETIME NOT NULL,
+    last_seen_date  DATETIME,
+    expire_date     DATETIME NOT NULL,
+    revoke_date     DATETIME,
+    revoke_by       TEXT
+);
+
+CREATE INDEX IF NOT EXISTS ix_user_session_user ON tb_user_session (user_name, revoke_date);
+
+CREATE TABLE IF NOT EXISTS tb_user_recovery_code (
+    recovery_code_id  INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_name         TEXT NOT NULL,
+    code_hash         TEXT NOT NULL,
+    create_date       DATETIME,
+    used_date         DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS tb_api_key (
+    api_key_id      TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(16)))),
+    key_name        TEXT NOT NULL,
+    key_prefix      TEXT NOT NULL,
+    key_hash        TEXT NOT NULL UNIQUE,
+    scopes          TEXT NOT NULL,
+    expire_date     DATETIME,
+    last_used_date  DATETIME,
+    revoke_date     DATETIME,
+    description     TEXT,
+    update_by       TEXT,
+    update_date     DATETIME DEFAULT (datetime('now', '+7 hours')),
+    is_active       INTEGER NOT NULL DEFAULT 1,
+    is_delete       INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS tb_reference (
+    row_id       INTEGER PRIMARY KEY AUTOINCREMENT,
+    ref_id       TEXT NOT NULL,
+    ref_int      INTEGER,
+    ref_text     TEXT,
+    update_by    TEXT,
+    update_date  DATETIME DEFAULT (datetime('now', '+7 hours'))
+);
+
+CREATE INDEX IF NOT EXISTS ix_reference_ref_id ON tb_reference (ref_id);
+
+CREATE TABLE IF NOT EXISTS tb_vendor_type (
+    autoID          INTEGER PRIMARY KEY AUTOINCREMENT,
+    prefix          TEXT NOT NULL DEFAULT 'VT',
+    vendor_type_id  TEXT UNIQUE,
+    type_name       TEXT NOT NULL,
+    description     TEXT,
+    update_by       TEXT,
+    update_date     DATETIME,
+    is_active       INTEGER NOT NULL DEFAULT 1,
+    is_delete       INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS tb_vendor (
+    autoID           INTEGER PRIMARY KEY AUTOINCREMENT,
+    prefix           TEXT NOT NULL DEFAULT 'VEN',
+    vendor_id        TEXT UNIQUE,
+    vendor_type_id   TEXT NOT NULL REFERENCES tb_vendor_type (vendor_type_id),
+    vendor_name      TEXT NOT NULL,
+    tax_id           TEXT,
+    branch_name      TEXT,
+    contact_person   TEXT,
+    phone1           TEXT,
+    phone2           TEXT,
+    email            TEXT,
+    website          TEXT,
+    address          TEXT,
+    sub_district     TEXT,
+    district         TEXT,
+    province         TEXT,
+    zip_code         TEXT,
+    credit_term_day  INTEGER DEFAULT 30,
+    currency         TEXT NOT NULL DEFAULT 'THB',
+    note             TEXT,
+    update_by        TEXT,
+    update_date      DATETIME,
+    is_active        INTEGER NOT NULL DEFAULT 1,
+    is_delete        INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS tb_customer_type (
+    autoID              INTEGER PRIMARY KEY AUTOINCREMENT,
+    prefix              TEXT NOT NULL DEFAULT 'CT',
+    customer_type_id    TEXT UNIQUE,
+    customer_type_name  TEXT NOT NULL,
+    base_credit_day     INTEGER DEFAULT 0,
+    description         TEXT,
+    update_by           TEXT,
+    update_date         DATETIME,
+    is_active           INTEGER NOT NULL DEFAULT 1,
+    is_delete           INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS tb_customer (
+    autoID            INTEGER PRIMARY KEY AUTOINCREMENT,
+    prefix            TEXT NOT NULL DEFAULT 'CUS',
+    customer_id       TEXT UNIQUE,
+    customer_type_id  TEXT NOT NULL REFERENCES tb_customer_type (customer_type_id),
+    customer_name     TEXT NOT NULL,
+    tax_id            TEXT,
+    branch_name       TEXT,
+    contact_person    TEXT,
+    phone1            TEXT,
+    phone2            TEXT,
+    email             TEXT,
+    line_id           TEXT,
+    address           TEXT,
+    sub_district      TEXT,
+    district          TEXT,
+    province          TEXT,
+    zip_code          TEXT,
+    credit_limit      REAL DEFAULT 0,
+    credit_term_day   INTEGER DEFAULT 0,
+    note              TEXT,
+    update_by         TEXT,
+    update_date       DATETIME,
+    is_active         INTEGER NOT NULL DEFAULT 1,
+    is_delete         INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS tb_discount_type (
+    autoID              INTEGER PRIMARY KEY AUTOINCREMENT,
+    prefix              TEXT NOT NULL DEFAULT 'DT',
+    discount_type_id    TEXT UNIQUE,
+    discount_type_name  TEXT NOT NULL,
+    description         TEXT,
+    update_by           TEXT,
+    update_date         DATETIME,
+    is_active           INTEGER NOT NULL DEFAULT 1,
+    is_delete           INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS tb_discount (
+    autoID            INTEGER PRIMARY KEY AUTOINCREMENT,
+    prefix            TEXT NOT NULL DEFAULT 'DIS',
+    discount_id       TEXT UNIQUE,
+    discount_type_id  TEXT NOT NULL REFERENCES tb_discount_type (discount_type_id),
+    discount_name     TEXT NOT NULL,
+    discount_code     TEXT,
+    description       TEXT,
+    discount_value    REAL DEFAULT 0,
+    is_percent        INTEGER NOT NULL DEFAULT 0,
+    min_order_amount  REAL,
+    start_date        DATETIME,
+    end_date          DATETIME,
+    update_by         TEXT,
+    update_date       DATETIME,
+    is_active         INTEGER NOT NULL DEFAULT 1,
+    is_delete         INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS tb_unit_type (
+    autoID          INTEGER PRIMARY KEY AUTOINCREMENT,
+    prefix          TEXT NOT NULL DEFAULT 'UT',
+    unit_type_id    TEXT UNIQUE,
+    unit_type_name  TEXT NOT NULL,
+    description     TEXT,
+    update_by       TEXT,
+    update_date     DATETIME,
+    is_active       INTEGER NOT NULL DEFAULT 1,
+    is_delete       INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS tb_product_category (
+    autoID               INTEGER PRIMARY KEY AUTOINCREMENT,
+    prefix               TEXT NOT NULL DEFAULT 'PC',
+    product_category_id  TEXT UNIQUE,
+    category_name        TEXT NOT NULL,
+    category_code        TEXT,
+    description          TEXT,
+    update_by            TEXT,
+    update_date          DATETIME,
+    is_active            INTEGER NOT NULL DEFAULT 1,
+    is_delete            INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS tb_product_group (
+    autoID               INTEGER PRIMARY KEY AUTOINCREMENT,
+    prefix               TEXT NOT NULL DEFAULT 'PTG',
+    product_group_id     TEXT UNIQUE,
+    product_category_id  TEXT NOT NULL REFERENCES tb_product_category (product_category_id),
+    product_group_name   TEXT NOT NULL,
+    description          TEXT,
+    update_by            TEXT,
+    update_date          DATETIME,
+    is_active            INTEGER NOT NULL DEFAULT 1,
+    is_delete            INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS tb_product_format_type (
+    autoID                  INTEGER PRIMARY KEY AUTOINCREMENT,
+    prefix                  TEXT NOT NULL DEFAULT 'PF',
+    product_format_type_id  TEXT UNIQUE,
+    format_name             TEXT NOT NULL,
+    description             TEXT,
+    update_by               TEXT,
+    update_date             DATETIME,
+    is_active               INTEGER NOT NULL DEFAULT 1,
+    is_delete               INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS tb_warehouse (
+    autoID                INTEGER PRIMARY KEY AUTOINCREMENT,
+    prefix                TEXT NOT NULL DEFAULT 'WH',
+    warehouse_id          TEXT UNIQUE,
+    warehouse_code        TEXT,
+    warehouse_name        TEXT NOT NULL,
+    description           TEXT,
+    is_main_dc            INTEGER NOT NULL DEFAULT 0,
+    allow_negative_stock  INTEGER NOT NULL DEFAULT 0,
+    update_by             TEXT,
+    update_date           DATETIME,
+    is_active             INTEGER NOT NULL DEFAULT 1,
+    is_delete             INTEGER NOT NULL DEFAULT 0
+);
+
+-- product_type_id คือรหัสกลุ่มสินค้า (tb_product_group) ส่วน product_name ใช้แสดงใน items ของเอกสาร
+CREATE TABLE IF NOT EXISTS tb_product (
+    autoID           INTEGER PRIMARY KEY AUTOINCREMENT,
+    prefix           TEXT NOT NULL DEFAULT 'PDT',
+    product_id       TEXT NOT NULL UNIQUE,
+    product_name_th  TEXT NOT NULL,
+    product_name_en  TEXT,
+    product_name     TEXT GENERATED ALWAYS AS (COALESCE(product_name_th, product_name_en)) VIRTUAL,
+    product_type_id  TEXT NOT NULL REFERENCES tb_product_group (product_group_id),
+    format_type_id   TEXT REFERENCES tb_product_format_type (product_format_type_id),
+    vendor_id        TEXT REFERENCES tb_vendor (vendor_id),
+    unit_type_id     TEXT REFERENCES tb_unit_type (unit_type_id),
+    isbn             TEXT,
+    author_name      TEXT,
+    publisher_date   DATE,
+    edition_number   INTEGER,
+    price            REAL DEFAULT 0,
+    cost             REAL DEFAULT 0,
+    description      TEXT,
+    note             TEXT,
+    count_stock      INTEGER NOT NULL DEFAULT 1,
+    update_by        TEXT,
+    update_date      DATETIME,
+    is_active        INTEGER NOT NULL DEFAULT 1,
+    is_delete        INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS tb_product_pack_config (
+    autoID                  INTEGER PRIMARY KEY AUTOINCREMENT,
+    prefix                  TEXT NOT NULL DEFAULT 'PPC',
+    product_pack_config_id  TEXT UNIQUE,
+    product_id              TEXT NOT NULL REFERENCES tb_product (product_id),
+    bundle_qty              INTEGER NOT NULL,
+    unit_type_id            TEXT NOT NULL REFERENCES tb_unit_type (unit_type_id),
+    note                    TEXT,
+    update_by               TEXT,
+    update_date             DATETIME,
+    id_status               INTEGER NOT NULL DEFAULT 1,
+    is_delete               INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS tb_receive_note (
+    autoID           INTEGER PRIMARY KEY AUTOINCREMENT,
+    prefix           TEXT NOT NULL DEFAULT 'RCV',
+    receive_note_id  TEXT UNIQUE,
+    vendor_id        TEXT NOT NULL REFERENCES tb_vendor (vendor_id),
+    warehouse_id     TEXT NOT NULL REFERENCES tb_warehouse (warehouse_id),
+    doc_date         DATETIME NOT NULL,
+    ref_invoice_no   TEXT,
+    receive_type     TEXT NOT NULL,
+    total_amount     REAL DEFAULT 0,
+    note             TEXT,
+    update_by        TEXT,
+    update_date      DATETIME,
+    is_active        INTEGER NOT NULL DEFAULT 1,
+    is_delete        INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS tb_receive_item (
+    auto_id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    receive_note_id  TEXT NOT NULL REFERENCES tb_receive_note (receive_note_id),
+    product_id       TEXT NOT NULL REFERENCES tb_product (product_id),
+    qty              REAL NOT NULL,
+    unit_cost        REAL DEFAULT 0,
+    line_total       REAL DEFAULT 0,
+    remark           TEXT,
+    update_date      DATETIME DEFAULT (datetime('now', '+7 hours')),
+    is_delete        INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS tb_order (
+    autoID           INTEGER PRIMARY KEY AUTOINCREMENT,
+    prefix           TEXT NOT NULL DEFAULT 'ORD',
+    order_id         TEXT UNIQUE,
+    customer_id      TEXT NOT NULL REFERENCES tb_customer (customer_id),
+    warehouse_id     TEXT NOT NULL REFERENCES tb_warehouse (warehouse_id),
+    doc_date         DATETIME NOT NULL,
+    doc_type         TEXT NOT NULL,
+    total_amount     REAL DEFAULT 0,
+    discount_amount  REAL DEFAULT 0,
+    net_amount       REAL DEFAULT 0,
+    vat_amount       REAL DEFAULT 0,
+    grand_total      REAL DEFAULT 0,
+    update_by        TEXT,
+    update_date      DATETIME,
+    is_active        INTEGER NOT NULL DEFAULT 1,
+    is_delete        INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS tb_order_item (
+    auto_id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id         TEXT NOT NULL REFERENCES tb_order (order_id),
+    product_id       TEXT NOT NULL REFERENCES tb_product (product_id),
+    qty              REAL NOT NULL,
+    unit_price       REAL DEFAULT 0,
+    discount_amount  REAL DEFAULT 0,
+    line_total       REAL DEFAULT 0,
+    remark           TEXT,
+    update_date      DATETIME DEFAULT (datetime('now', '+7 hours')),
+    is_delete        INTEGER NOT NULL DEFAULT 0
+);
@@ -0,0 +1,29 @@
+-- ลบตารางทั้งหมดของ 0001_init โดยลบตารางที่อ้างถึงตารางอื่นก่อน เฉพาะฐานข้อมูลที่ 0001_init สร้างขึ้นเอง
+-- ฐานข้อมูลเดิมที่ 0001 รับตารางที่มีอยู่แล้วมาใช้ (tb_schema_adopted มีรายการ) หรือรัน 0001 ก่อนมีการบันทึกนี้ ไม่ถูกลบ
+IF OBJECT_ID(N'dbo.tb_schema_adopted', N'U') IS NULL OR EXISTS (SELECT 1 FROM dbo.tb_schema_adopted)
+    THROW 50001, N'0001_init down refused: the tables existed before this migration (see dbo.tb_schema_adopted), drop them manually if that is really intended', 1;
+GO
+
+DROP TABLE IF EXISTS tb_order_item;
+DROP TABLE IF EXISTS tb_order;
+DROP TABLE IF EXISTS tb_receive_item;
+DROP TABLE IF EXISTS tb_receive_note;
+DROP TABLE IF EXISTS tb_product_pack_config;
+DROP TABLE IF EXISTS tb_product;
+DROP TABLE IF EXISTS tb_warehouse;
+DROP TABLE IF EXISTS tb_product_format_type;
+DROP TABLE IF EXISTS tb_product_group;
+DROP TABLE IF EXISTS tb_product_category;
+DROP TABLE IF EXISTS tb_unit_type;
+DROP TABLE IF EXISTS tb_discount;
+DROP TABLE IF EXISTS tb_discount_type;
+DROP TABLE IF EXISTS tb_customer;
+DROP TABLE IF EXISTS tb_customer_type;
+DROP TABLE IF EXISTS tb_vendor;
+DROP TABLE IF EXISTS tb_vendor_type;
+DROP TABLE IF EXISTS tb_reference;
+DROP TABLE IF EXISTS tb_api_key;
+DROP TABLE IF EXISTS tb_user_recovery_code;
+DROP TABLE IF EXISTS tb_user_session;
+DROP TABLE IF EXISTS tb_users;
+DROP TABLE IF EXISTS tb_schema_adopted;
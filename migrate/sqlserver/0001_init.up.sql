@@ -0,0 +1,801 @@
+-- โครงสร้างตารางและ Trigger ของ PenbunAPI บน SQL Server ตาม standard.md
+-- ทุกตารางหลักมี autoID, prefix, รหัสหลัก, update_by, update_date, is_delete
+-- TRIG_GENERATE_[TABLE]_ID สร้างรหัสจาก prefix ต่อด้วย autoID 6 หลัก (เช่น VT000001) หลัง INSERT
+-- TRIG_AUTO_UPDATE_DATE_[TABLE] บันทึก update_date ตามเวลาประเทศไทยหลัง UPDATE
+-- ตารางที่มีอยู่แล้วจะไม่ถูกสร้างซ้ำ ฐานข้อมูลเดิมจึงรัน migration นี้ได้ (ชื่อคอลัมน์ที่ต่างกันแก้ใน 0002)
+-- ตารางที่มีอยู่ก่อนแล้วถูกบันทึกใน tb_schema_adopted และ 0001_init.down ไม่ยอมลบตารางของฐานข้อมูลเดิม
+-- Trigger ที่มีอยู่แล้ว (เช่น Trigger สร้างรหัสของฐานข้อมูลเดิม) ไม่ถูกแทนที่ จึงสร้างผ่าน EXEC เฉพาะเมื่อยังไม่มี
+-- แต่ละ Batch คั่นด้วยบรรทัด GO
+
+IF OBJECT_ID(N'dbo.tb_schema_adopted', N'U') IS NULL
+CREATE TABLE dbo.tb_schema_adopted (
+    table_name   NVARCHAR(128) NOT NULL PRIMARY KEY,
+    adopt_date   DATETIME NOT NULL
+);
+GO
+
+INSERT INTO dbo.tb_schema_adopted (table_name, adopt_date)
+SELECT t.name, GETDATE()
+FROM sys.tables t
+WHERE t.name IN (
+    N'tb_users',
+    N'tb_user_session',
+    N'tb_user_recovery_code',
+    N'tb_api_key',
+    N'tb_reference',
+    N'tb_vendor_type',
+    N'tb_vendor',
+    N'tb_customer_type',
+    N'tb_customer',
+    N'tb_discount_type',
+    N'tb_discount',
+    N'tb_unit_type',
+    N'tb_product_category',
+    N'tb_product_group',
+    N'tb_product_format_type',
+    N'tb_warehouse',
+    N'tb_product',
+    N'tb_product_pack_config',
+    N'tb_receive_note',
+    N'tb_receive_item',
+    N'tb_order',
+    N'tb_order_item'
+)
+AND NOT EXISTS (SELECT 1 FROM dbo.tb_schema_adopted a WHERE a.table_name = t.name);
+GO
+
+IF OBJECT_ID(N'dbo.tb_users', N'U') IS NULL
+CREATE TABLE dbo.tb_users (
+    user_id          INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    user_name        NVARCHAR(50) NOT NULL UNIQUE,
+    user_password    NVARCHAR(255) NOT NULL,
+    user_role        NVARCHAR(255) NULL,
+    mfa_secret       NVARCHAR(64) NULL,
+    mfa_enabled      BIT NOT NULL DEFAULT 0,
+    mfa_last_step    BIGINT NULL,
+    mfa_enable_date  DATETIME NULL,
+    update_date      DATETIME NULL DEFAULT CAST(SYSDATETIMEOFFSET() AT TIME ZONE 'SE Asia Standard Time' AS DATETIME)
+);
+GO
+
+IF OBJECT_ID(N'dbo.tb_user_session', N'U') IS NULL
+CREATE TABLE dbo.tb_user_session (
+    session_id      VARCHAR(32) NOT NULL PRIMARY KEY,
+    user_name       NVARCHAR(50) NOT NULL,
+    user_agent      NVARCHAR(512) NULL,
+    ip_address      VARCHAR(45) NULL,
+    issue_date      DATETIME NOT NULL,
+    last_seen_date  DATETIME NOT NULL,
+    expire_date     DATETIME NOT NULL,
+    revoke_date     DATETIME NULL,
+    revoke_by       NVARCHAR(50) NULL
+);
+GO
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = N'ix_user_session_user')
+CREATE INDEX ix_user_session_user ON dbo.tb_user_session (user_name, revoke_date);
+GO
+
+IF OBJECT_ID(N'dbo.tb_user_recovery_code', N'U') IS NULL
+CREATE TABLE dbo.tb_user_recovery_code (
+    recovery_code_id  INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    user_name         NVARCHAR(50) NOT NULL,
+    code_hash         CHAR(64) NOT NULL,
+    create_date       DATETIME NOT NULL,
+    used_date         DATETIME NULL
+);
+GO
+
+IF OBJECT_ID(N'dbo.tb_api_key', N'U') IS NULL
+CREATE TABLE dbo.tb_api_key (
+    api_key_id      NVARCHAR(36) NOT NULL PRIMARY KEY DEFAULT LOWER(CONVERT(NVARCHAR(36), NEWID())),
+    key_name        NVARCHAR(255) NOT NULL,
+    key_prefix      NVARCHAR(16) NOT NULL,
+    key_hash        CHAR(64) NOT NULL UNIQUE,
+    scopes          NVARCHAR(1000) NOT NULL,
+    expire_date     DATETIME NULL,
+    last_used_date  DATETIME NULL,
+    revoke_date     DATETIME NULL,
+    description     NVARCHAR(500) NULL,
+    update_by       NVARCHAR(50) NULL,
+    update_date     DATETIME NULL DEFAULT CAST(SYSDATETIMEOFFSET() AT TIME ZONE 'SE Asia Standard Time' AS DATETIME),
+    is_active       BIT NOT NULL DEFAULT 1,
+    is_delete       BIT NOT NULL DEFAULT 0
+);
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_AUTO_UPDATE_DATE_API_KEY', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_AUTO_UPDATE_DATE_API_KEY ON dbo.tb_api_key
+AFTER UPDATE
+AS
+BEGIN
+    SET NOCOUNT ON;
+    IF TRIGGER_NESTLEVEL(@@PROCID) > 1 RETURN;
+    -- การใช้ Key (middleware บันทึก last_used_date) ไม่ใช่การแก้ไข
+    IF UPDATE(last_used_date) AND NOT UPDATE(update_by) AND NOT UPDATE(is_active) AND NOT UPDATE(is_delete) RETURN;
+    UPDATE t SET update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE ''SE Asia Standard Time'' AS DATETIME)
+    FROM dbo.tb_api_key t JOIN inserted i ON t.api_key_id = i.api_key_id;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.tb_reference', N'U') IS NULL
+CREATE TABLE dbo.tb_reference (
+    row_id       INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    ref_id       NVARCHAR(50) NOT NULL,
+    ref_int      INT NULL,
+    ref_text     NVARCHAR(255) NULL,
+    update_by    NVARCHAR(50) NULL,
+    update_date  DATETIME NULL DEFAULT CAST(SYSDATETIMEOFFSET() AT TIME ZONE 'SE Asia Standard Time' AS DATETIME)
+);
+GO
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = N'ix_reference_ref_id')
+CREATE INDEX ix_reference_ref_id ON dbo.tb_reference (ref_id);
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_AUTO_UPDATE_DATE_REFERENCE', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_AUTO_UPDATE_DATE_REFERENCE ON dbo.tb_reference
+AFTER UPDATE
+AS
+BEGIN
+    SET NOCOUNT ON;
+    IF TRIGGER_NESTLEVEL(@@PROCID) > 1 RETURN;
+    UPDATE t SET update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE ''SE Asia Standard Time'' AS DATETIME)
+    FROM dbo.tb_reference t JOIN inserted i ON t.row_id = i.row_id;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.tb_vendor_type', N'U') IS NULL
+CREATE TABLE dbo.tb_vendor_type (
+    autoID          INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    prefix          NVARCHAR(5) NOT NULL DEFAULT 'VT',
+    vendor_type_id  NVARCHAR(50) NULL UNIQUE,
+    type_name       NVARCHAR(255) NOT NULL,
+    description     NVARCHAR(500) NULL,
+    update_by       NVARCHAR(50) NULL,
+    update_date     DATETIME NULL,
+    is_active       BIT NOT NULL DEFAULT 1,
+    is_delete       BIT NOT NULL DEFAULT 0
+);
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_GENERATE_VENDOR_TYPE_ID', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_GENERATE_VENDOR_TYPE_ID ON dbo.tb_vendor_type
+AFTER INSERT
+AS
+BEGIN
+    SET NOCOUNT ON;
+    UPDATE t SET vendor_type_id = t.prefix + RIGHT(''000000'' + CAST(t.autoID AS NVARCHAR(10)), 6)
+    FROM dbo.tb_vendor_type t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_AUTO_UPDATE_DATE_VENDOR_TYPE', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_AUTO_UPDATE_DATE_VENDOR_TYPE ON dbo.tb_vendor_type
+AFTER UPDATE
+AS
+BEGIN
+    SET NOCOUNT ON;
+    IF TRIGGER_NESTLEVEL(@@PROCID) > 1 RETURN;
+    UPDATE t SET update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE ''SE Asia Standard Time'' AS DATETIME)
+    FROM dbo.tb_vendor_type t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.tb_vendor', N'U') IS NULL
+CREATE TABLE dbo.tb_vendor (
+    autoID           INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    prefix           NVARCHAR(5) NOT NULL DEFAULT 'VEN',
+    vendor_id        NVARCHAR(50) NULL UNIQUE,
+    vendor_type_id   NVARCHAR(50) NOT NULL REFERENCES dbo.tb_vendor_type (vendor_type_id),
+    vendor_name      NVARCHAR(255) NOT NULL,
+    tax_id           NVARCHAR(20) NULL,
+    branch_name      NVARCHAR(255) NULL,
+    contact_person   NVARCHAR(255) NULL,
+    phone1           NVARCHAR(20) NULL,
+    phone2           NVARCHAR(20) NULL,
+    email            NVARCHAR(255) NULL,
+    website          NVARCHAR(255) NULL,
+    address          NVARCHAR(500) NULL,
+    sub_district     NVARCHAR(100) NULL,
+    district         NVARCHAR(100) NULL,
+    province         NVARCHAR(100) NULL,
+    zip_code         NVARCHAR(10) NULL,
+    credit_term_day  INT NULL DEFAULT 30,
+    currency         NVARCHAR(3) NOT NULL DEFAULT 'THB',
+    note             NVARCHAR(500) NULL,
+    update_by        NVARCHAR(50) NULL,
+    update_date      DATETIME NULL,
+    is_active        BIT NOT NULL DEFAULT 1,
+    is_delete        BIT NOT NULL DEFAULT 0
+);
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_GENERATE_VENDOR_ID', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_GENERATE_VENDOR_ID ON dbo.tb_vendor
+AFTER INSERT
+AS
+BEGIN
+    SET NOCOUNT ON;
+    UPDATE t SET vendor_id = t.prefix + RIGHT(''000000'' + CAST(t.autoID AS NVARCHAR(10)), 6)
+    FROM dbo.tb_vendor t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_AUTO_UPDATE_DATE_VENDOR', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_AUTO_UPDATE_DATE_VENDOR ON dbo.tb_vendor
+AFTER UPDATE
+AS
+BEGIN
+    SET NOCOUNT ON;
+    IF TRIGGER_NESTLEVEL(@@PROCID) > 1 RETURN;
+    UPDATE t SET update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE ''SE Asia Standard Time'' AS DATETIME)
+    FROM dbo.tb_vendor t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.tb_customer_type', N'U') IS NULL
+CREATE TABLE dbo.tb_customer_type (
+    autoID              INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    prefix              NVARCHAR(5) NOT NULL DEFAULT 'CT',
+    customer_type_id    NVARCHAR(50) NULL UNIQUE,
+    customer_type_name  NVARCHAR(255) NOT NULL,
+    base_credit_day     INT NULL DEFAULT 0,
+    description         NVARCHAR(500) NULL,
+    update_by           NVARCHAR(50) NULL,
+    update_date         DATETIME NULL,
+    is_active           BIT NOT NULL DEFAULT 1,
+    is_delete           BIT NOT NULL DEFAULT 0
+);
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_GENERATE_CUSTOMER_TYPE_ID', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_GENERATE_CUSTOMER_TYPE_ID ON dbo.tb_customer_type
+AFTER INSERT
+AS
+BEGIN
+    SET NOCOUNT ON;
+    UPDATE t SET customer_type_id = t.prefix + RIGHT(''000000'' + CAST(t.autoID AS NVARCHAR(10)), 6)
+    FROM dbo.tb_customer_type t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_AUTO_UPDATE_DATE_CUSTOMER_TYPE', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_AUTO_UPDATE_DATE_CUSTOMER_TYPE ON dbo.tb_customer_type
+AFTER UPDATE
+AS
+BEGIN
+    SET NOCOUNT ON;
+    IF TRIGGER_NESTLEVEL(@@PROCID) > 1 RETURN;
+    UPDATE t SET update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE ''SE Asia Standard Time'' AS DATETIME)
+    FROM dbo.tb_customer_type t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.tb_customer', N'U') IS NULL
+CREATE TABLE dbo.tb_customer (
+    autoID            INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    prefix            NVARCHAR(5) NOT NULL DEFAULT 'CUS',
+    customer_id       NVARCHAR(50) NULL UNIQUE,
+    customer_type_id  NVARCHAR(50) NOT NULL REFERENCES dbo.tb_customer_type (customer_type_id),
+    customer_name     NVARCHAR(255) NOT NULL,
+    tax_id            NVARCHAR(20) NULL,
+    branch_name       NVARCHAR(255) NULL,
+    contact_person    NVARCHAR(255) NULL,
+    phone1            NVARCHAR(20) NULL,
+    phone2            NVARCHAR(20) NULL,
+    email             NVARCHAR(255) NULL,
+    line_id           NVARCHAR(100) NULL,
+    address           NVARCHAR(500) NULL,
+    sub_district      NVARCHAR(100) NULL,
+    district          NVARCHAR(100) NULL,
+    province          NVARCHAR(100) NULL,
+    zip_code          NVARCHAR(10) NULL,
+    credit_limit      DECIMAL(18, 2) NULL DEFAULT 0,
+    credit_term_day   INT NULL DEFAULT 0,
+    note              NVARCHAR(500) NULL,
+    update_by         NVARCHAR(50) NULL,
+    update_date       DATETIME NULL,
+    is_active         BIT NOT NULL DEFAULT 1,
+    is_delete         BIT NOT NULL DEFAULT 0
+);
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_GENERATE_CUSTOMER_ID', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_GENERATE_CUSTOMER_ID ON dbo.tb_customer
+AFTER INSERT
+AS
+BEGIN
+    SET NOCOUNT ON;
+    UPDATE t SET customer_id = t.prefix + RIGHT(''000000'' + CAST(t.autoID AS NVARCHAR(10)), 6)
+    FROM dbo.tb_customer t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_AUTO_UPDATE_DATE_CUSTOMER', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_AUTO_UPDATE_DATE_CUSTOMER ON dbo.tb_customer
+AFTER UPDATE
+AS
+BEGIN
+    SET NOCOUNT ON;
+    IF TRIGGER_NESTLEVEL(@@PROCID) > 1 RETURN;
+    UPDATE t SET update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE ''SE Asia Standard Time'' AS DATETIME)
+    FROM dbo.tb_customer t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.tb_discount_type', N'U') IS NULL
+CREATE TABLE dbo.tb_discount_type (
+    autoID              INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    prefix              NVARCHAR(5) NOT NULL DEFAULT 'DT',
+    discount_type_id    NVARCHAR(50) NULL UNIQUE,
+    discount_type_name  NVARCHAR(255) NOT NULL,
+    description         NVARCHAR(500) NULL,
+    update_by           NVARCHAR(50) NULL,
+    update_date         DATETIME NULL,
+    is_active           BIT NOT NULL DEFAULT 1,
+    is_delete           BIT NOT NULL DEFAULT 0
+);
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_GENERATE_DISCOUNT_TYPE_ID', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_GENERATE_DISCOUNT_TYPE_ID ON dbo.tb_discount_type
+AFTER INSERT
+AS
+BEGIN
+    SET NOCOUNT ON;
+    UPDATE t SET discount_type_id = t.prefix + RIGHT(''000000'' + CAST(t.autoID AS NVARCHAR(10)), 6)
+    FROM dbo.tb_discount_type t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_AUTO_UPDATE_DATE_DISCOUNT_TYPE', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_AUTO_UPDATE_DATE_DISCOUNT_TYPE ON dbo.tb_discount_type
+AFTER UPDATE
+AS
+BEGIN
+    SET NOCOUNT ON;
+    IF TRIGGER_NESTLEVEL(@@PROCID) > 1 RETURN;
+    UPDATE t SET update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE ''SE Asia Standard Time'' AS DATETIME)
+    FROM dbo.tb_discount_type t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.tb_discount', N'U') IS NULL
+CREATE TABLE dbo.tb_discount (
+    autoID            INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    prefix            NVARCHAR(5) NOT NULL DEFAULT 'DIS',
+    discount_id       NVARCHAR(50) NULL UNIQUE,
+    discount_type_id  NVARCHAR(50) NOT NULL REFERENCES dbo.tb_discount_type (discount_type_id),
+    discount_name     NVARCHAR(255) NOT NULL,
+    discount_code     NVARCHAR(50) NULL,
+    description       NVARCHAR(500) NULL,
+    discount_value    DECIMAL(18, 2) NULL DEFAULT 0,
+    is_percent        BIT NOT NULL DEFAULT 0,
+    min_order_amount  DECIMAL(18, 2) NULL,
+    start_date        DATETIME NULL,
+    end_date          DATETIME NULL,
+    update_by         NVARCHAR(50) NULL,
+    update_date       DATETIME NULL,
+    is_active         BIT NOT NULL DEFAULT 1,
+    is_delete         BIT NOT NULL DEFAULT 0
+);
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_GENERATE_DISCOUNT_ID', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_GENERATE_DISCOUNT_ID ON dbo.tb_discount
+AFTER INSERT
+AS
+BEGIN
+    SET NOCOUNT ON;
+    UPDATE t SET discount_id = t.prefix + RIGHT(''000000'' + CAST(t.autoID AS NVARCHAR(10)), 6)
+    FROM dbo.tb_discount t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_AUTO_UPDATE_DATE_DISCOUNT', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_AUTO_UPDATE_DATE_DISCOUNT ON dbo.tb_discount
+AFTER UPDATE
+AS
+BEGIN
+    SET NOCOUNT ON;
+    IF TRIGGER_NESTLEVEL(@@PROCID) > 1 RETURN;
+    UPDATE t SET update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE ''SE Asia Standard Time'' AS DATETIME)
+    FROM dbo.tb_discount t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.tb_unit_type', N'U') IS NULL
+CREATE TABLE dbo.tb_unit_type (
+    autoID          INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    prefix          NVARCHAR(5) NOT NULL DEFAULT 'UT',
+    unit_type_id    NVARCHAR(50) NULL UNIQUE,
+    unit_type_name  NVARCHAR(255) NOT NULL,
+    description     NVARCHAR(500) NULL,
+    update_by       NVARCHAR(50) NULL,
+    update_date     DATETIME NULL,
+    is_active       BIT NOT NULL DEFAULT 1,
+    is_delete       BIT NOT NULL DEFAULT 0
+);
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_GENERATE_UNIT_TYPE_ID', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_GENERATE_UNIT_TYPE_ID ON dbo.tb_unit_type
+AFTER INSERT
+AS
+BEGIN
+    SET NOCOUNT ON;
+    UPDATE t SET unit_type_id = t.prefix + RIGHT(''000000'' + CAST(t.autoID AS NVARCHAR(10)), 6)
+    FROM dbo.tb_unit_type t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_AUTO_UPDATE_DATE_UNIT_TYPE', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_AUTO_UPDATE_DATE_UNIT_TYPE ON dbo.tb_unit_type
+AFTER UPDATE
+AS
+BEGIN
+    SET NOCOUNT ON;
+    IF TRIGGER_NESTLEVEL(@@PROCID) > 1 RETURN;
+    UPDATE t SET update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE ''SE Asia Standard Time'' AS DATETIME)
+    FROM dbo.tb_unit_type t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.tb_product_category', N'U') IS NULL
+CREATE TABLE dbo.tb_product_category (
+    autoID               INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    prefix               NVARCHAR(5) NOT NULL DEFAULT 'PC',
+    product_category_id  NVARCHAR(50) NULL UNIQUE,
+    category_name        NVARCHAR(255) NOT NULL,
+    category_code        NVARCHAR(50) NULL,
+    description          NVARCHAR(500) NULL,
+    update_by            NVARCHAR(50) NULL,
+    update_date          DATETIME NULL,
+    is_active            BIT NOT NULL DEFAULT 1,
+    is_delete            BIT NOT NULL DEFAULT 0
+);
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_GENERATE_PRODUCT_CATEGORY_ID', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_GENERATE_PRODUCT_CATEGORY_ID ON dbo.tb_product_category
+AFTER INSERT
+AS
+BEGIN
+    SET NOCOUNT ON;
+    UPDATE t SET product_category_id = t.prefix + RIGHT(''000000'' + CAST(t.autoID AS NVARCHAR(10)), 6)
+    FROM dbo.tb_product_category t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_AUTO_UPDATE_DATE_PRODUCT_CATEGORY', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_AUTO_UPDATE_DATE_PRODUCT_CATEGORY ON dbo.tb_product_category
+AFTER UPDATE
+AS
+BEGIN
+    SET NOCOUNT ON;
+    IF TRIGGER_NESTLEVEL(@@PROCID) > 1 RETURN;
+    UPDATE t SET update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE ''SE Asia Standard Time'' AS DATETIME)
+    FROM dbo.tb_product_category t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.tb_product_group', N'U') IS NULL
+CREATE TABLE dbo.tb_product_group (
+    autoID               INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    prefix               NVARCHAR(5) NOT NULL DEFAULT 'PTG',
+    product_group_id     NVARCHAR(50) NULL UNIQUE,
+    product_category_id  NVARCHAR(50) NOT NULL REFERENCES dbo.tb_product_category (product_category_id),
+    product_group_name   NVARCHAR(255) NOT NULL,
+    description          NVARCHAR(500) NULL,
+    update_by            NVARCHAR(50) NULL,
+    update_date          DATETIME NULL,
+    is_active            BIT NOT NULL DEFAULT 1,
+    is_delete            BIT NOT NULL DEFAULT 0
+);
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_GENERATE_PRODUCT_GROUP_ID', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_GENERATE_PRODUCT_GROUP_ID ON dbo.tb_product_group
+AFTER INSERT
+AS
+BEGIN
+    SET NOCOUNT ON;
+    UPDATE t SET product_group_id = t.prefix + RIGHT(''000000'' + CAST(t.autoID AS NVARCHAR(10)), 6)
+    FROM dbo.tb_product_group t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_AUTO_UPDATE_DATE_PRODUCT_GROUP', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_AUTO_UPDATE_DATE_PRODUCT_GROUP ON dbo.tb_product_group
+AFTER UPDATE
+AS
+BEGIN
+    SET NOCOUNT ON;
+    IF TRIGGER_NESTLEVEL(@@PROCID) > 1 RETURN;
+    UPDATE t SET update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE ''SE Asia Standard Time'' AS DATETIME)
+    FROM dbo.tb_product_group t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.tb_product_format_type', N'U') IS NULL
+CREATE TABLE dbo.tb_product_format_type (
+    autoID                  INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    prefix                  NVARCHAR(5) NOT NULL DEFAULT 'PF',
+    product_format_type_id  NVARCHAR(50) NULL UNIQUE,
+    format_name             NVARCHAR(255) NOT NULL,
+    description             NVARCHAR(500) NULL,
+    update_by               NVARCHAR(50) NULL,
+    update_date             DATETIME NULL,
+    is_active               BIT NOT NULL DEFAULT 1,
+    is_delete               BIT NOT NULL DEFAULT 0
+);
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_GENERATE_PRODUCT_FORMAT_TYPE_ID', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_GENERATE_PRODUCT_FORMAT_TYPE_ID ON dbo.tb_product_format_type
+AFTER INSERT
+AS
+BEGIN
+    SET NOCOUNT ON;
+    UPDATE t SET product_format_type_id = t.prefix + RIGHT(''000000'' + CAST(t.autoID AS NVARCHAR(10)), 6)
+    FROM dbo.tb_product_format_type t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_AUTO_UPDATE_DATE_PRODUCT_FORMAT_TYPE', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_AUTO_UPDATE_DATE_PRODUCT_FORMAT_TYPE ON dbo.tb_product_format_type
+AFTER UPDATE
+AS
+BEGIN
+    SET NOCOUNT ON;
+    IF TRIGGER_NESTLEVEL(@@PROCID) > 1 RETURN;
+    UPDATE t SET update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE ''SE Asia Standard Time'' AS DATETIME)
+    FROM dbo.tb_product_format_type t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.tb_warehouse', N'U') IS NULL
+CREATE TABLE dbo.tb_warehouse (
+    autoID                INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    prefix                NVARCHAR(5) NOT NULL DEFAULT 'WH',
+    warehouse_id          NVARCHAR(50) NULL UNIQUE,
+    warehouse_code        NVARCHAR(50) NULL,
+    warehouse_name        NVARCHAR(255) NOT NULL,
+    description           NVARCHAR(500) NULL,
+    is_main_dc            BIT NOT NULL DEFAULT 0,
+    allow_negative_stock  BIT NOT NULL DEFAULT 0,
+    update_by             NVARCHAR(50) NULL,
+    update_date           DATETIME NULL,
+    is_active             BIT NOT NULL DEFAULT 1,
+    is_delete             BIT NOT NULL DEFAULT 0
+);
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_GENERATE_WAREHOUSE_ID', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_GENERATE_WAREHOUSE_ID ON dbo.tb_warehouse
+AFTER INSERT
+AS
+BEGIN
+    SET NOCOUNT ON;
+    UPDATE t SET warehouse_id = t.prefix + RIGHT(''000000'' + CAST(t.autoID AS NVARCHAR(10)), 6)
+    FROM dbo.tb_warehouse t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_AUTO_UPDATE_DATE_WAREHOUSE', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_AUTO_UPDATE_DATE_WAREHOUSE ON dbo.tb_warehouse
+AFTER UPDATE
+AS
+BEGIN
+    SET NOCOUNT ON;
+    IF TRIGGER_NESTLEVEL(@@PROCID) > 1 RETURN;
+    UPDATE t SET update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE ''SE Asia Standard Time'' AS DATETIME)
+    FROM dbo.tb_warehouse t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.tb_product', N'U') IS NULL
+CREATE TABLE dbo.tb_product (
+    autoID           INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    prefix           NVARCHAR(5) NOT NULL DEFAULT 'PDT',
+    product_id       NVARCHAR(50) NULL UNIQUE,
+    product_name_th  NVARCHAR(255) NOT NULL,
+    product_name_en  NVARCHAR(255) NULL,
+    product_name     AS COALESCE(product_name_th, product_name_en),
+    product_type_id  NVARCHAR(50) NOT NULL REFERENCES dbo.tb_product_group (product_group_id),
+    format_type_id   NVARCHAR(50) NULL REFERENCES dbo.tb_product_format_type (product_format_type_id),
+    vendor_id        NVARCHAR(50) NULL REFERENCES dbo.tb_vendor (vendor_id),
+    unit_type_id     NVARCHAR(50) NULL REFERENCES dbo.tb_unit_type (unit_type_id),
+    isbn             NVARCHAR(20) NULL,
+    author_name      NVARCHAR(255) NULL,
+    publisher_date   DATE NULL,
+    edition_number   INT NULL,
+    price            DECIMAL(18, 2) NULL DEFAULT 0,
+    cost             DECIMAL(18, 2) NULL DEFAULT 0,
+    description      NVARCHAR(500) NULL,
+    note             NVARCHAR(500) NULL,
+    count_stock      BIT NOT NULL DEFAULT 1,
+    update_by        NVARCHAR(50) NULL,
+    update_date      DATETIME NULL,
+    is_active        BIT NOT NULL DEFAULT 1,
+    is_delete        BIT NOT NULL DEFAULT 0
+);
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_GENERATE_PRODUCT_ID', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_GENERATE_PRODUCT_ID ON dbo.tb_product
+AFTER INSERT
+AS
+BEGIN
+    SET NOCOUNT ON;
+    UPDATE t SET product_id = t.prefix + RIGHT(''000000'' + CAST(t.autoID AS NVARCHAR(10)), 6)
+    FROM dbo.tb_product t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_AUTO_UPDATE_DATE_PRODUCT', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_AUTO_UPDATE_DATE_PRODUCT ON dbo.tb_product
+AFTER UPDATE
+AS
+BEGIN
+    SET NOCOUNT ON;
+    IF TRIGGER_NESTLEVEL(@@PROCID) > 1 RETURN;
+    UPDATE t SET update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE ''SE Asia Standard Time'' AS DATETIME)
+    FROM dbo.tb_product t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.tb_product_pack_config', N'U') IS NULL
+CREATE TABLE dbo.tb_product_pack_config (
+    autoID                  INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    prefix                  NVARCHAR(5) NOT NULL DEFAULT 'PPC',
+    product_pack_config_id  NVARCHAR(50) NULL UNIQUE,
+    product_id              NVARCHAR(50) NOT NULL REFERENCES dbo.tb_product (product_id),
+    bundle_qty              INT NOT NULL,
+    unit_type_id            NVARCHAR(50) NOT NULL REFERENCES dbo.tb_unit_type (unit_type_id),
+    note                    NVARCHAR(500) NULL,
+    update_by               NVARCHAR(50) NULL,
+    update_date             DATETIME NULL,
+    id_status               BIT NOT NULL DEFAULT 1,
+    is_delete               BIT NOT NULL DEFAULT 0
+);
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_GENERATE_PRODUCT_PACK_CONFIG_ID', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_GENERATE_PRODUCT_PACK_CONFIG_ID ON dbo.tb_product_pack_config
+AFTER INSERT
+AS
+BEGIN
+    SET NOCOUNT ON;
+    UPDATE t SET product_pack_config_id = t.prefix + RIGHT(''000000'' + CAST(t.autoID AS NVARCHAR(10)), 6)
+    FROM dbo.tb_product_pack_config t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_AUTO_UPDATE_DATE_PRODUCT_PACK_CONFIG', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_AUTO_UPDATE_DATE_PRODUCT_PACK_CONFIG ON dbo.tb_product_pack_config
+AFTER UPDATE
+AS
+BEGIN
+    SET NOCOUNT ON;
+    IF TRIGGER_NESTLEVEL(@@PROCID) > 1 RETURN;
+    UPDATE t SET update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE ''SE Asia Standard Time'' AS DATETIME)
+    FROM dbo.tb_product_pack_config t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.tb_receive_note', N'U') IS NULL
+CREATE TABLE dbo.tb_receive_note (
+    autoID           INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    prefix           NVARCHAR(5) NOT NULL DEFAULT 'RCV',
+    receive_note_id  NVARCHAR(50) NULL UNIQUE,
+    vendor_id        NVARCHAR(50) NOT NULL REFERENCES dbo.tb_vendor (vendor_id),
+    warehouse_id     NVARCHAR(50) NOT NULL REFERENCES dbo.tb_warehouse (warehouse_id),
+    doc_date         DATETIME NOT NULL,
+    ref_invoice_no   NVARCHAR(50) NULL,
+    receive_type     NVARCHAR(20) NOT NULL,
+    total_amount     DECIMAL(18, 2) NULL DEFAULT 0,
+    note             NVARCHAR(500) NULL,
+    update_by        NVARCHAR(50) NULL,
+    update_date      DATETIME NULL,
+    is_active        BIT NOT NULL DEFAULT 1,
+    is_delete        BIT NOT NULL DEFAULT 0
+);
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_GENERATE_RECEIVE_NOTE_ID', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_GENERATE_RECEIVE_NOTE_ID ON dbo.tb_receive_note
+AFTER INSERT
+AS
+BEGIN
+    SET NOCOUNT ON;
+    UPDATE t SET receive_note_id = t.prefix + RIGHT(''000000'' + CAST(t.autoID AS NVARCHAR(10)), 6)
+    FROM dbo.tb_receive_note t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_AUTO_UPDATE_DATE_RECEIVE_NOTE', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_AUTO_UPDATE_DATE_RECEIVE_NOTE ON dbo.tb_receive_note
+AFTER UPDATE
+AS
+BEGIN
+    SET NOCOUNT ON;
+    IF TRIGGER_NESTLEVEL(@@PROCID) > 1 RETURN;
+    UPDATE t SET update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE ''SE Asia Standard Time'' AS DATETIME)
+    FROM dbo.tb_receive_note t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.tb_receive_item', N'U') IS NULL
+CREATE TABLE dbo.tb_receive_item (
+    auto_id          INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    receive_note_id  NVARCHAR(50) NOT NULL REFERENCES dbo.tb_receive_note (receive_note_id),
+    product_id       NVARCHAR(50) NOT NULL REFERENCES dbo.tb_product (product_id),
+    qty              DECIMAL(18, 3) NOT NULL,
+    unit_cost        DECIMAL(18, 2) NULL DEFAULT 0,
+    line_total       DECIMAL(18, 2) NULL DEFAULT 0,
+    remark           NVARCHAR(500) NULL,
+    update_date      DATETIME NULL DEFAULT CAST(SYSDATETIMEOFFSET() AT TIME ZONE 'SE Asia Standard Time' AS DATETIME),
+    is_delete        BIT NOT NULL DEFAULT 0
+);
+GO
+
+IF OBJECT_ID(N'dbo.tb_order', N'U') IS NULL
+CREATE TABLE dbo.tb_order (
+    autoID           INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    prefix           NVARCHAR(5) NOT NULL DEFAULT 'ORD',
+    order_id         NVARCHAR(50) NULL UNIQUE,
+    customer_id      NVARCHAR(50) NOT NULL REFERENCES dbo.tb_customer (customer_id),
+    warehouse_id     NVARCHAR(50) NOT NULL REFERENCES dbo.tb_warehouse (warehouse_id),
+    doc_date         DATETIME NOT NULL,
+    doc_type         NVARCHAR(20) NOT NULL,
+    total_amount     DECIMAL(18, 2) NULL DEFAULT 0,
+    discount_amount  DECIMAL(18, 2) NULL DEFAULT 0,
+    net_amount       DECIMAL(18, 2) NULL DEFAULT 0,
+    vat_amount       DECIMAL(18, 2) NULL DEFAULT 0,
+    grand_total      DECIMAL(18, 2) NULL DEFAULT 0,
+    update_by        NVARCHAR(50) NULL,
+    update_date      DATETIME NULL,
+    is_active        BIT NOT NULL DEFAULT 1,
+    is_delete        BIT NOT NULL DEFAULT 0
+);
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_GENERATE_ORDER_ID', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_GENERATE_ORDER_ID ON dbo.tb_order
+AFTER INSERT
+AS
+BEGIN
+    SET NOCOUNT ON;
+    UPDATE t SET order_id = t.prefix + RIGHT(''000000'' + CAST(t.autoID AS NVARCHAR(10)), 6)
+    FROM dbo.tb_order t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.TRIG_AUTO_UPDATE_DATE_ORDER', N'TR') IS NULL
+EXEC (N'CREATE TRIGGER dbo.TRIG_AUTO_UPDATE_DATE_ORDER ON dbo.tb_order
+AFTER UPDATE
+AS
+BEGIN
+    SET NOCOUNT ON;
+    IF TRIGGER_NESTLEVEL(@@PROCID) > 1 RETURN;
+    UPDATE t SET update_date = CAST(SYSDATETIMEOFFSET() AT TIME ZONE ''SE Asia Standard Time'' AS DATETIME)
+    FROM dbo.tb_order t JOIN inserted i ON t.autoID = i.autoID;
+END');
+GO
+
+IF OBJECT_ID(N'dbo.tb_order_item', N'U') IS NULL
+CREATE TABLE dbo.tb_order_item (
+    auto_id          INT IDENTITY(1,1) NOT NULL PRIMARY KEY,
+    order_id         NVARCHAR(50) NOT NULL REFERENCES dbo.tb_order (order_id),
+    product_id       NVARCHAR(50) NOT NULL REFERENCES dbo.tb_product (product_id),
+    qty              DECIMAL(18, 3) NOT NULL,
+    unit_price       DECIMAL(18, 2) NULL DEFAULT 0,
+    discount_amount  DECIMAL(18, 2) NULL DEFAULT 0,
+    line_total       DECIMAL(18, 2) NULL DEFAULT 0,
+    remark           NVARCHAR(500) NULL,
+    update_date      DATETIME NULL DEFAULT CAST(SYSDATETIMEOFFSET() AT TIME ZONE 'SE Asia Standard Time' AS DATETIME),
+    is_delete        BIT NOT NULL DEFAULT 0
+);
+GO
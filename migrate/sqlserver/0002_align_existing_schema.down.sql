@@ -0,0 +1,3 @@
+-- 0002 เพิ่มเฉพาะคอลัมน์ที่ไม่มี (ไม่เปลี่ยนชื่อหรือลบคอลัมน์เดิม) ฐานข้อมูลที่ 0001 สร้างเองมีคอลัมน์เหล่านี้ตั้งแต่แรก
+-- ส่วนฐานข้อมูลเดิม (tb_schema_adopted มีรายการ) คอลัมน์ที่เพิ่มเป็น NULL หรือมีค่าเริ่มต้นจึงคงไว้ได้โดยไม่กระทบระบบเดิม
+-- การย้อนจึงไม่ต้องทำอะไร นอกจากลบรุ่นออกจาก tb_schema_migration
@@ -0,0 +1,18 @@
+-- เพิ่มคอลัมน์ที่ Repository ใช้ให้ฐานข้อมูลที่สร้างไว้ก่อนมี migration (0001 ข้ามตารางที่มีอยู่แล้ว)
+-- ฐานข้อมูลที่สร้างจาก 0001 มีคอลัมน์เหล่านี้ครบแล้ว ทุกคำสั่งจึงทำงานเฉพาะเมื่อยังไม่มีคอลัมน์
+-- ไม่เปลี่ยนชื่อคอลัมน์เดิมของ tb_product (SQL v2.2) เพราะกระทบรายงานและ ETL ภายนอก
+-- ให้สั่งเองด้วย go run . migrate legacy-columns apply หลัง Backup (ดู migrate/legacy.go)
+
+-- tb_product: ชื่อสินค้าสำหรับค้นหา
+IF COL_LENGTH(N'dbo.tb_product', N'product_name') IS NULL
+    ALTER TABLE dbo.tb_product ADD product_name AS COALESCE(product_name_th, product_name_en);
+
+-- tb_users: บทบาทและ MFA (เดิมต้อง ALTER TABLE เองตาม README)
+IF COL_LENGTH(N'dbo.tb_users', N'user_role') IS NULL
+    ALTER TABLE dbo.tb_users ADD user_role NVARCHAR(255) NULL;
+IF COL_LENGTH(N'dbo.tb_users', N'mfa_secret') IS NULL
+    ALTER TABLE dbo.tb_users ADD
+        mfa_secret      NVARCHAR(64) NULL,
+        mfa_enabled     BIT          NOT NULL DEFAULT 0,
+        mfa_enable_date DATETIME     NULL,
+        mfa_last_step   BIGINT       NULL;
@@ -0,0 +1,18 @@
+package models
+
+// ApiKey represents a machine-to-machine credential stored in tb_api_key.
+// Only the SHA-256 hash of the key is persisted; the plain key is returned once on insert.
+type ApiKey struct {
+	ApiKeyID     string   `json:"api_key_id"`
+	KeyName      string   `json:"key_name" validate:"required,max=255"`
+	KeyPrefix    string   `json:"key_prefix"`
+	Scopes       []string `json:"scopes" validate:"required"`
+	ExpireDate   *string  `json:"expire_date"`
+	LastUsedDate *string  `json:"last_used_date,omitempty"`
+	RevokeDate   *string  `json:"revoke_date,omitempty"`
+	Description  *string  `json:"description"`
+	UpdateBy     *string  `json:"update_by"`
+	UpdateDate   *string  `json:"update_date"`
+	IsActive     *bool    `json:"is_active,omitempty"`
+	IsDelete     bool     `json:"is_delete"`
+}
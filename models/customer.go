@@ -2,22 +2,22 @@ package models
 
 type Customer struct {
 	CustomerID     string   `json:"customer_id"`
-	CustomerTypeID string   `json:"customer_type_id"`
-	CustomerName   string   `json:"customer_name"`
-	TaxID          *string  `json:"tax_id"`
+	CustomerTypeID string   `json:"customer_type_id" validate:"required,max=50" ref:"tb_customer_type.customer_type_id"`
+	CustomerName   string   `json:"customer_name" validate:"required,max=255"`
+	TaxID          *string  `json:"tax_id" validate:"taxid"`
 	BranchName     *string  `json:"branch_name"`
 	ContactPerson  *string  `json:"contact_person"`
 	Phone1         *string  `json:"phone1"`
 	Phone2         *string  `json:"phone2"`
-	Email          *string  `json:"email"`
+	Email          *string  `json:"email" validate:"max=255,email"`
 	LineID         *string  `json:"line_id"`
 	Address        *string  `json:"address"`
 	SubDistrict    *string  `json:"sub_district"`
 	District       *string  `json:"district"`
 	Province       *string  `json:"province"`
-	ZipCode        *string  `json:"zip_code"`
-	CreditLimit    *float64 `json:"credit_limit"`
-	CreditTermDay  *int     `json:"credit_term_day"`
+	ZipCode        *string  `json:"zip_code" validate:"zip"`
+	CreditLimit    *float64 `json:"credit_limit" validate:"gte=0"`
+	CreditTermDay  *int     `json:"credit_term_day" validate:"gte=0"`
 	Note           *string  `json:"note"`
 	UpdateBy       *string  `json:"update_by"`
 	UpdateDate     *string  `json:"update_date"`
@@ -2,8 +2,8 @@ package models
 
 type CustomerType struct {
 	CustomerTypeID string  `json:"customer_type_id"`
-	CustomerTypeName string  `json:"customer_type_name"`
-	BaseCreditDay    *int    `json:"base_credit_day"`
+	CustomerTypeName string  `json:"customer_type_name" validate:"required,max=255"`
+	BaseCreditDay    *int    `json:"base_credit_day" validate:"gte=0"`
 	Description      *string `json:"description"`
 	UpdateBy       *string `json:"update_by"`
 	UpdateDate     *string `json:"update_date"`
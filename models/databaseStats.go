@@ -0,0 +1,21 @@
+package models
+
+// DatabaseStats คือสถานะของฐานข้อมูลและ Connection Pool (จาก sql.DBStats) สำหรับ Admin
+type DatabaseStats struct {
+	Driver             string `json:"driver"`
+	Reachable          bool   `json:"reachable"`
+	PingMS             int64  `json:"ping_ms"`
+	MaxOpenConnections int    `json:"max_open_connections"` // 0 = ไม่จำกัด
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`       // จำนวน Request ที่ต้องรอ Connection ว่าง
+	WaitDurationMS     int64  `json:"wait_duration_ms"` // เวลารอรวมทั้งหมด
+	MaxIdleClosed      int64  `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64  `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64  `json:"max_lifetime_closed"`
+
+	// Replica คือสถานะของ Read Replica (ไม่มีหากไม่ได้ตั้งค่า DB_READ_HOST)
+	// ระหว่างที่ Replica ไม่พร้อมใช้งาน Select จะอ่านจากฐานข้อมูลหลักแทน
+	Replica *DatabaseStats `json:"replica,omitempty"`
+}
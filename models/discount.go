@@ -2,13 +2,13 @@ package models
 
 type Discount struct {
 	DiscountID     string   `json:"discount_id"`
-	DiscountTypeID string   `json:"discount_type_id"`
-	DiscountName   string   `json:"discount_name"`
-	DiscountCode   *string  `json:"discount_code"`
+	DiscountTypeID string   `json:"discount_type_id" validate:"required,max=50" ref:"tb_discount_type.discount_type_id"`
+	DiscountName   string   `json:"discount_name" validate:"required,max=255"`
+	DiscountCode   *string  `json:"discount_code" validate:"max=50"`
 	Description    *string  `json:"description"`
-	DiscountValue  float64  `json:"discount_value"`
+	DiscountValue  float64  `json:"discount_value" validate:"gte=0"`
 	IsPercent      bool     `json:"is_percent"`
-	MinOrderAmount *float64 `json:"min_order_amount"`
+	MinOrderAmount *float64 `json:"min_order_amount" validate:"gte=0"`
 	StartDate      *string  `json:"start_date"`
 	EndDate        *string  `json:"end_date"`
 	UpdateBy       *string  `json:"update_by"`
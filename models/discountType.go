@@ -2,7 +2,7 @@ package models
 
 type DiscountType struct {
 	DiscountTypeID   string  `json:"discount_type_id"`
-	DiscountTypeName string  `json:"discount_type_name"`
+	DiscountTypeName string  `json:"discount_type_name" validate:"required,max=255"`
 	Description      *string `json:"description"`
 	UpdateBy         *string `json:"update_by"`
 	UpdateDate       *string `json:"update_date"`
@@ -6,15 +6,15 @@ type Order struct {
 	AutoID         int        `json:"auto_id"`
 	Prefix         string     `json:"prefix"`
 	OrderID        string     `json:"order_id"`
-	CustomerID     string     `json:"customer_id"`
-	WarehouseID    string     `json:"warehouse_id"`
-	DocDate        time.Time  `json:"doc_date"`
-	DocType        string     `json:"doc_type"` // CASH, CREDIT
-	TotalAmount    float64    `json:"total_amount"`
-	DiscountAmount float64    `json:"discount_amount"`
-	NetAmount      float64    `json:"net_amount"`
-	VatAmount      float64    `json:"vat_amount"`
-	GrandTotal     float64    `json:"grand_total"`
+	CustomerID     string     `json:"customer_id" validate:"required,max=50" ref:"tb_customer.customer_id"`
+	WarehouseID    string     `json:"warehouse_id" validate:"required,max=50" ref:"tb_warehouse.warehouse_id"`
+	DocDate        time.Time  `json:"doc_date" validate:"required"`
+	DocType        string     `json:"doc_type" validate:"required,oneof=CASH CREDIT"` // CASH, CREDIT
+	TotalAmount    float64    `json:"total_amount" validate:"gte=0"`
+	DiscountAmount float64    `json:"discount_amount" validate:"gte=0"`
+	NetAmount      float64    `json:"net_amount" validate:"gte=0"`
+	VatAmount      float64    `json:"vat_amount" validate:"gte=0"`
+	GrandTotal     float64    `json:"grand_total" validate:"gte=0"`
 	UpdateBy       string     `json:"update_by"`
 	UpdateDate     time.Time  `json:"update_date"`
 	IsActive       bool       `json:"is_active"`
@@ -27,11 +27,11 @@ type Order struct {
 type OrderItem struct {
 	AutoID         int        `json:"auto_id"`
 	OrderID        string     `json:"order_id"`
-	ProductID      string     `json:"product_id"`
-	Qty            float64    `json:"qty"`
-	UnitPrice      float64    `json:"unit_price"`
-	DiscountAmount float64    `json:"discount_amount"`
-	LineTotal      float64    `json:"line_total"`
+	ProductID      string     `json:"product_id" validate:"required,max=50" ref:"tb_product.product_id"`
+	Qty            float64    `json:"qty" validate:"gt=0"`
+	UnitPrice      float64    `json:"unit_price" validate:"gte=0"`
+	DiscountAmount float64    `json:"discount_amount" validate:"gte=0"`
+	LineTotal      float64    `json:"line_total" validate:"gte=0"`
 	Remark         *string    `json:"remark"`
 	UpdateDate     *time.Time `json:"update_date"`
 	IsDelete       bool       `json:"is_delete"`
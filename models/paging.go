@@ -0,0 +1,14 @@
+package models
+
+// Page คือรูปแบบ Response มาตรฐานของ Select Page ทุก Module (ใช้กับ /api/v2)
+// Total และ TotalPages เป็น null เมื่อไม่ได้นับ total (?total=false หรือ Cursor Mode)
+// Page เป็น 0 และมี NextCursor แทนเมื่อใช้ Cursor Mode
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	Total      *int   `json:"total"`
+	TotalPages *int   `json:"total_pages"`
+	HasNext    bool   `json:"has_next"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
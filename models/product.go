@@ -6,18 +6,18 @@ type Product struct {
 	AutoID         int       `json:"auto_id"`
 	Prefix         string    `json:"prefix"`
 	ProductID      string    `json:"product_id"`
-	ProductNameTH  string    `json:"product_name_th"`
+	ProductNameTH  string    `json:"product_name_th" validate:"required,max=255"`
 	ProductNameEN  *string   `json:"product_name_en"`
-	ProductTypeID  string    `json:"product_type_id"` // This is technically product_group_id in v2.2 SQL. I should rename it to match SQL `product_group_id`.
-	FormatTypeID   *string   `json:"format_type_id"` // SQL: product_format_type_id. Go: format_type_id. Close enough but inconsistency.
-	VendorID       *string   `json:"vendor_id"`
-	UnitTypeID     *string   `json:"unit_type_id"`
-	ISBN           *string   `json:"isbn"`
+	ProductTypeID  string    `json:"product_type_id" validate:"required,max=50" ref:"tb_product_group.product_group_id"` // SQL v2.2 ใช้ product_group_id (เปลี่ยนชื่อด้วย migrate legacy-columns apply)
+	FormatTypeID   *string   `json:"format_type_id" ref:"tb_product_format_type.product_format_type_id"` // SQL v2.2 ใช้ product_format_type_id (เปลี่ยนชื่อด้วย migrate legacy-columns)
+	VendorID       *string   `json:"vendor_id" ref:"tb_vendor.vendor_id"`
+	UnitTypeID     *string   `json:"unit_type_id" ref:"tb_unit_type.unit_type_id"`
+	ISBN           *string   `json:"isbn" validate:"isbn"`
 	AuthorName     *string   `json:"author_name"`
 	PublisherDate  *string   `json:"publisher_date"`
-	EditionNumber  *int      `json:"edition_number"`
-	Price          float64   `json:"price"` // SQL: sell_price. Go value: price.
-	Cost           float64   `json:"cost"`  // SQL: cost_price. Go value: cost.
+	EditionNumber  *int      `json:"edition_number" validate:"gte=0"`
+	Price          float64   `json:"price" validate:"gte=0"` // SQL v2.2 ใช้ sell_price (เปลี่ยนชื่อด้วย migrate legacy-columns)
+	Cost           float64   `json:"cost" validate:"gte=0"`  // SQL v2.2 ใช้ cost_price (เปลี่ยนชื่อด้วย migrate legacy-columns)
 	Description    *string   `json:"description"`
 	Note           *string   `json:"note"`
 	CountStock     bool      `json:"count_stock"` // SQL: count_stock (1=stock, 0=service).
@@ -2,8 +2,8 @@ package models
 
 type ProductCategory struct {
 	ProductCategoryID string  `json:"product_category_id"`
-	CategoryName      string  `json:"category_name"`
-	CategoryCode      string  `json:"category_code"`
+	CategoryName      string  `json:"category_name" validate:"required,max=255"`
+	CategoryCode      string  `json:"category_code" validate:"max=50"`
 	Description       *string `json:"description,omitempty"`
 	UpdateBy          *string `json:"update_by,omitempty"`
 	UpdateDate        *string `json:"update_date,omitempty"`
@@ -2,7 +2,7 @@ package models
 
 type ProductFormatType struct {
 	ProductFormatTypeID string  `json:"product_format_type_id"`
-	FormatName          string  `json:"format_name"`
+	FormatName          string  `json:"format_name" validate:"required,max=255"`
 	Description         *string `json:"description,omitempty"`
 	UpdateBy            *string `json:"update_by,omitempty"`
 	UpdateDate          *string `json:"update_date,omitempty"`
@@ -2,8 +2,8 @@ package models
 
 type ProductGroup struct {
 	ProductGroupID    string  `json:"product_group_id"`
-	ProductCategoryID string  `json:"product_category_id"`
-	ProductGroupName  string  `json:"product_group_name"`
+	ProductCategoryID string  `json:"product_category_id" validate:"required,max=50" ref:"tb_product_category.product_category_id"`
+	ProductGroupName  string  `json:"product_group_name" validate:"required,max=255"`
 	Description       *string `json:"description"`
 	UpdateBy          *string `json:"update_by"`
 	UpdateDate        *string `json:"update_date"`
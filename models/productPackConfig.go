@@ -3,9 +3,9 @@ package models
 type ProductPackConfig struct {
 	AutoID              int    `json:"auto_id"`
 	ProductPackConfigID string `json:"product_pack_config_id"`
-	ProductID           string `json:"product_id"`
-	BundleQty           int    `json:"bundle_qty"`
-	UnitTypeID          string `json:"unit_type_id"`
+	ProductID           string `json:"product_id" validate:"required,max=50" ref:"tb_product.product_id"`
+	BundleQty           int    `json:"bundle_qty" validate:"gte=1"`
+	UnitTypeID          string `json:"unit_type_id" validate:"required,max=50" ref:"tb_unit_type.unit_type_id"`
 	Note                string `json:"note"`
 	UpdateBy            string `json:"update_by"`
 	UpdateDate          string `json:"update_date"`
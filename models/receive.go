@@ -6,12 +6,12 @@ type ReceiveNote struct {
 	AutoID        int        `json:"auto_id"`
 	Prefix        string     `json:"prefix"`
 	ReceiveNoteID string     `json:"receive_note_id"`
-	VendorID      string     `json:"vendor_id"`
-	WarehouseID   string     `json:"warehouse_id"`
-	DocDate       time.Time  `json:"doc_date"`
+	VendorID      string     `json:"vendor_id" validate:"required,max=50" ref:"tb_vendor.vendor_id"`
+	WarehouseID   string     `json:"warehouse_id" validate:"required,max=50" ref:"tb_warehouse.warehouse_id"`
+	DocDate       time.Time  `json:"doc_date" validate:"required"`
 	RefInvoiceNo  *string    `json:"ref_invoice_no"`
-	ReceiveType   string     `json:"receive_type"` // PO, GIFT, RETURN
-	TotalAmount   float64    `json:"total_amount"`
+	ReceiveType   string     `json:"receive_type" validate:"required,oneof=PO GIFT RETURN"` // PO, GIFT, RETURN
+	TotalAmount   float64    `json:"total_amount" validate:"gte=0"`
 	Note          *string    `json:"note"`
 	UpdateBy      string     `json:"update_by"`
 	UpdateDate    time.Time  `json:"update_date"`
@@ -25,10 +25,10 @@ type ReceiveNote struct {
 type ReceiveItem struct {
 	AutoID        int        `json:"auto_id"`
 	ReceiveNoteID string     `json:"receive_note_id"`
-	ProductID     string     `json:"product_id"`
-	Qty           float64    `json:"qty"`
-	UnitCost      float64    `json:"unit_cost"`
-	LineTotal     float64    `json:"line_total"`
+	ProductID     string     `json:"product_id" validate:"required,max=50" ref:"tb_product.product_id"`
+	Qty           float64    `json:"qty" validate:"gt=0"`
+	UnitCost      float64    `json:"unit_cost" validate:"gte=0"`
+	LineTotal     float64    `json:"line_total" validate:"gte=0"`
 	Remark        *string    `json:"remark"`
 	UpdateDate    *time.Time `json:"update_date"`
 	IsDelete      bool       `json:"is_delete"`
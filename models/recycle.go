@@ -0,0 +1,10 @@
+package models
+
+// DeletedRecord คือรายการในถังขยะ (ข้อมูลที่ถูก Soft Delete) ของแต่ละ module
+// deleted_by/deleted_date มาจาก update_by/update_date ที่บันทึกไว้ตอนลบ
+type DeletedRecord struct {
+	ID          string  `json:"id"`
+	Name        *string `json:"name"`
+	DeletedBy   *string `json:"deleted_by"`
+	DeletedDate *string `json:"deleted_date"`
+}
@@ -1,11 +1,12 @@
 package models
 
 // Reference represents the structure of the tb_reference table
+// ref_id คือชื่อกลุ่ม (เช่น PAYMENT_TERM) ส่วน ref_int/ref_text คือรหัสและค่าที่แสดงของแต่ละรายการในกลุ่ม
 type Reference struct {
-	RowID      int    `json:"row_id"`
-	RefID      string `json:"ref_id"`
-	RefInt     int    `json:"ref_int"`
-	RefText    string `json:"ref_text"`
-	UpdateBy   string `json:"update_by"`
-	UpdateDate string `json:"update_date"`
+	RowID      int     `json:"row_id"`
+	RefID      string  `json:"ref_id" validate:"required,max=50"`
+	RefInt     *int    `json:"ref_int"`
+	RefText    *string `json:"ref_text"`
+	UpdateBy   *string `json:"update_by"`
+	UpdateDate *string `json:"update_date"`
 }
\ No newline at end of file
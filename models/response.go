@@ -2,6 +2,7 @@ package models
 
 type ApiResponse struct {
 	Status  string `json:"status"`
+	Code    string `json:"code,omitempty"` // รหัส Error (เฉพาะ Response ที่ผ่าน ErrorHandler) เช่น "duplicate"
 	Message string `json:"message,omitempty"`
 	Data    any    `json:"data,omitempty"`
 }
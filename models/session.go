@@ -0,0 +1,13 @@
+package models
+
+// UserSession คือ Session การ Login หนึ่งครั้งของผู้ใช้ (หนึ่งอุปกรณ์/Browser)
+type UserSession struct {
+	SessionID    string  `json:"session_id"`
+	UserName     string  `json:"user_name"`
+	UserAgent    *string `json:"user_agent"`
+	IPAddress    *string `json:"ip_address"`
+	IssueDate    *string `json:"issue_date"`
+	LastSeenDate *string `json:"last_seen_date"`
+	ExpireDate   *string `json:"expire_date"`
+	Current      bool    `json:"current"`
+}
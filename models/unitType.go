@@ -2,7 +2,7 @@ package models
 
 type UnitType struct {
 	UnitTypeID   string `json:"unit_type_id"`
-	UnitTypeName string `json:"unit_type_name"`
+	UnitTypeName string `json:"unit_type_name" validate:"required,max=255"`
 	Description  string `json:"description"`
 	UpdateBy     string `json:"update_by"`
 	UpdateDate   string `json:"update_date"`
@@ -5,3 +5,23 @@ type User struct {
 	UserName string `json:"username"`
 	Password string `json:"password"`
 }
+
+// MFARequest ใช้สำหรับ Login ขั้นที่ 2 และการจัดการ MFA (ส่ง code หรือ recovery_code อย่างใดอย่างหนึ่ง)
+type MFARequest struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// MFAEnrollment คือข้อมูลที่ใช้ลงทะเบียน Authenticator (แสดงครั้งเดียวตอน enroll)
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+// MFAStatus แสดงสถานะ MFA ของผู้ใช้
+type MFAStatus struct {
+	Enabled                bool `json:"mfa_enabled"`
+	Required               bool `json:"mfa_required"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
@@ -2,21 +2,21 @@ package models
 
 type Vendor struct {
 	VendorID      string  `json:"vendor_id"`
-	VendorTypeID  string  `json:"vendor_type_id"`
-	VendorName    string  `json:"vendor_name"`
-	TaxID         *string `json:"tax_id"`
+	VendorTypeID  string  `json:"vendor_type_id" validate:"required,max=50" ref:"tb_vendor_type.vendor_type_id"`
+	VendorName    string  `json:"vendor_name" validate:"required,max=255"`
+	TaxID         *string `json:"tax_id" validate:"taxid"`
 	BranchName    *string `json:"branch_name"`
 	ContactPerson *string `json:"contact_person"`
 	Phone1        *string `json:"phone1"`
 	Phone2        *string `json:"phone2"`
-	Email         *string `json:"email"`
+	Email         *string `json:"email" validate:"max=255,email"`
 	Website       *string `json:"website"`
 	Address       *string `json:"address"`
 	SubDistrict   *string `json:"sub_district"`
 	District      *string `json:"district"`
 	Province      *string `json:"province"`
-	ZipCode       *string `json:"zip_code"`
-	CreditTermDay *int    `json:"credit_term_day"`
+	ZipCode       *string `json:"zip_code" validate:"zip"`
+	CreditTermDay *int    `json:"credit_term_day" validate:"gte=0"`
 	Currency      *string `json:"currency"`
 	Note          *string `json:"note"`
 	UpdateBy      *string `json:"update_by"`
@@ -3,7 +3,7 @@ package models
 type VendorType struct {
 	VendorTypeID string  `json:"vendor_type_id"`
 	Prefix       string  `json:"prefix"`
-	TypeName     string  `json:"type_name"`
+	TypeName     string  `json:"type_name" validate:"required,max=255"`
 	Description  *string `json:"description"`
 	UpdateBy     *string `json:"update_by"`
 	UpdateDate   *string `json:"update_date"`
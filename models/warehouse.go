@@ -2,8 +2,8 @@ package models
 
 type Warehouse struct {
 	WarehouseID        string  `json:"warehouse_id"`
-	WarehouseCode      string  `json:"warehouse_code"`
-	WarehouseName      string  `json:"warehouse_name"`
+	WarehouseCode      string  `json:"warehouse_code" validate:"required,max=50"`
+	WarehouseName      string  `json:"warehouse_name" validate:"required,max=255"`
 	Description        *string `json:"description"`
 	IsMainDC           *bool   `json:"is_main_dc"`
 	AllowNegativeStock *bool   `json:"allow_negative_stock"`
@@ -0,0 +1,133 @@
+// Package replica เลือกฐานข้อมูลที่ใช้อ่านข้อมูล ระหว่าง Read Replica และฐานข้อมูลหลัก (Primary)
+// Select ของ Repository อ่านจาก Replica ส่วน Insert/Update/Delete และทุกอย่างใน utils.ExecuteTransaction ใช้ Primary เสมอ
+// Request ที่ต้องเห็นข้อมูลที่เพิ่งเขียน (read-your-writes) บังคับอ่านจาก Primary ด้วย WithPrimary
+// และเมื่อ Replica ไม่พร้อมใช้งาน Router จะอ่านจาก Primary แทนจนกว่าการตรวจสถานะครั้งถัดไปจะสำเร็จ
+package replica
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// checkTimeout คือเวลาสูงสุดของการ Ping Replica แต่ละครั้ง
+const checkTimeout = 2 * time.Second
+
+type primaryKey struct{}
+
+// WithPrimary คืน ctx ที่บังคับให้ Router.Reader ใช้ Primary (เช่น Request ที่เขียนข้อมูล หรือขอ read-your-writes)
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// PrimaryOnly รายงานว่า ctx ถูกบังคับให้อ่านจาก Primary หรือไม่
+func PrimaryOnly(ctx context.Context) bool {
+	v, _ := ctx.Value(primaryKey{}).(bool)
+	return v
+}
+
+// Router คือฐานข้อมูล Primary พร้อม Read Replica (ถ้ามี) และสถานะล่าสุดของ Replica
+type Router struct {
+	primary  *sql.DB
+	replica  *sql.DB // nil = ไม่มี Replica (อ่านจาก Primary เสมอ)
+	healthy  atomic.Bool
+	checking atomic.Bool
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// New สร้าง Router บน primary และ replica (nil หากไม่ได้ตั้งค่า DB_READ_HOST)
+// Replica ถือว่าพร้อมใช้งานจนกว่า Watch หรือการอ่านที่ผิดพลาดจะตรวจพบว่าไม่พร้อม
+func New(primary, replica *sql.DB) *Router {
+	r := &Router{primary: primary, replica: replica, stop: make(chan struct{})}
+	r.healthy.Store(replica != nil)
+	return r
+}
+
+// Primary คืนฐานข้อมูลหลัก
+func (r *Router) Primary() *sql.DB {
+	return r.primary
+}
+
+// Replica คืน Read Replica (nil หากไม่ได้ตั้งค่า)
+func (r *Router) Replica() *sql.DB {
+	return r.replica
+}
+
+// Healthy รายงานว่า Replica พร้อมใช้งานหรือไม่ (false เสมอหากไม่มี Replica)
+func (r *Router) Healthy() bool {
+	return r.healthy.Load()
+}
+
+// Reader คืนฐานข้อมูลสำหรับอ่านของ ctx: Replica หากพร้อมใช้งานและ ctx ไม่ได้บังคับ Primary
+func (r *Router) Reader(ctx context.Context) *sql.DB {
+	if r.replica == nil || !r.healthy.Load() || PrimaryOnly(ctx) {
+		return r.primary
+	}
+	return r.replica
+}
+
+// Read รัน fn กับฐานข้อมูลสำหรับอ่านของ ctx หาก fn บน Replica ผิดพลาด (ที่ไม่ใช่ sql.ErrNoRows หรือ ctx หมดเวลา)
+// จะตรวจสถานะ Replica ใหม่และรัน fn อีกครั้งบน Primary เพื่อไม่ให้ Request ล้มเพราะ Replica
+func (r *Router) Read(ctx context.Context, fn func(db *sql.DB) error) error {
+	db := r.Reader(ctx)
+	err := fn(db)
+	if err == nil || db == r.primary || errors.Is(err, sql.ErrNoRows) || ctx.Err() != nil {
+		return err
+	}
+	log.Printf("[WARN] Read replica query failed, retrying on primary: %v", err)
+	go r.check()
+	return fn(r.primary)
+}
+
+// Watch ตรวจสถานะ Replica ทุก interval จนกว่าจะเรียก Close (ไม่มีผลหากไม่มี Replica หรือ interval <= 0)
+func (r *Router) Watch(interval time.Duration) {
+	if r.replica == nil || interval <= 0 {
+		return
+	}
+	r.check()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.check()
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// check Ping Replica แล้วบันทึกสถานะ (ข้ามหากมีการตรวจค้างอยู่แล้ว) และบันทึก Log เมื่อสถานะเปลี่ยน
+func (r *Router) check() {
+	if r.replica == nil || !r.checking.CompareAndSwap(false, true) {
+		return
+	}
+	defer r.checking.Store(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+	err := r.replica.PingContext(ctx)
+	if was := r.healthy.Swap(err == nil); was != (err == nil) {
+		if err != nil {
+			log.Printf("[WARN] Read replica is unhealthy, reading from primary: %v", err)
+		} else {
+			log.Println("Read replica is healthy again")
+		}
+	}
+}
+
+// Close หยุด Watch และปิดการเชื่อมต่อ Replica (Primary ปิดโดยผู้สร้าง)
+func (r *Router) Close() error {
+	r.stopOnce.Do(func() { close(r.stop) })
+	if r.replica == nil {
+		return nil
+	}
+	return r.replica.Close()
+}
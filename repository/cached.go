@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"PenbunAPI/cache"
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"PenbunAPI/utils"
+	"context"
+	"time"
+)
+
+// ReferenceListKey คือ key ของ tb_reference ทั้งตาราง (List ที่ไม่มี filter)
+const ReferenceListKey = "tb_reference:all"
+
+// listKey และ itemKey คือ key ของ List และ Get ของตาราง table ใน Cache
+func listKey(table string) string { return table + ":all" }
+
+func itemKey(table, id string) string { return table + ":id:" + id }
+
+// cached คือ Repository ที่ List และ Get อ่านผ่าน Cache ส่วนการเขียนล้าง key ของตารางหลังสำเร็จ
+// ข้อมูลที่ไม่อยู่ใน Cache อ่านจากฐานข้อมูลหลัก เพื่อไม่ให้ข้อมูลที่ Replica ยังตามไม่ทันค้างอยู่ใน Cache จนครบ ttl
+// และ ctx ที่บังคับอ่านจาก Primary (replica.WithPrimary เช่นการอ่านก่อนแก้ไข หรือ X-Read-Consistency: primary) ไม่อ่านจาก Cache
+type cached[T any] struct {
+	Repository[T]
+	cache cache.Cache
+	table string
+	ttl   time.Duration
+}
+
+// Cached ห่อ repo ของข้อมูลหลักตาราง table (เช่น tb_unit_type) ให้ List และ Get อ่านผ่าน c นาน ttl
+// Create ล้าง List ส่วน Update, SoftDelete และ Purge ล้างทั้ง List และรหัสนั้น (Restore ล้างผ่าน CachedRecycle)
+// SoftDelete แบบ cascade ล้าง List และรหัสของแถวที่ถูกลบตามในตารางอื่นด้วย
+// Page และ Search ไม่ผ่าน Cache เพราะ filter แต่ละ Request ต่างกัน
+func Cached[T any](repo Repository[T], c cache.Cache, table string, ttl time.Duration) Repository[T] {
+	return &cached[T]{Repository: repo, cache: c, table: table, ttl: ttl}
+}
+
+func (r *cached[T]) List(ctx context.Context) ([]T, error) {
+	if replica.PrimaryOnly(ctx) {
+		return r.Repository.List(ctx)
+	}
+	return cache.ReadThrough(ctx, r.cache, listKey(r.table), r.ttl, func(ctx context.Context) ([]T, error) {
+		return r.Repository.List(replica.WithPrimary(ctx))
+	})
+}
+
+func (r *cached[T]) Get(ctx context.Context, id string) (T, error) {
+	if replica.PrimaryOnly(ctx) {
+		return r.Repository.Get(ctx, id)
+	}
+	return cache.ReadThrough(ctx, r.cache, itemKey(r.table, id), r.ttl, func(ctx context.Context) (T, error) {
+		return r.Repository.Get(replica.WithPrimary(ctx), id)
+	})
+}
+
+func (r *cached[T]) Create(ctx context.Context, v *T) (string, error) {
+	id, err := r.Repository.Create(ctx, v)
+	if err == nil {
+		cache.Invalidate(ctx, r.cache, listKey(r.table))
+	}
+	return id, err
+}
+
+func (r *cached[T]) Update(ctx context.Context, id string, v *T) error {
+	err := r.Repository.Update(ctx, id, v)
+	if err == nil {
+		cache.Invalidate(ctx, r.cache, listKey(r.table), itemKey(r.table, id))
+	}
+	return err
+}
+
+func (r *cached[T]) SoftDelete(ctx context.Context, id, user string, cascade bool) error {
+	cascaded := utils.CascadedRows{}
+	err := r.Repository.SoftDelete(utils.WithCascadedRows(ctx, cascaded), id, user, cascade)
+	if err == nil {
+		keys := []string{listKey(r.table), itemKey(r.table, id)}
+		for table, ids := range cascaded {
+			keys = append(keys, listKey(table))
+			for _, depID := range ids {
+				keys = append(keys, itemKey(table, depID))
+			}
+		}
+		cache.Invalidate(ctx, r.cache, keys...)
+	}
+	return err
+}
+
+func (r *cached[T]) Purge(ctx context.Context, id string) error {
+	err := r.Repository.Purge(ctx, id)
+	if err == nil {
+		cache.Invalidate(ctx, r.cache, listKey(r.table), itemKey(r.table, id))
+	}
+	return err
+}
+
+// cachedRecycle ล้าง key ของตารางที่ถูก Restore
+type cachedRecycle struct {
+	RecycleRepository
+	cache cache.Cache
+}
+
+// CachedRecycle ห่อ repo ให้ Restore ล้าง List และรหัสนั้นของ bin.Table ใน c (ตารางที่ไม่ได้ Cache ไม่มีผล)
+func CachedRecycle(repo RecycleRepository, c cache.Cache) RecycleRepository {
+	return &cachedRecycle{RecycleRepository: repo, cache: c}
+}
+
+func (r *cachedRecycle) Restore(ctx context.Context, bin RecycleBin, id, user string) error {
+	err := r.RecycleRepository.Restore(ctx, bin, id, user)
+	if err == nil {
+		cache.Invalidate(ctx, r.cache, listKey(bin.Table), itemKey(bin.Table, id))
+	}
+	return err
+}
+
+// cachedReference อ่าน tb_reference ทั้งตารางผ่าน Cache (Lookup ผ่าน Cache ของ utils.LookupReference อยู่แล้ว)
+type cachedReference struct {
+	ReferenceRepository
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// CachedReference ห่อ repo ให้ List ที่ไม่มี filter อ่านผ่าน c นาน ttl
+// Create และ Update ล้าง List และกลุ่ม ref_id เดิมและใหม่ (utils.InvalidateReference)
+func CachedReference(repo ReferenceRepository, c cache.Cache, ttl time.Duration) ReferenceRepository {
+	return &cachedReference{ReferenceRepository: repo, cache: c, ttl: ttl}
+}
+
+func (r *cachedReference) List(ctx context.Context, filters map[string]string) ([]models.Reference, error) {
+	if len(filters) > 0 || replica.PrimaryOnly(ctx) {
+		return r.ReferenceRepository.List(ctx, filters)
+	}
+	return cache.ReadThrough(ctx, r.cache, ReferenceListKey, r.ttl, func(ctx context.Context) ([]models.Reference, error) {
+		return r.ReferenceRepository.List(replica.WithPrimary(ctx), nil)
+	})
+}
+
+func (r *cachedReference) Create(ctx context.Context, item *models.Reference) error {
+	err := r.ReferenceRepository.Create(ctx, item)
+	if err == nil {
+		cache.Invalidate(ctx, r.cache, ReferenceListKey)
+		utils.InvalidateReference(ctx, item.RefID)
+	}
+	return err
+}
+
+func (r *cachedReference) Update(ctx context.Context, rowID int, item *models.Reference) (string, error) {
+	oldRefID, err := r.ReferenceRepository.Update(ctx, rowID, item)
+	if err == nil {
+		cache.Invalidate(ctx, r.cache, ReferenceListKey)
+		utils.InvalidateReference(ctx, oldRefID, item.RefID)
+	}
+	return oldRefID, err
+}
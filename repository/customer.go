@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"context"
+	"database/sql"
+)
+
+// CustomerRepository คือการทำงานกับ tb_customer (พร้อมชื่อประเภทลูกค้าจาก tb_customer_type)
+type CustomerRepository interface {
+	Repository[models.Customer]
+}
+
+type customerRepository struct {
+	sqlTable[models.Customer]
+}
+
+// NewCustomerRepository สร้าง CustomerRepository บนฐานข้อมูล dbs
+func NewCustomerRepository(dbs *replica.Router) CustomerRepository {
+	return &customerRepository{sqlTable[models.Customer]{
+		db:       dbs.Primary(),
+		reads:    dbs,
+		table:    "tb_customer",
+		idColumn: "customer_id",
+		alias:    "c",
+		columns: `c.customer_id, c.customer_type_id, c.customer_name, c.tax_id, c.branch_name,
+		       c.contact_person, c.phone1, c.phone2, c.email, c.line_id,
+		       c.address, c.sub_district, c.district, c.province, c.zip_code,
+		       c.credit_limit, c.credit_term_day, c.note,
+		       c.update_by, c.update_date, c.is_active,
+		       ct.customer_type_name`,
+		from: `tb_customer c
+		LEFT JOIN tb_customer_type ct ON c.customer_type_id = ct.customer_type_id`,
+		search: []string{"c.customer_name"},
+		active: "is_active",
+		scan: func(s scanner, item *models.Customer) error {
+			var upd sql.NullTime
+			if err := s.Scan(
+				&item.CustomerID, &item.CustomerTypeID, &item.CustomerName, &item.TaxID, &item.BranchName,
+				&item.ContactPerson, &item.Phone1, &item.Phone2, &item.Email, &item.LineID,
+				&item.Address, &item.SubDistrict, &item.District, &item.Province, &item.ZipCode,
+				&item.CreditLimit, &item.CreditTermDay, &item.Note,
+				&item.UpdateBy, &upd, &item.IsActive, &item.CustomerTypeName,
+			); err != nil {
+				return err
+			}
+			item.UpdateDate = formatDate(upd)
+			return nil
+		},
+	}}
+}
+
+// NewMemoryCustomerRepository สร้าง CustomerRepository ในหน่วยความจำสำหรับทดสอบ
+func NewMemoryCustomerRepository() CustomerRepository {
+	return NewMemory[models.Customer]("CUS", "customer_id", "customer_name")
+}
+
+func (r *customerRepository) Create(ctx context.Context, item *models.Customer) (string, error) {
+	return r.insert(ctx, item, `
+		INSERT INTO tb_customer (
+			customer_type_id, customer_name, tax_id, branch_name,
+			contact_person, phone1, phone2, email, line_id,
+			address, sub_district, district, province, zip_code,
+			credit_limit, credit_term_day, note, update_by
+		)
+		OUTPUT INSERTED.autoID INTO @inserted
+		VALUES (
+			@TypeID, @Name, @TaxID, @Branch,
+			@Contact, @Phone1, @Phone2, @Email, @LineID,
+			@Address, @SubDistrict, @District, @Province, @ZipCode,
+			COALESCE(@CreditLimit, 0), COALESCE(@CreditTerm, 0), @Note, @UpdateBy
+		)`,
+		sql.Named("TypeID", item.CustomerTypeID),
+		sql.Named("Name", item.CustomerName),
+		sql.Named("TaxID", item.TaxID),
+		sql.Named("Branch", item.BranchName),
+		sql.Named("Contact", item.ContactPerson),
+		sql.Named("Phone1", item.Phone1),
+		sql.Named("Phone2", item.Phone2),
+		sql.Named("Email", item.Email),
+		sql.Named("LineID", item.LineID),
+		sql.Named("Address", item.Address),
+		sql.Named("SubDistrict", item.SubDistrict),
+		sql.Named("District", item.District),
+		sql.Named("Province", item.Province),
+		sql.Named("ZipCode", item.ZipCode),
+		sql.Named("CreditLimit", item.CreditLimit),
+		sql.Named("CreditTerm", item.CreditTermDay),
+		sql.Named("Note", item.Note),
+		sql.Named("UpdateBy", item.UpdateBy),
+	)
+}
+
+func (r *customerRepository) Update(ctx context.Context, id string, item *models.Customer) error {
+	return r.update(ctx, id, item, `
+		UPDATE tb_customer
+		SET customer_type_id = COALESCE(NULLIF(@TypeID, ''), customer_type_id),
+			customer_name = COALESCE(NULLIF(@Name, ''), customer_name),
+			tax_id = COALESCE(@TaxID, tax_id),
+			branch_name = COALESCE(@Branch, branch_name),
+			contact_person = COALESCE(@Contact, contact_person),
+			phone1 = COALESCE(@Phone1, phone1),
+			phone2 = COALESCE(@Phone2, phone2),
+			email = COALESCE(@Email, email),
+			line_id = COALESCE(@LineID, line_id),
+			address = COALESCE(@Address, address),
+			sub_district = COALESCE(@SubDistrict, sub_district),
+			district = COALESCE(@District, district),
+			province = COALESCE(@Province, province),
+			zip_code = COALESCE(@ZipCode, zip_code),
+			credit_limit = COALESCE(@CreditLimit, credit_limit),
+			credit_term_day = COALESCE(@CreditTerm, credit_term_day),
+			note = COALESCE(@Note, note),
+			update_by = @UpdateBy,
+			is_active = COALESCE(@IsActive, is_active)
+		WHERE customer_id = @ID AND is_delete = 0`,
+		sql.Named("TypeID", item.CustomerTypeID),
+		sql.Named("Name", item.CustomerName),
+		sql.Named("TaxID", item.TaxID),
+		sql.Named("Branch", item.BranchName),
+		sql.Named("Contact", item.ContactPerson),
+		sql.Named("Phone1", item.Phone1),
+		sql.Named("Phone2", item.Phone2),
+		sql.Named("Email", item.Email),
+		sql.Named("LineID", item.LineID),
+		sql.Named("Address", item.Address),
+		sql.Named("SubDistrict", item.SubDistrict),
+		sql.Named("District", item.District),
+		sql.Named("Province", item.Province),
+		sql.Named("ZipCode", item.ZipCode),
+		sql.Named("CreditLimit", item.CreditLimit),
+		sql.Named("CreditTerm", item.CreditTermDay),
+		sql.Named("Note", item.Note),
+		sql.Named("UpdateBy", item.UpdateBy),
+		sql.Named("IsActive", item.IsActive),
+		sql.Named("ID", id),
+	)
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"context"
+	"database/sql"
+)
+
+// CustomerTypeRepository คือการทำงานกับ tb_customer_type
+type CustomerTypeRepository interface {
+	Repository[models.CustomerType]
+}
+
+type customerTypeRepository struct {
+	sqlTable[models.CustomerType]
+}
+
+// NewCustomerTypeRepository สร้าง CustomerTypeRepository บนฐานข้อมูล dbs
+func NewCustomerTypeRepository(dbs *replica.Router) CustomerTypeRepository {
+	return &customerTypeRepository{sqlTable[models.CustomerType]{
+		db:       dbs.Primary(),
+		reads:    dbs,
+		table:    "tb_customer_type",
+		idColumn: "customer_type_id",
+		columns:  "customer_type_id, customer_type_name, base_credit_day, description, update_by, update_date, is_active",
+		from:     "tb_customer_type",
+		search:   []string{"customer_type_name"},
+		active:   "is_active",
+		scan: func(s scanner, item *models.CustomerType) error {
+			var upd sql.NullTime
+			if err := s.Scan(&item.CustomerTypeID, &item.CustomerTypeName, &item.BaseCreditDay, &item.Description, &item.UpdateBy, &upd, &item.IsActive); err != nil {
+				return err
+			}
+			item.UpdateDate = formatDate(upd)
+			return nil
+		},
+	}}
+}
+
+// NewMemoryCustomerTypeRepository สร้าง CustomerTypeRepository ในหน่วยความจำสำหรับทดสอบ
+func NewMemoryCustomerTypeRepository() CustomerTypeRepository {
+	return NewMemory[models.CustomerType]("CT", "customer_type_id", "customer_type_name")
+}
+
+func (r *customerTypeRepository) Create(ctx context.Context, item *models.CustomerType) (string, error) {
+	return r.insert(ctx, item, `
+		INSERT INTO tb_customer_type (customer_type_name, base_credit_day, description, update_by)
+		OUTPUT INSERTED.autoID INTO @inserted
+		VALUES (@CustomerTypeName, @BaseCreditDay, @Description, @UpdateBy)`,
+		sql.Named("CustomerTypeName", item.CustomerTypeName),
+		sql.Named("BaseCreditDay", item.BaseCreditDay),
+		sql.Named("Description", item.Description),
+		sql.Named("UpdateBy", item.UpdateBy),
+	)
+}
+
+func (r *customerTypeRepository) Update(ctx context.Context, id string, item *models.CustomerType) error {
+	return r.update(ctx, id, item, `
+		UPDATE tb_customer_type
+		SET customer_type_name = COALESCE(NULLIF(@CustomerTypeName, ''), customer_type_name),
+			base_credit_day = COALESCE(@BaseCreditDay, base_credit_day),
+			description = COALESCE(@Description, description),
+			update_by = @UpdateBy,
+			is_active = COALESCE(@IsActive, is_active)
+		WHERE customer_type_id = @ID AND is_delete = 0`,
+		sql.Named("CustomerTypeName", item.CustomerTypeName),
+		sql.Named("BaseCreditDay", item.BaseCreditDay),
+		sql.Named("Description", item.Description),
+		sql.Named("UpdateBy", item.UpdateBy),
+		sql.Named("IsActive", item.IsActive),
+		sql.Named("ID", id),
+	)
+}
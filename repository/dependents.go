@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"PenbunAPI/models"
+	"PenbunAPI/utils"
+)
+
+// ตารางที่อ้างถึงข้อมูลอื่นผ่าน tag `ref` ของ model ใช้ตรวจสอบก่อน Delete/Remove
+// ข้อมูลหลัก Soft Delete ตามได้ด้วย ?cascade=soft (พร้อมปิดคอลัมน์สถานะของตาราง) ส่วนเอกสารไม่ถูกลบตามเสมอ
+func init() {
+	utils.RegisterRefs("tb_customer", "customer_id", "is_active", true, models.Customer{})
+	utils.RegisterRefs("tb_vendor", "vendor_id", "is_active", true, models.Vendor{})
+	utils.RegisterRefs("tb_discount", "discount_id", "is_active", true, models.Discount{})
+	utils.RegisterRefs("tb_product_group", "product_group_id", "is_active", true, models.ProductGroup{})
+	utils.RegisterRefs("tb_product", "product_id", "is_active", true, models.Product{})
+	utils.RegisterRefs("tb_product_pack_config", "product_pack_config_id", "id_status", true, models.ProductPackConfig{})
+
+	utils.RegisterRefs("tb_receive_note", "receive_note_id", "", false, models.ReceiveNote{})
+	utils.RegisterRefs("tb_receive_item", "receive_note_id", "", false, models.ReceiveItem{})
+	utils.RegisterRefs("tb_order", "order_id", "", false, models.Order{})
+	utils.RegisterRefs("tb_order_item", "order_id", "", false, models.OrderItem{})
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"context"
+	"database/sql"
+)
+
+// DiscountRepository คือการทำงานกับ tb_discount (พร้อมชื่อประเภทส่วนลดจาก tb_discount_type)
+type DiscountRepository interface {
+	Repository[models.Discount]
+}
+
+type discountRepository struct {
+	sqlTable[models.Discount]
+}
+
+// NewDiscountRepository สร้าง DiscountRepository บนฐานข้อมูล dbs
+func NewDiscountRepository(dbs *replica.Router) DiscountRepository {
+	return &discountRepository{sqlTable[models.Discount]{
+		db:       dbs.Primary(),
+		reads:    dbs,
+		table:    "tb_discount",
+		idColumn: "discount_id",
+		alias:    "d",
+		columns: `d.discount_id, d.discount_type_id, d.discount_name, d.discount_code, d.description,
+		       d.discount_value, d.is_percent, d.min_order_amount,
+		       d.start_date, d.end_date,
+		       d.update_by, d.update_date, d.is_active,
+		       dt.discount_type_name`,
+		from: `tb_discount d
+		LEFT JOIN tb_discount_type dt ON d.discount_type_id = dt.discount_type_id`,
+		search: []string{"d.discount_name"},
+		active: "is_active",
+		scan: func(s scanner, item *models.Discount) error {
+			var upd, start, end sql.NullTime
+			if err := s.Scan(
+				&item.DiscountID, &item.DiscountTypeID, &item.DiscountName, &item.DiscountCode, &item.Description,
+				&item.DiscountValue, &item.IsPercent, &item.MinOrderAmount,
+				&start, &end,
+				&item.UpdateBy, &upd, &item.IsActive, &item.DiscountTypeName,
+			); err != nil {
+				return err
+			}
+			item.UpdateDate = formatDate(upd)
+			item.StartDate = formatDate(start)
+			item.EndDate = formatDate(end)
+			return nil
+		},
+	}}
+}
+
+// NewMemoryDiscountRepository สร้าง DiscountRepository ในหน่วยความจำสำหรับทดสอบ
+func NewMemoryDiscountRepository() DiscountRepository {
+	return NewMemory[models.Discount]("DIS", "discount_id", "discount_name")
+}
+
+func (r *discountRepository) Create(ctx context.Context, item *models.Discount) (string, error) {
+	return r.insert(ctx, item, `
+		INSERT INTO tb_discount (
+			discount_type_id, discount_name, discount_code, description,
+			discount_value, is_percent, min_order_amount,
+			start_date, end_date, update_by
+		)
+		OUTPUT INSERTED.autoID INTO @inserted
+		VALUES (
+			@TypeID, @Name, @Code, @Desc,
+			@Value, @IsPercent, @MinOrder,
+			@StartDate, @EndDate, @UpdateBy
+		)`,
+		sql.Named("TypeID", item.DiscountTypeID),
+		sql.Named("Name", item.DiscountName),
+		sql.Named("Code", item.DiscountCode),
+		sql.Named("Desc", item.Description),
+		sql.Named("Value", item.DiscountValue),
+		sql.Named("IsPercent", item.IsPercent),
+		sql.Named("MinOrder", item.MinOrderAmount),
+		sql.Named("StartDate", item.StartDate), // Driver converts string to datetime if format is correct
+		sql.Named("EndDate", item.EndDate),
+		sql.Named("UpdateBy", item.UpdateBy),
+	)
+}
+
+func (r *discountRepository) Update(ctx context.Context, id string, item *models.Discount) error {
+	return r.update(ctx, id, item, `
+		UPDATE tb_discount
+		SET discount_type_id = COALESCE(NULLIF(@TypeID, ''), discount_type_id),
+			discount_name = COALESCE(NULLIF(@Name, ''), discount_name),
+			discount_code = COALESCE(@Code, discount_code),
+			description = COALESCE(@Desc, description),
+			discount_value = COALESCE(@Value, discount_value),
+			is_percent = COALESCE(@IsPercent, is_percent),
+			min_order_amount = COALESCE(@MinOrder, min_order_amount),
+			start_date = COALESCE(@StartDate, start_date),
+			end_date = COALESCE(@EndDate, end_date),
+			update_by = @UpdateBy,
+			is_active = COALESCE(@IsActive, is_active)
+		WHERE discount_id = @ID AND is_delete = 0`,
+		sql.Named("TypeID", item.DiscountTypeID),
+		sql.Named("Name", item.DiscountName),
+		sql.Named("Code", item.DiscountCode),
+		sql.Named("Desc", item.Description),
+		sql.Named("Value", item.DiscountValue),
+		sql.Named("IsPercent", item.IsPercent),
+		sql.Named("MinOrder", item.MinOrderAmount),
+		sql.Named("StartDate", item.StartDate),
+		sql.Named("EndDate", item.EndDate),
+		sql.Named("UpdateBy", item.UpdateBy),
+		sql.Named("IsActive", item.IsActive),
+		sql.Named("ID", id),
+	)
+}
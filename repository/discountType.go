@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"context"
+	"database/sql"
+)
+
+// DiscountTypeRepository คือการทำงานกับ tb_discount_type
+type DiscountTypeRepository interface {
+	Repository[models.DiscountType]
+}
+
+type discountTypeRepository struct {
+	sqlTable[models.DiscountType]
+}
+
+// NewDiscountTypeRepository สร้าง DiscountTypeRepository บนฐานข้อมูล dbs
+func NewDiscountTypeRepository(dbs *replica.Router) DiscountTypeRepository {
+	return &discountTypeRepository{sqlTable[models.DiscountType]{
+		db:       dbs.Primary(),
+		reads:    dbs,
+		table:    "tb_discount_type",
+		idColumn: "discount_type_id",
+		columns:  "discount_type_id, discount_type_name, description, update_by, update_date, is_active",
+		from:     "tb_discount_type",
+		search:   []string{"discount_type_name"},
+		active:   "is_active",
+		scan: func(s scanner, item *models.DiscountType) error {
+			var upd sql.NullTime
+			if err := s.Scan(&item.DiscountTypeID, &item.DiscountTypeName, &item.Description, &item.UpdateBy, &upd, &item.IsActive); err != nil {
+				return err
+			}
+			item.UpdateDate = formatDate(upd)
+			return nil
+		},
+	}}
+}
+
+// NewMemoryDiscountTypeRepository สร้าง DiscountTypeRepository ในหน่วยความจำสำหรับทดสอบ
+func NewMemoryDiscountTypeRepository() DiscountTypeRepository {
+	return NewMemory[models.DiscountType]("DT", "discount_type_id", "discount_type_name")
+}
+
+func (r *discountTypeRepository) Create(ctx context.Context, item *models.DiscountType) (string, error) {
+	return r.insert(ctx, item, `
+		INSERT INTO tb_discount_type (discount_type_name, description, update_by)
+		OUTPUT INSERTED.autoID INTO @inserted
+		VALUES (@DiscountTypeName, @Description, @UpdateBy)`,
+		sql.Named("DiscountTypeName", item.DiscountTypeName),
+		sql.Named("Description", item.Description),
+		sql.Named("UpdateBy", item.UpdateBy),
+	)
+}
+
+func (r *discountTypeRepository) Update(ctx context.Context, id string, item *models.DiscountType) error {
+	return r.update(ctx, id, item, `
+		UPDATE tb_discount_type
+		SET discount_type_name = COALESCE(NULLIF(@DiscountTypeName, ''), discount_type_name),
+			description = COALESCE(@Description, description),
+			update_by = @UpdateBy,
+			is_active = COALESCE(@IsActive, is_active)
+		WHERE discount_type_id = @ID AND is_delete = 0`,
+		sql.Named("DiscountTypeName", item.DiscountTypeName),
+		sql.Named("Description", item.Description),
+		sql.Named("UpdateBy", item.UpdateBy),
+		sql.Named("IsActive", item.IsActive),
+		sql.Named("ID", id),
+	)
+}
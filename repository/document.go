@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"PenbunAPI/utils"
+	"context"
+	"database/sql"
+	"reflect"
+	"sync"
+)
+
+// Document คือเอกสารแบบ Header + Items (เช่นใบรับสินค้า ใบสั่งขาย)
+// ใช้ทั้งเป็น Body ของ Insert และเป็น Response ของ Get ({"header": ..., "items": [...]})
+type Document[H, I any] struct {
+	Header H   `json:"header"`
+	Items  []I `json:"items" validate:"required"`
+}
+
+// DocumentRepository คือการทำงานกับเอกสารที่มีตาราง Header และ Items
+// Header ที่ถูก Soft Delete แล้วจะไม่ถูกอ่าน และการลบจะทำกับ Items ไปพร้อมกันใน Transaction เดียว
+type DocumentRepository[H, I any] interface {
+	// List คืน Header ทั้งหมด (เรียงตามวันที่เอกสารล่าสุด)
+	List(ctx context.Context) ([]H, error)
+	// Page คืน Header ตาม filter/sort/paging ของ lq (เหมือน Repository.Page)
+	Page(ctx context.Context, lq utils.ListQuery) ([]H, *int, error)
+	// Get คืน Header พร้อม Items ตามรหัส หรือ ErrNotFound
+	Get(ctx context.Context, id string) (Document[H, I], error)
+	// Create ตรวจสอบ foreign key ของ Header และ Items แล้วเพิ่มทั้งเอกสาร คืนรหัสที่ Trigger สร้างให้
+	Create(ctx context.Context, doc *Document[H, I], user string) (string, error)
+	// SoftDelete เปลี่ยน is_delete = 1 (และ is_active = 0) ของ Header และ Items ที่ยังไม่ถูกลบ
+	// Items ได้ update_date เดียวกับ Header ซึ่ง Restore ใช้เลือกเฉพาะ Items ที่ถูกลบพร้อมกัน
+	SoftDelete(ctx context.Context, id, user string) error
+	// Purge ลบ Items แล้วลบ Header จริง
+	Purge(ctx context.Context, id string) error
+}
+
+// sqlDocument คือส่วนที่เหมือนกันของ DocumentRepository บนฐานข้อมูล
+// Header ใช้ sqlTable ส่วน Items อ่านด้วย items (ต้องมีเงื่อนไข @ID) และ scanItem
+type sqlDocument[H, I any] struct {
+	sqlTable[H]
+	itemTable string // ตาราง Items เช่น tb_receive_item
+	parentKey string // คอลัมน์ใน itemTable ที่อ้างถึง Header เช่น receive_note_id
+	items     string // SELECT Items ของเอกสาร @ID
+	scanItem  func(s scanner, v *I) error
+}
+
+func (d *sqlDocument[H, I]) Get(ctx context.Context, id string) (Document[H, I], error) {
+	var doc Document[H, I]
+	header, err := d.sqlTable.Get(ctx, id)
+	if err != nil {
+		return doc, err
+	}
+	doc.Header = header
+
+	err = d.reads.Read(ctx, func(db *sql.DB) error {
+		doc.Items = nil
+		rows, err := db.QueryContext(ctx, d.items, sql.Named("ID", id))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var item I
+			if err := d.scanItem(rows, &item); err != nil {
+				return err
+			}
+			doc.Items = append(doc.Items, item)
+		}
+		return rows.Err()
+	})
+	return doc, err
+}
+
+// create ตรวจสอบ foreign key ของทั้งเอกสารใน Transaction เดียวกับการเพิ่ม Header (headerSQL ต้องมี OUTPUT INSERTED.autoID INTO @inserted)
+// และ Items ทีละแถวด้วย itemSQL โดย itemArgs คืน parameter ของแต่ละแถวตามรหัสเอกสารที่ได้
+func (d *sqlDocument[H, I]) create(ctx context.Context, doc *Document[H, I], headerSQL string, headerArgs []interface{}, itemSQL string, itemArgs func(id string, item *I) []interface{}) (string, error) {
+	var id string
+	err := utils.ExecuteTransaction(ctx, d.db, []func(tx *sql.Tx) error{
+		func(tx *sql.Tx) error {
+			return utils.CheckRefs(ctx, tx, d.dialect(), doc)
+		},
+		func(tx *sql.Tx) (err error) {
+			id, err = d.dialect().InsertReturningID(ctx, tx, d.table, d.idColumn, headerSQL, headerArgs...)
+			return err
+		},
+		func(tx *sql.Tx) error {
+			for i := range doc.Items {
+				if _, err := tx.ExecContext(ctx, itemSQL, itemArgs(id, &doc.Items[i])...); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+	return id, err
+}
+
+func (d *sqlDocument[H, I]) SoftDelete(ctx context.Context, id, user string) error {
+	return utils.ExecuteTransaction(ctx, d.db, []func(tx *sql.Tx) error{
+		func(tx *sql.Tx) error {
+			return d.precondition(ctx, tx, id)
+		},
+		func(tx *sql.Tx) error {
+			return affected(tx.ExecContext(ctx, `UPDATE `+d.table+` SET is_delete = 1, is_active = 0, update_by = @UpdateBy, update_date = `+d.dialect().Now()+` WHERE `+d.idColumn+` = @ID AND is_delete = 0`,
+				sql.Named("ID", id), sql.Named("UpdateBy", user)))
+		},
+		func(tx *sql.Tx) error {
+			// items ได้ update_date เดียวกับ Header (อ่านหลัง Trigger ของ Header ทำงานแล้ว) เพื่อให้ Restore แยกออกจาก
+			// items ที่ถูกลบไว้ก่อนหน้าได้
+			_, err := tx.ExecContext(ctx, `
+				UPDATE `+d.itemTable+`
+				SET is_delete = 1,
+				    update_date = (SELECT update_date FROM `+d.table+` WHERE `+d.idColumn+` = @ID)
+				WHERE `+d.parentKey+` = @ID AND is_delete = 0`, sql.Named("ID", id))
+			return err
+		},
+	})
+}
+
+// Purge ลบ Items ก่อนเพราะติด FK
+func (d *sqlDocument[H, I]) Purge(ctx context.Context, id string) error {
+	return utils.ExecuteTransaction(ctx, d.db, []func(tx *sql.Tx) error{
+		func(tx *sql.Tx) error {
+			return d.precondition(ctx, tx, id)
+		},
+		func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "DELETE FROM "+d.itemTable+" WHERE "+d.parentKey+" = @ID", sql.Named("ID", id))
+			return err
+		},
+		func(tx *sql.Tx) error {
+			return affected(tx.ExecContext(ctx, "DELETE FROM "+d.table+" WHERE "+d.idColumn+" = @ID", sql.Named("ID", id)))
+		},
+	})
+}
+
+// MemoryDocument คือ DocumentRepository ในหน่วยความจำ สำหรับทดสอบ Handler โดยไม่ต้องมีฐานข้อมูล
+// Header เก็บด้วย Memory[H] (จึงมีข้อจำกัดเดียวกัน) และ Items เก็บแยกตามรหัสเอกสาร
+type MemoryDocument[H, I any] struct {
+	*Memory[H]
+	mu        sync.Mutex
+	parentKey int // field ของรหัสเอกสารใน I (ชื่อ json เดียวกับใน H)
+	items     map[string][]I
+}
+
+// NewMemoryDocument สร้าง MemoryDocument โดย idField คือชื่อ json ของรหัสเอกสารใน H
+func NewMemoryDocument[H, I any](prefix, idField string) *MemoryDocument[H, I] {
+	return &MemoryDocument[H, I]{Memory: NewMemory[H](prefix, idField), parentKey: memoryField[I](idField), items: map[string][]I{}}
+}
+
+func (m *MemoryDocument[H, I]) Get(ctx context.Context, id string) (Document[H, I], error) {
+	header, err := m.Memory.Get(ctx, id)
+	if err != nil {
+		return Document[H, I]{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return Document[H, I]{Header: header, Items: m.items[id]}, nil
+}
+
+func (m *MemoryDocument[H, I]) Create(ctx context.Context, doc *Document[H, I], user string) (string, error) {
+	id, err := m.Memory.Create(ctx, &doc.Header)
+	if err != nil {
+		return "", err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := append([]I(nil), doc.Items...)
+	for i := range items {
+		reflect.ValueOf(&items[i]).Elem().Field(m.parentKey).SetString(id)
+	}
+	m.items[id] = items
+	return id, nil
+}
+
+func (m *MemoryDocument[H, I]) SoftDelete(ctx context.Context, id, user string) error {
+	return m.Memory.SoftDelete(ctx, id, user, false)
+}
+
+func (m *MemoryDocument[H, I]) Purge(ctx context.Context, id string) error {
+	if err := m.Memory.Purge(ctx, id); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, id)
+	return nil
+}
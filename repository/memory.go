@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"PenbunAPI/dialect"
+	"PenbunAPI/models"
+	"PenbunAPI/utils"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Memory คือ Repository[T] ในหน่วยความจำ สำหรับทดสอบ Handler โดยไม่ต้องมีฐานข้อมูล
+//
+//   - Create สร้างรหัสจาก prefix ต่อด้วยเลขลำดับ dialect.IDDigits หลัก (แทน Trigger เช่น VEN000001) และไม่ตรวจสอบ foreign key
+//   - Update แทนที่เฉพาะ field ที่มีค่า (เหมือน COALESCE ของ Update ส่วนใหญ่ใน v1)
+//   - Page ใช้เฉพาะ Offset/Limit/total ของ lq โดยไม่รองรับ ?filter=, ?sort=, ?q= และ Cursor (เรียงตามลำดับที่เพิ่ม)
+//   - SoftDelete ไม่ตรวจสอบข้อมูลที่อ้างถึง
+type Memory[T any] struct {
+	mu         sync.Mutex
+	prefix     string
+	idField    int
+	nameFields []int
+	seq        int
+	rows       []memoryRow[T]
+}
+
+type memoryRow[T any] struct {
+	value   T
+	deleted bool
+}
+
+// NewMemory สร้าง Memory ของ T โดย idField และ nameFields คือชื่อ field ตาม json tag (เช่น "product_id")
+// nameFields คือ field ที่ Search ค้นหา
+func NewMemory[T any](prefix, idField string, nameFields ...string) *Memory[T] {
+	m := &Memory[T]{prefix: prefix, idField: memoryField[T](idField)}
+	for _, name := range nameFields {
+		m.nameFields = append(m.nameFields, memoryField[T](name))
+	}
+	return m
+}
+
+// memoryField คืน index ของ field ใน T ตามชื่อ json (panic หากไม่พบ เพราะเป็นความผิดพลาดตอนเขียนโค้ด)
+func memoryField[T any](name string) int {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("json"), ",")[0] == name {
+			return i
+		}
+	}
+	panic(fmt.Sprintf("repository: %s has no field %q", t.Name(), name))
+}
+
+func (m *Memory[T]) id(v *T) string {
+	return reflect.ValueOf(v).Elem().Field(m.idField).String()
+}
+
+// find คืน index ของแถวที่ยังไม่ถูกลบตามรหัส (-1 หากไม่พบ)
+func (m *Memory[T]) find(id string, withDeleted bool) int {
+	for i := range m.rows {
+		if m.id(&m.rows[i].value) == id && (withDeleted || !m.rows[i].deleted) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *Memory[T]) active() []T {
+	var list []T
+	for _, row := range m.rows {
+		if !row.deleted {
+			list = append(list, row.value)
+		}
+	}
+	return list
+}
+
+func (m *Memory[T]) List(ctx context.Context) ([]T, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.active(), nil
+}
+
+func (m *Memory[T]) Page(ctx context.Context, lq utils.ListQuery) ([]T, *int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := m.active()
+	var total *int
+	if lq.WithTotal {
+		n := len(list)
+		total = &n
+	}
+	if lq.Offset >= len(list) {
+		return nil, total, nil
+	}
+	list = list[lq.Offset:]
+	if len(list) > lq.Fetch() {
+		list = list[:lq.Fetch()]
+	}
+	return list, total, nil
+}
+
+func (m *Memory[T]) Get(ctx context.Context, id string) (T, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if i := m.find(id, false); i >= 0 {
+		return m.rows[i].value, nil
+	}
+	var zero T
+	return zero, ErrNotFound
+}
+
+func (m *Memory[T]) Search(ctx context.Context, name string) ([]T, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var list []T
+	for _, v := range m.active() {
+		rv := reflect.ValueOf(v)
+		for _, i := range m.nameFields {
+			f := reflect.Indirect(rv.Field(i))
+			if f.IsValid() && strings.Contains(strings.ToLower(f.String()), strings.ToLower(name)) {
+				list = append(list, v)
+				break
+			}
+		}
+	}
+	return list, nil
+}
+
+func (m *Memory[T]) Create(ctx context.Context, v *T) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seq++
+	id := fmt.Sprintf("%s%0*d", m.prefix, dialect.IDDigits, m.seq)
+	row := *v
+	reflect.ValueOf(&row).Elem().Field(m.idField).SetString(id)
+	m.rows = append(m.rows, memoryRow[T]{value: row})
+	return id, nil
+}
+
+func (m *Memory[T]) Update(ctx context.Context, id string, v *T) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := m.find(id, false)
+	if i < 0 {
+		return ErrNotFound
+	}
+	dst := reflect.ValueOf(&m.rows[i].value).Elem()
+	src := reflect.ValueOf(v).Elem()
+	for f := 0; f < src.NumField(); f++ {
+		if f != m.idField && dst.Field(f).CanSet() && !src.Field(f).IsZero() {
+			dst.Field(f).Set(src.Field(f))
+		}
+	}
+	return nil
+}
+
+func (m *Memory[T]) SoftDelete(ctx context.Context, id, user string, cascade bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := m.find(id, false)
+	if i < 0 {
+		return ErrNotFound
+	}
+	m.rows[i].deleted = true
+	return nil
+}
+
+func (m *Memory[T]) Purge(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := m.find(id, true)
+	if i < 0 {
+		return ErrNotFound
+	}
+	m.rows = append(m.rows[:i], m.rows[i+1:]...)
+	return nil
+}
+
+// Restore กู้คืนแถวที่ถูก SoftDelete (ใช้กับ NewMemoryRecycleRepository)
+func (m *Memory[T]) Restore(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.rows {
+		if m.rows[i].deleted && m.id(&m.rows[i].value) == id {
+			m.rows[i].deleted = false
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// Deleted คืนรายการในถังขยะ โดยใช้ field แรกของ nameFields เป็นชื่อ (ไม่มีผู้ลบและวันที่ลบ)
+func (m *Memory[T]) Deleted() []models.DeletedRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var list []models.DeletedRecord
+	for i := range m.rows {
+		if !m.rows[i].deleted {
+			continue
+		}
+		item := models.DeletedRecord{ID: m.id(&m.rows[i].value)}
+		if len(m.nameFields) > 0 {
+			f := reflect.Indirect(reflect.ValueOf(m.rows[i].value).Field(m.nameFields[0]))
+			if f.IsValid() {
+				name := f.String()
+				item.Name = &name
+			}
+		}
+		list = append(list, item)
+	}
+	return list
+}
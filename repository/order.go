@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"context"
+	"database/sql"
+)
+
+// Order คือใบสั่งขาย (Header + Items)
+type Order = Document[models.Order, models.OrderItem]
+
+// OrderRepository คือการทำงานกับ tb_order และ tb_order_item
+type OrderRepository interface {
+	DocumentRepository[models.Order, models.OrderItem]
+}
+
+type orderRepository struct {
+	sqlDocument[models.Order, models.OrderItem]
+}
+
+// NewOrderRepository สร้าง OrderRepository บนฐานข้อมูล dbs
+func NewOrderRepository(dbs *replica.Router) OrderRepository {
+	return &orderRepository{sqlDocument[models.Order, models.OrderItem]{
+		sqlTable: sqlTable[models.Order]{
+			db:       dbs.Primary(),
+			reads:    dbs,
+			table:    "tb_order",
+			idColumn: "order_id",
+			alias:    "o",
+			columns: `o.order_id, o.customer_id, c.customer_name, o.warehouse_id, w.warehouse_name,
+		       o.doc_date, o.doc_type, o.total_amount, o.discount_amount, o.net_amount, o.vat_amount, o.grand_total,
+		       o.update_by, o.update_date, o.is_active`,
+			from: `tb_order o
+		LEFT JOIN tb_customer c ON o.customer_id = c.customer_id
+		LEFT JOIN tb_warehouse w ON o.warehouse_id = w.warehouse_id`,
+			listOrder: "o.doc_date DESC",
+			scan: func(s scanner, o *models.Order) error {
+				var warehouseName *string
+				return s.Scan(&o.OrderID, &o.CustomerID, &o.CustomerName, &o.WarehouseID, &warehouseName,
+					&o.DocDate, &o.DocType, &o.TotalAmount, &o.DiscountAmount, &o.NetAmount, &o.VatAmount, &o.GrandTotal,
+					&o.UpdateBy, &o.UpdateDate, &o.IsActive)
+			},
+		},
+		itemTable: "tb_order_item",
+		parentKey: "order_id",
+		items: `
+		SELECT oi.auto_id, oi.order_id, oi.product_id, p.product_name,
+		       oi.qty, oi.unit_price, oi.discount_amount, oi.line_total, oi.remark
+		FROM tb_order_item oi
+		LEFT JOIN tb_product p ON oi.product_id = p.product_id
+		WHERE oi.order_id = @ID AND oi.is_delete = 0`,
+		scanItem: func(s scanner, item *models.OrderItem) error {
+			return s.Scan(&item.AutoID, &item.OrderID, &item.ProductID, &item.ProductName,
+				&item.Qty, &item.UnitPrice, &item.DiscountAmount, &item.LineTotal, &item.Remark)
+		},
+	}}
+}
+
+// NewMemoryOrderRepository สร้าง OrderRepository ในหน่วยความจำสำหรับทดสอบ
+func NewMemoryOrderRepository() OrderRepository {
+	return NewMemoryDocument[models.Order, models.OrderItem]("ORD", "order_id")
+}
+
+func (r *orderRepository) Create(ctx context.Context, doc *Order, user string) (string, error) {
+	h := &doc.Header
+	return r.create(ctx, doc, `
+		INSERT INTO tb_order (
+			customer_id, warehouse_id, doc_date, doc_type,
+			total_amount, discount_amount, net_amount, vat_amount, grand_total,
+			update_by, is_active
+		)
+		OUTPUT INSERTED.autoID INTO @inserted
+		VALUES (
+			@CustomerID, @WarehouseID, @DocDate, @DocType,
+			@Total, @Discount, @Net, @Vat, @Grand,
+			@UpdateBy, 1
+		)`,
+		[]interface{}{
+			sql.Named("CustomerID", h.CustomerID),
+			sql.Named("WarehouseID", h.WarehouseID),
+			sql.Named("DocDate", h.DocDate),
+			sql.Named("DocType", h.DocType),
+			sql.Named("Total", h.TotalAmount),
+			sql.Named("Discount", h.DiscountAmount),
+			sql.Named("Net", h.NetAmount),
+			sql.Named("Vat", h.VatAmount),
+			sql.Named("Grand", h.GrandTotal),
+			sql.Named("UpdateBy", user),
+		}, `
+		INSERT INTO tb_order_item (order_id, product_id, qty, unit_price, discount_amount, line_total, remark)
+		VALUES (@OrderID, @ProductID, @Qty, @Price, @Discount, @Total, @Remark)`,
+		func(id string, item *models.OrderItem) []interface{} {
+			return []interface{}{
+				sql.Named("OrderID", id),
+				sql.Named("ProductID", item.ProductID),
+				sql.Named("Qty", item.Qty),
+				sql.Named("Price", item.UnitPrice),
+				sql.Named("Discount", item.DiscountAmount),
+				sql.Named("Total", item.LineTotal),
+				sql.Named("Remark", item.Remark),
+			}
+		},
+	)
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"context"
+	"database/sql"
+)
+
+// ProductRepository คือการทำงานกับ tb_product
+type ProductRepository interface {
+	Repository[models.Product]
+}
+
+type productRepository struct {
+	sqlTable[models.Product]
+}
+
+// NewProductRepository สร้าง ProductRepository บนฐานข้อมูล dbs
+func NewProductRepository(dbs *replica.Router) ProductRepository {
+	return &productRepository{sqlTable[models.Product]{
+		db:       dbs.Primary(),
+		reads:    dbs,
+		table:    "tb_product",
+		idColumn: "product_id",
+		columns: `autoID, prefix, product_id, product_name_th, product_name_en,
+		       product_type_id, format_type_id, vendor_id, unit_type_id,
+		       isbn, author_name, publisher_date, edition_number,
+		       price, cost, description, note,
+		       count_stock, is_active, is_delete, update_by, update_date`,
+		from:   "tb_product",
+		search: []string{"product_name_th", "product_name_en"},
+		scan: func(s scanner, p *models.Product) error {
+			return s.Scan(
+				&p.AutoID, &p.Prefix, &p.ProductID, &p.ProductNameTH, &p.ProductNameEN,
+				&p.ProductTypeID, &p.FormatTypeID, &p.VendorID, &p.UnitTypeID,
+				&p.ISBN, &p.AuthorName, &p.PublisherDate, &p.EditionNumber,
+				&p.Price, &p.Cost, &p.Description, &p.Note,
+				&p.CountStock, &p.IsActive, &p.IsDelete, &p.UpdateBy, &p.UpdateDate,
+			)
+		},
+	}}
+}
+
+// NewMemoryProductRepository สร้าง ProductRepository ในหน่วยความจำสำหรับทดสอบ
+func NewMemoryProductRepository() ProductRepository {
+	return NewMemory[models.Product]("PDT", "product_id", "product_name_th", "product_name_en")
+}
+
+func (r *productRepository) Create(ctx context.Context, p *models.Product) (string, error) {
+	// 🚩 DUMMY ID for TRIGGER mechanism (product_id is NOT NULL)
+	dummyID := "TEMP"
+
+	return r.insert(ctx, p, `
+		INSERT INTO tb_product (
+			product_id, product_name_th, product_name_en,
+			product_type_id, format_type_id, vendor_id, unit_type_id,
+			isbn, author_name, publisher_date, edition_number,
+			price, cost, description, note,
+			count_stock, update_by
+		)
+		OUTPUT INSERTED.autoID INTO @inserted
+		VALUES (
+			@ProductID, @NameTH, @NameEN,
+			@TypeID, @FormatID, @VendorID, @UnitID,
+			@ISBN, @Author, @PubDate, @Edition,
+			@Price, @Cost, @Desc, @Note,
+			@CountStock, @UpdateBy
+		)`,
+		sql.Named("ProductID", dummyID), // Passed strictly to satisfy NOT NULL constraint
+		sql.Named("NameTH", p.ProductNameTH),
+		sql.Named("NameEN", p.ProductNameEN),
+		sql.Named("TypeID", p.ProductTypeID),
+		sql.Named("FormatID", p.FormatTypeID),
+		sql.Named("VendorID", p.VendorID),
+		sql.Named("UnitID", p.UnitTypeID),
+		sql.Named("ISBN", p.ISBN),
+		sql.Named("Author", p.AuthorName),
+		sql.Named("PubDate", p.PublisherDate),
+		sql.Named("Edition", p.EditionNumber),
+		sql.Named("Price", p.Price),
+		sql.Named("Cost", p.Cost),
+		sql.Named("Desc", p.Description),
+		sql.Named("Note", p.Note),
+		sql.Named("CountStock", p.CountStock),
+		sql.Named("UpdateBy", p.UpdateBy),
+	)
+}
+
+func (r *productRepository) Update(ctx context.Context, id string, p *models.Product) error {
+	return r.update(ctx, id, p, `
+		UPDATE tb_product
+		SET product_name_th = COALESCE(NULLIF(@NameTH, ''), product_name_th),
+			product_name_en = @NameEN,
+			product_type_id = @TypeID,
+			format_type_id = @FormatID,
+			vendor_id = @VendorID,
+			unit_type_id = @UnitID,
+			isbn = @ISBN,
+			author_name = @Author,
+			publisher_date = @PubDate,
+			edition_number = @Edition,
+			price = @Price,
+			cost = @Cost,
+			description = @Desc,
+			note = @Note,
+			count_stock = @CountStock,
+			update_by = @UpdateBy
+		WHERE product_id = @ID AND is_delete = 0`,
+		sql.Named("NameTH", p.ProductNameTH),
+		sql.Named("NameEN", p.ProductNameEN),
+		sql.Named("TypeID", p.ProductTypeID),
+		sql.Named("FormatID", p.FormatTypeID),
+		sql.Named("VendorID", p.VendorID),
+		sql.Named("UnitID", p.UnitTypeID),
+		sql.Named("ISBN", p.ISBN),
+		sql.Named("Author", p.AuthorName),
+		sql.Named("PubDate", p.PublisherDate),
+		sql.Named("Edition", p.EditionNumber),
+		sql.Named("Price", p.Price),
+		sql.Named("Cost", p.Cost),
+		sql.Named("Desc", p.Description),
+		sql.Named("Note", p.Note),
+		sql.Named("CountStock", p.CountStock),
+		sql.Named("UpdateBy", p.UpdateBy),
+		sql.Named("ID", id),
+	)
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"context"
+	"database/sql"
+)
+
+// ProductCategoryRepository คือการทำงานกับ tb_product_category
+type ProductCategoryRepository interface {
+	Repository[models.ProductCategory]
+}
+
+type productCategoryRepository struct {
+	sqlTable[models.ProductCategory]
+}
+
+// NewProductCategoryRepository สร้าง ProductCategoryRepository บนฐานข้อมูล dbs
+func NewProductCategoryRepository(dbs *replica.Router) ProductCategoryRepository {
+	return &productCategoryRepository{sqlTable[models.ProductCategory]{
+		db:       dbs.Primary(),
+		reads:    dbs,
+		table:    "tb_product_category",
+		idColumn: "product_category_id",
+		columns:  "product_category_id, category_name, category_code, description, update_by, update_date, is_active, is_delete",
+		from:     "tb_product_category",
+		search:   []string{"category_name"},
+		scan: func(s scanner, pc *models.ProductCategory) error {
+			return s.Scan(&pc.ProductCategoryID, &pc.CategoryName, &pc.CategoryCode, &pc.Description, &pc.UpdateBy, &pc.UpdateDate, &pc.IsActive, &pc.IsDelete)
+		},
+	}}
+}
+
+// NewMemoryProductCategoryRepository สร้าง ProductCategoryRepository ในหน่วยความจำสำหรับทดสอบ
+func NewMemoryProductCategoryRepository() ProductCategoryRepository {
+	return NewMemory[models.ProductCategory]("PC", "product_category_id", "category_name")
+}
+
+func (r *productCategoryRepository) Create(ctx context.Context, pc *models.ProductCategory) (string, error) {
+	return r.insert(ctx, pc, `
+		INSERT INTO tb_product_category (category_name, category_code, description, update_by)
+		OUTPUT INSERTED.autoID INTO @inserted
+		VALUES (@CategoryName, @CategoryCode, @Description, @UpdateBy)`,
+		sql.Named("CategoryName", pc.CategoryName),
+		sql.Named("CategoryCode", pc.CategoryCode),
+		sql.Named("Description", pc.Description),
+		sql.Named("UpdateBy", pc.UpdateBy),
+	)
+}
+
+func (r *productCategoryRepository) Update(ctx context.Context, id string, pc *models.ProductCategory) error {
+	return r.update(ctx, id, pc, `
+		UPDATE tb_product_category
+		SET category_name = COALESCE(NULLIF(@CategoryName, ''), category_name),
+			category_code = COALESCE(NULLIF(@CategoryCode, ''), category_code),
+		    description = COALESCE(@Description, description),
+		    update_by = @UpdateBy,
+		    is_active = COALESCE(@Status, is_active)
+		WHERE product_category_id = @ID AND is_delete = 0`,
+		sql.Named("CategoryName", pc.CategoryName),
+		sql.Named("CategoryCode", pc.CategoryCode),
+		sql.Named("Description", pc.Description),
+		sql.Named("UpdateBy", pc.UpdateBy),
+		sql.Named("Status", pc.IsActive),
+		sql.Named("ID", id),
+	)
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"context"
+	"database/sql"
+)
+
+// ProductFormatTypeRepository คือการทำงานกับ tb_product_format_type
+type ProductFormatTypeRepository interface {
+	Repository[models.ProductFormatType]
+}
+
+type productFormatTypeRepository struct {
+	sqlTable[models.ProductFormatType]
+}
+
+// NewProductFormatTypeRepository สร้าง ProductFormatTypeRepository บนฐานข้อมูล dbs
+func NewProductFormatTypeRepository(dbs *replica.Router) ProductFormatTypeRepository {
+	return &productFormatTypeRepository{sqlTable[models.ProductFormatType]{
+		db:       dbs.Primary(),
+		reads:    dbs,
+		table:    "tb_product_format_type",
+		idColumn: "product_format_type_id",
+		columns:  "product_format_type_id, format_name, description, update_by, update_date, is_active, is_delete",
+		from:     "tb_product_format_type",
+		search:   []string{"format_name"},
+		scan: func(s scanner, ft *models.ProductFormatType) error {
+			return s.Scan(&ft.ProductFormatTypeID, &ft.FormatName, &ft.Description, &ft.UpdateBy, &ft.UpdateDate, &ft.IsActive, &ft.IsDelete)
+		},
+	}}
+}
+
+// NewMemoryProductFormatTypeRepository สร้าง ProductFormatTypeRepository ในหน่วยความจำสำหรับทดสอบ
+func NewMemoryProductFormatTypeRepository() ProductFormatTypeRepository {
+	return NewMemory[models.ProductFormatType]("PF", "product_format_type_id", "format_name")
+}
+
+func (r *productFormatTypeRepository) Create(ctx context.Context, ft *models.ProductFormatType) (string, error) {
+	return r.insert(ctx, ft, `
+		INSERT INTO tb_product_format_type (format_name, description, update_by)
+		OUTPUT INSERTED.autoID INTO @inserted
+		VALUES (@FormatName, @Description, @UpdateBy)`,
+		sql.Named("FormatName", ft.FormatName),
+		sql.Named("Description", ft.Description),
+		sql.Named("UpdateBy", ft.UpdateBy),
+	)
+}
+
+func (r *productFormatTypeRepository) Update(ctx context.Context, id string, ft *models.ProductFormatType) error {
+	return r.update(ctx, id, ft, `
+		UPDATE tb_product_format_type
+		SET format_name = COALESCE(NULLIF(@FormatName, ''), format_name),
+		    description = COALESCE(@Description, description),
+		    update_by = @UpdateBy,
+		    is_active = COALESCE(@IsActive, is_active)
+		WHERE product_format_type_id = @ID AND is_delete = 0`,
+		sql.Named("FormatName", ft.FormatName),
+		sql.Named("Description", ft.Description),
+		sql.Named("UpdateBy", ft.UpdateBy),
+		sql.Named("IsActive", ft.IsActive),
+		sql.Named("ID", id),
+	)
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"context"
+	"database/sql"
+)
+
+// ProductGroupRepository คือการทำงานกับ tb_product_group (พร้อมชื่อหมวดหมู่จาก tb_product_category)
+type ProductGroupRepository interface {
+	Repository[models.ProductGroup]
+}
+
+type productGroupRepository struct {
+	sqlTable[models.ProductGroup]
+}
+
+// NewProductGroupRepository สร้าง ProductGroupRepository บนฐานข้อมูล dbs
+func NewProductGroupRepository(dbs *replica.Router) ProductGroupRepository {
+	return &productGroupRepository{sqlTable[models.ProductGroup]{
+		db:       dbs.Primary(),
+		reads:    dbs,
+		table:    "tb_product_group",
+		idColumn: "product_group_id",
+		alias:    "g",
+		columns: `g.product_group_id, g.product_category_id, g.product_group_name, g.description,
+		       g.update_by, g.update_date, g.is_active,
+		       c.category_name`,
+		from: `tb_product_group g
+		LEFT JOIN tb_product_category c ON g.product_category_id = c.product_category_id`,
+		search: []string{"g.product_group_name"},
+		active: "is_active",
+		scan: func(s scanner, item *models.ProductGroup) error {
+			var upd sql.NullTime
+			if err := s.Scan(&item.ProductGroupID, &item.ProductCategoryID, &item.ProductGroupName, &item.Description,
+				&item.UpdateBy, &upd, &item.IsActive, &item.CategoryName); err != nil {
+				return err
+			}
+			item.UpdateDate = formatDate(upd)
+			return nil
+		},
+	}}
+}
+
+// NewMemoryProductGroupRepository สร้าง ProductGroupRepository ในหน่วยความจำสำหรับทดสอบ
+func NewMemoryProductGroupRepository() ProductGroupRepository {
+	return NewMemory[models.ProductGroup]("PTG", "product_group_id", "product_group_name")
+}
+
+func (r *productGroupRepository) Create(ctx context.Context, item *models.ProductGroup) (string, error) {
+	return r.insert(ctx, item, `
+		INSERT INTO tb_product_group (product_category_id, product_group_name, description, update_by)
+		OUTPUT INSERTED.autoID INTO @inserted
+		VALUES (@CategoryID, @GroupName, @Description, @UpdateBy)`,
+		sql.Named("CategoryID", item.ProductCategoryID),
+		sql.Named("GroupName", item.ProductGroupName),
+		sql.Named("Description", item.Description),
+		sql.Named("UpdateBy", item.UpdateBy),
+	)
+}
+
+func (r *productGroupRepository) Update(ctx context.Context, id string, item *models.ProductGroup) error {
+	return r.update(ctx, id, item, `
+		UPDATE tb_product_group
+		SET product_category_id = COALESCE(NULLIF(@CategoryID, ''), product_category_id),
+			product_group_name = COALESCE(NULLIF(@GroupName, ''), product_group_name),
+			description = COALESCE(@Description, description),
+			update_by = @UpdateBy,
+			is_active = COALESCE(@IsActive, is_active)
+		WHERE product_group_id = @ID AND is_delete = 0`,
+		sql.Named("CategoryID", item.ProductCategoryID),
+		sql.Named("GroupName", item.ProductGroupName),
+		sql.Named("Description", item.Description),
+		sql.Named("UpdateBy", item.UpdateBy),
+		sql.Named("IsActive", item.IsActive),
+		sql.Named("ID", id),
+	)
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"context"
+	"database/sql"
+)
+
+// ProductPackConfigRepository คือการทำงานกับ tb_product_pack_config
+type ProductPackConfigRepository interface {
+	Repository[models.ProductPackConfig]
+}
+
+type productPackConfigRepository struct {
+	sqlTable[models.ProductPackConfig]
+}
+
+// NewProductPackConfigRepository สร้าง ProductPackConfigRepository บนฐานข้อมูล dbs
+// Search ค้นหาจากหมายเหตุหรือรหัสสินค้า
+func NewProductPackConfigRepository(dbs *replica.Router) ProductPackConfigRepository {
+	return &productPackConfigRepository{sqlTable[models.ProductPackConfig]{
+		db:       dbs.Primary(),
+		reads:    dbs,
+		table:    "tb_product_pack_config",
+		idColumn: "product_pack_config_id",
+		columns:  "autoID, product_pack_config_id, product_id, bundle_qty, unit_type_id, note, update_by, update_date, id_status",
+		from:     "tb_product_pack_config",
+		search:   []string{"note", "product_id"},
+		scan: func(s scanner, cfg *models.ProductPackConfig) error {
+			return s.Scan(&cfg.AutoID, &cfg.ProductPackConfigID, &cfg.ProductID, &cfg.BundleQty, &cfg.UnitTypeID, &cfg.Note, &cfg.UpdateBy, &cfg.UpdateDate, &cfg.IDStatus)
+		},
+	}}
+}
+
+// NewMemoryProductPackConfigRepository สร้าง ProductPackConfigRepository ในหน่วยความจำสำหรับทดสอบ
+func NewMemoryProductPackConfigRepository() ProductPackConfigRepository {
+	return NewMemory[models.ProductPackConfig]("PPC", "product_pack_config_id", "note", "product_id")
+}
+
+func (r *productPackConfigRepository) Create(ctx context.Context, cfg *models.ProductPackConfig) (string, error) {
+	return r.insert(ctx, cfg, `
+		INSERT INTO tb_product_pack_config (product_id, bundle_qty, unit_type_id, note, update_by, id_status)
+		OUTPUT INSERTED.autoID INTO @inserted
+		VALUES (@ProductID, @BundleQty, @UnitTypeID, @Note, @UpdateBy, @IDStatus)`,
+		sql.Named("ProductID", cfg.ProductID),
+		sql.Named("BundleQty", cfg.BundleQty),
+		sql.Named("UnitTypeID", cfg.UnitTypeID),
+		sql.Named("Note", cfg.Note),
+		sql.Named("UpdateBy", cfg.UpdateBy),
+		sql.Named("IDStatus", cfg.IDStatus),
+	)
+}
+
+// Update แทนที่ทุก field (ไม่ใช่ COALESCE) เพราะ Handler รับข้อมูลครบทั้งแถว
+func (r *productPackConfigRepository) Update(ctx context.Context, id string, cfg *models.ProductPackConfig) error {
+	return r.update(ctx, id, cfg, `
+		UPDATE tb_product_pack_config
+		SET product_id = @ProductID,
+			bundle_qty = @BundleQty,
+			unit_type_id = @UnitTypeID,
+			note = @Note,
+			update_by = @UpdateBy,
+			id_status = @IDStatus
+		WHERE product_pack_config_id = @ID AND is_delete = 0`,
+		sql.Named("ProductID", cfg.ProductID),
+		sql.Named("BundleQty", cfg.BundleQty),
+		sql.Named("UnitTypeID", cfg.UnitTypeID),
+		sql.Named("Note", cfg.Note),
+		sql.Named("UpdateBy", cfg.UpdateBy),
+		sql.Named("IDStatus", cfg.IDStatus),
+		sql.Named("ID", id),
+	)
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"PenbunAPI/utils"
+	"context"
+	"database/sql"
+)
+
+// ReceiveNote คือใบรับสินค้า (Header + Items)
+type ReceiveNote = Document[models.ReceiveNote, models.ReceiveItem]
+
+// ReceiveRepository คือการทำงานกับ tb_receive_note และ tb_receive_item
+type ReceiveRepository interface {
+	DocumentRepository[models.ReceiveNote, models.ReceiveItem]
+	// UpdateNote แก้ไขเฉพาะ ref_invoice_no และ note ของ Header หรือคืน ErrNotFound
+	UpdateNote(ctx context.Context, id string, refInvoiceNo, note *string, user string) error
+}
+
+type receiveRepository struct {
+	sqlDocument[models.ReceiveNote, models.ReceiveItem]
+}
+
+// NewReceiveRepository สร้าง ReceiveRepository บนฐานข้อมูล dbs
+func NewReceiveRepository(dbs *replica.Router) ReceiveRepository {
+	return &receiveRepository{sqlDocument[models.ReceiveNote, models.ReceiveItem]{
+		sqlTable: sqlTable[models.ReceiveNote]{
+			db:       dbs.Primary(),
+			reads:    dbs,
+			table:    "tb_receive_note",
+			idColumn: "receive_note_id",
+			alias:    "r",
+			columns: `r.receive_note_id, r.vendor_id, v.vendor_name, r.warehouse_id, w.warehouse_name,
+		       r.doc_date, r.ref_invoice_no, r.receive_type, r.total_amount, r.note,
+		       r.update_by, r.update_date, r.is_active`,
+			from: `tb_receive_note r
+		LEFT JOIN tb_vendor v ON r.vendor_id = v.vendor_id
+		LEFT JOIN tb_warehouse w ON r.warehouse_id = w.warehouse_id`,
+			listOrder: "r.doc_date DESC",
+			scan: func(s scanner, r *models.ReceiveNote) error {
+				var warehouseName *string
+				return s.Scan(&r.ReceiveNoteID, &r.VendorID, &r.VendorName, &r.WarehouseID, &warehouseName,
+					&r.DocDate, &r.RefInvoiceNo, &r.ReceiveType, &r.TotalAmount, &r.Note,
+					&r.UpdateBy, &r.UpdateDate, &r.IsActive)
+			},
+		},
+		itemTable: "tb_receive_item",
+		parentKey: "receive_note_id",
+		items: `
+		SELECT ri.auto_id, ri.receive_note_id, ri.product_id, p.product_name,
+		       ri.qty, ri.unit_cost, ri.line_total, ri.remark
+		FROM tb_receive_item ri
+		LEFT JOIN tb_product p ON ri.product_id = p.product_id
+		WHERE ri.receive_note_id = @ID AND ri.is_delete = 0`,
+		scanItem: func(s scanner, item *models.ReceiveItem) error {
+			return s.Scan(&item.AutoID, &item.ReceiveNoteID, &item.ProductID, &item.ProductName,
+				&item.Qty, &item.UnitCost, &item.LineTotal, &item.Remark)
+		},
+	}}
+}
+
+// NewMemoryReceiveRepository สร้าง ReceiveRepository ในหน่วยความจำสำหรับทดสอบ
+func NewMemoryReceiveRepository() ReceiveRepository {
+	return &memoryReceiveRepository{NewMemoryDocument[models.ReceiveNote, models.ReceiveItem]("RCV", "receive_note_id")}
+}
+
+func (r *receiveRepository) Create(ctx context.Context, doc *ReceiveNote, user string) (string, error) {
+	h := &doc.Header
+	return r.create(ctx, doc, `
+		INSERT INTO tb_receive_note (
+			vendor_id, warehouse_id, doc_date, ref_invoice_no, receive_type,
+			total_amount, note, update_by, is_active
+		)
+		OUTPUT INSERTED.autoID INTO @inserted
+		VALUES (
+			@VendorID, @WarehouseID, @DocDate, @RefInvoiceNo, @ReceiveType,
+			@TotalAmount, @Note, @UpdateBy, 1
+		)`,
+		[]interface{}{
+			sql.Named("VendorID", h.VendorID),
+			sql.Named("WarehouseID", h.WarehouseID),
+			sql.Named("DocDate", h.DocDate),
+			sql.Named("RefInvoiceNo", h.RefInvoiceNo),
+			sql.Named("ReceiveType", h.ReceiveType),
+			sql.Named("TotalAmount", h.TotalAmount), // Should be calculated but take from FE for now
+			sql.Named("Note", h.Note),
+			sql.Named("UpdateBy", user),
+		}, `
+		INSERT INTO tb_receive_item (receive_note_id, product_id, qty, unit_cost, line_total, remark)
+		VALUES (@NoteID, @ProductID, @Qty, @Cost, @Total, @Remark)`,
+		func(id string, item *models.ReceiveItem) []interface{} {
+			// Update Stock จะทำใน Layer 7 (ตอนนี้บันทึกเอกสารอย่างเดียว)
+			return []interface{}{
+				sql.Named("NoteID", id),
+				sql.Named("ProductID", item.ProductID),
+				sql.Named("Qty", item.Qty),
+				sql.Named("Cost", item.UnitCost),
+				sql.Named("Total", item.LineTotal),
+				sql.Named("Remark", item.Remark),
+			}
+		},
+	)
+}
+
+func (r *receiveRepository) UpdateNote(ctx context.Context, id string, refInvoiceNo, note *string, user string) error {
+	return utils.ExecuteTransaction(ctx, r.db, []func(tx *sql.Tx) error{
+		func(tx *sql.Tx) error {
+			return r.precondition(ctx, tx, id)
+		},
+		func(tx *sql.Tx) error {
+			return affected(tx.ExecContext(ctx, `
+				UPDATE tb_receive_note
+				SET ref_invoice_no = @Ref, note = @Note, update_by = @UpdateBy,
+				    update_date = `+r.dialect().Now()+`
+				WHERE receive_note_id = @ID AND is_delete = 0`,
+				sql.Named("Ref", refInvoiceNo),
+				sql.Named("Note", note),
+				sql.Named("UpdateBy", user),
+				sql.Named("ID", id),
+			))
+		},
+	})
+}
+
+type memoryReceiveRepository struct {
+	*MemoryDocument[models.ReceiveNote, models.ReceiveItem]
+}
+
+func (m *memoryReceiveRepository) UpdateNote(ctx context.Context, id string, refInvoiceNo, note *string, user string) error {
+	return m.Update(ctx, id, &models.ReceiveNote{RefInvoiceNo: refInvoiceNo, Note: note, UpdateBy: user})
+}
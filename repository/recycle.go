@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"PenbunAPI/dialect"
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"PenbunAPI/utils"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// RecycleBin คือข้อมูลของตารางที่ใช้กับ Restore และถังขยะ (Recycle Bin)
+type RecycleBin struct {
+	Table      string      // ตารางหลัก
+	IDColumn   string      // รหัสของแถว
+	NameColumn string      // คอลัมน์ที่แสดงเป็นชื่อในถังขยะ
+	Model      interface{} // model ที่มี tag `ref` สำหรับตรวจสอบก่อน Restore
+	ItemTable  string      // ตาราง items ของเอกสาร (Restore พร้อม Header)
+	ItemModel  interface{}
+}
+
+// RecycleRepository คือการทำงานกับแถวที่ถูก Soft Delete (is_delete = 1) ของทุก Module
+type RecycleRepository interface {
+	// Restore เปลี่ยน is_delete กลับเป็น 0 โดยไม่เปลี่ยนสถานะ is_active (แถวที่ถูกลบจึงกลับมาแบบปิดใช้งาน)
+	// items ของเอกสารกู้คืนเฉพาะรายการที่ถูกลบพร้อม Header (update_date เดียวกัน ดู DocumentRepository.SoftDelete)
+	// คืน ErrNotFound หากไม่มีในถังขยะ และคืน 409 จาก utils.CheckRowRefs หากข้อมูลที่แถวนี้อ้างถึงถูกลบไปแล้ว
+	Restore(ctx context.Context, bin RecycleBin, id, user string) error
+	Page(ctx context.Context, bin RecycleBin, lq utils.ListQuery) ([]models.DeletedRecord, *int, error)
+}
+
+type recycleRepository struct {
+	db    *sql.DB
+	reads *replica.Router
+}
+
+// NewRecycleRepository สร้าง RecycleRepository บนฐานข้อมูล dbs
+func NewRecycleRepository(dbs *replica.Router) RecycleRepository {
+	return &recycleRepository{db: dbs.Primary(), reads: dbs}
+}
+
+func (r *recycleRepository) Restore(ctx context.Context, bin RecycleBin, id, user string) error {
+	d := dialect.Of(r.db)
+	var steps []func(tx *sql.Tx) error
+	if bin.ItemTable != "" {
+		// ต้องทำก่อน Header เพราะเทียบกับ update_date ของ Header ขณะที่ถูกลบ
+		// items ที่ถูกลบแยกไว้ก่อนหน้า (update_date ไม่ตรง) ยังคงอยู่ในสถานะลบ
+		steps = append(steps, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `
+				UPDATE `+bin.ItemTable+`
+				SET is_delete = 0
+				WHERE `+bin.IDColumn+` = @ID AND is_delete = 1
+				  AND update_date = (SELECT update_date FROM `+bin.Table+` WHERE `+bin.IDColumn+` = @ID AND is_delete = 1)`,
+				sql.Named("ID", id))
+			return err
+		})
+	}
+	steps = append(steps,
+		func(tx *sql.Tx) error {
+			return affected(tx.ExecContext(ctx, `
+				UPDATE `+bin.Table+`
+				SET is_delete = 0,
+				    update_by = @UpdateBy,
+				    update_date = `+d.Now()+`
+				WHERE `+bin.IDColumn+` = @ID AND is_delete = 1`,
+				sql.Named("ID", id), sql.Named("UpdateBy", user)))
+		},
+		func(tx *sql.Tx) error {
+			return utils.CheckRowRefs(ctx, tx, d, bin.Table, bin.IDColumn, id, bin.Model)
+		},
+	)
+	if bin.ItemTable != "" {
+		steps = append(steps, func(tx *sql.Tx) error {
+			return utils.CheckRowRefs(ctx, tx, d, bin.ItemTable, bin.IDColumn, id, bin.ItemModel)
+		})
+	}
+	return utils.ExecuteTransaction(ctx, r.db, steps)
+}
+
+func (r *recycleRepository) Page(ctx context.Context, bin RecycleBin, lq utils.ListQuery) ([]models.DeletedRecord, *int, error) {
+	query := `
+		SELECT ` + bin.IDColumn + `, ` + bin.NameColumn + `, update_by, update_date
+		FROM ` + bin.Table + `
+		WHERE is_delete = 1` + lq.Where + `
+		ORDER BY ` + lq.OrderBy + dialect.Of(r.db).Paginate("@Offset", "@Limit")
+	var list []models.DeletedRecord
+	var total *int
+	err := r.reads.Read(ctx, func(db *sql.DB) error {
+		list = nil
+		rows, err := db.QueryContext(ctx, query, lq.With(sql.Named("Offset", lq.Offset), sql.Named("Limit", lq.Fetch()))...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var item models.DeletedRecord
+			var deleted sql.NullTime
+			if err := rows.Scan(&item.ID, &item.Name, &item.DeletedBy, &deleted); err != nil {
+				return err
+			}
+			item.DeletedDate = formatDate(deleted)
+			list = append(list, item)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		total, err = lq.Count(ctx, db, `SELECT COUNT(*) FROM `+bin.Table+` WHERE is_delete = 1`+lq.Where)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return list, total, nil
+}
+
+// Recyclable คือ Repository ในหน่วยความจำที่ใช้กับ NewMemoryRecycleRepository ได้ (เช่น *Memory[T])
+type Recyclable interface {
+	Restore(id string) error
+	Deleted() []models.DeletedRecord
+}
+
+type memoryRecycleRepository struct {
+	mu     sync.Mutex
+	tables map[string]Recyclable
+}
+
+// NewMemoryRecycleRepository สร้าง RecycleRepository ในหน่วยความจำสำหรับทดสอบ
+// tables จับคู่ชื่อตาราง (RecycleBin.Table) กับ Repository ในหน่วยความจำของ Module นั้น
+// ตารางที่ไม่ได้ส่งมาถือว่าถังขยะว่าง
+func NewMemoryRecycleRepository(tables map[string]Recyclable) RecycleRepository {
+	return &memoryRecycleRepository{tables: tables}
+}
+
+func (r *memoryRecycleRepository) table(bin RecycleBin) Recyclable {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.tables[bin.Table]
+}
+
+func (r *memoryRecycleRepository) Restore(ctx context.Context, bin RecycleBin, id, user string) error {
+	t := r.table(bin)
+	if t == nil {
+		return ErrNotFound
+	}
+	return t.Restore(id)
+}
+
+func (r *memoryRecycleRepository) Page(ctx context.Context, bin RecycleBin, lq utils.ListQuery) ([]models.DeletedRecord, *int, error) {
+	var list []models.DeletedRecord
+	if t := r.table(bin); t != nil {
+		list = t.Deleted()
+	}
+	var total *int
+	if lq.WithTotal {
+		n := len(list)
+		total = &n
+	}
+	if lq.Offset >= len(list) {
+		return nil, total, nil
+	}
+	list = list[lq.Offset:]
+	if len(list) > lq.Fetch() {
+		list = list[:lq.Fetch()]
+	}
+	return list, total, nil
+}
@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"PenbunAPI/dialect"
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"PenbunAPI/utils"
+	"context"
+	"database/sql"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ReferenceRepository คือการทำงานกับ tb_reference (ไม่มี Soft Delete)
+// ชื่อคอลัมน์ที่ส่งเข้ามาต้องผ่าน allow-list ของ Handler แล้ว เพราะถูกต่อเป็น SQL โดยตรง
+type ReferenceRepository interface {
+	// Find คืนแถวแรก (ตาม row_id) ที่ column = value หรือ ErrNotFound
+	Find(ctx context.Context, column, value string) (models.Reference, error)
+	// Lookup คืนทุกรายการในกลุ่ม refID (ผ่าน cache ของ utils.LookupReference)
+	Lookup(ctx context.Context, refID string) ([]models.Reference, error)
+	// List คืนทุกแถวที่ตรงกับ filters (คอลัมน์ -> ค่า) เรียงตาม ref_id, ref_int, row_id
+	List(ctx context.Context, filters map[string]string) ([]models.Reference, error)
+	// Create เพิ่มรายการ และบันทึก row_id ที่ได้ลงใน item
+	Create(ctx context.Context, item *models.Reference) error
+	// Update แก้ไขรายการตาม row_id คืน ref_id เดิม (เพื่อล้าง cache ของกลุ่มเดิม) หรือ ErrNotFound
+	Update(ctx context.Context, rowID int, item *models.Reference) (string, error)
+}
+
+type referenceRepository struct {
+	db    *sql.DB
+	reads *replica.Router
+}
+
+// NewReferenceRepository สร้าง ReferenceRepository บนฐานข้อมูล dbs
+func NewReferenceRepository(dbs *replica.Router) ReferenceRepository {
+	return &referenceRepository{db: dbs.Primary(), reads: dbs}
+}
+
+func (r *referenceRepository) Find(ctx context.Context, column, value string) (models.Reference, error) {
+	query := dialect.Of(r.db).SelectFirst(utils.ReferenceColumns, "FROM tb_reference WHERE "+column+" = @Value ORDER BY row_id")
+	var item models.Reference
+	err := r.reads.Read(ctx, func(db *sql.DB) (err error) {
+		item, err = utils.ScanReference(db.QueryRowContext(ctx, query, sql.Named("Value", value)))
+		return err
+	})
+	if err == sql.ErrNoRows {
+		return item, ErrNotFound
+	}
+	return item, err
+}
+
+// Lookup อ่านจากฐานข้อมูลหลักเพราะผลถูก cache ไว้ (ไม่ให้ข้อมูลที่ Replica ยังตามไม่ทันค้างอยู่ใน cache)
+func (r *referenceRepository) Lookup(ctx context.Context, refID string) ([]models.Reference, error) {
+	return utils.LookupReference(ctx, r.db, refID)
+}
+
+func (r *referenceRepository) List(ctx context.Context, filters map[string]string) ([]models.Reference, error) {
+	var where []string
+	var args []interface{}
+	for column, value := range filters {
+		where = append(where, column+" = @"+column)
+		args = append(args, sql.Named(column, value))
+	}
+	query := "SELECT " + utils.ReferenceColumns + " FROM tb_reference"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	list := []models.Reference{}
+	err := r.reads.Read(ctx, func(db *sql.DB) error {
+		list = list[:0]
+		rows, err := db.QueryContext(ctx, query+" ORDER BY ref_id, ref_int, row_id", args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			item, err := utils.ScanReference(rows)
+			if err != nil {
+				return err
+			}
+			list = append(list, item)
+		}
+		return rows.Err()
+	})
+	return list, err
+}
+
+func (r *referenceRepository) Create(ctx context.Context, item *models.Reference) error {
+	return utils.ExecuteTransaction(ctx, r.db, []func(tx *sql.Tx) error{
+		func(tx *sql.Tx) error {
+			rowID, err := dialect.Of(r.db).InsertIdentity(ctx, tx, `
+				INSERT INTO tb_reference (ref_id, ref_int, ref_text, update_by)
+				OUTPUT INSERTED.row_id INTO @inserted
+				VALUES (@RefID, @RefInt, @RefText, @UpdateBy)`,
+				sql.Named("RefID", item.RefID),
+				sql.Named("RefInt", item.RefInt),
+				sql.Named("RefText", item.RefText),
+				sql.Named("UpdateBy", item.UpdateBy),
+			)
+			item.RowID = int(rowID)
+			return err
+		},
+	})
+}
+
+func (r *referenceRepository) Update(ctx context.Context, rowID int, item *models.Reference) (string, error) {
+	var oldRefID string
+	err := utils.ExecuteTransaction(ctx, r.db, []func(tx *sql.Tx) error{
+		func(tx *sql.Tx) error {
+			err := utils.CheckRowVersion(ctx, tx, dialect.Of(r.db), "tb_reference", "row_id", rowID)
+			if err == nil {
+				err = tx.QueryRowContext(ctx, `SELECT ref_id FROM tb_reference WHERE row_id = @ID`, sql.Named("ID", rowID)).Scan(&oldRefID)
+			}
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		},
+		func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `
+				UPDATE tb_reference
+				SET ref_id = COALESCE(NULLIF(@RefID, ''), ref_id),
+					ref_int = COALESCE(@RefInt, ref_int),
+					ref_text = COALESCE(@RefText, ref_text),
+					update_by = @UpdateBy
+				WHERE row_id = @ID`,
+				sql.Named("RefID", item.RefID),
+				sql.Named("RefInt", item.RefInt),
+				sql.Named("RefText", item.RefText),
+				sql.Named("UpdateBy", item.UpdateBy),
+				sql.Named("ID", rowID),
+			)
+			return err
+		},
+		func(tx *sql.Tx) error {
+			return dialect.Of(r.db).Touch(ctx, tx, "tb_reference", "row_id", rowID)
+		},
+	})
+	return oldRefID, err
+}
+
+// memoryReferenceRepository คือ ReferenceRepository ในหน่วยความจำสำหรับทดสอบ
+type memoryReferenceRepository struct {
+	mu   sync.Mutex
+	seq  int
+	rows []models.Reference
+}
+
+// NewMemoryReferenceRepository สร้าง ReferenceRepository ในหน่วยความจำสำหรับทดสอบ
+func NewMemoryReferenceRepository() ReferenceRepository {
+	return &memoryReferenceRepository{}
+}
+
+// value คืนค่าของคอลัมน์ในรูป string เพื่อเทียบกับค่าจาก Query String
+func (m *memoryReferenceRepository) value(item models.Reference, column string) string {
+	switch column {
+	case "row_id":
+		return strconv.Itoa(item.RowID)
+	case "ref_id":
+		return item.RefID
+	case "ref_int":
+		if item.RefInt != nil {
+			return strconv.Itoa(*item.RefInt)
+		}
+	case "ref_text":
+		if item.RefText != nil {
+			return *item.RefText
+		}
+	}
+	return ""
+}
+
+func (m *memoryReferenceRepository) Find(ctx context.Context, column, value string) (models.Reference, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, item := range m.rows {
+		if m.value(item, column) == value {
+			return item, nil
+		}
+	}
+	return models.Reference{}, ErrNotFound
+}
+
+func (m *memoryReferenceRepository) List(ctx context.Context, filters map[string]string) ([]models.Reference, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := []models.Reference{}
+	for _, item := range m.rows {
+		match := true
+		for column, value := range filters {
+			if m.value(item, column) != value {
+				match = false
+				break
+			}
+		}
+		if match {
+			list = append(list, item)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].RefID < list[j].RefID })
+	return list, nil
+}
+
+func (m *memoryReferenceRepository) Lookup(ctx context.Context, refID string) ([]models.Reference, error) {
+	return m.List(ctx, map[string]string{"ref_id": refID})
+}
+
+func (m *memoryReferenceRepository) Create(ctx context.Context, item *models.Reference) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seq++
+	item.RowID = m.seq
+	m.rows = append(m.rows, *item)
+	return nil
+}
+
+func (m *memoryReferenceRepository) Update(ctx context.Context, rowID int, item *models.Reference) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.rows {
+		row := &m.rows[i]
+		if row.RowID != rowID {
+			continue
+		}
+		old := row.RefID
+		if item.RefID != "" {
+			row.RefID = item.RefID
+		}
+		if item.RefInt != nil {
+			row.RefInt = item.RefInt
+		}
+		if item.RefText != nil {
+			row.RefText = item.RefText
+		}
+		row.UpdateBy = item.UpdateBy
+		return old, nil
+	}
+	return "", ErrNotFound
+}
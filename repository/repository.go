@@ -0,0 +1,64 @@
+// Package repository แยก SQL ออกจาก Controller
+// แต่ละ Module มี interface ของตัวเอง (เช่น ProductRepository) พร้อม Implementation บนฐานข้อมูล
+// (SQL Server หรือ SQLite โดยส่วนที่เขียนต่างกันอยู่ใน package dialect) และ Implementation ในหน่วยความจำ (Memory) สำหรับทดสอบ Handler โดยไม่ต้องมีฐานข้อมูล
+package repository
+
+import (
+	"PenbunAPI/utils"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrNotFound คือ Error เมื่อไม่พบแถวตามรหัส (หรือแถวนั้นถูก Soft Delete ไปแล้ว)
+var ErrNotFound = errors.New("record not found")
+
+// Repository คือการทำงานกับข้อมูลหลักหนึ่งตาราง โดยไม่อ่านหรือแก้ไขแถวที่ถูก Soft Delete แล้ว
+type Repository[T any] interface {
+	// List คืนข้อมูลทั้งหมด (Select All)
+	List(ctx context.Context) ([]T, error)
+	// Page คืนข้อมูลตาม filter/sort/paging ของ lq จำนวน lq.Fetch() แถว (ให้ Handler ตัดด้วย utils.CursorPage)
+	// พร้อม total (nil หากไม่ได้ขอ)
+	Page(ctx context.Context, lq utils.ListQuery) ([]T, *int, error)
+	// Get คืนข้อมูลตามรหัส หรือ ErrNotFound
+	Get(ctx context.Context, id string) (T, error)
+	// Search ค้นหาตามชื่อแบบ LIKE
+	Search(ctx context.Context, name string) ([]T, error)
+	// Create ตรวจสอบ foreign key (tag `ref`) แล้วเพิ่มข้อมูล คืนรหัสที่ Trigger สร้างให้
+	Create(ctx context.Context, v *T) (string, error)
+	// Update ตรวจสอบ foreign key แล้วแก้ไขข้อมูลตามรหัส หรือคืน ErrNotFound
+	Update(ctx context.Context, id string, v *T) error
+	// SoftDelete เปลี่ยน is_delete = 1 หลังตรวจสอบข้อมูลที่ยังอ้างถึง (utils.GuardDelete)
+	SoftDelete(ctx context.Context, id, user string, cascade bool) error
+	// Purge ลบข้อมูลจริงหลังตรวจสอบข้อมูลที่อ้างถึง (utils.GuardRemove)
+	Purge(ctx context.Context, id string) error
+}
+
+// scanner คือ *sql.Row หรือ *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// formatDate แปลงวันที่จากฐานข้อมูลเป็น string รูปแบบเดียวกับที่ Response ใช้ (utils.FormatDate, nil หากเป็น NULL)
+func formatDate(t sql.NullTime) *string {
+	if !t.Valid {
+		return nil
+	}
+	s := utils.FormatDate(t.Time)
+	return &s
+}
+
+// affected แปลงจำนวนแถวที่ถูกแก้ไขเป็น ErrNotFound เมื่อไม่มีแถวใดถูกแก้ไข
+func affected(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
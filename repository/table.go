@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"PenbunAPI/dialect"
+	"PenbunAPI/replica"
+	"PenbunAPI/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// sqlTable คือส่วนที่เหมือนกันของ Repository บนฐานข้อมูล (SQL Server หรือ SQLite ตาม dialect.Of)
+// ทุก method อ่านข้อมูลด้วยคอลัมน์และ JOIN ชุดเดียวกัน จึงไม่ต้องเขียน SELECT ซ้ำในแต่ละ Handler
+// Repository ของแต่ละ Module ฝัง sqlTable ไว้แล้วเขียนเฉพาะ Create และ Update ของตัวเอง
+type sqlTable[T any] struct {
+	db        *sql.DB         // ฐานข้อมูลหลัก สำหรับการเขียนและการตรวจสอบก่อนเขียน
+	reads     *replica.Router // ฐานข้อมูลสำหรับ List/Page/Get/Search (Read Replica หากมีและ ctx ไม่ได้บังคับ Primary)
+	table     string          // ตารางหลัก เช่น tb_customer
+	idColumn  string          // รหัสหลัก เช่น customer_id
+	alias     string          // alias ของตารางหลักใน from (ว่างหากไม่มี JOIN)
+	columns   string          // คอลัมน์ของ SELECT ตามลำดับที่ scan อ่าน
+	from      string          // ตารางหลักพร้อม JOIN เช่น "tb_customer c LEFT JOIN ..."
+	search    []string        // คอลัมน์ที่ Search ค้นหาแบบ LIKE
+	listOrder string          // ORDER BY ของ List และ Search (ว่าง = ไม่เรียง)
+	active    string          // คอลัมน์สถานะที่ปิด (= 0) พร้อม Soft Delete (ว่างหากไม่ต้องปิด)
+	scan      func(s scanner, v *T) error
+}
+
+// dialect คืนส่วนของ SQL ที่ขึ้นกับฐานข้อมูลของ t.db
+func (t *sqlTable[T]) dialect() dialect.Dialect {
+	return dialect.Of(t.db)
+}
+
+// col คืนชื่อคอลัมน์ของตารางหลักพร้อม alias
+func (t *sqlTable[T]) col(name string) string {
+	if t.alias == "" {
+		return name
+	}
+	return t.alias + "." + name
+}
+
+// query อ่านแถวที่ยังไม่ถูกลบผ่าน t.reads โดย where ต้องขึ้นต้นด้วย " AND " (หรือว่าง)
+func (t *sqlTable[T]) query(ctx context.Context, where, tail string, args ...interface{}) ([]T, error) {
+	query := "SELECT " + t.columns + " FROM " + t.from + " WHERE " + t.col("is_delete") + " = 0" + where + tail
+	var list []T
+	err := t.reads.Read(ctx, func(db *sql.DB) error {
+		list = nil
+		rows, err := db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var v T
+			if err := t.scan(rows, &v); err != nil {
+				return err
+			}
+			list = append(list, v)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (t *sqlTable[T]) orderBy() string {
+	if t.listOrder == "" {
+		return ""
+	}
+	return " ORDER BY " + t.listOrder
+}
+
+func (t *sqlTable[T]) List(ctx context.Context) ([]T, error) {
+	return t.query(ctx, "", t.orderBy())
+}
+
+func (t *sqlTable[T]) Page(ctx context.Context, lq utils.ListQuery) ([]T, *int, error) {
+	list, err := t.query(ctx, lq.Where, " ORDER BY "+lq.OrderBy+t.dialect().Paginate("@Offset", "@Limit"),
+		lq.With(sql.Named("Offset", lq.Offset), sql.Named("Limit", lq.Fetch()))...)
+	if err != nil {
+		return nil, nil, err
+	}
+	var total *int
+	err = t.reads.Read(ctx, func(db *sql.DB) (err error) {
+		total, err = lq.Count(ctx, db, "SELECT COUNT(*) FROM "+t.from+" WHERE "+t.col("is_delete")+" = 0"+lq.Where)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return list, total, nil
+}
+
+func (t *sqlTable[T]) Get(ctx context.Context, id string) (T, error) {
+	var v T
+	list, err := t.query(ctx, " AND "+t.col(t.idColumn)+" = @ID", "", sql.Named("ID", id))
+	if err != nil {
+		return v, err
+	}
+	if len(list) == 0 {
+		return v, ErrNotFound
+	}
+	return list[0], nil
+}
+
+func (t *sqlTable[T]) Search(ctx context.Context, name string) ([]T, error) {
+	var or []string
+	for _, column := range t.search {
+		or = append(or, t.dialect().Contains(column, "@Name"))
+	}
+	return t.query(ctx, " AND ("+strings.Join(or, " OR ")+")", t.orderBy(), sql.Named("Name", name))
+}
+
+func (t *sqlTable[T]) SoftDelete(ctx context.Context, id, user string, cascade bool) error {
+	deactivate := ""
+	if t.active != "" {
+		deactivate = t.active + " = 0,"
+	}
+	return utils.ExecuteTransaction(ctx, t.db, []func(tx *sql.Tx) error{
+		func(tx *sql.Tx) error {
+			return t.precondition(ctx, tx, id)
+		},
+		func(tx *sql.Tx) error {
+			return utils.GuardDelete(ctx, tx, t.dialect(), t.table, id, user, cascade)
+		},
+		func(tx *sql.Tx) error {
+			return affected(tx.ExecContext(ctx, `
+				UPDATE `+t.table+`
+				SET is_delete = 1, `+deactivate+`
+				    update_by = @UpdateBy,
+				    update_date = `+t.dialect().Now()+`
+				WHERE `+t.idColumn+` = @ID AND is_delete = 0`,
+				sql.Named("ID", id), sql.Named("UpdateBy", user)))
+		},
+	})
+}
+
+func (t *sqlTable[T]) Purge(ctx context.Context, id string) error {
+	return utils.ExecuteTransaction(ctx, t.db, []func(tx *sql.Tx) error{
+		func(tx *sql.Tx) error {
+			return t.precondition(ctx, tx, id)
+		},
+		func(tx *sql.Tx) error {
+			return utils.GuardRemove(ctx, tx, t.table, id)
+		},
+		func(tx *sql.Tx) error {
+			return affected(tx.ExecContext(ctx, "DELETE FROM "+t.table+" WHERE "+t.idColumn+" = @ID", sql.Named("ID", id)))
+		},
+	})
+}
+
+// insert ตรวจสอบ foreign key ของ v แล้วรัน insertSQL (ต้องมี OUTPUT INSERTED.autoID INTO @inserted) ใน Transaction เดียวกัน
+// คืนรหัสที่ Trigger สร้างให้
+func (t *sqlTable[T]) insert(ctx context.Context, v *T, insertSQL string, args ...interface{}) (string, error) {
+	var id string
+	err := utils.ExecuteTransaction(ctx, t.db, []func(tx *sql.Tx) error{
+		func(tx *sql.Tx) error {
+			return utils.CheckRefs(ctx, tx, t.dialect(), v)
+		},
+		func(tx *sql.Tx) (err error) {
+			id, err = t.dialect().InsertReturningID(ctx, tx, t.table, t.idColumn, insertSQL, args...)
+			return err
+		},
+	})
+	return id, err
+}
+
+// update ตรวจสอบ foreign key ของ v แล้วรัน updateSQL (ต้องมีเงื่อนไข is_delete = 0) ของแถวรหัส id ใน Transaction เดียวกัน
+// คืน ErrNotFound หากไม่มีแถวถูกแก้ไข
+func (t *sqlTable[T]) update(ctx context.Context, id string, v *T, updateSQL string, args ...interface{}) error {
+	return utils.ExecuteTransaction(ctx, t.db, []func(tx *sql.Tx) error{
+		func(tx *sql.Tx) error {
+			return t.precondition(ctx, tx, id)
+		},
+		func(tx *sql.Tx) error {
+			return utils.CheckRefs(ctx, tx, t.dialect(), v)
+		},
+		func(tx *sql.Tx) error {
+			return affected(tx.ExecContext(ctx, updateSQL, args...))
+		},
+		func(tx *sql.Tx) error {
+			return t.dialect().Touch(ctx, tx, t.table, t.idColumn, id)
+		},
+	})
+}
+
+// precondition ตรวจ If-Match ของ Request กับแถวรหัส id (utils.CheckRowVersion) เป็นขั้นตอนแรกของ Transaction ของการเขียน
+// คืน ErrNotFound หากไม่พบแถว
+func (t *sqlTable[T]) precondition(ctx context.Context, tx *sql.Tx, id string) error {
+	err := utils.CheckRowVersion(ctx, tx, t.dialect(), t.table, t.idColumn, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
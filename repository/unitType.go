@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"context"
+	"database/sql"
+)
+
+// UnitTypeRepository คือการทำงานกับ tb_unit_type
+type UnitTypeRepository interface {
+	Repository[models.UnitType]
+}
+
+type unitTypeRepository struct {
+	sqlTable[models.UnitType]
+}
+
+// NewUnitTypeRepository สร้าง UnitTypeRepository บนฐานข้อมูล dbs
+func NewUnitTypeRepository(dbs *replica.Router) UnitTypeRepository {
+	return &unitTypeRepository{sqlTable[models.UnitType]{
+		db:       dbs.Primary(),
+		reads:    dbs,
+		table:    "tb_unit_type",
+		idColumn: "unit_type_id",
+		columns:  "unit_type_id, unit_type_name, COALESCE(description, ''), update_by, update_date, is_active",
+		from:     "tb_unit_type",
+		search:   []string{"unit_type_name"},
+		scan: func(s scanner, ut *models.UnitType) error {
+			return s.Scan(&ut.UnitTypeID, &ut.UnitTypeName, &ut.Description, &ut.UpdateBy, &ut.UpdateDate, &ut.IsActive)
+		},
+	}}
+}
+
+// NewMemoryUnitTypeRepository สร้าง UnitTypeRepository ในหน่วยความจำสำหรับทดสอบ
+func NewMemoryUnitTypeRepository() UnitTypeRepository {
+	return NewMemory[models.UnitType]("UT", "unit_type_id", "unit_type_name")
+}
+
+func (r *unitTypeRepository) Create(ctx context.Context, ut *models.UnitType) (string, error) {
+	return r.insert(ctx, ut, `
+		INSERT INTO tb_unit_type (unit_type_id, unit_type_name, description, update_by)
+		OUTPUT INSERTED.autoID INTO @inserted
+		VALUES (NULL, @Name, @Desc, @By)`,
+		sql.Named("Name", ut.UnitTypeName),
+		sql.Named("Desc", ut.Description),
+		sql.Named("By", ut.UpdateBy),
+	)
+}
+
+func (r *unitTypeRepository) Update(ctx context.Context, id string, ut *models.UnitType) error {
+	return r.update(ctx, id, ut, `
+		UPDATE tb_unit_type
+		SET unit_type_name = COALESCE(NULLIF(@Name, ''), unit_type_name),
+			description = COALESCE(@Desc, description),
+			update_by = @By,
+			is_active = COALESCE(@Status, is_active)
+		WHERE unit_type_id = @ID AND is_delete = 0`,
+		sql.Named("Name", ut.UnitTypeName),
+		sql.Named("Desc", ut.Description),
+		sql.Named("By", ut.UpdateBy),
+		sql.Named("Status", ut.IsActive),
+		sql.Named("ID", id),
+	)
+}
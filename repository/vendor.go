@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"context"
+	"database/sql"
+)
+
+// VendorRepository คือการทำงานกับ tb_vendor (พร้อมชื่อประเภทผู้จำหน่ายจาก tb_vendor_type)
+type VendorRepository interface {
+	Repository[models.Vendor]
+}
+
+type vendorRepository struct {
+	sqlTable[models.Vendor]
+}
+
+// NewVendorRepository สร้าง VendorRepository บนฐานข้อมูล dbs
+func NewVendorRepository(dbs *replica.Router) VendorRepository {
+	return &vendorRepository{sqlTable[models.Vendor]{
+		db:       dbs.Primary(),
+		reads:    dbs,
+		table:    "tb_vendor",
+		idColumn: "vendor_id",
+		alias:    "v",
+		columns: `v.vendor_id, v.vendor_type_id, v.vendor_name, v.tax_id, v.branch_name,
+		       v.contact_person, v.phone1, v.phone2, v.email, v.website,
+		       v.address, v.sub_district, v.district, v.province, v.zip_code,
+		       v.credit_term_day, v.currency, v.note,
+		       v.update_by, v.update_date, v.is_active,
+		       vt.type_name`,
+		from: `tb_vendor v
+		LEFT JOIN tb_vendor_type vt ON v.vendor_type_id = vt.vendor_type_id`,
+		search: []string{"v.vendor_name"},
+		active: "is_active",
+		scan: func(s scanner, item *models.Vendor) error {
+			var upd sql.NullTime
+			if err := s.Scan(
+				&item.VendorID, &item.VendorTypeID, &item.VendorName, &item.TaxID, &item.BranchName,
+				&item.ContactPerson, &item.Phone1, &item.Phone2, &item.Email, &item.Website,
+				&item.Address, &item.SubDistrict, &item.District, &item.Province, &item.ZipCode,
+				&item.CreditTermDay, &item.Currency, &item.Note,
+				&item.UpdateBy, &upd, &item.IsActive, &item.VendorTypeName,
+			); err != nil {
+				return err
+			}
+			item.UpdateDate = formatDate(upd)
+			return nil
+		},
+	}}
+}
+
+// NewMemoryVendorRepository สร้าง VendorRepository ในหน่วยความจำสำหรับทดสอบ
+func NewMemoryVendorRepository() VendorRepository {
+	return NewMemory[models.Vendor]("VEN", "vendor_id", "vendor_name")
+}
+
+func (r *vendorRepository) Create(ctx context.Context, item *models.Vendor) (string, error) {
+	return r.insert(ctx, item, `
+		INSERT INTO tb_vendor (
+			vendor_type_id, vendor_name, tax_id, branch_name,
+			contact_person, phone1, phone2, email, website,
+			address, sub_district, district, province, zip_code,
+			credit_term_day, currency, note, update_by
+		)
+		OUTPUT INSERTED.autoID INTO @inserted
+		VALUES (
+			@TypeID, @Name, @TaxID, @Branch,
+			@Contact, @Phone1, @Phone2, @Email, @Website,
+			@Address, @SubDistrict, @District, @Province, @ZipCode,
+			COALESCE(@CreditTerm, 30), COALESCE(@Currency, 'THB'), @Note, @UpdateBy
+		)`,
+		sql.Named("TypeID", item.VendorTypeID),
+		sql.Named("Name", item.VendorName),
+		sql.Named("TaxID", item.TaxID),
+		sql.Named("Branch", item.BranchName),
+		sql.Named("Contact", item.ContactPerson),
+		sql.Named("Phone1", item.Phone1),
+		sql.Named("Phone2", item.Phone2),
+		sql.Named("Email", item.Email),
+		sql.Named("Website", item.Website),
+		sql.Named("Address", item.Address),
+		sql.Named("SubDistrict", item.SubDistrict),
+		sql.Named("District", item.District),
+		sql.Named("Province", item.Province),
+		sql.Named("ZipCode", item.ZipCode),
+		sql.Named("CreditTerm", item.CreditTermDay),
+		sql.Named("Currency", item.Currency),
+		sql.Named("Note", item.Note),
+		sql.Named("UpdateBy", item.UpdateBy),
+	)
+}
+
+func (r *vendorRepository) Update(ctx context.Context, id string, item *models.Vendor) error {
+	return r.update(ctx, id, item, `
+		UPDATE tb_vendor
+		SET vendor_type_id = COALESCE(NULLIF(@TypeID, ''), vendor_type_id),
+			vendor_name = COALESCE(NULLIF(@Name, ''), vendor_name),
+			tax_id = COALESCE(@TaxID, tax_id),
+			branch_name = COALESCE(@Branch, branch_name),
+			contact_person = COALESCE(@Contact, contact_person),
+			phone1 = COALESCE(@Phone1, phone1),
+			phone2 = COALESCE(@Phone2, phone2),
+			email = COALESCE(@Email, email),
+			website = COALESCE(@Website, website),
+			address = COALESCE(@Address, address),
+			sub_district = COALESCE(@SubDistrict, sub_district),
+			district = COALESCE(@District, district),
+			province = COALESCE(@Province, province),
+			zip_code = COALESCE(@ZipCode, zip_code),
+			credit_term_day = COALESCE(@CreditTerm, credit_term_day),
+			currency = COALESCE(@Currency, currency),
+			note = COALESCE(@Note, note),
+			update_by = @UpdateBy,
+			is_active = COALESCE(@IsActive, is_active)
+		WHERE vendor_id = @ID AND is_delete = 0`,
+		sql.Named("TypeID", item.VendorTypeID),
+		sql.Named("Name", item.VendorName),
+		sql.Named("TaxID", item.TaxID),
+		sql.Named("Branch", item.BranchName),
+		sql.Named("Contact", item.ContactPerson),
+		sql.Named("Phone1", item.Phone1),
+		sql.Named("Phone2", item.Phone2),
+		sql.Named("Email", item.Email),
+		sql.Named("Website", item.Website),
+		sql.Named("Address", item.Address),
+		sql.Named("SubDistrict", item.SubDistrict),
+		sql.Named("District", item.District),
+		sql.Named("Province", item.Province),
+		sql.Named("ZipCode", item.ZipCode),
+		sql.Named("CreditTerm", item.CreditTermDay),
+		sql.Named("Currency", item.Currency),
+		sql.Named("Note", item.Note),
+		sql.Named("UpdateBy", item.UpdateBy),
+		sql.Named("IsActive", item.IsActive),
+		sql.Named("ID", id),
+	)
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"context"
+	"database/sql"
+)
+
+// VendorTypeRepository คือการทำงานกับ tb_vendor_type
+type VendorTypeRepository interface {
+	Repository[models.VendorType]
+}
+
+type vendorTypeRepository struct {
+	sqlTable[models.VendorType]
+}
+
+// NewVendorTypeRepository สร้าง VendorTypeRepository บนฐานข้อมูล dbs
+func NewVendorTypeRepository(dbs *replica.Router) VendorTypeRepository {
+	return &vendorTypeRepository{sqlTable[models.VendorType]{
+		db:        dbs.Primary(),
+		reads:     dbs,
+		table:     "tb_vendor_type",
+		idColumn:  "vendor_type_id",
+		columns:   "vendor_type_id, prefix, type_name, description, update_by, update_date, is_active, is_delete",
+		from:      "tb_vendor_type",
+		search:    []string{"type_name"},
+		listOrder: "update_date DESC, vendor_type_id ASC",
+		scan: func(s scanner, vt *models.VendorType) error {
+			var upd sql.NullTime
+			if err := s.Scan(&vt.VendorTypeID, &vt.Prefix, &vt.TypeName, &vt.Description, &vt.UpdateBy, &upd, &vt.IsActive, &vt.IsDelete); err != nil {
+				return err
+			}
+			vt.UpdateDate = formatDate(upd)
+			return nil
+		},
+	}}
+}
+
+// NewMemoryVendorTypeRepository สร้าง VendorTypeRepository ในหน่วยความจำสำหรับทดสอบ
+func NewMemoryVendorTypeRepository() VendorTypeRepository {
+	return NewMemory[models.VendorType]("VT", "vendor_type_id", "type_name")
+}
+
+// Search เรียงผลตามชื่อประเภท (ต่างจาก List ที่เรียงตามวันที่แก้ไขล่าสุด)
+func (r *vendorTypeRepository) Search(ctx context.Context, name string) ([]models.VendorType, error) {
+	return r.query(ctx, " AND "+r.dialect().Contains("type_name", "@Name"), " ORDER BY type_name ASC, vendor_type_id ASC", sql.Named("Name", name))
+}
+
+func (r *vendorTypeRepository) Create(ctx context.Context, vt *models.VendorType) (string, error) {
+	return r.insert(ctx, vt, `
+		INSERT INTO tb_vendor_type (type_name, description, is_active, update_by)
+		OUTPUT INSERTED.autoID INTO @inserted
+		VALUES (@TypeName, @Description, @IsActive, @UpdateBy)`,
+		sql.Named("TypeName", vt.TypeName),
+		sql.Named("Description", vt.Description),
+		sql.Named("IsActive", vt.IsActive),
+		sql.Named("UpdateBy", vt.UpdateBy),
+	)
+}
+
+func (r *vendorTypeRepository) Update(ctx context.Context, id string, vt *models.VendorType) error {
+	return r.update(ctx, id, vt, `
+		UPDATE tb_vendor_type
+		SET type_name = COALESCE(NULLIF(@TypeName, ''), type_name),
+		    description = COALESCE(@Description, description),
+		    is_active = COALESCE(@IsActive, is_active),
+		    update_by = @UpdateBy
+		WHERE vendor_type_id = @ID AND is_delete = 0`,
+		sql.Named("TypeName", vt.TypeName),
+		sql.Named("Description", vt.Description),
+		sql.Named("IsActive", vt.IsActive),
+		sql.Named("UpdateBy", vt.UpdateBy),
+		sql.Named("ID", id),
+	)
+}
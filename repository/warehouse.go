@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"context"
+	"database/sql"
+)
+
+// WarehouseRepository คือการทำงานกับ tb_warehouse
+type WarehouseRepository interface {
+	Repository[models.Warehouse]
+}
+
+type warehouseRepository struct {
+	sqlTable[models.Warehouse]
+}
+
+// NewWarehouseRepository สร้าง WarehouseRepository บนฐานข้อมูล dbs
+func NewWarehouseRepository(dbs *replica.Router) WarehouseRepository {
+	return &warehouseRepository{sqlTable[models.Warehouse]{
+		db:       dbs.Primary(),
+		reads:    dbs,
+		table:    "tb_warehouse",
+		idColumn: "warehouse_id",
+		columns:  "warehouse_id, warehouse_code, warehouse_name, description, is_main_dc, allow_negative_stock, update_by, update_date, is_active",
+		from:     "tb_warehouse",
+		search:   []string{"warehouse_name"},
+		active:   "is_active",
+		scan: func(s scanner, item *models.Warehouse) error {
+			var upd sql.NullTime
+			if err := s.Scan(&item.WarehouseID, &item.WarehouseCode, &item.WarehouseName, &item.Description, &item.IsMainDC, &item.AllowNegativeStock, &item.UpdateBy, &upd, &item.IsActive); err != nil {
+				return err
+			}
+			item.UpdateDate = formatDate(upd)
+			return nil
+		},
+	}}
+}
+
+// NewMemoryWarehouseRepository สร้าง WarehouseRepository ในหน่วยความจำสำหรับทดสอบ
+func NewMemoryWarehouseRepository() WarehouseRepository {
+	return NewMemory[models.Warehouse]("WH", "warehouse_id", "warehouse_name")
+}
+
+func (r *warehouseRepository) Create(ctx context.Context, item *models.Warehouse) (string, error) {
+	return r.insert(ctx, item, `
+		INSERT INTO tb_warehouse (warehouse_code, warehouse_name, description, is_main_dc, allow_negative_stock, update_by)
+		OUTPUT INSERTED.autoID INTO @inserted
+		VALUES (@WarehouseCode, @WarehouseName, @Description, COALESCE(@IsMainDC, 0), COALESCE(@AllowNegativeStock, 0), @UpdateBy)`,
+		sql.Named("WarehouseCode", item.WarehouseCode),
+		sql.Named("WarehouseName", item.WarehouseName),
+		sql.Named("Description", item.Description),
+		sql.Named("IsMainDC", item.IsMainDC),
+		sql.Named("AllowNegativeStock", item.AllowNegativeStock),
+		sql.Named("UpdateBy", item.UpdateBy),
+	)
+}
+
+func (r *warehouseRepository) Update(ctx context.Context, id string, item *models.Warehouse) error {
+	return r.update(ctx, id, item, `
+		UPDATE tb_warehouse
+		SET warehouse_code = COALESCE(NULLIF(@WarehouseCode, ''), warehouse_code),
+			warehouse_name = COALESCE(NULLIF(@WarehouseName, ''), warehouse_name),
+			description = COALESCE(@Description, description),
+			is_main_dc = COALESCE(@IsMainDC, is_main_dc),
+			allow_negative_stock = COALESCE(@AllowNegativeStock, allow_negative_stock),
+			update_by = @UpdateBy,
+			is_active = COALESCE(@IsActive, is_active)
+		WHERE warehouse_id = @ID AND is_delete = 0`,
+		sql.Named("WarehouseCode", item.WarehouseCode),
+		sql.Named("WarehouseName", item.WarehouseName),
+		sql.Named("Description", item.Description),
+		sql.Named("IsMainDC", item.IsMainDC),
+		sql.Named("AllowNegativeStock", item.AllowNegativeStock),
+		sql.Named("UpdateBy", item.UpdateBy),
+		sql.Named("IsActive", item.IsActive),
+		sql.Named("ID", id),
+	)
+}
@@ -0,0 +1,108 @@
+package routes
+
+import (
+	"PenbunAPI/container"
+	"PenbunAPI/controllers"
+	"PenbunAPI/models"
+	"PenbunAPI/repository"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// handlers คือ Handler ของทุก Module ซึ่งสร้างจาก Dependency ใน container.Container ชุดเดียวกัน
+type handlers struct {
+	auth              *controllers.AuthHandler
+	mfa               *controllers.MFAHandler
+	session           *controllers.SessionHandler
+	apiKey            *controllers.ApiKeyHandler
+	admin             *controllers.AdminHandler
+	reference         *controllers.ReferenceHandler
+	vendor            *controllers.VendorHandler
+	vendorType        *controllers.VendorTypeHandler
+	customer          *controllers.CustomerHandler
+	customerType      *controllers.CustomerTypeHandler
+	discount          *controllers.DiscountHandler
+	discountType      *controllers.DiscountTypeHandler
+	unitType          *controllers.UnitTypeHandler
+	productGroup      *controllers.ProductGroupHandler
+	productCategory   *controllers.ProductCategoryHandler
+	productFormatType *controllers.ProductFormatTypeHandler
+	productPackConfig *controllers.ProductPackConfigHandler
+	product           *controllers.ProductHandler
+	warehouse         *controllers.WarehouseHandler
+	receive           *controllers.ReceiveHandler
+	order             *controllers.OrderHandler
+	recycle           *controllers.RecycleHandler
+}
+
+// newHandlers สร้าง Handler ทุก Module จาก ctn (Module ข้อมูลหลักและเอกสารใช้ Repository บน ctn.DB โดย Select อ่านผ่าน ctn.Reads)
+// ข้อมูลหลักที่อ่านบ่อย (ประเภทต่างๆ, หมวดสินค้า และ tb_reference) อ่านผ่าน ctn.Cache
+// และ panic หาก Handler ใดยังขาด Dependency เพื่อให้ระบบหยุดตั้งแต่ตอนเริ่ม แทนการ panic ระหว่างรับ Request
+func newHandlers(ctn *container.Container) *handlers {
+	if err := ctn.Check(); err != nil {
+		panic("routes: " + err.Error())
+	}
+	db, dbs := ctn.DB, ctn.Reads
+	h := &handlers{
+		auth:              controllers.NewAuthHandler(db, ctn.Sessions, ctn.Logger),
+		mfa:               controllers.NewMFAHandler(db, ctn.Sessions, ctn.Logger, ctn.Config.MFAIssuer),
+		session:           controllers.NewSessionHandler(db, ctn.Sessions, ctn.Logger),
+		apiKey:            controllers.NewApiKeyHandler(db),
+		admin:             controllers.NewAdminHandler(dbs),
+		reference:         controllers.NewReferenceHandler(repository.CachedReference(repository.NewReferenceRepository(dbs), ctn.Cache, ctn.Config.CacheTTL)),
+		vendor:            controllers.NewVendorHandler(repository.NewVendorRepository(dbs)),
+		vendorType:        controllers.NewVendorTypeHandler(cached[models.VendorType](ctn, repository.NewVendorTypeRepository(dbs), "tb_vendor_type")),
+		customer:          controllers.NewCustomerHandler(repository.NewCustomerRepository(dbs)),
+		customerType:      controllers.NewCustomerTypeHandler(cached[models.CustomerType](ctn, repository.NewCustomerTypeRepository(dbs), "tb_customer_type")),
+		discount:          controllers.NewDiscountHandler(repository.NewDiscountRepository(dbs)),
+		discountType:      controllers.NewDiscountTypeHandler(cached[models.DiscountType](ctn, repository.NewDiscountTypeRepository(dbs), "tb_discount_type")),
+		unitType:          controllers.NewUnitTypeHandler(cached[models.UnitType](ctn, repository.NewUnitTypeRepository(dbs), "tb_unit_type")),
+		productGroup:      controllers.NewProductGroupHandler(repository.NewProductGroupRepository(dbs)),
+		productCategory:   controllers.NewProductCategoryHandler(cached[models.ProductCategory](ctn, repository.NewProductCategoryRepository(dbs), "tb_product_category")),
+		productFormatType: controllers.NewProductFormatTypeHandler(cached[models.ProductFormatType](ctn, repository.NewProductFormatTypeRepository(dbs), "tb_product_format_type")),
+		productPackConfig: controllers.NewProductPackConfigHandler(repository.NewProductPackConfigRepository(dbs)),
+		product:           controllers.NewProductHandler(repository.NewProductRepository(dbs)),
+		warehouse:         controllers.NewWarehouseHandler(repository.NewWarehouseRepository(dbs)),
+		receive:           controllers.NewReceiveHandler(repository.NewReceiveRepository(dbs)),
+		order:             controllers.NewOrderHandler(repository.NewOrderRepository(dbs)),
+		recycle:           controllers.NewRecycleHandler(repository.CachedRecycle(repository.NewRecycleRepository(dbs), ctn.Cache)),
+	}
+	if err := h.check(); err != nil {
+		panic("routes: " + err.Error())
+	}
+	return h
+}
+
+// cached ห่อ repo ของข้อมูลหลักตาราง table ให้ List และ Get อ่านผ่าน ctn.Cache นาน ctn.Config.CacheTTL
+func cached[T any](ctn *container.Container, repo repository.Repository[T], table string) repository.Repository[T] {
+	return repository.Cached(repo, ctn.Cache, table, ctn.Config.CacheTTL)
+}
+
+// check ยืนยันว่าทุก Handler ถูกสร้างแล้ว และ Dependency ภายในแต่ละ Handler (ฐานข้อมูล, Repository, Service, Logger)
+// ไม่เป็น nil ทุก Route ที่ลงทะเบียนด้วย method ของ Handler เหล่านี้จึงเรียก Dependency ได้เสมอ
+func (h *handlers) check() error {
+	var missing []string
+	v := reflect.ValueOf(h).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		handler := v.Field(i)
+		if handler.IsNil() {
+			missing = append(missing, name)
+			continue
+		}
+		deps := handler.Elem()
+		for j := 0; j < deps.NumField(); j++ {
+			switch dep := deps.Field(j); dep.Kind() {
+			case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Func:
+				if dep.IsNil() {
+					missing = append(missing, name+"."+deps.Type().Field(j).Name)
+				}
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("handlers with missing dependencies: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -1,8 +1,11 @@
 package routes
 
 import (
+	"PenbunAPI/controllers"
+	"PenbunAPI/dialect"
 	"database/sql"
 	"log"
+
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -28,7 +31,7 @@ func RegisterPublicRoutes(group fiber.Router, db *sql.DB) {
 	// Route "/welcome"
 	group.Get("/welcome", func(c *fiber.Ctx) error {
 		var version string
-		err := db.QueryRow("SELECT @@VERSION").Scan(&version)
+		err := db.QueryRowContext(c.UserContext(), dialect.Of(db).Version()).Scan(&version)
 		if err != nil {
 			log.Println("[ERROR] Failed to query database:", err)
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -42,3 +45,7 @@ func RegisterPublicRoutes(group fiber.Router, db *sql.DB) {
 
 }
 
+// RegisterWellKnownRoutes ลงทะเบียน Route มาตรฐานระดับ Root เช่น JWKS สำหรับตรวจสอบ Token
+func RegisterWellKnownRoutes(app *fiber.App) {
+	app.Get("/.well-known/jwks.json", controllers.GetJWKS)
+}
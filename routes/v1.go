@@ -1,205 +1,280 @@
 package routes
 
 import (
-	"PenbunAPI/controllers"
+	"PenbunAPI/auth"
+	"PenbunAPI/container"
 	"PenbunAPI/middleware"
-	"os"
-
-	"database/sql"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // RegisterV1Routes will register all V1 routes
-func RegisterV1Routes(app *fiber.App, db *sql.DB) {
+func RegisterV1Routes(app *fiber.App, ctn *container.Container) {
+	h := newHandlers(ctn)
+	jwt := middleware.JWTMiddleware(ctn.DB, ctn.Sessions)
+
 	// Group สำหรับ API Version 1
-	v1 := app.Group("/api/v1")
+	v1 := app.Group("/api/v1", middleware.Timeout(ctn.Config.RequestTimeout), middleware.ReadPreference(), middleware.ETag()) // งบเวลา (REQUEST_TIMEOUT), การเลือก Read Replica และ ETag/304
 
 	// Group สำหรับ Public API [ver 1.0.1]
-	public := v1.Group("/public")
-	RegisterPublicRoutes(public, db)
+	public := v1.Group("/public", middleware.Timeout(ctn.Config.Timeout("public")))
+	RegisterPublicRoutes(public, ctn.DB)
 
 	// Group สำหรับ login/logout API [ver 1.0.1]
-	public.Post("/login", controllers.Login) // Route สำหรับ login (ไม่ใช้ Middleware)
+	public.Post("/login", h.auth.Login)       // Route สำหรับ login (ไม่ใช้ Middleware)
+	public.Post("/login/mfa", middleware.RateLimit(ctn.Config.MFARateLimit, time.Minute), h.mfa.LoginMFA) // Login ขั้นที่ 2 ด้วยรหัส TOTP หรือ Recovery Code
 	// Apply Middleware to logout to enable user logging
-	public.Post("/logout", middleware.JWTMiddleware(os.Getenv("JWT_SECRET")), controllers.Logout)
+	public.Post("/logout", jwt, h.auth.Logout)
 
 	// Group สำหรับ Protected API [ver 1.0.1]
 	protected := v1.Group("/protected")
-	protected.Use(middleware.JWTMiddleware(os.Getenv("JWT_SECRET")))
+	protected.Use(middleware.GroupTimeout(ctn.Config.GroupTimeouts), jwt) // งบเวลาตาม module (REQUEST_TIMEOUT_<MODULE>)
+
+	protected.Post("/refresh", middleware.DenyAPIKey(), h.auth.RefreshToken) // Route สำหรับ Refresh Token
+	protected.Get("/reference", h.reference.GetReference)                    // Route สำหรับ get ค่า references
+
+	// Group สำหรับ Reference API (tb_reference)
+	reference := protected.Group("/reference")
+	reference.Post("/insert", h.reference.InsertReference)
+	reference.Get("/select/all", h.reference.SelectAllReferences)
+	reference.Get("/select/:refid", h.reference.SelectReferenceByRefID)
+	reference.Put("/update/:id", h.reference.UpdateReferenceByID)
+
+	// การลบข้อมูลจริงต้องผ่าน MFA สำหรับ role ตาม MFA_REQUIRED_ROLES
+	requireMFA := middleware.RequireMFA()
+	ifMatch := middleware.IfMatch()
+
+	// Group สำหรับ API Key Management (เฉพาะ admin ที่ Login ด้วย JWT เท่านั้น เพราะ Key ใช้แทนผู้ใช้ได้ทั้งระบบ)
+	apiKey := protected.Group("/apikey", middleware.DenyAPIKey(), middleware.RequireRole(auth.RoleAdmin))
+	apiKey.Post("/insert", h.apiKey.InsertApiKey)
+	apiKey.Get("/select/all", h.apiKey.SelectAllApiKeys)
+	apiKey.Get("/select/:id", h.apiKey.SelectApiKeyByID)
+	apiKey.Put("/update/:id", h.apiKey.UpdateApiKeyByID)
+	apiKey.Put("/revoke/:id", h.apiKey.RevokeApiKeyByID)
+	apiKey.Put("/delete/:id", h.apiKey.DeleteApiKeyByID)
+	apiKey.Delete("/remove/:id", requireMFA, h.apiKey.RemoveApiKeyByID)
+
+	// Group สำหรับ MFA (TOTP) ของผู้ใช้ปัจจุบัน
+	mfa := protected.Group("/mfa", middleware.DenyAPIKey())
+	mfa.Get("/status", h.mfa.SelectMFAStatus)
+	mfa.Post("/enroll", h.mfa.EnrollMFA)
+	mfa.Post("/verify", h.mfa.VerifyMFA)
+	mfa.Post("/recovery/regenerate", h.mfa.RegenerateRecoveryCodes)
+	mfa.Post("/disable", h.mfa.DisableMFA)
+
+	// Group สำหรับ Session ของผู้ใช้ปัจจุบัน และ Force Logout โดย Admin
+	session := protected.Group("/session", middleware.DenyAPIKey())
+	session.Get("/select/all", h.session.SelectMySessions)
+	session.Put("/revoke/others", h.session.RevokeOtherSessions)
+	session.Put("/revoke/:id", h.session.RevokeMySession)
+	sessionAdmin := session.Group("/user", middleware.RequireRole(auth.RoleAdmin))
+	sessionAdmin.Get("/:username", h.session.SelectUserSessions)
+	sessionAdmin.Put("/:username/revoke", requireMFA, h.session.ForceLogoutUser)
 
-	protected.Post("/refresh", controllers.RefreshToken)  // Route สำหรับ Refresh Token
-	protected.Get("/reference", controllers.GetReference) // Route สำหรับ get ค่า references
+	// Group สำหรับผู้ดูแลระบบ (สถานะฐานข้อมูลและ Connection Pool)
+	admin := protected.Group("/admin", middleware.DenyAPIKey(), middleware.RequireRole(auth.RoleAdmin))
+	admin.Get("/db/stats", h.admin.DatabaseStats)
 
 	// Group สำหรับ Vendor API [ver 2.3.0]
 	vendor := protected.Group("/vendor")
-	vendor.Post("/insert", controllers.InsertVendor)
-	vendor.Get("/select/all", controllers.SelectAllVendors)
-	vendor.Get("/select/page", controllers.SelectPageVendors)
-	vendor.Get("/select/:id", controllers.SelectVendorByID)
-	vendor.Get("/select/name/:name", controllers.SelectVendorByName)
-	vendor.Put("/update/:id", controllers.UpdateVendorByID)
-	vendor.Put("/delete/:id", controllers.DeleteVendorByID)
-	vendor.Delete("/remove/:id", controllers.RemoveVendorByID)
+	vendor.Post("/insert", h.vendor.InsertVendor)
+	vendor.Get("/select/all", h.vendor.SelectAllVendors)
+	vendor.Get("/select/page", h.vendor.SelectPageVendors)
+	vendor.Get("/select/:id", h.vendor.SelectVendorByID)
+	vendor.Get("/select/name/:name", h.vendor.SelectVendorByName)
+	vendor.Put("/update/:id", ifMatch, h.vendor.UpdateVendorByID)
+	vendor.Put("/delete/:id", ifMatch, h.vendor.DeleteVendorByID)
+	vendor.Put("/restore/:id", h.recycle.RestoreByID("vendor"))
+	vendor.Get("/recycle", h.recycle.SelectRecycleBin("vendor"))
+	vendor.Delete("/remove/:id", requireMFA, ifMatch, h.vendor.RemoveVendorByID)
 
 	// Group สำหรับ Customer Type API [ver 1.5.3]
 	customerType := protected.Group("/customertype")
-	customerType.Post("/insert", controllers.InsertCustomerType)
-	customerType.Get("/select/all", controllers.SelectAllCustomerTypes)
-	customerType.Get("/select/page", controllers.SelectPageCustomerTypes)
-	customerType.Get("/select/:id", controllers.SelectCustomerTypeByID)
-	customerType.Put("/update/:id", controllers.UpdateCustomerTypeByID)
-	customerType.Put("/delete/:id", controllers.DeleteCustomerTypeByID)
-	customerType.Delete("/remove/:id", controllers.RemoveCustomerTypeByID)
+	customerType.Post("/insert", h.customerType.InsertCustomerType)
+	customerType.Get("/select/all", h.customerType.SelectAllCustomerTypes)
+	customerType.Get("/select/page", h.customerType.SelectPageCustomerTypes)
+	customerType.Get("/select/:id", h.customerType.SelectCustomerTypeByID)
+	customerType.Put("/update/:id", ifMatch, h.customerType.UpdateCustomerTypeByID)
+	customerType.Put("/delete/:id", ifMatch, h.customerType.DeleteCustomerTypeByID)
+	customerType.Put("/restore/:id", h.recycle.RestoreByID("customertype"))
+	customerType.Get("/recycle", h.recycle.SelectRecycleBin("customertype"))
+	customerType.Delete("/remove/:id", requireMFA, ifMatch, h.customerType.RemoveCustomerTypeByID)
 
 	// Group สำหรับ Customer API [ver 1.5.3]
 	customer := protected.Group("/customer")
-	customer.Post("/insert", controllers.InsertCustomer)           // เพิ่ม Customer
-	customer.Get("/select/all", controllers.SelectAllCustomers)    // ดึงข้อมูล Customer ทั้งหมด (ไม่มี Paging)
-	customer.Get("/select/page", controllers.SelectPageCustomers)  // ดึงข้อมูล Customer ทั้งหมด (รองรับ Paging)
-	customer.Get("/select/:id", controllers.SelectCustomerByID)    // ดึงข้อมูล Customer ตาม ID
-	customer.Put("/update/:id", controllers.UpdateCustomerByID)    // อัปเดต Customer ตาม ID
-	customer.Put("/delete/:id", controllers.DeleteCustomerByID)    // เปลี่ยน is_delete = 1
-	customer.Delete("/remove/:id", controllers.RemoveCustomerByID) // ลบข้อมูลจริง
+	customer.Post("/insert", h.customer.InsertCustomer)                                                           // เพิ่ม Customer
+	customer.Get("/select/all", h.customer.SelectAllCustomers)                                                    // ดึงข้อมูล Customer ทั้งหมด (ไม่มี Paging)
+	customer.Get("/select/page", h.customer.SelectPageCustomers)                                                  // ดึงข้อมูล Customer ทั้งหมด (รองรับ Paging)
+	customer.Get("/select/:id", h.customer.SelectCustomerByID)                                                    // ดึงข้อมูล Customer ตาม ID
+	customer.Put("/update/:id", ifMatch, h.customer.UpdateCustomerByID) // อัปเดต Customer ตาม ID
+	customer.Put("/delete/:id", ifMatch, h.customer.DeleteCustomerByID) // เปลี่ยน is_delete = 1
+	customer.Put("/restore/:id", h.recycle.RestoreByID("customer"))
+	customer.Get("/recycle", h.recycle.SelectRecycleBin("customer"))
+	customer.Delete("/remove/:id", requireMFA, ifMatch, h.customer.RemoveCustomerByID) // ลบข้อมูลจริง
 
 	// Group สำหรับ Discount Type API [ver 1.5.5]
 	discountType := protected.Group("/discounttype")
-	discountType.Post("/insert", controllers.InsertDiscountType)
-	discountType.Get("/select/all", controllers.SelectAllDiscountType)
-	discountType.Get("/select/page", controllers.SelectPageDiscountType)
-	discountType.Get("/select/:id", controllers.SelectDiscountTypeByID)
-	discountType.Get("/select/name/:name", controllers.SelectDiscountTypeByName)
-	discountType.Put("/update/:id", controllers.UpdateDiscountTypeByID)
-	discountType.Put("/delete/:id", controllers.DeleteDiscountTypeByID)
-	discountType.Delete("/remove/:id", controllers.RemoveDiscountTypeByID)
+	discountType.Post("/insert", h.discountType.InsertDiscountType)
+	discountType.Get("/select/all", h.discountType.SelectAllDiscountType)
+	discountType.Get("/select/page", h.discountType.SelectPageDiscountType)
+	discountType.Get("/select/:id", h.discountType.SelectDiscountTypeByID)
+	discountType.Get("/select/name/:name", h.discountType.SelectDiscountTypeByName)
+	discountType.Put("/update/:id", ifMatch, h.discountType.UpdateDiscountTypeByID)
+	discountType.Put("/delete/:id", ifMatch, h.discountType.DeleteDiscountTypeByID)
+	discountType.Put("/restore/:id", h.recycle.RestoreByID("discounttype"))
+	discountType.Get("/recycle", h.recycle.SelectRecycleBin("discounttype"))
+	discountType.Delete("/remove/:id", requireMFA, ifMatch, h.discountType.RemoveDiscountTypeByID)
 
 	// Group สำหรับ Discount API [ver 1.5.7]
 	discount := protected.Group("/discount")
-	discount.Post("/insert", controllers.InsertDiscount)
-	discount.Get("/select/all", controllers.SelectAllDiscount)
-	discount.Get("/select/page", controllers.SelectPageDiscount)
-	discount.Get("/select/:id", controllers.SelectDiscountByID)
-	discount.Put("/update/:id", controllers.UpdateDiscountByID)
-	discount.Put("/delete/:id", controllers.DeleteDiscountByID)
-	discount.Delete("/remove/:id", controllers.RemoveDiscountByID)
+	discount.Post("/insert", h.discount.InsertDiscount)
+	discount.Get("/select/all", h.discount.SelectAllDiscount)
+	discount.Get("/select/page", h.discount.SelectPageDiscount)
+	discount.Get("/select/:id", h.discount.SelectDiscountByID)
+	discount.Put("/update/:id", ifMatch, h.discount.UpdateDiscountByID)
+	discount.Put("/delete/:id", ifMatch, h.discount.DeleteDiscountByID)
+	discount.Put("/restore/:id", h.recycle.RestoreByID("discount"))
+	discount.Get("/recycle", h.recycle.SelectRecycleBin("discount"))
+	discount.Delete("/remove/:id", requireMFA, ifMatch, h.discount.RemoveDiscountByID)
 
 	// Group สำหรับ Vendor Type API [ver 1.7.1]
 	vendorType := protected.Group("/vendortype")
-	vendorType.Post("/insert", controllers.InsertVendorType)                 // เพิ่ม Vendor Type
-	vendorType.Get("/select/all", controllers.SelectAllVendorType)           // ดึงข้อมูล Vendor Type ทั้งหมด
-	vendorType.Get("/select/page", controllers.SelectPageVendorType)         // ดึงข้อมูล Vendor Type แบบ Paging
-	vendorType.Get("/select/:id", controllers.SelectVendorTypeByID)          // ดึงข้อมูล Vendor Type ตาม ID
-	vendorType.Get("/select/name/:name", controllers.SelectVendorTypeByName) // ดึงข้อมูล Vendor Type ตาม Name
-	vendorType.Put("/update/:id", controllers.UpdateVendorTypeByID)          // อัปเดต Vendor Type ตาม ID
-	vendorType.Put("/delete/:id", controllers.DeleteVendorTypeByID)          // เปลี่ยน is_delete = 1
-	vendorType.Delete("/remove/:id", controllers.RemoveVendorTypeByID)       // ลบข้อมูลจริง
+	vendorType.Post("/insert", h.vendorType.InsertVendorType)                                                               // เพิ่ม Vendor Type
+	vendorType.Get("/select/all", h.vendorType.SelectAllVendorType)                                                         // ดึงข้อมูล Vendor Type ทั้งหมด
+	vendorType.Get("/select/page", h.vendorType.SelectPageVendorType)                                                       // ดึงข้อมูล Vendor Type แบบ Paging
+	vendorType.Get("/select/:id", h.vendorType.SelectVendorTypeByID)                                                        // ดึงข้อมูล Vendor Type ตาม ID
+	vendorType.Get("/select/name/:name", h.vendorType.SelectVendorTypeByName)                                               // ดึงข้อมูล Vendor Type ตาม Name
+	vendorType.Put("/update/:id", ifMatch, h.vendorType.UpdateVendorTypeByID) // อัปเดต Vendor Type ตาม ID
+	vendorType.Put("/delete/:id", ifMatch, h.vendorType.DeleteVendorTypeByID) // เปลี่ยน is_delete = 1
+	vendorType.Put("/restore/:id", h.recycle.RestoreByID("vendortype"))
+	vendorType.Get("/recycle", h.recycle.SelectRecycleBin("vendortype"))
+	vendorType.Delete("/remove/:id", requireMFA, ifMatch, h.vendorType.RemoveVendorTypeByID) // ลบข้อมูลจริง
 
 	// Group สำหรับ Vendor API [ver 2.3.0]
 	vendorGroup := protected.Group("/vendor")
-	vendorGroup.Post("/insert", controllers.InsertVendor)
-	vendorGroup.Get("/select/all", controllers.SelectAllVendors)
-	vendorGroup.Get("/select/page", controllers.SelectPageVendors)
-	vendorGroup.Get("/select/:id", controllers.SelectVendorByID)
-	vendorGroup.Get("/select/name/:name", controllers.SelectVendorByName)
-	vendorGroup.Put("/update/:id", controllers.UpdateVendorByID)
-	vendorGroup.Put("/delete/:id", controllers.DeleteVendorByID)
-	vendorGroup.Delete("/remove/:id", controllers.RemoveVendorByID)
+	vendorGroup.Post("/insert", h.vendor.InsertVendor)
+	vendorGroup.Get("/select/all", h.vendor.SelectAllVendors)
+	vendorGroup.Get("/select/page", h.vendor.SelectPageVendors)
+	vendorGroup.Get("/select/:id", h.vendor.SelectVendorByID)
+	vendorGroup.Get("/select/name/:name", h.vendor.SelectVendorByName)
+	vendorGroup.Put("/update/:id", ifMatch, h.vendor.UpdateVendorByID)
+	vendorGroup.Put("/delete/:id", ifMatch, h.vendor.DeleteVendorByID)
+	vendorGroup.Delete("/remove/:id", requireMFA, ifMatch, h.vendor.RemoveVendorByID)
 
 	// Group สำหรับ Unit Type API [ver 1.7.3]
 	unitType := protected.Group("/unittype")
-	unitType.Post("/insert", controllers.InsertUnitType)                 // เพิ่ม Unit Type
-	unitType.Get("/select/all", controllers.SelectAllUnitType)           // ดึงข้อมูลทั้งหมด
-	unitType.Get("/select/page", controllers.SelectPageUnitType)         // ดึงข้อมูลแบบ Paging
-	unitType.Get("/select/:id", controllers.SelectUnitTypeByID)          // ดึงข้อมูลตาม ID
-	unitType.Get("/select/name/:name", controllers.SelectUnitTypeByName) // ดึงข้อมูลตาม Name
-	unitType.Put("/update/:id", controllers.UpdateUnitTypeByID)          // อัปเดตตาม ID
-	unitType.Put("/delete/:id", controllers.DeleteUnitTypeByID)          // Soft Delete
-	unitType.Delete("/remove/:id", controllers.RemoveUnitTypeByID)       // ลบข้อมูลจริง
+	unitType.Post("/insert", h.unitType.InsertUnitType)                                                           // เพิ่ม Unit Type
+	unitType.Get("/select/all", h.unitType.SelectAllUnitType)                                                     // ดึงข้อมูลทั้งหมด
+	unitType.Get("/select/page", h.unitType.SelectPageUnitType)                                                   // ดึงข้อมูลแบบ Paging
+	unitType.Get("/select/:id", h.unitType.SelectUnitTypeByID)                                                    // ดึงข้อมูลตาม ID
+	unitType.Get("/select/name/:name", h.unitType.SelectUnitTypeByName)                                           // ดึงข้อมูลตาม Name
+	unitType.Put("/update/:id", ifMatch, h.unitType.UpdateUnitTypeByID) // อัปเดตตาม ID
+	unitType.Put("/delete/:id", ifMatch, h.unitType.DeleteUnitTypeByID) // Soft Delete
+	unitType.Put("/restore/:id", h.recycle.RestoreByID("unittype"))
+	unitType.Get("/recycle", h.recycle.SelectRecycleBin("unittype"))
+	unitType.Delete("/remove/:id", requireMFA, ifMatch, h.unitType.RemoveUnitTypeByID) // ลบข้อมูลจริง
 
 	// Group สำหรับ Product Group API [ver 2.3.0]
 	productGroup := protected.Group("/productgroup")
-	productGroup.Post("/insert", controllers.InsertProductGroup)
-	productGroup.Get("/select/all", controllers.SelectAllProductGroup)
-	productGroup.Get("/select/page", controllers.SelectPageProductGroup)
-	productGroup.Get("/select/:id", controllers.SelectProductGroupByID)
-	productGroup.Get("/select/name/:name", controllers.SelectProductGroupByName)
-	productGroup.Put("/update/:id", controllers.UpdateProductGroupByID)
-	productGroup.Put("/delete/:id", controllers.DeleteProductGroupByID)
-	productGroup.Delete("/remove/:id", controllers.RemoveProductGroupByID)
+	productGroup.Post("/insert", h.productGroup.InsertProductGroup)
+	productGroup.Get("/select/all", h.productGroup.SelectAllProductGroup)
+	productGroup.Get("/select/page", h.productGroup.SelectPageProductGroup)
+	productGroup.Get("/select/:id", h.productGroup.SelectProductGroupByID)
+	productGroup.Get("/select/name/:name", h.productGroup.SelectProductGroupByName)
+	productGroup.Put("/update/:id", ifMatch, h.productGroup.UpdateProductGroupByID)
+	productGroup.Put("/delete/:id", ifMatch, h.productGroup.DeleteProductGroupByID)
+	productGroup.Put("/restore/:id", h.recycle.RestoreByID("productgroup"))
+	productGroup.Get("/recycle", h.recycle.SelectRecycleBin("productgroup"))
+	productGroup.Delete("/remove/:id", requireMFA, ifMatch, h.productGroup.RemoveProductGroupByID)
 
 	// Group สำหรับ Product Category API [ver 1.8.3]
 	productCategory := protected.Group("/productcategory")
-	productCategory.Post("/insert", controllers.InsertProductCategory)
-	productCategory.Get("/select/all", controllers.SelectAllProductCategory)
-	productCategory.Get("/select/page", controllers.SelectPageProductCategory)
-	productCategory.Get("/select/:id", controllers.SelectProductCategoryByID)
-	productCategory.Get("/select/name/:name", controllers.SelectProductCategoryByName)
-	productCategory.Put("/update/:id", controllers.UpdateProductCategoryByID)
-	productCategory.Put("/delete/:id", controllers.DeleteProductCategoryByID)
-	productCategory.Delete("/remove/:id", controllers.RemoveProductCategoryByID)
+	productCategory.Post("/insert", h.productCategory.InsertProductCategory)
+	productCategory.Get("/select/all", h.productCategory.SelectAllProductCategory)
+	productCategory.Get("/select/page", h.productCategory.SelectPageProductCategory)
+	productCategory.Get("/select/:id", h.productCategory.SelectProductCategoryByID)
+	productCategory.Get("/select/name/:name", h.productCategory.SelectProductCategoryByName)
+	productCategory.Put("/update/:id", ifMatch, h.productCategory.UpdateProductCategoryByID)
+	productCategory.Put("/delete/:id", ifMatch, h.productCategory.DeleteProductCategoryByID)
+	productCategory.Put("/restore/:id", h.recycle.RestoreByID("productcategory"))
+	productCategory.Get("/recycle", h.recycle.SelectRecycleBin("productcategory"))
+	productCategory.Delete("/remove/:id", requireMFA, ifMatch, h.productCategory.RemoveProductCategoryByID)
 
 	// Group สำหรับ Product Format Type API [ver 1.8.1]
 	productFormatType := protected.Group("/productformattype")
-	productFormatType.Post("/insert", controllers.InsertProductFormatType)                 // เพิ่ม Product Format Type
-	productFormatType.Get("/select/all", controllers.SelectAllProductFormatType)           // ดึงข้อมูลทั้งหมด
-	productFormatType.Get("/select/page", controllers.SelectPageProductFormatType)         // ดึงข้อมูลแบบ Paging
-	productFormatType.Get("/select/:id", controllers.SelectProductFormatTypeByID)          // ดึงข้อมูลตาม ID
-	productFormatType.Get("/select/name/:name", controllers.SelectProductFormatTypeByName) // ดึงข้อมูลตาม Name
-	productFormatType.Put("/update/:id", controllers.UpdateProductFormatTypeByID)          // อัปเดตตาม ID
-	productFormatType.Put("/delete/:id", controllers.DeleteProductFormatTypeByID)          // Soft Delete
-	productFormatType.Delete("/remove/:id", controllers.RemoveProductFormatTypeByID)       // ลบข้อมูลจริง
+	productFormatType.Post("/insert", h.productFormatType.InsertProductFormatType)                                                                             // เพิ่ม Product Format Type
+	productFormatType.Get("/select/all", h.productFormatType.SelectAllProductFormatType)                                                                       // ดึงข้อมูลทั้งหมด
+	productFormatType.Get("/select/page", h.productFormatType.SelectPageProductFormatType)                                                                     // ดึงข้อมูลแบบ Paging
+	productFormatType.Get("/select/:id", h.productFormatType.SelectProductFormatTypeByID)                                                                      // ดึงข้อมูลตาม ID
+	productFormatType.Get("/select/name/:name", h.productFormatType.SelectProductFormatTypeByName)                                                             // ดึงข้อมูลตาม Name
+	productFormatType.Put("/update/:id", ifMatch, h.productFormatType.UpdateProductFormatTypeByID) // อัปเดตตาม ID
+	productFormatType.Put("/delete/:id", ifMatch, h.productFormatType.DeleteProductFormatTypeByID) // Soft Delete
+	productFormatType.Put("/restore/:id", h.recycle.RestoreByID("productformattype"))
+	productFormatType.Get("/recycle", h.recycle.SelectRecycleBin("productformattype"))
+	productFormatType.Delete("/remove/:id", requireMFA, ifMatch, h.productFormatType.RemoveProductFormatTypeByID) // ลบข้อมูลจริง
 
 	// Group สำหรับ Product Pack Config API [ver 1.8.2]
 	productPackConfig := protected.Group("/productpackconfig")
-	productPackConfig.Post("/insert", controllers.InsertProductPackConfig)                 // เพิ่ม Product Pack Config
-	productPackConfig.Get("/select/all", controllers.SelectAllProductPackConfig)           // ดึงข้อมูลทั้งหมด
-	productPackConfig.Get("/select/page", controllers.SelectPageProductPackConfig)         // ดึงข้อมูลแบบ Paging
-	productPackConfig.Get("/select/:id", controllers.SelectProductPackConfigByID)          // ดึงข้อมูลตาม ID
-	productPackConfig.Get("/select/name/:name", controllers.SelectProductPackConfigByName) // ดึงข้อมูลตาม Name (Note/ProductID)
-	productPackConfig.Put("/update/:id", controllers.UpdateProductPackConfigByID)          // อัปเดตตาม ID
-	productPackConfig.Put("/delete/:id", controllers.DeleteProductPackConfigByID)          // Soft Delete
-	productPackConfig.Delete("/remove/:id", controllers.RemoveProductPackConfigByID)       // ลบข้อมูลจริง
+	productPackConfig.Post("/insert", h.productPackConfig.InsertProductPackConfig)                                                                             // เพิ่ม Product Pack Config
+	productPackConfig.Get("/select/all", h.productPackConfig.SelectAllProductPackConfig)                                                                       // ดึงข้อมูลทั้งหมด
+	productPackConfig.Get("/select/page", h.productPackConfig.SelectPageProductPackConfig)                                                                     // ดึงข้อมูลแบบ Paging
+	productPackConfig.Get("/select/:id", h.productPackConfig.SelectProductPackConfigByID)                                                                      // ดึงข้อมูลตาม ID
+	productPackConfig.Get("/select/name/:name", h.productPackConfig.SelectProductPackConfigByName)                                                             // ดึงข้อมูลตาม Name (Note/ProductID)
+	productPackConfig.Put("/update/:id", ifMatch, h.productPackConfig.UpdateProductPackConfigByID) // อัปเดตตาม ID
+	productPackConfig.Put("/delete/:id", ifMatch, h.productPackConfig.DeleteProductPackConfigByID) // Soft Delete
+	productPackConfig.Put("/restore/:id", h.recycle.RestoreByID("productpackconfig"))
+	productPackConfig.Get("/recycle", h.recycle.SelectRecycleBin("productpackconfig"))
+	productPackConfig.Delete("/remove/:id", requireMFA, ifMatch, h.productPackConfig.RemoveProductPackConfigByID) // ลบข้อมูลจริง
 
 	// Group สำหรับ Product API [ver 2.0.0]
 	product := protected.Group("/product")
-	product.Post("/insert", controllers.InsertProduct)                 // เพิ่ม Product (with dummy ID 'TEMP')
-	product.Get("/select/all", controllers.SelectAllProducts)          // ดึงข้อมูล Product ทั้งหมด
-	product.Get("/select/page", controllers.SelectPageProducts)        // ดึงข้อมูล Product แบบ Paging
-	product.Get("/select/:id", controllers.SelectProductByID)          // ดึงข้อมูล Product ตาม ID
-	product.Get("/select/name/:name", controllers.SelectProductByName) // ดึงข้อมูล Product ตาม Name (TH or EN)
-	product.Put("/update/:id", controllers.UpdateProductByID)          // อัปเดต Product ตาม ID
-	product.Put("/delete/:id", controllers.DeleteProductByID)          // Soft Delete
-	product.Delete("/remove/:id", controllers.RemoveProductByID)       // ลบข้อมูลจริง
+	product.Post("/insert", h.product.InsertProduct)                                                         // เพิ่ม Product (with dummy ID 'TEMP')
+	product.Get("/select/all", h.product.SelectAllProducts)                                                  // ดึงข้อมูล Product ทั้งหมด
+	product.Get("/select/page", h.product.SelectPageProducts)                                                // ดึงข้อมูล Product แบบ Paging
+	product.Get("/select/:id", h.product.SelectProductByID)                                                  // ดึงข้อมูล Product ตาม ID
+	product.Get("/select/name/:name", h.product.SelectProductByName)                                         // ดึงข้อมูล Product ตาม Name (TH or EN)
+	product.Put("/update/:id", ifMatch, h.product.UpdateProductByID) // อัปเดต Product ตาม ID
+	product.Put("/delete/:id", ifMatch, h.product.DeleteProductByID) // Soft Delete
+	product.Put("/restore/:id", h.recycle.RestoreByID("product"))
+	product.Get("/recycle", h.recycle.SelectRecycleBin("product"))
+	product.Delete("/remove/:id", requireMFA, ifMatch, h.product.RemoveProductByID) // ลบข้อมูลจริง
 
 	// Group สำหรับ Warehouse API [ver 2.3.0]
 	warehouse := protected.Group("/warehouse")
-	warehouse.Post("/insert", controllers.InsertWarehouse)
-	warehouse.Get("/select/all", controllers.SelectAllWarehouse)
-	warehouse.Get("/select/page", controllers.SelectPageWarehouse)
-	warehouse.Get("/select/:id", controllers.SelectWarehouseByID)
-	warehouse.Get("/select/name/:name", controllers.SelectWarehouseByName)
-	warehouse.Put("/update/:id", controllers.UpdateWarehouseByID)
-	warehouse.Put("/delete/:id", controllers.DeleteWarehouseByID)
-	warehouse.Delete("/remove/:id", controllers.RemoveWarehouseByID)
+	warehouse.Post("/insert", h.warehouse.InsertWarehouse)
+	warehouse.Get("/select/all", h.warehouse.SelectAllWarehouse)
+	warehouse.Get("/select/page", h.warehouse.SelectPageWarehouse)
+	warehouse.Get("/select/:id", h.warehouse.SelectWarehouseByID)
+	warehouse.Get("/select/name/:name", h.warehouse.SelectWarehouseByName)
+	warehouse.Put("/update/:id", ifMatch, h.warehouse.UpdateWarehouseByID)
+	warehouse.Put("/delete/:id", ifMatch, h.warehouse.DeleteWarehouseByID)
+	warehouse.Put("/restore/:id", h.recycle.RestoreByID("warehouse"))
+	warehouse.Get("/recycle", h.recycle.SelectRecycleBin("warehouse"))
+	warehouse.Delete("/remove/:id", requireMFA, ifMatch, h.warehouse.RemoveWarehouseByID)
 
 	// Group สำหรับ Receive API [ver 2.3.0]
 	receive := protected.Group("/receive")
-	receive.Post("/insert", controllers.InsertReceiveNote)
-	receive.Get("/select/all", controllers.SelectAllReceiveNotes)
-	receive.Get("/select/page", controllers.SelectPageReceiveNotes)
-	receive.Get("/select/:id", controllers.SelectReceiveNoteByID)
-	receive.Put("/update/:id", controllers.UpdateReceiveNoteByID)
-	receive.Put("/delete/:id", controllers.DeleteReceiveNoteByID)
-	receive.Delete("/remove/:id", controllers.RemoveReceiveNoteByID)
+	receive.Post("/insert", h.receive.InsertReceiveNote)
+	receive.Get("/select/all", h.receive.SelectAllReceiveNotes)
+	receive.Get("/select/page", h.receive.SelectPageReceiveNotes)
+	receive.Get("/select/:id", h.receive.SelectReceiveNoteByID)
+	receive.Put("/update/:id", ifMatch, h.receive.UpdateReceiveNoteByID)
+	receive.Put("/delete/:id", ifMatch, h.receive.DeleteReceiveNoteByID)
+	receive.Put("/restore/:id", h.recycle.RestoreByID("receive"))
+	receive.Get("/recycle", h.recycle.SelectRecycleBin("receive"))
+	receive.Delete("/remove/:id", requireMFA, ifMatch, h.receive.RemoveReceiveNoteByID)
 
 	// Group สำหรับ Order API [ver 2.3.0]
 	order := protected.Group("/order")
-	order.Post("/insert", controllers.InsertOrder)
-	order.Get("/select/all", controllers.SelectAllOrders)
-	order.Get("/select/page", controllers.SelectPageOrders)
-	order.Get("/select/:id", controllers.SelectOrderByID)
-	order.Put("/update/:id", controllers.UpdateOrderByID)
-	order.Put("/delete/:id", controllers.DeleteOrderByID)
-	order.Delete("/remove/:id", controllers.RemoveOrderByID)
+	order.Post("/insert", h.order.InsertOrder)
+	order.Get("/select/all", h.order.SelectAllOrders)
+	order.Get("/select/page", h.order.SelectPageOrders)
+	order.Get("/select/:id", h.order.SelectOrderByID)
+	order.Put("/update/:id", ifMatch, h.order.UpdateOrderByID)
+	order.Put("/delete/:id", ifMatch, h.order.DeleteOrderByID)
+	order.Put("/restore/:id", h.recycle.RestoreByID("order"))
+	order.Get("/recycle", h.recycle.SelectRecycleBin("order"))
+	order.Delete("/remove/:id", requireMFA, ifMatch, h.order.RemoveOrderByID)
 
 }
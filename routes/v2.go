@@ -1,25 +1,223 @@
 package routes
 
 import (
+	"PenbunAPI/auth"
+	"PenbunAPI/container"
 	"PenbunAPI/controllers"
 	"PenbunAPI/middleware"
-	"database/sql"
-	"os"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func RegisterV2Routes(app *fiber.App, db *sql.DB) {
+// resource คือ Handler ของ Module หนึ่งที่นำมาจัดเป็น REST Resource ใน v2
+type resource struct {
+	List   fiber.Handler // Select Page
+	Get    fiber.Handler // Select By ID
+	Create fiber.Handler // Insert
+	Update fiber.Handler // Update By ID
+	Delete fiber.Handler // Delete By ID (Soft Delete)
+	Purge  fiber.Handler // Remove By ID (Hard Delete)
+}
+
+// registerResource ลงทะเบียน Route แบบ REST ของ resource ภายใต้ /<name>
+// module คือชื่อ Group ใน v1 ซึ่งใช้เป็น scope ของ API Key (เช่น "product:read") และเป็นถังขยะที่ recycle ใช้
+//
+//	GET    /<name>            รายการแบบ Paging (models.Page)
+//	POST   /<name>            เพิ่มข้อมูล ตอบ 201 พร้อม Location header
+//	GET    /<name>/:id        ข้อมูลตาม ID
+//	PATCH  /<name>/:id        แก้ไขเฉพาะ field ที่ส่งมา (JSON Merge Patch)
+//	DELETE /<name>/:id        Soft Delete
+//	POST   /<name>/:id/purge  ลบข้อมูลจริง (ผ่าน purgeGuard เช่น MFA)
+//	GET    /<name>/recycle    ถังขยะ (รายการที่ถูก Soft Delete)
+//	POST   /<name>/:id/restore  กู้คืนข้อมูลที่ถูก Soft Delete
+//
+// PATCH, DELETE และ purge ตรวจ If-Match กับรุ่นของแถวภายใน Transaction ของการเขียน (middleware.IfMatch)
+func registerResource(r fiber.Router, name, module string, res resource, recycle *controllers.RecycleHandler, purgeGuard fiber.Handler) {
+	middleware.MapResourceScope(name, module)
+	base := "/" + name
+	ifMatch := middleware.IfMatch()
+	r.Get(base, res.List)
+	r.Post(base, middleware.Location(), res.Create)
+	r.Get(base+"/recycle", recycle.SelectRecycleBin(module)) // ต้องมาก่อน /:id
+	r.Get(base+"/:id", res.Get)
+	r.Patch(base+"/:id", ifMatch, controllers.MergePatch(res.Get, res.Update))
+	r.Delete(base+"/:id", ifMatch, res.Delete)
+	r.Post(base+"/:id/purge", purgeGuard, ifMatch, res.Purge)
+	r.Post(base+"/:id/restore", recycle.RestoreByID(module))
+}
+
+func RegisterV2Routes(app *fiber.App, ctn *container.Container) {
+	h := newHandlers(ctn)
+	jwt := middleware.JWTMiddleware(ctn.DB, ctn.Sessions)
+
 	// Group สำหรับ API Version 2
-	v2 := app.Group("/api/v2")
+	v2 := app.Group("/api/v2", middleware.Timeout(ctn.Config.RequestTimeout), middleware.ReadPreference(), middleware.ETag()) // งบเวลา (REQUEST_TIMEOUT), การเลือก Read Replica และ ETag/304
 
 	// Group สำหรับ public API
-	public := v2.Group("/public")
-	RegisterPublicRoutes(public, db)
+	public := v2.Group("/public", middleware.Timeout(ctn.Config.Timeout("public")))
+	RegisterPublicRoutes(public, ctn.DB)
 
 	// Group สำหรับ protected API
+	// รายการแบบ Paging ทุก Module ตอบด้วย models.Page รูปแบบเดียวกัน (items, page, limit, total, total_pages, has_next)
 	protected := v2.Group("/protected")
+	protected.Use(middleware.GroupTimeout(ctn.Config.GroupTimeouts), jwt, middleware.PageEnvelope())
+	protected.Post("/refresh", middleware.DenyAPIKey(), h.auth.RefreshToken) // Route สำหรับ Refresh Token
+
+	// การลบข้อมูลจริงต้องผ่าน MFA สำหรับ role ตาม MFA_REQUIRED_ROLES
+	requireMFA := middleware.RequireMFA()
+
+	registerResource(protected, "vendors", "vendor", resource{
+		List:   h.vendor.SelectPageVendors,
+		Get:    h.vendor.SelectVendorByID,
+		Create: h.vendor.InsertVendor,
+		Update: h.vendor.UpdateVendorByID,
+		Delete: h.vendor.DeleteVendorByID,
+		Purge:  h.vendor.RemoveVendorByID,
+	}, h.recycle, requireMFA)
+
+	registerResource(protected, "vendor-types", "vendortype", resource{
+		List:   h.vendorType.SelectPageVendorType,
+		Get:    h.vendorType.SelectVendorTypeByID,
+		Create: h.vendorType.InsertVendorType,
+		Update: h.vendorType.UpdateVendorTypeByID,
+		Delete: h.vendorType.DeleteVendorTypeByID,
+		Purge:  h.vendorType.RemoveVendorTypeByID,
+	}, h.recycle, requireMFA)
+
+	registerResource(protected, "customers", "customer", resource{
+		List:   h.customer.SelectPageCustomers,
+		Get:    h.customer.SelectCustomerByID,
+		Create: h.customer.InsertCustomer,
+		Update: h.customer.UpdateCustomerByID,
+		Delete: h.customer.DeleteCustomerByID,
+		Purge:  h.customer.RemoveCustomerByID,
+	}, h.recycle, requireMFA)
+
+	registerResource(protected, "customer-types", "customertype", resource{
+		List:   h.customerType.SelectPageCustomerTypes,
+		Get:    h.customerType.SelectCustomerTypeByID,
+		Create: h.customerType.InsertCustomerType,
+		Update: h.customerType.UpdateCustomerTypeByID,
+		Delete: h.customerType.DeleteCustomerTypeByID,
+		Purge:  h.customerType.RemoveCustomerTypeByID,
+	}, h.recycle, requireMFA)
+
+	registerResource(protected, "discounts", "discount", resource{
+		List:   h.discount.SelectPageDiscount,
+		Get:    h.discount.SelectDiscountByID,
+		Create: h.discount.InsertDiscount,
+		Update: h.discount.UpdateDiscountByID,
+		Delete: h.discount.DeleteDiscountByID,
+		Purge:  h.discount.RemoveDiscountByID,
+	}, h.recycle, requireMFA)
+
+	registerResource(protected, "discount-types", "discounttype", resource{
+		List:   h.discountType.SelectPageDiscountType,
+		Get:    h.discountType.SelectDiscountTypeByID,
+		Create: h.discountType.InsertDiscountType,
+		Update: h.discountType.UpdateDiscountTypeByID,
+		Delete: h.discountType.DeleteDiscountTypeByID,
+		Purge:  h.discountType.RemoveDiscountTypeByID,
+	}, h.recycle, requireMFA)
+
+	registerResource(protected, "unit-types", "unittype", resource{
+		List:   h.unitType.SelectPageUnitType,
+		Get:    h.unitType.SelectUnitTypeByID,
+		Create: h.unitType.InsertUnitType,
+		Update: h.unitType.UpdateUnitTypeByID,
+		Delete: h.unitType.DeleteUnitTypeByID,
+		Purge:  h.unitType.RemoveUnitTypeByID,
+	}, h.recycle, requireMFA)
+
+	registerResource(protected, "product-groups", "productgroup", resource{
+		List:   h.productGroup.SelectPageProductGroup,
+		Get:    h.productGroup.SelectProductGroupByID,
+		Create: h.productGroup.InsertProductGroup,
+		Update: h.productGroup.UpdateProductGroupByID,
+		Delete: h.productGroup.DeleteProductGroupByID,
+		Purge:  h.productGroup.RemoveProductGroupByID,
+	}, h.recycle, requireMFA)
+
+	registerResource(protected, "product-categories", "productcategory", resource{
+		List:   h.productCategory.SelectPageProductCategory,
+		Get:    h.productCategory.SelectProductCategoryByID,
+		Create: h.productCategory.InsertProductCategory,
+		Update: h.productCategory.UpdateProductCategoryByID,
+		Delete: h.productCategory.DeleteProductCategoryByID,
+		Purge:  h.productCategory.RemoveProductCategoryByID,
+	}, h.recycle, requireMFA)
+
+	registerResource(protected, "product-format-types", "productformattype", resource{
+		List:   h.productFormatType.SelectPageProductFormatType,
+		Get:    h.productFormatType.SelectProductFormatTypeByID,
+		Create: h.productFormatType.InsertProductFormatType,
+		Update: h.productFormatType.UpdateProductFormatTypeByID,
+		Delete: h.productFormatType.DeleteProductFormatTypeByID,
+		Purge:  h.productFormatType.RemoveProductFormatTypeByID,
+	}, h.recycle, requireMFA)
+
+	registerResource(protected, "product-pack-configs", "productpackconfig", resource{
+		List:   h.productPackConfig.SelectPageProductPackConfig,
+		Get:    h.productPackConfig.SelectProductPackConfigByID,
+		Create: h.productPackConfig.InsertProductPackConfig,
+		Update: h.productPackConfig.UpdateProductPackConfigByID,
+		Delete: h.productPackConfig.DeleteProductPackConfigByID,
+		Purge:  h.productPackConfig.RemoveProductPackConfigByID,
+	}, h.recycle, requireMFA)
+
+	registerResource(protected, "products", "product", resource{
+		List:   h.product.SelectPageProducts,
+		Get:    h.product.SelectProductByID,
+		Create: h.product.InsertProduct,
+		Update: h.product.UpdateProductByID,
+		Delete: h.product.DeleteProductByID,
+		Purge:  h.product.RemoveProductByID,
+	}, h.recycle, requireMFA)
+
+	registerResource(protected, "warehouses", "warehouse", resource{
+		List:   h.warehouse.SelectPageWarehouse,
+		Get:    h.warehouse.SelectWarehouseByID,
+		Create: h.warehouse.InsertWarehouse,
+		Update: h.warehouse.UpdateWarehouseByID,
+		Delete: h.warehouse.DeleteWarehouseByID,
+		Purge:  h.warehouse.RemoveWarehouseByID,
+	}, h.recycle, requireMFA)
+
+	registerResource(protected, "receive-notes", "receive", resource{
+		List:   h.receive.SelectPageReceiveNotes,
+		Get:    h.receive.SelectReceiveNoteByID,
+		Create: h.receive.InsertReceiveNote,
+		Update: h.receive.UpdateReceiveNoteByID,
+		Delete: h.receive.DeleteReceiveNoteByID,
+		Purge:  h.receive.RemoveReceiveNoteByID,
+	}, h.recycle, requireMFA)
+
+	registerResource(protected, "orders", "order", resource{
+		List:   h.order.SelectPageOrders,
+		Get:    h.order.SelectOrderByID,
+		Create: h.order.InsertOrder,
+		Update: h.order.UpdateOrderByID,
+		Delete: h.order.DeleteOrderByID,
+		Purge:  h.order.RemoveOrderByID,
+	}, h.recycle, requireMFA)
+
+	// tb_reference ไม่มี Soft Delete จึงมีเฉพาะรายการ, เพิ่ม, อ่าน และแก้ไข (:id คือ row_id)
+	middleware.MapResourceScope("references", "reference")
+	getReference := h.reference.SelectReferenceByRowID
+	ifMatch := middleware.IfMatch()
+	protected.Get("/references", h.reference.SelectAllReferences)
+	protected.Post("/references", middleware.Location(), h.reference.InsertReference)
+	protected.Get("/references/:id", getReference)
+	protected.Patch("/references/:id", ifMatch, controllers.MergePatch(getReference, h.reference.UpdateReferenceByID))
 
-	protected.Use(middleware.JWTMiddleware(os.Getenv("JWT_SECRET")))
-	protected.Post("/refresh", controllers.RefreshToken) // Route สำหรับ Refresh Token
+	// การจัดการ API Key สงวนไว้สำหรับผู้ใช้ (ไม่มีถังขยะ การเพิกถอนใช้ POST /api-keys/:id/revoke)
+	getApiKey := h.apiKey.SelectApiKeyByID
+	apiKeys := protected.Group("/api-keys", middleware.DenyAPIKey(), middleware.RequireRole(auth.RoleAdmin))
+	apiKeys.Get("", h.apiKey.SelectAllApiKeys)
+	apiKeys.Post("", middleware.Location(), h.apiKey.InsertApiKey)
+	apiKeys.Get("/:id", getApiKey)
+	apiKeys.Patch("/:id", ifMatch, controllers.MergePatch(getApiKey, h.apiKey.UpdateApiKeyByID))
+	apiKeys.Delete("/:id", ifMatch, h.apiKey.DeleteApiKeyByID)
+	apiKeys.Post("/:id/purge", requireMFA, ifMatch, h.apiKey.RemoveApiKeyByID)
+	apiKeys.Post("/:id/revoke", ifMatch, h.apiKey.RevokeApiKeyByID)
 }
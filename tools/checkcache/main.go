@@ -0,0 +1,292 @@
+// checkcache ตรวจ Cache ทั้งสองแบบ (cache.LRU และ cache.Redis) ด้วยชุดตรวจเดียวกัน
+// Redis ตรวจกับ Server จำลองในโปรแกรมนี้ (RESP: PING, AUTH, SELECT, GET, SET PX, DEL) จึงไม่ต้องมี Redis จริง
+//
+//	go run ./tools/checkcache
+package main
+
+import (
+	"PenbunAPI/cache"
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+func main() {
+	ctx := context.Background()
+
+	fmt.Println("== LRU")
+	lru := cache.NewLRU(2)
+	contract(ctx, lru)
+	// key ที่ไม่ได้ใช้นานที่สุดถูกลบเมื่อเกิน size
+	set(ctx, lru, "a", "1", 0)
+	set(ctx, lru, "b", "2", 0)
+	expect(ctx, lru, "a", "1")
+	set(ctx, lru, "c", "3", 0)
+	expectMiss(ctx, lru, "b")
+	expect(ctx, lru, "a", "1")
+	fmt.Println("eviction ok")
+
+	fmt.Println("== Redis (fake server)")
+	srv := newFakeRedis("secret")
+	addr, err := srv.listen()
+	if err != nil {
+		fail("listen: %v", err)
+	}
+	r := cache.NewRedis(cache.RedisOptions{Addr: addr, Password: "secret", DB: 2, Prefix: "penbun:"})
+	if err := r.Ping(ctx); err != nil {
+		fail("ping: %v", err)
+	}
+	contract(ctx, r)
+	// Prefix ถูกใส่ให้ทุก key และคำสั่งทำงานบนฐานข้อมูลที่ SELECT ไว้
+	set(ctx, r, "prefixed", "x", 0)
+	if _, ok := srv.get(2, "penbun:prefixed"); !ok {
+		fail("key was not stored as penbun:prefixed in db 2")
+	}
+	fmt.Println("prefix and select ok")
+	// Password ผิดต้องคืน error (ReadThrough จะอ่านจากฐานข้อมูลแทน)
+	bad := cache.NewRedis(cache.RedisOptions{Addr: addr, Password: "wrong"})
+	if _, _, err := bad.Get(ctx, "k"); err == nil {
+		fail("wrong password was accepted")
+	}
+	fmt.Println("auth failure reported")
+	// Server ที่ปิดไปแล้วต้องคืน error ไม่ค้าง
+	r.Close()
+	srv.close()
+	if _, _, err := r.Get(ctx, "k"); err == nil {
+		fail("get from closed server succeeded")
+	}
+	fmt.Println("unreachable server reported")
+
+	fmt.Println("== ReadThrough")
+	readThrough(ctx)
+	fmt.Println("OK")
+}
+
+// contract คือชุดตรวจที่ Cache ทุกแบบต้องผ่าน
+func contract(ctx context.Context, c cache.Cache) {
+	expectMiss(ctx, c, "missing")
+
+	set(ctx, c, "k", "v1", 0)
+	expect(ctx, c, "k", "v1")
+	set(ctx, c, "k", "v2", 0)
+	expect(ctx, c, "k", "v2")
+	fmt.Println("get/set ok")
+
+	set(ctx, c, "short", "v", 50*time.Millisecond)
+	expect(ctx, c, "short", "v")
+	time.Sleep(80 * time.Millisecond)
+	expectMiss(ctx, c, "short")
+	fmt.Println("ttl ok")
+
+	set(ctx, c, "d1", "v", 0)
+	set(ctx, c, "d2", "v", 0)
+	if err := c.Delete(ctx, "d1", "d2", "never-set"); err != nil {
+		fail("delete: %v", err)
+	}
+	expectMiss(ctx, c, "d1")
+	expectMiss(ctx, c, "d2")
+	fmt.Println("delete ok")
+}
+
+// readThrough ตรวจว่าค่าที่โหลดแล้วไม่โหลดซ้ำจนกว่าจะ Invalidate และ error ของ load ไม่ถูกเก็บ
+func readThrough(ctx context.Context) {
+	c := cache.NewLRU(0)
+	loads := 0
+	load := func(context.Context) ([]string, error) {
+		loads++
+		return []string{"item", strconv.Itoa(loads)}, nil
+	}
+	for i := 0; i < 3; i++ {
+		v, err := cache.ReadThrough(ctx, c, "list", time.Minute, load)
+		if err != nil || len(v) != 2 || v[1] != "1" {
+			fail("read through #%d: %v %v", i, v, err)
+		}
+	}
+	if loads != 1 {
+		fail("loaded %d times, want 1", loads)
+	}
+	cache.Invalidate(ctx, c, "list")
+	if v, _ := cache.ReadThrough(ctx, c, "list", time.Minute, load); v[1] != "2" {
+		fail("read after invalidate returned %v", v)
+	}
+	if _, err := cache.ReadThrough(ctx, c, "err", time.Minute, func(context.Context) (int, error) {
+		return 0, io.ErrUnexpectedEOF
+	}); err != io.ErrUnexpectedEOF {
+		fail("load error: got %v", err)
+	}
+	if _, ok, _ := c.Get(ctx, "err"); ok {
+		fail("failed load was cached")
+	}
+	fmt.Println("read through and invalidate ok")
+}
+
+func set(ctx context.Context, c cache.Cache, key, value string, ttl time.Duration) {
+	if err := c.Set(ctx, key, []byte(value), ttl); err != nil {
+		fail("set %s: %v", key, err)
+	}
+}
+
+func expect(ctx context.Context, c cache.Cache, key, want string) {
+	got, ok, err := c.Get(ctx, key)
+	if err != nil || !ok || string(got) != want {
+		fail("get %s: got %q ok=%v err=%v, want %q", key, got, ok, err, want)
+	}
+}
+
+func expectMiss(ctx context.Context, c cache.Cache, key string) {
+	if got, ok, err := c.Get(ctx, key); err != nil || ok {
+		fail("get %s: got %q ok=%v err=%v, want miss", key, got, ok, err)
+	}
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Printf("FAIL: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// fakeRedis คือ Redis จำลองที่รองรับเฉพาะคำสั่งที่ cache.Redis ใช้
+type fakeRedis struct {
+	password string
+	ln       net.Listener
+	mu       sync.Mutex
+	dbs      map[int]map[string]fakeEntry
+}
+
+type fakeEntry struct {
+	value   string
+	expires time.Time
+}
+
+func newFakeRedis(password string) *fakeRedis {
+	return &fakeRedis{password: password, dbs: map[int]map[string]fakeEntry{}}
+}
+
+func (f *fakeRedis) listen() (string, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	f.ln = ln
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return ln.Addr().String(), nil
+}
+
+func (f *fakeRedis) close() { f.ln.Close() }
+
+func (f *fakeRedis) get(db int, key string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e, ok := f.dbs[db][key]
+	if !ok || (!e.expires.IsZero() && time.Now().After(e.expires)) {
+		return "", false
+	}
+	return e.value, true
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed := f.password == ""
+	db := 0
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(args[0])
+		if !authed && cmd != "AUTH" {
+			io.WriteString(conn, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+		switch cmd {
+		case "AUTH":
+			if len(args) == 2 && args[1] == f.password {
+				authed = true
+				io.WriteString(conn, "+OK\r\n")
+			} else {
+				io.WriteString(conn, "-WRONGPASS invalid password\r\n")
+			}
+		case "PING":
+			io.WriteString(conn, "+PONG\r\n")
+		case "SELECT":
+			db, _ = strconv.Atoi(args[1])
+			io.WriteString(conn, "+OK\r\n")
+		case "GET":
+			if v, ok := f.get(db, args[1]); ok {
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(v), v)
+			} else {
+				io.WriteString(conn, "$-1\r\n")
+			}
+		case "SET":
+			e := fakeEntry{value: args[2]}
+			if len(args) == 5 && strings.EqualFold(args[3], "PX") {
+				ms, _ := strconv.Atoi(args[4])
+				e.expires = time.Now().Add(time.Duration(ms) * time.Millisecond)
+			}
+			f.mu.Lock()
+			if f.dbs[db] == nil {
+				f.dbs[db] = map[string]fakeEntry{}
+			}
+			f.dbs[db][args[1]] = e
+			f.mu.Unlock()
+			io.WriteString(conn, "+OK\r\n")
+		case "DEL":
+			n := 0
+			f.mu.Lock()
+			for _, key := range args[1:] {
+				if _, ok := f.dbs[db][key]; ok {
+					delete(f.dbs[db], key)
+					n++
+				}
+			}
+			f.mu.Unlock()
+			fmt.Fprintf(conn, ":%d\r\n", n)
+		default:
+			fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+		}
+	}
+}
+
+// readCommand อ่านคำสั่งหนึ่งคำสั่งในรูป Array ของ Bulk String (*N $len ...)
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("bad command header %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, fmt.Errorf("bad argument header %q", line)
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
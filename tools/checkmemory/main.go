@@ -0,0 +1,126 @@
+// checkmemory เรียก Handler จริงบน Repository ในหน่วยความจำ (repository.NewMemoryXRepository) ผ่าน Fiber
+// เพื่อยืนยันว่า Fake ใช้แทนฐานข้อมูลในการทดสอบ Handler ได้ (รหัส, ค้นหา, แก้ไข, ถังขยะ และเอกสาร) โดยไม่ต้องมีฐานข้อมูล
+//
+//	go run ./tools/checkmemory
+package main
+
+import (
+	"PenbunAPI/config"
+	"PenbunAPI/controllers"
+	"PenbunAPI/middleware"
+	"PenbunAPI/repository"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type step struct {
+	method   string
+	path     string
+	body     string
+	status   int
+	want     string // ข้อความที่ Response ต้องมี (ว่าง = ไม่ตรวจ)
+	location string // Location header ที่ต้องได้ (ว่าง = ไม่ตรวจ)
+}
+
+func main() {
+	config.Logger = logrus.New() // ErrorHandler บันทึก Log ผ่าน config.Logger
+	config.Logger.SetOutput(io.Discard)
+
+	vendors := repository.NewMemoryVendorRepository()
+	receives := repository.NewMemoryReceiveRepository()
+	vendor := controllers.NewVendorHandler(vendors)
+	receive := controllers.NewReceiveHandler(receives)
+	reference := controllers.NewReferenceHandler(repository.NewMemoryReferenceRepository())
+	recycle := controllers.NewRecycleHandler(repository.NewMemoryRecycleRepository(map[string]repository.Recyclable{
+		"tb_vendor": vendors.(repository.Recyclable),
+	}))
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	v := app.Group("/vendor")
+	v.Post("/insert", vendor.InsertVendor)
+	v.Get("/select/page", vendor.SelectPageVendors)
+	v.Get("/select/name/:name", vendor.SelectVendorByName)
+	v.Get("/select/:id", vendor.SelectVendorByID)
+	v.Put("/update/:id", vendor.UpdateVendorByID)
+	v.Patch("/:id", controllers.MergePatch(vendor.SelectVendorByID, vendor.UpdateVendorByID))
+	v.Put("/delete/:id", vendor.DeleteVendorByID)
+	v.Get("/recycle", recycle.SelectRecycleBin("vendor"))
+	v.Put("/restore/:id", recycle.RestoreByID("vendor"))
+	v.Delete("/remove/:id", vendor.RemoveVendorByID)
+	app.Post("/receive/insert", receive.InsertReceiveNote)
+	app.Get("/receive/select/:id", receive.SelectReceiveNoteByID)
+	app.Post("/references", middleware.Location(), reference.InsertReference)
+	app.Get("/references/:id", reference.SelectReferenceByRowID)
+
+	for _, s := range []step{
+		// รหัสมีรูปแบบเดียวกับ Trigger (prefix ของตาราง + เลข 6 หลัก)
+		{method: "POST", path: "/vendor/insert", body: `{"vendor_type_id":"VT000001","vendor_name":"Penbun Press"}`, status: 201, want: `"VEN000001"`},
+		{method: "POST", path: "/vendor/insert", body: `{"vendor_type_id":"VT000001","vendor_name":"Ink Supply"}`, status: 201, want: `"VEN000002"`},
+		{method: "GET", path: "/vendor/select/VEN000001", status: 200, want: "Penbun Press"},
+		{method: "GET", path: "/vendor/select/VEN999999", status: 404},
+		{method: "GET", path: "/vendor/select/name/ink", status: 200, want: "VEN000002"},
+		{method: "GET", path: "/vendor/select/page?limit=1&total=true", status: 200, want: `"total":2`},
+
+		// Update คงค่าเดิมของ field ที่ไม่ได้ส่ง ส่วน PATCH ปฏิเสธ null
+		{method: "PUT", path: "/vendor/update/VEN000001", body: `{"phone1":"020000000"}`, status: 200},
+		{method: "GET", path: "/vendor/select/VEN000001", status: 200, want: "Penbun Press"},
+		{method: "PATCH", path: "/vendor/VEN000001", body: `{"vendor_name":"Penbun Publishing"}`, status: 200},
+		{method: "GET", path: "/vendor/select/VEN000001", status: 200, want: "020000000"},
+		{method: "GET", path: "/vendor/select/VEN000001", status: 200, want: "Penbun Publishing"},
+		{method: "PATCH", path: "/vendor/VEN000001", body: `{"phone1":null}`, status: 400, want: "not_null"},
+
+		// Soft Delete, ถังขยะ, Restore และ Purge
+		{method: "PUT", path: "/vendor/delete/VEN000002", status: 200},
+		{method: "GET", path: "/vendor/select/VEN000002", status: 404},
+		{method: "GET", path: "/vendor/recycle", status: 200, want: "VEN000002"},
+		{method: "PUT", path: "/vendor/restore/VEN000002", status: 200},
+		{method: "PUT", path: "/vendor/restore/VEN000002", status: 404},
+		{method: "GET", path: "/vendor/select/VEN000002", status: 200},
+		{method: "DELETE", path: "/vendor/remove/VEN000002", status: 200},
+		{method: "GET", path: "/vendor/select/VEN000002", status: 404},
+
+		// เอกสาร Header + Items
+		{method: "POST", path: "/receive/insert", body: `{"header":{"vendor_id":"VEN000001","warehouse_id":"WH000001","doc_date":"2026-10-19T09:00:00+07:00","receive_type":"PO","total_amount":1800},"items":[{"product_id":"PDT000001","qty":10,"unit_cost":180,"line_total":1800}]}`, status: 201, want: `"RCV000001"`},
+		{method: "GET", path: "/receive/select/RCV000001", status: 200, want: "PDT000001"},
+
+		// tb_reference ใช้ row_id เป็นรหัส
+		{method: "POST", path: "/references", body: `{"ref_id":"PAYMENT_TERM","ref_int":30,"ref_text":"30 days"}`, status: 201, location: "/references/1"},
+		{method: "GET", path: "/references/1", status: 200, want: "30 days"},
+	} {
+		run(app, s)
+	}
+	fmt.Println("OK")
+}
+
+func run(app *fiber.App, s step) {
+	req := httptest.NewRequest(s.method, s.path, strings.NewReader(s.body))
+	if s.body != "" {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	res, err := app.Test(req, -1)
+	if err != nil {
+		fail("%s %s: %v", s.method, s.path, err)
+	}
+	raw, _ := io.ReadAll(res.Body)
+	if res.StatusCode != s.status {
+		fail("%s %s: got %d, want %d: %s", s.method, s.path, res.StatusCode, s.status, raw)
+	}
+	if s.want != "" && !strings.Contains(string(raw), s.want) {
+		fail("%s %s: response does not contain %q: %s", s.method, s.path, s.want, raw)
+	}
+	if s.location != "" && res.Header.Get(fiber.HeaderLocation) != s.location {
+		fail("%s %s: Location = %q, want %q", s.method, s.path, res.Header.Get(fiber.HeaderLocation), s.location)
+	}
+	fmt.Printf("%s %s -> %d\n", s.method, s.path, res.StatusCode)
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Printf("FAIL: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,87 @@
+// checkroutes ลงทะเบียน Route ทั้งหมดของ v1 และ v2 ด้วย container.Container ที่ไม่ได้เชื่อมต่อฐานข้อมูลจริง
+// เพื่อยืนยันว่าทุก Handler ได้รับ Dependency ครบ (newHandlers จะ panic หากมีตัวใดเป็น nil)
+//
+//	go run ./tools/checkroutes
+package main
+
+import (
+	"PenbunAPI/config"
+	"PenbunAPI/container"
+	"PenbunAPI/middleware"
+	"PenbunAPI/routes"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+
+	_ "github.com/denisenkom/go-mssqldb"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	// sql.Open ไม่เชื่อมต่อจนกว่าจะมี Query แรก จึงใช้ตรวจการประกอบ Dependency ได้โดยไม่ต้องมีฐานข้อมูล
+	db, err := sql.Open("sqlserver", "sqlserver://localhost")
+	if err != nil {
+		fail("open database: %v", err)
+	}
+	// ErrorHandler และ Middleware บันทึก Log ผ่าน config.Logger เหมือนที่ main ตั้งค่าด้วย config.InitLogger
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	config.Logger = logger
+	ctn := container.New(db, logger, container.LoadConfig())
+
+	app := fiber.New(fiber.Config{CaseSensitive: true, StrictRouting: true, ErrorHandler: middleware.ErrorHandler})
+	if err := register(app, ctn); err != nil {
+		fail("register routes: %v", err)
+	}
+	fmt.Printf("registered %d routes, all handlers resolved their dependencies\n", len(app.GetRoutes(true)))
+
+	// Route ที่ไม่ใช้ฐานข้อมูลต้องตอบได้ และ Route ที่ต้อง Login ต้องถูกปฏิเสธโดย JWT Middleware (ไม่ใช่ panic)
+	for _, check := range []struct {
+		path   string
+		status int
+	}{
+		{"/api/v1/public/hello", fiber.StatusOK},
+		{"/api/v1/protected/vendor/select/all", fiber.StatusUnauthorized},
+		{"/api/v2/protected/vendors", fiber.StatusUnauthorized},
+	} {
+		res, err := app.Test(httptest.NewRequest(fiber.MethodGet, check.path, nil))
+		if err != nil {
+			fail("GET %s: %v", check.path, err)
+		}
+		if res.StatusCode != check.status {
+			fail("GET %s: got %d, want %d", check.path, res.StatusCode, check.status)
+		}
+		fmt.Printf("GET %s -> %d\n", check.path, res.StatusCode)
+	}
+
+	// Container ที่ขาด Dependency ต้องถูกปฏิเสธตั้งแต่ตอนลงทะเบียน Route
+	broken := container.New(db, logger, container.LoadConfig())
+	broken.Sessions = nil
+	if err := register(fiber.New(), broken); err == nil {
+		fail("container without Sessions was accepted")
+	} else {
+		fmt.Println("missing dependency rejected:", err)
+	}
+	fmt.Println("OK")
+}
+
+// register ลงทะเบียน Route ทั้งหมดแบบเดียวกับ main และแปลง panic ของ newHandlers เป็น error
+func register(app *fiber.App, ctn *container.Container) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	routes.RegisterWellKnownRoutes(app)
+	routes.RegisterV1Routes(app, ctn)
+	routes.RegisterV2Routes(app, ctn)
+	return nil
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Printf("FAIL: "+format+"\n", args...)
+	os.Exit(1)
+}
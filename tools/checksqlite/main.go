@@ -0,0 +1,581 @@
+// checksqlite สร้างตารางบน SQLite ในหน่วยความจำ (dialect.SQLite) ด้วย migrate แล้วเรียก Route จริงตั้งแต่ Login
+// จนถึง Insert/Update/Delete/Restore ของข้อมูลหลักและเอกสาร เพื่อยืนยันว่า SQL ทุกส่วนทำงานได้โดยไม่ต้องมี SQL Server
+//
+//	CGO_ENABLED=1 go run ./tools/checksqlite
+package main
+
+import (
+	"PenbunAPI/auth"
+	"PenbunAPI/cache"
+	"PenbunAPI/config"
+	"PenbunAPI/container"
+	"PenbunAPI/dialect"
+	"PenbunAPI/middleware"
+	"PenbunAPI/migrate"
+	"PenbunAPI/models"
+	"PenbunAPI/replica"
+	"PenbunAPI/repository"
+	"PenbunAPI/routes"
+	"PenbunAPI/utils"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// step คือ Request หนึ่งครั้ง ค่า {{name}} ใน path/body ถูกแทนด้วยค่าที่ step ก่อนหน้าเก็บไว้ใน save
+type step struct {
+	method  string
+	path    string
+	body    string
+	status  int
+	save    map[string]string // ชื่อตัวแปร -> key ใน data ของ Response
+	apiKey  bool              // ส่ง X-API-Key แทน Bearer Token
+	primary bool              // ส่ง X-Read-Consistency: primary
+	want    string            // ข้อความที่ Response ต้องมี (ว่าง = ไม่ตรวจ)
+	headers map[string]string // Header เพิ่มเติม (ใช้ {{name}} ได้)
+	etag    string            // ชื่อตัวแปรที่เก็บ ETag ของ Response
+}
+
+// mfaRateLimit คือ MFA_RATE_LIMIT ที่ใช้ในการตรวจ (checkMFA เรียก /login/mfa จนเกินค่านี้)
+const mfaRateLimit = 10
+
+var placeholder = regexp.MustCompile(`\{\{(\w+)\}\}`)
+
+func main() {
+	if os.Getenv("JWT_SECRET") == "" && os.Getenv("JWT_PRIVATE_KEY_FILE") == "" {
+		os.Setenv("JWT_SECRET", "checksqlite-secret")
+	}
+	if err := config.LoadJWTKeys(); err != nil {
+		fail("load JWT keys: %v", err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	config.Logger = logger
+
+	db, err := dialect.OpenSQLite(":memory:")
+	if err != nil {
+		fail("open sqlite: %v", err)
+	}
+	defer db.Close()
+	checkMigrations(db)
+	if err := seedUser(db, "admin", "admin-password", "admin"); err != nil {
+		fail("seed user: %v", err)
+	}
+
+	cfg := container.LoadConfig()
+	cfg.MFARateLimit = mfaRateLimit
+	ctn := container.New(db, logger, cfg)
+	app := fiber.New(fiber.Config{CaseSensitive: true, StrictRouting: true, ErrorHandler: middleware.ErrorHandler})
+	routes.RegisterWellKnownRoutes(app)
+	routes.RegisterV1Routes(app, ctn)
+	routes.RegisterV2Routes(app, ctn)
+
+	vars := map[string]string{}
+	run(app, vars, "", step{method: "POST", path: "/api/v1/public/login", body: `{"username":"admin","password":"admin-password"}`, status: 200})
+	token := vars["token"]
+	if token == "" {
+		fail("login returned no token")
+	}
+
+	const v1 = "/api/v1/protected"
+	for _, s := range []step{
+		{method: "GET", path: "/api/v1/public/welcome", status: 200},
+
+		// ข้อมูลหลัก: รหัสต้องถูกสร้างจาก prefix แทน Trigger
+		{method: "POST", path: v1 + "/vendortype/insert", body: `{"type_name":"Publisher","is_active":true}`, status: 201, save: map[string]string{"vt": "vendor_type_id"}},
+		{method: "POST", path: v1 + "/vendor/insert", body: `{"vendor_type_id":"{{vt}}","vendor_name":"Penbun Press"}`, status: 201, save: map[string]string{"vendor": "vendor_id"}},
+		{method: "POST", path: v1 + "/vendor/insert", body: `{"vendor_type_id":"VT999999","vendor_name":"Ghost"}`, status: 422},
+		{method: "GET", path: v1 + "/vendor/select/{{vendor}}", status: 200},
+		{method: "GET", path: v1 + "/vendor/select/name/Press", status: 200},
+		{method: "GET", path: v1 + "/vendor/select/page?limit=1&total=true&sort=-vendor_name", status: 200},
+		{method: "PUT", path: v1 + "/vendor/update/{{vendor}}", body: `{"note":"updated"}`, status: 200},
+		{method: "GET", path: v1 + "/vendortype/select/name/Pub", status: 200},
+		{method: "POST", path: v1 + "/warehouse/insert", body: `{"warehouse_code":"MAIN","warehouse_name":"Main DC"}`, status: 201, save: map[string]string{"wh": "warehouse_id"}},
+		{method: "POST", path: v1 + "/productcategory/insert", body: `{"category_name":"Books","category_code":"BK"}`, status: 201, save: map[string]string{"cat": "product_category_id"}},
+		{method: "POST", path: v1 + "/productgroup/insert", body: `{"product_category_id":"{{cat}}","product_group_name":"Novels"}`, status: 201, save: map[string]string{"grp": "product_group_id"}},
+		{method: "POST", path: v1 + "/unittype/insert", body: `{"unit_type_name":"Piece"}`, status: 201, save: map[string]string{"unit": "unit_type_id"}},
+		{method: "POST", path: v1 + "/product/insert", body: `{"product_name_th":"นิยาย","product_type_id":"{{grp}}","vendor_id":"{{vendor}}","unit_type_id":"{{unit}}","price":250,"cost":180,"count_stock":true}`, status: 201, save: map[string]string{"product": "product_id"}},
+		{method: "GET", path: v1 + "/product/select/{{product}}", status: 200},
+
+		// เอกสาร Header + Items
+		{method: "POST", path: v1 + "/receive/insert", body: `{"header":{"vendor_id":"{{vendor}}","warehouse_id":"{{wh}}","doc_date":"2026-10-19T09:00:00+07:00","receive_type":"PO","total_amount":1800},"items":[{"product_id":"{{product}}","qty":10,"unit_cost":180,"line_total":1800}]}`, status: 201, save: map[string]string{"rcv": "receive_note_id"}},
+		{method: "GET", path: v1 + "/receive/select/{{rcv}}", status: 200},
+		{method: "PUT", path: v1 + "/vendor/update/{{vendor}}", body: `{"vendor_type_id":"VT999999"}`, status: 422, want: "vendor_type_id"},
+		{method: "POST", path: v1 + "/receive/insert", body: `{"header":{"vendor_id":"{{vendor}}","warehouse_id":"{{wh}}","doc_date":"2026-10-19T09:00:00+07:00","receive_type":"PO","total_amount":0},"items":[{"product_id":"PDT999999","qty":1}]}`, status: 422, want: "items[0].product_id"},
+		{method: "PUT", path: v1 + "/receive/update/{{rcv}}", body: `{"note":"checked"}`, status: 200},
+		{method: "GET", path: v1 + "/receive/select/page?limit=5", status: 200},
+
+		// Delete Guard, Soft Delete, ถังขยะ และ Restore
+		{method: "PUT", path: v1 + "/unittype/delete/{{unit}}", status: 409},
+		{method: "PUT", path: v1 + "/receive/delete/{{rcv}}", status: 200},
+		{method: "GET", path: v1 + "/receive/recycle", status: 200},
+		{method: "PUT", path: v1 + "/receive/restore/{{rcv}}", status: 200},
+		{method: "POST", path: v1 + "/customertype/insert", body: `{"customer_type_name":"Retail"}`, status: 201, save: map[string]string{"ct": "customer_type_id"}},
+		{method: "PUT", path: v1 + "/customertype/delete/{{ct}}", status: 200},
+		{method: "GET", path: v1 + "/customertype/recycle?q=Retail", status: 200},
+		{method: "PUT", path: v1 + "/customertype/restore/{{ct}}", status: 200},
+		{method: "GET", path: v1 + "/customertype/select/{{ct}}", status: 200},
+		// ?cascade=soft ลบลูกค้าของประเภทนั้นตาม (ปิด is_active) และล้าง Cache ของ tb_customer ด้วย
+		{method: "POST", path: v1 + "/customer/insert", body: `{"customer_type_id":"{{ct}}","customer_name":"Walk-in"}`, status: 201, save: map[string]string{"cust": "customer_id"}},
+		{method: "GET", path: v1 + "/customer/select/{{cust}}", status: 200},
+		{method: "GET", path: v1 + "/customer/select/all", status: 200, want: "Walk-in"},
+		{method: "PUT", path: v1 + "/customertype/delete/{{ct}}", status: 409, want: "reference_violation"},
+		{method: "PUT", path: v1 + "/customertype/delete/{{ct}}?cascade=soft", status: 200},
+		{method: "GET", path: v1 + "/customer/select/{{cust}}", status: 404},
+		{method: "GET", path: v1 + "/customer/recycle?q=Walk-in", status: 200, want: "Walk-in"},
+
+		// REST v2
+		{method: "GET", path: "/api/v2/protected/vendors?limit=10", status: 200},
+		{method: "PATCH", path: "/api/v2/protected/vendors/{{vendor}}", body: `{"phone1":"020000000"}`, status: 200},
+
+		// tb_reference, Session และ API Key
+		{method: "POST", path: v1 + "/reference/insert", body: `{"ref_id":"PAYMENT_TERM","ref_int":30,"ref_text":"30 days"}`, status: 201},
+		{method: "GET", path: v1 + "/reference/select/PAYMENT_TERM", status: 200},
+
+		// Cache ของข้อมูลหลัก: การแก้ไขต้องล้าง Cache ที่ Select ก่อนหน้าเก็บไว้
+		{method: "GET", path: v1 + "/unittype/select/all", status: 200, want: "Piece"},
+		{method: "GET", path: v1 + "/unittype/select/{{unit}}", status: 200, want: "Piece"},
+		{method: "PUT", path: v1 + "/unittype/update/{{unit}}", body: `{"unit_type_name":"Box"}`, status: 200},
+		{method: "GET", path: v1 + "/unittype/select/{{unit}}", status: 200, want: "Box"},
+		{method: "GET", path: v1 + "/unittype/select/all", status: 200, want: "Box"},
+		{method: "GET", path: v1 + "/reference/select/all", status: 200, want: "30 days"},
+		{method: "POST", path: v1 + "/reference/insert", body: `{"ref_id":"PAYMENT_TERM","ref_int":60,"ref_text":"60 days"}`, status: 201},
+		{method: "GET", path: v1 + "/reference/select/all", status: 200, want: "60 days"},
+		{method: "GET", path: v1 + "/reference/select/PAYMENT_TERM", status: 200, want: "60 days"},
+		{method: "POST", path: "/api/v2/protected/references", body: `{"ref_id":"PAYMENT_TERM","ref_int":90,"ref_text":"90 days"}`, status: 201, save: map[string]string{"ref": "row_id"}},
+		{method: "GET", path: "/api/v2/protected/references/{{ref}}", status: 200, want: "90 days", etag: "refTag"},
+		{method: "PATCH", path: "/api/v2/protected/references/{{ref}}", body: `{"ref_text":null}`, status: 400, want: "ref_text"},
+		{method: "PATCH", path: "/api/v2/protected/references/{{ref}}", body: `{"ref_text":"stale"}`, status: 412, headers: map[string]string{"If-Match": `W/"19990101000000"`}},
+		{method: "PATCH", path: "/api/v2/protected/references/{{ref}}", body: `{"ref_text":"90 days net"}`, status: 200, headers: map[string]string{"If-Match": "{{refTag}}"}},
+		{method: "GET", path: "/api/v2/protected/references", status: 200, want: "90 days net"},
+
+		// ETag: 304 เมื่อข้อมูลไม่เปลี่ยน และ If-Match ที่ไม่ตรงกับข้อมูลปัจจุบันตอบ 412
+		{method: "GET", path: v1 + "/unittype/select/all", status: 200, etag: "units"},
+		{method: "GET", path: v1 + "/unittype/select/all", status: 304, headers: map[string]string{"If-None-Match": "{{units}}"}},
+		{method: "GET", path: v1 + "/unittype/select/{{unit}}", status: 200, etag: "unitTag"},
+		{method: "GET", path: v1 + "/unittype/select/{{unit}}", status: 304, headers: map[string]string{"If-None-Match": "{{unitTag}}"}},
+		{method: "PUT", path: v1 + "/unittype/update/{{unit}}", body: `{"description":"stale"}`, status: 412, want: "precondition_failed", headers: map[string]string{"If-Match": `W/"19990101000000"`}},
+		{method: "PUT", path: v1 + "/unittype/update/{{unit}}", body: `{"description":"current"}`, status: 200, headers: map[string]string{"If-Match": "{{unitTag}}"}},
+		{method: "PUT", path: v1 + "/unittype/update/{{unit}}", body: `{"description":"same second"}`, status: 412, headers: map[string]string{"If-Match": "{{unitTag}}"}},
+		{method: "PUT", path: v1 + "/unittype/delete/{{unit}}", status: 412, headers: map[string]string{"If-Match": `W/"19990101000000"`}},
+		{method: "PUT", path: v1 + "/unittype/update/UT999999", body: `{"description":"missing"}`, status: 404, headers: map[string]string{"If-Match": "*"}},
+		{method: "GET", path: "/api/v2/protected/unit-types?limit=1&total=true&sort=unit_type_id", status: 200, etag: "unitPage"},
+		{method: "POST", path: v1 + "/unittype/insert", body: `{"unit_type_name":"Pack"}`, status: 201},
+		{method: "GET", path: v1 + "/unittype/select/all", status: 200, headers: map[string]string{"If-None-Match": "{{units}}"}},
+		{method: "GET", path: "/api/v2/protected/unit-types?limit=1&total=true&sort=unit_type_id", status: 200, headers: map[string]string{"If-None-Match": "{{unitPage}}"}}, // แถวในหน้าเดิม แต่ total เปลี่ยน
+		{method: "GET", path: "/api/v2/protected/unit-types/{{unit}}", status: 200, etag: "unitTag"},
+		{method: "PATCH", path: "/api/v2/protected/unit-types/{{unit}}", body: `{"description":"stale"}`, status: 412, headers: map[string]string{"If-Match": `W/"19990101000000"`}},
+		{method: "PATCH", path: "/api/v2/protected/unit-types/{{unit}}", body: `{"description":"v2"}`, status: 200, headers: map[string]string{"If-Match": "{{unitTag}}"}},
+		{method: "DELETE", path: "/api/v2/protected/unit-types/{{unit}}", status: 412, headers: map[string]string{"If-Match": `W/"19990101000000"`}},
+		{method: "GET", path: v1 + "/session/select/all", status: 200},
+		{method: "POST", path: v1 + "/apikey/insert", body: `{"key_name":"sync","scopes":["vendor:read"]}`, status: 201, save: map[string]string{"key": "api_key"}},
+		{method: "GET", path: "/api/v2/protected/api-keys", status: 200, want: "sync"},
+		{method: "POST", path: "/api/v2/protected/api-keys", body: `{"key_name":"report","scopes":["order:read"]}`, status: 201, save: map[string]string{"reportKey": "api_key_id"}},
+		{method: "GET", path: "/api/v2/protected/api-keys/{{reportKey}}", status: 200, etag: "reportTag"},
+		{method: "PATCH", path: "/api/v2/protected/api-keys/{{reportKey}}", body: `{"description":null}`, status: 400, want: "not_null"},
+		{method: "PATCH", path: "/api/v2/protected/api-keys/{{reportKey}}", body: `{"key_name":"stale"}`, status: 412, headers: map[string]string{"If-Match": `W/"19990101000000"`}},
+		{method: "PATCH", path: "/api/v2/protected/api-keys/{{reportKey}}", body: `{"key_name":"daily report"}`, status: 200, headers: map[string]string{"If-Match": "{{reportTag}}"}},
+		{method: "GET", path: "/api/v2/protected/api-keys/{{reportKey}}", status: 200, want: "daily report"},
+		{method: "POST", path: "/api/v2/protected/api-keys/{{reportKey}}/revoke", status: 200},
+		{method: "GET", path: v1 + "/vendor/select/all", status: 200, apiKey: true},
+
+		// Key ที่ออกโดย Session ที่ไม่ผ่าน MFA (scope "*") ใช้ลบข้อมูลจริงแทนผู้ใช้ไม่ได้
+		{method: "POST", path: v1 + "/apikey/insert", body: `{"key_name":"everything","scopes":["*"]}`, status: 201, save: map[string]string{"key": "api_key"}},
+		{method: "DELETE", path: v1 + "/vendor/remove/{{vendor}}", status: 403, want: "API keys cannot", apiKey: true},
+		{method: "POST", path: "/api/v2/protected/vendors/{{vendor}}/purge", status: 403, want: "API keys cannot", apiKey: true},
+		{method: "GET", path: v1 + "/vendor/select/{{vendor}}", status: 200, apiKey: true},
+
+		// สถานะฐานข้อมูล (admin เท่านั้น)
+		{method: "GET", path: v1 + "/admin/db/stats", status: 200},
+		{method: "GET", path: v1 + "/admin/db/stats", status: 403, apiKey: true},
+	} {
+		run(app, vars, token, s)
+	}
+	checkIfMatch(db, vars)
+	checkCascade(db, vars)
+	checkRestore(db, vars)
+	checkTimeout(db)
+	checkReplica(db, logger, vars, token)
+	checkApiKeyAdmin(app, db, vars)
+	checkMFA(app, db, vars)
+	fmt.Println("OK")
+}
+
+// checkMigrations ยืนยันว่า Check ปฏิเสธฐานข้อมูลว่าง และ up/down ทุกรุ่นย้อนกลับได้ แล้วจบด้วยโครงสร้างรุ่นล่าสุด
+func checkMigrations(db *sql.DB) {
+	if err := migrate.Check(context.Background(), db); !errors.Is(err, migrate.ErrSchemaBehind) {
+		fail("check on empty database: got %v, want ErrSchemaBehind", err)
+	}
+	for _, args := range [][]string{{"up"}, {"down", "all"}, {"up"}, {"status"}} {
+		fmt.Printf("migrate %v\n", args)
+		if err := migrate.Run(context.Background(), db, args, os.Stdout); err != nil {
+			fail("migrate %v: %v", args, err)
+		}
+	}
+	if err := migrate.Check(context.Background(), db); err != nil {
+		fail("check after migrate up: %v", err)
+	}
+	// ฐานข้อมูลที่ migration สร้างไม่มีคอลัมน์ชื่อเก่า และ up ไม่เปลี่ยนชื่อคอลัมน์ให้เอง
+	if err := migrate.CheckLegacyColumns(context.Background(), db); err != nil {
+		fail("legacy columns after migrate up: %v", err)
+	}
+	var out strings.Builder
+	if err := migrate.Run(context.Background(), db, []string{"legacy-columns"}, &out); err != nil || !strings.Contains(out.String(), "no legacy columns") {
+		fail("migrate legacy-columns: %v %q", err, out.String())
+	}
+}
+
+// checkTimeout ยืนยันว่า Request ที่เกินงบเวลาของ middleware.Timeout ตอบ 504 (code timeout)
+// ทั้งกรณี Handler คืน error และตอบ 5xx เอง และ Transaction ที่เริ่มหลังหมดเวลาไม่ถูกบันทึก
+func checkTimeout(db *sql.DB) {
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	slow := app.Group("/slow", middleware.Timeout(20*time.Millisecond))
+	slow.Post("/insert", func(c *fiber.Ctx) error {
+		time.Sleep(50 * time.Millisecond)
+		err := utils.ExecuteTransaction(c.UserContext(), db, []func(tx *sql.Tx) error{
+			func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(c.UserContext(), `INSERT INTO tb_reference (ref_id, ref_int, ref_text) VALUES ('TIMEOUT', 1, 'late')`)
+				return err
+			},
+		})
+		if err != nil {
+			return utils.Internal(err, "Failed to insert")
+		}
+		return c.SendStatus(fiber.StatusCreated)
+	})
+	slow.Get("/status", func(c *fiber.Ctx) error {
+		<-c.UserContext().Done()
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ApiResponse{Status: "error", Message: "query failed"})
+	})
+	slow.Get("/fast", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for _, tc := range []struct {
+		method, path string
+		status       int
+	}{
+		{"POST", "/slow/insert", fiber.StatusGatewayTimeout},
+		{"GET", "/slow/status", fiber.StatusGatewayTimeout},
+		{"GET", "/slow/fast", fiber.StatusOK},
+	} {
+		res, err := app.Test(httptest.NewRequest(tc.method, tc.path, nil), -1)
+		if err != nil {
+			fail("%s %s: %v", tc.method, tc.path, err)
+		}
+		raw, _ := io.ReadAll(res.Body)
+		if res.StatusCode != tc.status {
+			fail("%s %s: got %d, want %d: %s", tc.method, tc.path, res.StatusCode, tc.status, raw)
+		}
+		var out models.ApiResponse
+		if tc.status == fiber.StatusGatewayTimeout && (json.Unmarshal(raw, &out) != nil || out.Code != utils.CodeTimeout) {
+			fail("%s %s: want code %q: %s", tc.method, tc.path, utils.CodeTimeout, raw)
+		}
+		fmt.Printf("%s %s -> %d (timeout check)\n", tc.method, tc.path, res.StatusCode)
+	}
+
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM tb_reference WHERE ref_id = 'TIMEOUT'`).Scan(&n); err != nil || n != 0 {
+		fail("timed out transaction was committed (rows=%d, err=%v)", n, err)
+	}
+}
+
+// checkReplica ใช้ SQLite ว่างอีกฐานหนึ่งเป็น Read Replica เพื่อยืนยันว่า GET อ่านจาก Replica,
+// X-Read-Consistency: primary และ Method อื่นอ่านจากฐานข้อมูลหลัก และเมื่อ Replica ใช้ไม่ได้จะอ่านจากฐานข้อมูลหลักแทน
+func checkReplica(db *sql.DB, logger *logrus.Logger, vars map[string]string, token string) {
+	read, err := dialect.OpenSQLite(":memory:")
+	if err != nil {
+		fail("open replica: %v", err)
+	}
+	if _, err := migrate.Up(context.Background(), read); err != nil {
+		fail("migrate replica: %v", err)
+	}
+
+	ctn := container.New(db, logger, container.LoadConfig())
+	ctn.UseReadReplica(read, 0)
+	app := fiber.New(fiber.Config{CaseSensitive: true, StrictRouting: true, ErrorHandler: middleware.ErrorHandler})
+	routes.RegisterV1Routes(app, ctn)
+	routes.RegisterV2Routes(app, ctn)
+
+	const v1 = "/api/v1/protected"
+	for _, s := range []step{
+		{method: "GET", path: v1 + "/vendor/select/{{vendor}}", status: 404},                // Replica ยังไม่มีข้อมูล
+		{method: "GET", path: v1 + "/vendor/select/{{vendor}}", status: 200, primary: true}, // read-your-writes
+		{method: "PATCH", path: "/api/v2/protected/vendors/{{vendor}}", body: `{"note":"via primary"}`, status: 200},
+		{method: "GET", path: v1 + "/admin/db/stats", status: 200},
+	} {
+		run(app, vars, token, s)
+	}
+
+	// Replica ใช้ไม่ได้ (ปิดการเชื่อมต่อ) Select ต้องอ่านจากฐานข้อมูลหลักแทนโดยไม่ตอบ 5xx
+	read.Close()
+	run(app, vars, token, step{method: "GET", path: v1 + "/vendor/select/{{vendor}}", status: 200})
+	run(app, vars, token, step{method: "GET", path: "/api/v2/protected/vendors?limit=5&total=true", status: 200})
+}
+
+// checkIfMatch ยืนยันว่า Repository ตรวจ If-Match ภายใน Transaction ของการเขียนเอง (middleware.IfMatch ไม่ได้ตรวจ)
+// เหมือนผู้ใช้สองคนที่ส่ง ETag เดียวกันพร้อมกัน คนแรกสำเร็จและคนที่สองต้องได้ 412
+func checkIfMatch(db *sql.DB, vars map[string]string) {
+	var updateDate sql.NullTime
+	if err := db.QueryRow(`SELECT update_date FROM tb_unit_type WHERE unit_type_id = @ID`, sql.Named("ID", vars["unit"])).Scan(&updateDate); err != nil {
+		fail("read unit type version: %v", err)
+	}
+	ctx := utils.WithIfMatch(context.Background(), `W/"`+utils.RowVersion(updateDate.Time)+`"`)
+	repo := repository.NewUnitTypeRepository(replica.New(db, nil))
+	if err := repo.Update(ctx, vars["unit"], &models.UnitType{Description: "first writer"}); err != nil {
+		fail("update with current version: %v", err)
+	}
+	err := repo.Update(ctx, vars["unit"], &models.UnitType{Description: "second writer"})
+	var appErr *utils.AppError
+	if !errors.As(err, &appErr) || appErr.Status != fiber.StatusPreconditionFailed {
+		fail("update with stale version: got %v, want 412", err)
+	}
+	fmt.Println("If-Match checked inside the write transaction")
+}
+
+// checkCascade ยืนยันว่าลูกค้าที่ถูกลบตามประเภทลูกค้า (?cascade=soft) ถูกปิด is_active ด้วย เหมือน Soft Delete โดยตรง
+// และ Soft Delete ผ่าน repository.Cached ล้าง Cache ของแถวที่ถูกลบตามในตารางอื่น (ลูกค้าที่อ่านผ่าน Cache ไว้ก่อนต้องไม่พบ)
+func checkCascade(db *sql.DB, vars map[string]string) {
+	var deleted, active int
+	if err := db.QueryRow(`SELECT is_delete, is_active FROM tb_customer WHERE customer_id = @ID`, sql.Named("ID", vars["cust"])).Scan(&deleted, &active); err != nil {
+		fail("read cascaded customer: %v", err)
+	}
+	if deleted != 1 || active != 0 {
+		fail("cascade: is_delete=%d, is_active=%d, want 1, 0", deleted, active)
+	}
+
+	ctx, dbs, lru := context.Background(), replica.New(db, nil), cache.NewLRU(0)
+	types := repository.Cached[models.CustomerType](repository.NewCustomerTypeRepository(dbs), lru, "tb_customer_type", time.Minute)
+	customers := repository.Cached[models.Customer](repository.NewCustomerRepository(dbs), lru, "tb_customer", time.Minute)
+	typeID, err := types.Create(ctx, &models.CustomerType{CustomerTypeName: "Wholesale"})
+	if err != nil {
+		fail("create customer type: %v", err)
+	}
+	customerID, err := customers.Create(ctx, &models.Customer{CustomerTypeID: typeID, CustomerName: "Book Shop"})
+	if err != nil {
+		fail("create customer: %v", err)
+	}
+	if _, err := customers.Get(ctx, customerID); err != nil {
+		fail("get customer: %v", err)
+	}
+	if list, err := customers.List(ctx); err != nil || len(list) == 0 {
+		fail("list customers: %d %v", len(list), err)
+	}
+	if err := types.SoftDelete(ctx, typeID, "admin", true); err != nil {
+		fail("cascade delete customer type: %v", err)
+	}
+	if _, err := customers.Get(ctx, customerID); !errors.Is(err, repository.ErrNotFound) {
+		fail("cached customer after cascade: got %v, want ErrNotFound", err)
+	}
+	list, err := customers.List(ctx)
+	if err != nil {
+		fail("list customers: %v", err)
+	}
+	for _, c := range list {
+		if c.CustomerID == customerID {
+			fail("cached customer list still has %s after cascade", customerID)
+		}
+	}
+	fmt.Println("Cascade soft delete deactivated the dependent rows and cleared their cache")
+}
+
+// checkRestore ยืนยันว่า Restore ของเอกสารกู้คืนเฉพาะ items ที่ถูกลบพร้อม Header และไม่เปิด is_active กลับ
+func checkRestore(db *sql.DB, vars map[string]string) {
+	ctx, id := context.Background(), vars["rcv"]
+	// item ที่ถูกลบแยกไว้ก่อนเอกสาร และอ้างถึงสินค้าที่ถูกลบไปแล้ว (ไม่ถูกกู้คืน จึงต้องไม่ทำให้ Restore ได้ 409)
+	var discontinued string
+	if err := db.QueryRow(`
+		INSERT INTO tb_product (product_id, product_name_th, product_type_id, is_active, is_delete)
+		SELECT 'PDT900001', 'Discontinued', product_type_id, 0, 1 FROM tb_product WHERE product_id = @Product
+		RETURNING product_id`, sql.Named("Product", vars["product"])).Scan(&discontinued); err != nil {
+		fail("insert deleted product: %v", err)
+	}
+	if _, err := db.Exec(`
+		INSERT INTO tb_receive_item (receive_note_id, product_id, qty, update_date, is_delete)
+		VALUES (@ID, @Product, 1, '2026-01-01 00:00:00.000', 1)`,
+		sql.Named("ID", id), sql.Named("Product", discontinued)); err != nil {
+		fail("insert deleted item: %v", err)
+	}
+	if err := repository.NewReceiveRepository(replica.New(db, nil)).SoftDelete(ctx, id, "admin"); err != nil {
+		fail("delete receive note: %v", err)
+	}
+	bin := repository.RecycleBin{Table: "tb_receive_note", IDColumn: "receive_note_id", NameColumn: "vendor_id",
+		Model: models.ReceiveNote{}, ItemTable: "tb_receive_item", ItemModel: models.ReceiveItem{}}
+	if err := repository.NewRecycleRepository(replica.New(db, nil)).Restore(ctx, bin, id, "admin"); err != nil {
+		fail("restore receive note: %v", err)
+	}
+	var active, restored, deleted int
+	if err := db.QueryRow(`
+		SELECT (SELECT is_active FROM tb_receive_note WHERE receive_note_id = @ID),
+		       (SELECT COUNT(*) FROM tb_receive_item WHERE receive_note_id = @ID AND is_delete = 0),
+		       (SELECT COUNT(*) FROM tb_receive_item WHERE receive_note_id = @ID AND is_delete = 1)`,
+		sql.Named("ID", id)).Scan(&active, &restored, &deleted); err != nil {
+		fail("read restored receive note: %v", err)
+	}
+	if active != 0 || restored != 1 || deleted != 1 {
+		fail("restore: is_active=%d, restored items=%d, deleted items=%d, want 0, 1, 1", active, restored, deleted)
+	}
+	fmt.Println("Restore brought back only the items deleted with the document")
+}
+
+// checkApiKeyAdmin ยืนยันว่าผู้ใช้ที่ไม่ใช่ admin ดู สร้าง แก้ไข เพิกถอน หรือลบ Key ของผู้อื่นไม่ได้ทั้ง v1 และ v2
+func checkApiKeyAdmin(app *fiber.App, db *sql.DB, vars map[string]string) {
+	if err := seedUser(db, "clerk", "clerk-password", "user"); err != nil {
+		fail("seed clerk: %v", err)
+	}
+	run(app, vars, "", step{method: "POST", path: "/api/v1/public/login", body: `{"username":"clerk","password":"clerk-password"}`, status: 200})
+	token := vars["token"]
+
+	v1 := "/api/v1/protected/apikey"
+	v2 := "/api/v2/protected/api-keys"
+	for _, s := range []step{
+		{method: "GET", path: v1 + "/select/all", status: 403},
+		{method: "GET", path: v1 + "/select/{{reportKey}}", status: 403},
+		{method: "POST", path: v1 + "/insert", body: `{"key_name":"mine","scopes":["*"]}`, status: 403},
+		{method: "PUT", path: v1 + "/update/{{reportKey}}", body: `{"scopes":["*"]}`, status: 403},
+		{method: "PUT", path: v1 + "/revoke/{{reportKey}}", status: 403},
+		{method: "DELETE", path: v1 + "/remove/{{reportKey}}", status: 403},
+		{method: "GET", path: v2, status: 403},
+		{method: "POST", path: v2, body: `{"key_name":"mine","scopes":["*"]}`, status: 403},
+		{method: "PATCH", path: v2 + "/{{reportKey}}", body: `{"scopes":["*"]}`, status: 403},
+		{method: "POST", path: v2 + "/{{reportKey}}/revoke", status: 403},
+		{method: "POST", path: v2 + "/{{reportKey}}/purge", status: 403},
+	} {
+		run(app, vars, token, s)
+	}
+}
+
+// checkMFA ยืนยันว่า Challenge Token ถูกยกเลิกเมื่อกรอกรหัสผิดครบ auth.MFAMaxAttempts ครั้ง
+// และ /login/mfa ตอบ 429 เมื่อเกิน MFA_RATE_LIMIT ต่อนาที
+func checkMFA(app *fiber.App, db *sql.DB, vars map[string]string) {
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		fail("generate TOTP secret: %v", err)
+	}
+	if err := seedUser(db, "mfa-user", "mfa-password", "user"); err != nil {
+		fail("seed MFA user: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE tb_users SET mfa_enabled = 1, mfa_secret = @Secret WHERE user_name = 'mfa-user'`, sql.Named("Secret", secret)); err != nil {
+		fail("enable MFA: %v", err)
+	}
+	if vars["mfa"], err = auth.IssueMFAChallenge("mfa-user"); err != nil {
+		fail("issue MFA challenge: %v", err)
+	}
+	wrong := "000000"
+	for n := 1; ; n++ {
+		if _, ok := utils.ValidateTOTP(secret, wrong, time.Now()); !ok {
+			break
+		}
+		wrong = fmt.Sprintf("%06d", n)
+	}
+
+	login := step{method: "POST", path: "/api/v1/public/login/mfa", body: `{"mfa_token":"{{mfa}}","code":"` + wrong + `"}`, status: 401}
+	sent := 0
+	for i := 1; i <= auth.MFAMaxAttempts; i++ {
+		login.want = `"attempts_remaining":` + strconv.Itoa(auth.MFAMaxAttempts-i)
+		if i == auth.MFAMaxAttempts {
+			login.want = "Too many invalid MFA codes"
+		}
+		run(app, vars, "", login)
+		sent++
+	}
+	login.want = "already used"
+	for ; sent < mfaRateLimit; sent++ {
+		run(app, vars, "", login)
+	}
+	login.status, login.want = 429, "rate_limited"
+	run(app, vars, "", login)
+}
+
+// seedUser เพิ่มผู้ใช้สำหรับ Login (ระบบไม่มี Route สมัครสมาชิก)
+func seedUser(db *sql.DB, userName, password, role string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`INSERT INTO tb_users (user_name, user_password, user_role) VALUES (@UserName, @Password, @Role)`,
+		sql.Named("UserName", userName), sql.Named("Password", string(hash)), sql.Named("Role", role))
+	return err
+}
+
+func run(app *fiber.App, vars map[string]string, token string, s step) {
+	expand := func(v string) string {
+		return placeholder.ReplaceAllStringFunc(v, func(m string) string { return vars[m[2:len(m)-2]] })
+	}
+	path, body := expand(s.path), expand(s.body)
+	req := httptest.NewRequest(s.method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	switch {
+	case s.apiKey:
+		req.Header.Set(middleware.APIKeyHeader, vars["key"])
+	case token != "":
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	}
+	if s.primary {
+		req.Header.Set(middleware.ReadConsistencyHeader, "primary")
+	}
+	for name, value := range s.headers {
+		req.Header.Set(name, expand(value))
+	}
+	res, err := app.Test(req, -1)
+	if err != nil {
+		fail("%s %s: %v", s.method, path, err)
+	}
+	raw, _ := io.ReadAll(res.Body)
+	if res.StatusCode != s.status {
+		fail("%s %s: got %d, want %d: %s", s.method, path, res.StatusCode, s.status, raw)
+	}
+	if s.want != "" && !strings.Contains(string(raw), s.want) {
+		fail("%s %s: response does not contain %q: %s", s.method, path, s.want, raw)
+	}
+
+	if s.etag != "" {
+		tag := res.Header.Get(fiber.HeaderETag)
+		if tag == "" {
+			fail("%s %s: response has no ETag", s.method, path)
+		}
+		vars[s.etag] = tag
+	}
+
+	var out struct {
+		Token string                 `json:"token"`
+		Data  map[string]interface{} `json:"data"`
+	}
+	_ = json.Unmarshal(raw, &out)
+	if out.Token != "" {
+		vars["token"] = out.Token
+	}
+	saved := ""
+	for name, key := range s.save {
+		v, _ := out.Data[key].(string)
+		if n, ok := out.Data[key].(float64); ok {
+			v = strconv.FormatFloat(n, 'f', -1, 64) // เช่น row_id
+		}
+		if v == "" {
+			fail("%s %s: response has no data.%s: %s", s.method, path, key, raw)
+		}
+		vars[name] = v
+		saved += " " + key + "=" + v
+	}
+	fmt.Printf("%s %s -> %d%s\n", s.method, path, res.StatusCode, saved)
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Printf("FAIL: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// APIKeyPrefix นำหน้าทุก API Key เพื่อให้แยกออกจาก JWT ได้ง่ายเวลาอ่าน Log หรือ Config
+const APIKeyPrefix = "pbk_"
+
+// GenerateAPIKey สร้าง API Key ใหม่ คืนค่า key จริง (แสดงครั้งเดียว), prefix สำหรับแสดงผล และ hash สำหรับเก็บลงฐานข้อมูล
+func GenerateAPIKey() (plain string, display string, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	plain = APIKeyPrefix + hex.EncodeToString(buf)
+	display = plain[:len(APIKeyPrefix)+8]
+	return plain, display, HashAPIKey(plain), nil
+}
+
+// HashAPIKey คืนค่า SHA-256 (hex) ของ API Key
+func HashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(plain)))
+	return hex.EncodeToString(sum[:])
+}
+
+// SplitScopes แปลง scopes ที่เก็บเป็น comma-separated ในฐานข้อมูลให้เป็น slice
+func SplitScopes(raw string) []string {
+	var scopes []string
+	for _, s := range strings.Split(raw, ",") {
+		if s = strings.TrimSpace(strings.ToLower(s)); s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// JoinScopes แปลง scopes เป็น comma-separated สำหรับบันทึกลงฐานข้อมูล
+func JoinScopes(scopes []string) string {
+	return strings.Join(SplitScopes(strings.Join(scopes, ",")), ",")
+}
+
+// ScopeAllows ตรวจสอบว่า scopes ที่ได้รับอนุญาตครอบคลุม module และ action ที่ร้องขอหรือไม่
+// รูปแบบ scope คือ "<module>:<read|write>" โดยใช้ "*" แทนได้ทั้งสองฝั่ง เช่น "*", "product:*", "*:read"
+func ScopeAllows(scopes []string, module, action string) bool {
+	for _, s := range scopes {
+		if s == "*" || s == "*:*" {
+			return true
+		}
+		parts := strings.SplitN(s, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if (parts[0] == "*" || parts[0] == module) && (parts[1] == "*" || parts[1] == action) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"PenbunAPI/dialect"
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxBlockerIDs คือจำนวนรหัสสูงสุดต่อกลุ่มที่แสดงใน Response (เกินจากนี้ตอบ more: true)
+const maxBlockerIDs = 20
+
+// dependentLink คือตารางที่อ้างถึงตารางอื่นผ่าน foreign key (สร้างจาก tag `ref` ของ model)
+type dependentLink struct {
+	table    string // ตารางที่อ้างถึง เช่น tb_customer
+	column   string // คอลัมน์ foreign key เช่น customer_type_id
+	idColumn string // รหัสที่แสดงเป็นรายการที่ติดอยู่ เช่น customer_id
+	active   string // คอลัมน์สถานะที่ปิด (= 0) เมื่อถูก Soft Delete ตาม เช่น is_active (ว่างหากไม่มี)
+	cascade  bool   // Soft Delete ตามได้เมื่อ ?cascade=soft
+}
+
+// dependentLinks เก็บ dependentLink ตามตารางที่ถูกอ้างถึง
+var dependentLinks = map[string][]dependentLink{}
+
+// RegisterRefs ลงทะเบียนตาราง table (รหัส idColumn) ที่มี foreign key ตาม tag `ref` ของ model
+// เพื่อให้ GuardDelete/GuardRemove ตรวจสอบข้อมูลที่ยังอ้างถึงได้
+// active คือคอลัมน์สถานะของ table ที่ปิดพร้อม Soft Delete ตาม (แต่ละตารางใช้ชื่อต่างกัน เช่น is_active, id_status)
+// cascade = false ใช้กับเอกสาร (ใบสั่งขาย, ใบรับสินค้า) ซึ่งต้องไม่ถูกลบตามข้อมูลหลักโดยอัตโนมัติ
+func RegisterRefs(table, idColumn, active string, cascade bool, model interface{}) {
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("ref")
+		if tag == "" {
+			continue
+		}
+		target, err := parseRefTag(tag)
+		if err != nil {
+			panic("utils.RegisterRefs: " + err.Error())
+		}
+		dependentLinks[target.table] = append(dependentLinks[target.table], dependentLink{
+			table:    table,
+			column:   fieldName(sf),
+			idColumn: idColumn,
+			active:   active,
+			cascade:  cascade,
+		})
+	}
+}
+
+// Blocker คือกลุ่มข้อมูลที่ยังอ้างถึงข้อมูลที่จะลบ
+type Blocker struct {
+	Entity string   `json:"entity"`
+	Field  string   `json:"field"`
+	IDs    []string `json:"ids"`
+	More   bool     `json:"more,omitempty"`
+}
+
+// CascadeSoft อ่าน query ?cascade= (รองรับเฉพาะ "soft") คืนค่า true เมื่อต้องการ Soft Delete ข้อมูลที่อ้างถึงไปพร้อมกัน
+func CascadeSoft(c *fiber.Ctx) (bool, error) {
+	switch c.Query("cascade") {
+	case "":
+		return false, nil
+	case "soft":
+		return true, nil
+	}
+	return false, BadRequest("cascade must be \"soft\"")
+}
+
+// CascadedRows คือรหัสของแถวที่ GuardDelete Soft Delete ตาม แยกตามตาราง (เช่น ให้ Repository ที่ Cache ล้าง key ของตารางเหล่านั้น)
+type CascadedRows map[string][]string
+
+type cascadedRowsKey struct{}
+
+// WithCascadedRows คืน ctx ที่ GuardDelete บันทึกแถวที่ถูก Soft Delete ตามลงใน rows
+func WithCascadedRows(ctx context.Context, rows CascadedRows) context.Context {
+	return context.WithValue(ctx, cascadedRowsKey{}, rows)
+}
+
+// GuardDelete ตรวจสอบข้อมูลที่ยังใช้งานอยู่ (is_delete = 0) ซึ่งอ้างถึงแถว id ของ table ก่อน Soft Delete
+// หาก cascade = true จะ Soft Delete ข้อมูลหลักที่อ้างถึง (ไล่ต่อเป็นทอด ๆ และปิดคอลัมน์สถานะ) ภายใน tx เดียวกันแทน
+// แต่หากมีเอกสารอ้างถึงอยู่ในทอดใดก็ตามจะปฏิเสธทั้งหมด คืนค่า 409 พร้อมรายการข้อมูลที่ติดอยู่
+// d คือ Dialect ของฐานข้อมูลที่ tx ทำงานอยู่ (ใช้บันทึก update_date ของข้อมูลที่ถูกลบตาม)
+// แถวที่ถูกลบตามถูกบันทึกใน CascadedRows ของ ctx (WithCascadedRows) หากมี
+func GuardDelete(ctx context.Context, tx *sql.Tx, d dialect.Dialect, table, id, user string, cascade bool) error {
+	var blockers []Blocker
+	var steps []func() error
+	if err := collectDependents(ctx, tx, d, table, id, user, cascade, &blockers, &steps, map[string]bool{}); err != nil {
+		return err
+	}
+	if len(blockers) > 0 {
+		return dependentsError(blockers, cascade)
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GuardRemove ตรวจสอบข้อมูลทั้งหมด (รวมที่ถูก Soft Delete แล้ว) ซึ่งอ้างถึงแถว id ของ table ก่อน Hard Delete
+func GuardRemove(ctx context.Context, tx *sql.Tx, table, id string) error {
+	var blockers []Blocker
+	for _, link := range dependentLinks[table] {
+		ids, err := dependentIDs(ctx, tx, link, id, false)
+		if err != nil {
+			return err
+		}
+		if len(ids) > 0 {
+			blockers = append(blockers, newBlocker(link, ids))
+		}
+	}
+	if len(blockers) > 0 {
+		return dependentsError(blockers, false)
+	}
+	return nil
+}
+
+func collectDependents(ctx context.Context, tx *sql.Tx, d dialect.Dialect, table, id, user string, cascade bool, blockers *[]Blocker, steps *[]func() error, visited map[string]bool) error {
+	key := table + "\x00" + id
+	if visited[key] {
+		return nil
+	}
+	visited[key] = true
+
+	for _, link := range dependentLinks[table] {
+		ids, err := dependentIDs(ctx, tx, link, id, true)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		if !cascade || !link.cascade {
+			*blockers = append(*blockers, newBlocker(link, ids))
+			continue
+		}
+		for _, depID := range ids {
+			if err := collectDependents(ctx, tx, d, link.table, depID, user, cascade, blockers, steps, visited); err != nil {
+				return err
+			}
+		}
+		link, parentID := link, id
+		deactivate := ""
+		if link.active != "" {
+			deactivate = link.active + " = 0,"
+		}
+		*steps = append(*steps, func() error {
+			_, err := tx.ExecContext(ctx, `
+				UPDATE `+link.table+`
+				SET is_delete = 1, `+deactivate+`
+				    update_by = @UpdateBy,
+				    update_date = `+d.Now()+`
+				WHERE `+link.column+` = @ID AND is_delete = 0`,
+				sql.Named("ID", parentID), sql.Named("UpdateBy", user))
+			if rows, ok := ctx.Value(cascadedRowsKey{}).(CascadedRows); ok && err == nil {
+				rows[link.table] = append(rows[link.table], ids...)
+			}
+			return err
+		})
+	}
+	return nil
+}
+
+func dependentIDs(ctx context.Context, tx *sql.Tx, link dependentLink, id string, activeOnly bool) ([]string, error) {
+	query := "SELECT DISTINCT " + link.idColumn + " FROM " + link.table + " WHERE " + link.column + " = @ID"
+	if activeOnly {
+		query += " AND is_delete = 0"
+	}
+	rows, err := tx.QueryContext(ctx, query+" ORDER BY "+link.idColumn, sql.Named("ID", id))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var depID string
+		if err := rows.Scan(&depID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, depID)
+	}
+	return ids, rows.Err()
+}
+
+func newBlocker(link dependentLink, ids []string) Blocker {
+	b := Blocker{Entity: entityName(link.table), Field: link.column, IDs: ids}
+	if len(ids) > maxBlockerIDs {
+		b.IDs, b.More = ids[:maxBlockerIDs], true
+	}
+	return b
+}
+
+func dependentsError(blockers []Blocker, cascade bool) *AppError {
+	entities := make([]string, 0, len(blockers))
+	for _, b := range blockers {
+		entities = append(entities, b.Entity)
+	}
+	msg := "Record is still referenced by " + strings.Join(entities, ", ")
+	if cascade {
+		msg += " (documents are never deleted by cascade)"
+	}
+	return NewAppError(fiber.StatusConflict, CodeReferenceViolation, msg).
+		WithDetails(fiber.Map{"dependents": blockers})
+}
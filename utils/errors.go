@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// รหัส Error ที่ client ใช้ตรวจสอบได้ (ส่งใน field "code" ของ ApiResponse)
+const (
+	CodeBadRequest         = "bad_request"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeConflict           = "conflict"
+	CodePreconditionFailed = "precondition_failed"
+	CodeDuplicate          = "duplicate"
+	CodeReferenceViolation = "reference_violation"
+	CodeValidation         = "validation_failed"
+	CodeDeadlock           = "deadlock"
+	CodeTimeout            = "timeout"
+	CodeRateLimited        = "rate_limited"
+	CodeInternal           = "internal_error"
+)
+
+// AppError คือ Error ที่ Handler คืนค่าให้ Global ErrorHandler แปลงเป็น ApiResponse
+// Err คือสาเหตุภายใน (เช่น SQL Error) ซึ่งบันทึกลง Log เท่านั้นและไม่ส่งให้ client
+type AppError struct {
+	Status    int         // HTTP status
+	Code      string      // รหัส Error เช่น "duplicate"
+	Message   string      // ข้อความที่แสดงให้ client ได้
+	Details   interface{} // ข้อมูลเพิ่มเติม (ส่งใน field "data")
+	Retryable bool        // client ลองใหม่ได้ (ตอบ Retry-After)
+	Err       error
+}
+
+func (e *AppError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// WithDetails คืนค่า AppError เดิมพร้อมข้อมูลเพิ่มเติม
+func (e *AppError) WithDetails(details interface{}) *AppError {
+	e.Details = details
+	return e
+}
+
+// NewAppError สร้าง AppError ด้วย HTTP status, code และข้อความ
+func NewAppError(status int, code, message string) *AppError {
+	return &AppError{Status: status, Code: code, Message: message}
+}
+
+// BadRequest คือข้อมูลที่ส่งมาไม่ถูกต้อง (400)
+func BadRequest(message string) *AppError {
+	return NewAppError(fiber.StatusBadRequest, CodeBadRequest, message)
+}
+
+// NotFound คือไม่พบข้อมูล (404)
+func NotFound(message string) *AppError {
+	return NewAppError(fiber.StatusNotFound, CodeNotFound, message)
+}
+
+// PreconditionFailed คือ If-Match ไม่ตรงกับ ETag ปัจจุบันของข้อมูล (412) เพราะมีผู้อื่นแก้ไขไปก่อน
+func PreconditionFailed(message string) *AppError {
+	return NewAppError(fiber.StatusPreconditionFailed, CodePreconditionFailed, message)
+}
+
+// Timeout คือ Request ใช้เวลาเกินงบเวลาของ Route Group (504) โดย err คือสาเหตุภายใน (เช่น context.DeadlineExceeded)
+func Timeout(err error) *AppError {
+	return &AppError{
+		Status:    fiber.StatusGatewayTimeout,
+		Code:      CodeTimeout,
+		Message:   "The request took too long to complete",
+		Retryable: true,
+		Err:       err,
+	}
+}
+
+// Internal ห่อ err ที่เกิดจากระบบ (เช่นฐานข้อมูล) โดย message คือข้อความที่แสดงให้ client
+// หาก err เป็น SQL Error ที่รู้จัก (Unique/FK/Deadlock) ErrorHandler จะตอบตาม MapSQLError แทน
+// และหาก err เป็น AppError อยู่แล้ว (เช่นคืนค่าจาก step ใน ExecuteTransaction) จะคืนค่าเดิม
+func Internal(err error, message string) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return &AppError{Status: fiber.StatusInternalServerError, Code: CodeInternal, Message: message, Err: err}
+}
+
+// AsAppError แปลง error ใด ๆ เป็น AppError สำหรับตอบ client
+// *fiber.Error ใช้ status/ข้อความเดิม, SQL Error ที่รู้จักถูก map ตาม MapSQLError,
+// error ที่เกิดจาก context หมดเวลาเป็น 504 และ error อื่นทั้งหมดเป็น 500 โดยไม่เปิดเผยข้อความภายใน
+func AsAppError(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		if appErr.Status >= fiber.StatusInternalServerError {
+			if errors.Is(appErr.Err, context.DeadlineExceeded) {
+				return Timeout(appErr.Err)
+			}
+			if mapped := MapSQLError(appErr.Err); mapped != nil {
+				return mapped
+			}
+		}
+		return appErr
+	}
+
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return NewAppError(fe.Code, codeForStatus(fe.Code), fe.Message)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Timeout(err)
+	}
+	if mapped := MapSQLError(err); mapped != nil {
+		return mapped
+	}
+	return Internal(err, "Internal server error")
+}
+
+// codeForStatus คืนค่า code มาตรฐานของ HTTP status (ใช้กับ fiber.NewError)
+func codeForStatus(status int) string {
+	switch status {
+	case fiber.StatusBadRequest:
+		return CodeBadRequest
+	case fiber.StatusUnauthorized:
+		return CodeUnauthorized
+	case fiber.StatusForbidden:
+		return CodeForbidden
+	case fiber.StatusNotFound:
+		return CodeNotFound
+	case fiber.StatusConflict:
+		return CodeConflict
+	case fiber.StatusPreconditionFailed:
+		return CodePreconditionFailed
+	}
+	if status >= fiber.StatusInternalServerError {
+		return CodeInternal
+	}
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
@@ -1,12 +1,55 @@
 package utils
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"PenbunAPI/auth"
+	"PenbunAPI/config"
+	"log"
+	"strings"
 
-// ResolveUser extracts the username from query parameters or returns "UNKNOWN"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// ResolveUser คืนค่าชื่อผู้ใช้ที่ผ่านการยืนยันตัวตนแล้ว (JWT หรือ API Key) สำหรับบันทึก update_by
+// ค่า ?user= จาก Query จะไม่ถูกนำมาใช้ หากส่งมาและไม่ตรงกับผู้ใช้จริงจะถูกบันทึกเป็นความพยายามปลอมตัว
 func ResolveUser(c *fiber.Ctx) string {
-	username := c.Query("user")
-	if username == "" {
-		username = "UNKNOWN"
+	return AuditUser(c, c.Query("user"))
+}
+
+// AuditUser คืนค่าผู้ใช้จริงจาก Token เสมอ โดย claimed คือค่าที่ผู้เรียกส่งมาเอง (เช่น update_by ใน Body)
+// ถ้า claimed ไม่ว่างและไม่ตรงกับผู้ใช้จริง จะบันทึก Log แล้วใช้ผู้ใช้จริงแทน
+func AuditUser(c *fiber.Ctx, claimed string) string {
+	username := "UNKNOWN"
+	if claims := auth.FromContext(c); claims != nil && claims.UserName != "" {
+		username = claims.UserName
+	}
+
+	claimed = strings.TrimSpace(claimed)
+	if claimed != "" && !strings.EqualFold(claimed, username) {
+		log.Printf("[WARN] Audit user spoof attempt: principal=%s claimed=%s path=%s", username, claimed, c.Path())
+		if config.Logger != nil {
+			config.Logger.WithFields(logrus.Fields{
+				"action":    "audit_spoof",
+				"principal": username,
+				"claimed":   claimed,
+				"method":    c.Method(),
+				"path":      c.OriginalURL(),
+			}).Warn("Ignored client-supplied audit user")
+		}
 	}
 	return username
 }
+
+// StampUser เหมือน AuditUser สำหรับ Model ที่เก็บ update_by เป็น *string
+func StampUser(c *fiber.Ctx, claimed *string) *string {
+	value := ""
+	if claimed != nil {
+		value = *claimed
+	}
+	username := AuditUser(c, value)
+	if value == "" {
+		// ไม่ได้ส่ง update_by มาใน Body ให้ตรวจค่า ?user= แทน (เพื่อ Log เท่านั้น)
+		username = ResolveUser(c)
+	}
+	return &username
+}
@@ -0,0 +1,364 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// FieldType กำหนดชนิดข้อมูลของ field เพื่อตรวจสอบและแปลงค่าก่อนส่งเป็น SQL parameter
+type FieldType int
+
+const (
+	FieldText FieldType = iota
+	FieldNumber
+	FieldDate
+	FieldBool
+)
+
+const (
+	// maxListFilters จำกัดจำนวน filter ต่อ Request
+	maxListFilters = 20
+
+	// DefaultPageLimit และ MaxPageLimit คือค่าเริ่มต้นและค่าสูงสุดของ ?limit=
+	DefaultPageLimit = 10
+	MaxPageLimit     = 100
+)
+
+// ListField คือ field ที่อนุญาตให้ filter/sort ได้ โดย Column คือชื่อคอลัมน์จริงใน SQL (รวม alias ของตาราง)
+type ListField struct {
+	Column string
+	Type   FieldType
+}
+
+// ListSpec คือ allow-list ของแต่ละ Controller สำหรับ ?filter=, ?sort= และ ?q=
+type ListSpec struct {
+	Fields      map[string]ListField // ชื่อ field ที่ client ใช้ => คอลัมน์
+	Search      []string             // คอลัมน์ที่ใช้ค้นหาด้วย ?q= (LIKE)
+	DefaultSort string               // ORDER BY เมื่อไม่ได้ส่ง ?sort= มา
+	Key         string               // field ที่ไม่ซ้ำและไม่เปลี่ยนค่า ใช้เป็น sort key ของ Cursor Pagination
+}
+
+// ListQuery คือผลลัพธ์ที่พร้อมนำไปต่อท้าย SQL
+// Where ขึ้นต้นด้วย " AND " (หรือว่าง) เพื่อต่อท้ายเงื่อนไข is_delete = 0 ที่มีอยู่แล้ว
+type ListQuery struct {
+	Where   string
+	OrderBy string
+	Args    []interface{}
+
+	Page      int
+	Limit     int
+	Offset    int
+	Cursor    bool // true เมื่อใช้ ?cursor= (Keyset Pagination)
+	WithTotal bool // true เมื่อต้องนับ total (ปิดได้ด้วย ?total=false, Cursor Mode ปิดเป็นค่าเริ่มต้น)
+}
+
+// cursorToken คือข้อมูลภายใน Cursor (encode เป็น base64 ให้ client ใช้แบบ opaque)
+type cursorToken struct {
+	Key  interface{} `json:"k"`
+	Desc bool        `json:"d"`
+}
+
+// Fetch คือจำนวนแถวที่ต้องดึงจริง (Cursor Mode ดึงเกิน 1 แถวเพื่อรู้ว่ามีหน้าถัดไปหรือไม่)
+func (q ListQuery) Fetch() int {
+	if q.Cursor {
+		return q.Limit + 1
+	}
+	return q.Limit
+}
+
+// Count นับจำนวนแถวทั้งหมดด้วย countQuery (ซึ่งต้องต่อท้ายด้วย q.Where แล้ว) คืนค่า nil หากไม่ได้ขอ total
+func (q ListQuery) Count(ctx context.Context, db *sql.DB, countQuery string) (*int, error) {
+	if !q.WithTotal {
+		return nil, nil
+	}
+	var total int
+	if err := db.QueryRowContext(ctx, countQuery, q.Args...).Scan(&total); err != nil {
+		return nil, err
+	}
+	return &total, nil
+}
+
+// Paged เพิ่ม next_cursor และ has_next ให้ Response เมื่อใช้ Cursor Mode (Offset Mode คืนค่า data เดิม)
+func (q ListQuery) Paged(data fiber.Map, nextCursor string) fiber.Map {
+	if q.Cursor {
+		delete(data, "page")
+		data["next_cursor"] = nextCursor
+		data["has_next"] = nextCursor != ""
+	}
+	return data
+}
+
+// CursorPage ตัดแถวที่ดึงเกินมาออก และสร้าง next_cursor จาก key ของแถวสุดท้าย
+func CursorPage[T any](q ListQuery, list []T, key func(T) interface{}) ([]T, string) {
+	if !q.Cursor || len(list) <= q.Limit {
+		return list, ""
+	}
+	list = list[:q.Limit]
+	data, err := json.Marshal(cursorToken{Key: key(list[len(list)-1]), Desc: strings.HasSuffix(q.OrderBy, " DESC")})
+	if err != nil {
+		return list, ""
+	}
+	return list, base64.RawURLEncoding.EncodeToString(data)
+}
+
+// With คืนค่า Args ของ filter ต่อด้วย args เพิ่มเติม (เช่น Offset/Limit)
+func (q ListQuery) With(args ...interface{}) []interface{} {
+	all := make([]interface{}, 0, len(q.Args)+len(args))
+	all = append(all, q.Args...)
+	return append(all, args...)
+}
+
+var listOperators = map[string]string{
+	"eq":  "=",
+	"ne":  "<>",
+	"gt":  ">",
+	"gte": ">=",
+	"lt":  "<",
+	"lte": "<=",
+}
+
+// ParseListQuery แปลง Query String เป็นเงื่อนไข SQL แบบ parameterized ตาม allow-list ของ spec
+//
+//	?filter=<field>:<op>:<value>   ใส่ได้หลายครั้ง op = eq, ne, gt, gte, lt, lte, like, in (คั่นค่าด้วย |), null, notnull
+//	?sort=-credit_limit,customer_name   ขึ้นต้นด้วย - คือเรียงจากมากไปน้อย
+//	?q=<text>                       ค้นหาแบบ LIKE ในคอลัมน์ spec.Search
+//
+//	?page=&limit=                   Offset Pagination (limit สูงสุด MaxPageLimit: v1 ลดเหลือ MaxPageLimit, v2 ตอบ 400)
+//	?cursor=&limit=                 Keyset Pagination เรียงตาม spec.Key (หน้าแรกส่ง cursor ว่าง แล้วใช้ next_cursor)
+//	?total=true|false               นับ total หรือไม่
+//
+// field หรือ op ที่ไม่รู้จักจะคืนค่า error สำหรับตอบกลับเป็น 400
+func ParseListQuery(c *fiber.Ctx, spec ListSpec) (ListQuery, error) {
+	var (
+		where []string
+		args  []interface{}
+	)
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", DefaultPageLimit)
+	if page < 1 {
+		return ListQuery{}, fmt.Errorf("page must be at least 1")
+	}
+	// v1 เดิมรับ limit ได้ไม่จำกัด จึงลดให้เหลือ MaxPageLimit แทนการปฏิเสธ เพื่อไม่ให้ client เดิมใช้งานไม่ได้
+	if limit > MaxPageLimit && !UsePageEnvelope(c) {
+		limit = MaxPageLimit
+	}
+	if limit < 1 || limit > MaxPageLimit {
+		return ListQuery{}, fmt.Errorf("limit must be between 1 and %d", MaxPageLimit)
+	}
+	cursorMode := c.Context().QueryArgs().Has("cursor")
+	withTotal := c.QueryBool("total", !cursorMode)
+	param := func(value interface{}) string {
+		name := fmt.Sprintf("lq%d", len(args))
+		args = append(args, sql.Named(name, value))
+		return "@" + name
+	}
+
+	filters := c.Context().QueryArgs().PeekMulti("filter")
+	if len(filters) > maxListFilters {
+		return ListQuery{}, fmt.Errorf("too many filters (max %d)", maxListFilters)
+	}
+	for _, raw := range filters {
+		parts := strings.SplitN(string(raw), ":", 3)
+		if len(parts) < 2 {
+			return ListQuery{}, fmt.Errorf("invalid filter %q, expected field:op:value", raw)
+		}
+		name, op := parts[0], strings.ToLower(parts[1])
+		field, ok := spec.Fields[name]
+		if !ok {
+			return ListQuery{}, fmt.Errorf("unknown filter field %q", name)
+		}
+
+		switch op {
+		case "null":
+			where = append(where, field.Column+" IS NULL")
+			continue
+		case "notnull":
+			where = append(where, field.Column+" IS NOT NULL")
+			continue
+		}
+		if len(parts) != 3 {
+			return ListQuery{}, fmt.Errorf("filter %q requires a value", raw)
+		}
+		value := parts[2]
+
+		switch op {
+		case "like":
+			if field.Type != FieldText {
+				return ListQuery{}, fmt.Errorf("operator like is only supported on text field %q", name)
+			}
+			where = append(where, field.Column+" LIKE "+param("%"+escapeLike(value)+"%")+` ESCAPE '\'`)
+		case "in":
+			var placeholders []string
+			for _, v := range strings.Split(value, "|") {
+				typed, err := listValue(field, v)
+				if err != nil {
+					return ListQuery{}, fmt.Errorf("filter %q: %v", name, err)
+				}
+				placeholders = append(placeholders, param(typed))
+			}
+			where = append(where, field.Column+" IN ("+strings.Join(placeholders, ", ")+")")
+		default:
+			sqlOp, ok := listOperators[op]
+			if !ok {
+				return ListQuery{}, fmt.Errorf("unknown filter operator %q", op)
+			}
+			typed, err := listValue(field, value)
+			if err != nil {
+				return ListQuery{}, fmt.Errorf("filter %q: %v", name, err)
+			}
+			where = append(where, field.Column+" "+sqlOp+" "+param(typed))
+		}
+	}
+
+	if q := strings.TrimSpace(c.Query("q")); q != "" && len(spec.Search) > 0 {
+		p := param("%" + escapeLike(q) + "%")
+		var or []string
+		for _, column := range spec.Search {
+			or = append(or, column+" LIKE "+p+` ESCAPE '\'`)
+		}
+		where = append(where, "("+strings.Join(or, " OR ")+")")
+	}
+
+	orderBy := spec.DefaultSort
+	if sortParam := strings.TrimSpace(c.Query("sort")); sortParam != "" {
+		var order []string
+		seen := map[string]bool{}
+		for _, s := range strings.Split(sortParam, ",") {
+			s = strings.TrimSpace(s)
+			dir := "ASC"
+			if strings.HasPrefix(s, "-") {
+				dir, s = "DESC", s[1:]
+			} else {
+				s = strings.TrimPrefix(s, "+")
+			}
+			field, ok := spec.Fields[s]
+			if !ok {
+				return ListQuery{}, fmt.Errorf("unknown sort field %q", s)
+			}
+			if seen[field.Column] {
+				return ListQuery{}, fmt.Errorf("duplicate sort field %q", s)
+			}
+			seen[field.Column] = true
+			order = append(order, field.Column+" "+dir)
+		}
+		orderBy = strings.Join(order, ", ")
+	}
+
+	if cursorMode {
+		cursorWhere, cursorOrder, err := parseCursor(c.Query("cursor"), c.Query("sort"), spec, param)
+		if err != nil {
+			return ListQuery{}, err
+		}
+		if cursorWhere != "" {
+			where = append(where, cursorWhere)
+		}
+		orderBy = cursorOrder
+		page = 1
+	}
+
+	q := ListQuery{
+		OrderBy:   orderBy,
+		Args:      args,
+		Page:      page,
+		Limit:     limit,
+		Offset:    (page - 1) * limit,
+		Cursor:    cursorMode,
+		WithTotal: withTotal,
+	}
+	if len(where) > 0 {
+		q.Where = " AND " + strings.Join(where, " AND ")
+	}
+	return q, nil
+}
+
+// parseCursor สร้างเงื่อนไข Keyset จาก Cursor (ว่าง = หน้าแรก) โดยเรียงตาม spec.Key เท่านั้น
+func parseCursor(cursor, sortParam string, spec ListSpec, param func(interface{}) string) (string, string, error) {
+	field, ok := spec.Fields[spec.Key]
+	if !ok {
+		return "", "", fmt.Errorf("cursor pagination is not supported on this endpoint")
+	}
+
+	desc := true
+	switch strings.TrimSpace(sortParam) {
+	case "", "-" + spec.Key:
+	case spec.Key, "+" + spec.Key:
+		desc = false
+	default:
+		return "", "", fmt.Errorf("cursor pagination only supports sort=%s or sort=-%s", spec.Key, spec.Key)
+	}
+	order := field.Column + " ASC"
+	if desc {
+		order = field.Column + " DESC"
+	}
+	if cursor == "" {
+		return "", order, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid cursor")
+	}
+	var token cursorToken
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	if err := dec.Decode(&token); err != nil || token.Key == nil {
+		return "", "", fmt.Errorf("invalid cursor")
+	}
+	if token.Desc != desc {
+		return "", "", fmt.Errorf("cursor does not match sort order")
+	}
+	value, err := listValue(field, fmt.Sprint(token.Key))
+	if err != nil {
+		return "", "", fmt.Errorf("invalid cursor")
+	}
+
+	op := " > "
+	if desc {
+		op = " < "
+	}
+	return field.Column + op + param(value), order, nil
+}
+
+// listValue ตรวจสอบและแปลงค่าตามชนิดของ field
+func listValue(field ListField, value string) (interface{}, error) {
+	switch field.Type {
+	case FieldNumber:
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return f, nil
+	case FieldDate:
+		for _, layout := range []string{"2006-01-02", "2006-01-02T15:04:05", time.RFC3339} {
+			if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not a date (YYYY-MM-DD)", value)
+	case FieldBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", value)
+		}
+		return b, nil
+	}
+	return value, nil
+}
+
+// escapeLike ป้องกันไม่ให้อักขระพิเศษของ LIKE ในค่าที่ผู้ใช้ส่งมาถูกตีความเป็น wildcard
+func escapeLike(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`, `[`, `\[`)
+	return r.Replace(s)
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"PenbunAPI/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// pageEnvelopeLocal คือ key ใน c.Locals ที่บอกว่า Route นี้ต้องตอบ Select Page ด้วย models.Page
+const pageEnvelopeLocal = "page_envelope"
+
+// EnablePageEnvelope ให้ Select Page ของ Request นี้ตอบด้วย models.Page แทนรูปแบบเดิมของแต่ละ Module
+func EnablePageEnvelope(c *fiber.Ctx) {
+	c.Locals(pageEnvelopeLocal, true)
+}
+
+// UsePageEnvelope คืนค่า true เมื่อ Request มาจาก Route ที่ใช้ models.Page (เช่น /api/v2)
+func UsePageEnvelope(c *fiber.Ctx) bool {
+	on, _ := c.Locals(pageEnvelopeLocal).(bool)
+	return on
+}
+
+// NewPage สร้าง models.Page จากผลลัพธ์ของ Select Page (list ต้องผ่าน CursorPage แล้ว)
+func NewPage[T any](q ListQuery, list []T, total *int, nextCursor string) models.Page[T] {
+	if list == nil {
+		list = []T{}
+	}
+	p := models.Page[T]{
+		Items:      list,
+		Limit:      q.Limit,
+		Total:      total,
+		NextCursor: nextCursor,
+	}
+	if total != nil {
+		pages := (*total + q.Limit - 1) / q.Limit
+		p.TotalPages = &pages
+	}
+
+	switch {
+	case q.Cursor:
+		p.HasNext = nextCursor != ""
+	case total != nil:
+		p.Page = q.Page
+		p.HasNext = q.Offset+q.Limit < *total
+	default:
+		// ไม่ได้นับ total: ถือว่ามีหน้าถัดไปเมื่อหน้านี้เต็ม
+		p.Page = q.Page
+		p.HasNext = len(list) == q.Limit
+	}
+	return p
+}
+
+// SendPage ตอบ Select Page ด้วย models.Page ภายใต้ ApiResponse
+func SendPage[T any](c *fiber.Ctx, q ListQuery, list []T, total *int, nextCursor string) error {
+	return c.JSON(models.ApiResponse{
+		Status: "success",
+		Data:   NewPage(q, list, total, nextCursor),
+	})
+}
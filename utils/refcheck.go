@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"PenbunAPI/dialect"
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ชื่อตาราง/คอลัมน์ใน tag `ref` ถูกต่อเข้า SQL โดยตรง จึงยอมรับเฉพาะตัวอักษร ตัวเลข และ "_"
+var refIdentPattern = regexp.MustCompile(`^\w+$`)
+
+type refTarget struct {
+	table, column string
+}
+
+// CheckRefs ตรวจสอบ foreign key ของ v ตาม tag `ref:"<table>.<column>"` เช่น `ref:"tb_customer_type.customer_type_id"`
+// ว่ามีอยู่จริงและยังไม่ถูกลบ (is_delete = 0) ก่อน Insert/Update
+// field ที่ไม่มีค่า (nil หรือ string ว่าง) จะถูกข้าม struct และ slice ของ struct ที่ซ้อนอยู่ (เช่น header/items) จะถูกตรวจสอบด้วย
+// ใช้เป็นขั้นตอนใน Transaction ของการเขียน แถวที่ถูกอ้างถึงถูกอ่านแบบล็อก (dialect.LockRow) จึงถูกลบระหว่างนั้นไม่ได้
+// คืนค่า 422 validation_failed พร้อมรายการ field ที่อ้างถึงข้อมูลที่ไม่มีอยู่ (rule "exists") หรือถูกลบแล้ว (rule "deleted")
+func CheckRefs(ctx context.Context, tx *sql.Tx, d dialect.Dialect, v interface{}) error {
+	var errs []FieldError
+	// ค่าเดียวกันที่อ้างหลายครั้ง (เช่น product_id ซ้ำใน items) ตรวจสอบกับฐานข้อมูลเพียงครั้งเดียว
+	seen := map[refTarget]map[string]string{}
+
+	var walk func(v reflect.Value, path string) error
+	walk = func(v reflect.Value, path string) error {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return nil
+			}
+			v = v.Elem()
+		}
+		switch v.Kind() {
+		case reflect.Struct:
+			if v.Type() == timeType {
+				return nil
+			}
+			t := v.Type()
+			for i := 0; i < t.NumField(); i++ {
+				sf := t.Field(i)
+				if !sf.IsExported() {
+					continue
+				}
+				name := fieldName(sf)
+				if name == "-" {
+					continue
+				}
+				if path != "" {
+					name = path + "." + name
+				}
+				tag := sf.Tag.Get("ref")
+				if tag == "" {
+					if err := walk(v.Field(i), name); err != nil {
+						return err
+					}
+					continue
+				}
+				value := indirect(v.Field(i))
+				if !value.IsValid() || value.Kind() != reflect.String || strings.TrimSpace(value.String()) == "" {
+					continue
+				}
+				target, err := parseRefTag(tag)
+				if err != nil {
+					return err
+				}
+				if seen[target] == nil {
+					seen[target] = map[string]string{}
+				}
+				rule, ok := seen[target][value.String()]
+				if !ok {
+					if rule, err = lookupRef(ctx, tx, d, target, value.String()); err != nil {
+						return err
+					}
+					seen[target][value.String()] = rule
+				}
+				if rule != "" {
+					errs = append(errs, refError(name, rule, value.String(), target))
+				}
+			}
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < v.Len(); i++ {
+				if err := walk(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := walk(reflect.ValueOf(v), ""); err != nil {
+		return Internal(err, "Failed to check references")
+	}
+	if len(errs) > 0 {
+		return NewAppError(fiber.StatusUnprocessableEntity, CodeValidation, "Referenced records not found").
+			WithDetails(fiber.Map{"errors": errs})
+	}
+	return nil
+}
+
+// CheckRowRefs ตรวจสอบ foreign key ของแถวที่บันทึกอยู่แล้วใน table (idColumn = id) ตาม tag `ref` ของ model
+// ใช้หลัง Restore เพื่อไม่ให้ข้อมูลกลับมาอ้างถึงข้อมูลที่ถูกลบไปแล้ว (รองรับหลายแถว เช่น items ของเอกสาร)
+// ตรวจเฉพาะแถวที่ใช้งานอยู่ (is_delete = 0) items ที่ยังถูกลบอยู่หลัง Restore จึงไม่ทำให้ได้ 409
+// คืนค่า 409 reference_violation พร้อมรายการ field ที่อ้างถึงข้อมูลที่ไม่มีอยู่ (rule "exists") หรือถูกลบแล้ว (rule "deleted")
+func CheckRowRefs(ctx context.Context, tx *sql.Tx, d dialect.Dialect, table, idColumn, id string, model interface{}) error {
+	t := reflect.TypeOf(model)
+	var columns []string
+	var targets []refTarget
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("ref")
+		if tag == "" {
+			continue
+		}
+		target, err := parseRefTag(tag)
+		if err != nil {
+			return Internal(err, "Failed to check references")
+		}
+		columns = append(columns, fieldName(t.Field(i)))
+		targets = append(targets, target)
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	// อ่านทุกแถวให้ครบก่อน แล้วจึงตรวจสอบทีละค่า (SQL Server ไม่รองรับหลาย Result Set พร้อมกันบน Connection เดียว)
+	rows, err := tx.QueryContext(ctx, "SELECT "+strings.Join(columns, ", ")+" FROM "+table+" WHERE "+idColumn+" = @ID AND is_delete = 0", sql.Named("ID", id))
+	if err != nil {
+		return Internal(err, "Failed to check references")
+	}
+	var values [][]sql.NullString
+	for rows.Next() {
+		row := make([]sql.NullString, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			rows.Close()
+			return Internal(err, "Failed to check references")
+		}
+		values = append(values, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return Internal(err, "Failed to check references")
+	}
+
+	var errs []FieldError
+	seen := map[refTarget]map[string]bool{}
+	for _, row := range values {
+		for i, v := range row {
+			if !v.Valid || strings.TrimSpace(v.String) == "" || seen[targets[i]][v.String] {
+				continue
+			}
+			if seen[targets[i]] == nil {
+				seen[targets[i]] = map[string]bool{}
+			}
+			seen[targets[i]][v.String] = true
+			rule, err := lookupRef(ctx, tx, d, targets[i], v.String)
+			if err != nil {
+				return Internal(err, "Failed to check references")
+			}
+			if rule != "" {
+				errs = append(errs, refError(columns[i], rule, v.String, targets[i]))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return NewAppError(fiber.StatusConflict, CodeReferenceViolation, "Referenced records have been deleted").
+			WithDetails(fiber.Map{"errors": errs})
+	}
+	return nil
+}
+
+func parseRefTag(tag string) (refTarget, error) {
+	table, column, ok := strings.Cut(tag, ".")
+	if !ok || !refIdentPattern.MatchString(table) || !refIdentPattern.MatchString(column) {
+		return refTarget{}, fmt.Errorf("invalid ref tag %q", tag)
+	}
+	return refTarget{table, column}, nil
+}
+
+// lookupRef คืนค่า "" หากพบข้อมูล, "exists" หากไม่พบ, "deleted" หากถูก Soft Delete แล้ว
+// แถวที่พบถูกล็อกจนจบ tx เพื่อให้ Soft Delete ของแถวนั้นรอจนกว่าการเขียนที่อ้างถึงจะ Commit
+func lookupRef(ctx context.Context, tx *sql.Tx, d dialect.Dialect, target refTarget, value string) (string, error) {
+	var isDelete bool
+	query := d.SelectFirst("is_delete", "FROM "+d.LockRow(target.table)+" WHERE "+target.column+" = @Value")
+	err := tx.QueryRowContext(ctx, query, sql.Named("Value", value)).Scan(&isDelete)
+	switch {
+	case err == sql.ErrNoRows:
+		return "exists", nil
+	case err != nil:
+		return "", err
+	case isDelete:
+		return "deleted", nil
+	}
+	return "", nil
+}
+
+func refError(field, rule, value string, target refTarget) FieldError {
+	entity := entityName(target.table)
+	if rule == "deleted" {
+		return FieldError{field, rule, fmt.Sprintf("%s %q has been deleted", entity, value)}
+	}
+	return FieldError{field, rule, fmt.Sprintf("%s %q does not exist", entity, value)}
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"PenbunAPI/cache"
+	"PenbunAPI/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ReferenceCacheTTL อายุของข้อมูล tb_reference ที่ cache ไว้ต่อกลุ่ม ref_id
+const ReferenceCacheTTL = 5 * time.Minute
+
+// ReferenceColumns คือคอลัมน์ของ tb_reference ตามลำดับที่ ScanReference อ่าน
+const ReferenceColumns = `row_id, ref_id, ref_int, ref_text, update_by, update_date`
+
+// referenceCache คือ Cache ของ LookupReference (LRU ของ instance นี้จนกว่า main จะเรียก SetReferenceCache)
+var referenceCache cache.Cache = cache.NewLRU(0)
+
+// SetReferenceCache ให้ LookupReference ใช้ c (Cache เดียวกับ Repository ตาม CACHE_DRIVER)
+func SetReferenceCache(c cache.Cache) {
+	referenceCache = c
+}
+
+func referenceKey(refID string) string {
+	return "tb_reference:ref:" + refID
+}
+
+// ScanReference อ่านข้อมูล tb_reference หนึ่งแถวตามลำดับของ ReferenceColumns
+func ScanReference(scanner interface{ Scan(...any) error }) (models.Reference, error) {
+	var item models.Reference
+	var upd sql.NullTime
+	if err := scanner.Scan(&item.RowID, &item.RefID, &item.RefInt, &item.RefText, &item.UpdateBy, &upd); err != nil {
+		return item, err
+	}
+	if upd.Valid {
+		t := FormatDate(upd.Time)
+		item.UpdateDate = &t
+	}
+	return item, nil
+}
+
+// LookupReference คืนค่ารายการทั้งหมดในกลุ่ม refID จาก db (ผ่าน cache) สำหรับให้ module อื่นใช้แปลงรหัสเป็นข้อความ
+func LookupReference(ctx context.Context, db *sql.DB, refID string) ([]models.Reference, error) {
+	return cache.ReadThrough(ctx, referenceCache, referenceKey(refID), ReferenceCacheTTL, func(ctx context.Context) ([]models.Reference, error) {
+		rows, err := db.QueryContext(ctx, `SELECT `+ReferenceColumns+` FROM tb_reference WHERE ref_id = @RefID ORDER BY ref_int, row_id`,
+			sql.Named("RefID", refID))
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		items := []models.Reference{}
+		for rows.Next() {
+			item, err := ScanReference(rows)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, rows.Err()
+	})
+}
+
+// InvalidateReference ล้าง cache ของกลุ่ม refID หลังมีการเพิ่ม/แก้ไขข้อมูล
+func InvalidateReference(ctx context.Context, refIDs ...string) {
+	var keys []string
+	for _, id := range refIDs {
+		keys = append(keys, referenceKey(id))
+	}
+	cache.Invalidate(ctx, referenceCache, keys...)
+}
@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// createdIDLocal คือ key ใน c.Locals ที่เก็บรหัสของข้อมูลที่เพิ่งสร้าง (ใช้สร้าง Location header)
+const createdIDLocal = "created_id"
+
+// SetCreatedID บันทึกรหัสของข้อมูลที่ Insert สำเร็จ เพื่อให้ Route แบบ REST ตอบ Location header
+func SetCreatedID(c *fiber.Ctx, id string) {
+	c.Locals(createdIDLocal, id)
+}
+
+// CreatedID คืนค่ารหัสที่บันทึกด้วย SetCreatedID (ว่างหากไม่มี)
+func CreatedID(c *fiber.Ctx) string {
+	id, _ := c.Locals(createdIDLocal).(string)
+	return id
+}
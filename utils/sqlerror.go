@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/gofiber/fiber/v2"
+)
+
+// หมายเลข Error ของ SQL Server ที่ map เป็น Response เฉพาะ
+const (
+	sqlErrUniqueConstraint = 2627 // Violation of PRIMARY KEY / UNIQUE KEY constraint
+	sqlErrUniqueIndex      = 2601 // Cannot insert duplicate key row ... unique index
+	sqlErrConstraint       = 547  // FOREIGN KEY / REFERENCE / CHECK constraint conflict
+	sqlErrDeadlock         = 1205 // Transaction was deadlocked ... chosen as the deadlock victim
+	sqlErrLockTimeout      = 1222 // Lock request time out period exceeded
+	sqlErrTruncated        = 8152 // String or binary data would be truncated
+	sqlErrTruncatedColumn  = 2628 // String or binary data would be truncated in table ..., column ...
+)
+
+var (
+	sqlTablePattern  = regexp.MustCompile(`table "(?:[^".]+\.)?([^"]+)"`)
+	sqlColumnPattern = regexp.MustCompile(`column '([^']+)'`)
+	sqlObjectPattern = regexp.MustCompile(`object '(?:[^'.]+\.)?([^']+)'`)
+)
+
+// MapSQLError แปลง SQL Server (หรือ SQLite) Error ที่รู้จักเป็น AppError ที่ปลอดภัยต่อการส่งให้ client
+// (ไม่มีข้อความ SQL ดิบ มีเพียงชื่อ entity/field ที่เกี่ยวข้อง) คืนค่า nil หากไม่ใช่ Error ที่ map ได้
+func MapSQLError(err error) *AppError {
+	if err == nil {
+		return nil
+	}
+	if mapped, ok := mapSQLiteError(err); ok {
+		if mapped != nil {
+			mapped.Err = err
+		}
+		return mapped
+	}
+	var sqlErr mssql.Error
+	if !errors.As(err, &sqlErr) {
+		return nil
+	}
+
+	// SQL Server มักส่ง Error จริงตามด้วย "The statement has been terminated." จึงต้องไล่ดูทุกตัว
+	all := sqlErr.All
+	if len(all) == 0 {
+		all = []mssql.Error{sqlErr}
+	}
+	for _, e := range all {
+		if mapped := mapSQLErrorNumber(e); mapped != nil {
+			mapped.Err = err
+			return mapped
+		}
+	}
+	return nil
+}
+
+func mapSQLErrorNumber(e mssql.Error) *AppError {
+	switch e.Number {
+	case sqlErrUniqueConstraint, sqlErrUniqueIndex:
+		return NewAppError(fiber.StatusConflict, CodeDuplicate, "A record with the same key already exists").
+			WithDetails(sqlDetails(map[string]string{"entity": entityName(match(sqlObjectPattern, e.Message))}))
+
+	case sqlErrConstraint:
+		table := entityName(match(sqlTablePattern, e.Message))
+		column := match(sqlColumnPattern, e.Message)
+		switch {
+		case strings.Contains(e.Message, "FOREIGN KEY constraint"):
+			// INSERT/UPDATE อ้างถึงข้อมูลที่ไม่มีอยู่ (table คือตารางที่ถูกอ้างถึง)
+			return NewAppError(fiber.StatusConflict, CodeReferenceViolation, "Referenced "+orRecord(table)+" does not exist").
+				WithDetails(sqlDetails(map[string]string{"referenced_entity": table, "field": column}))
+		case strings.Contains(e.Message, "REFERENCE constraint"):
+			// DELETE ข้อมูลที่ยังถูกอ้างถึง (table คือตารางที่อ้างถึงข้อมูลนี้อยู่)
+			return NewAppError(fiber.StatusConflict, CodeReferenceViolation, "Record is still referenced by "+orRecord(table)).
+				WithDetails(sqlDetails(map[string]string{"dependent_entity": table, "field": column}))
+		case strings.Contains(e.Message, "CHECK constraint"):
+			return NewAppError(fiber.StatusUnprocessableEntity, CodeValidation, "Value is not allowed").
+				WithDetails(sqlDetails(map[string]string{"field": column}))
+		}
+
+	case sqlErrTruncated, sqlErrTruncatedColumn:
+		return NewAppError(fiber.StatusUnprocessableEntity, CodeValidation, "Value is too long").
+			WithDetails(sqlDetails(map[string]string{"field": match(sqlColumnPattern, e.Message)}))
+
+	case sqlErrDeadlock, sqlErrLockTimeout:
+		return &AppError{
+			Status:    fiber.StatusServiceUnavailable,
+			Code:      CodeDeadlock,
+			Message:   "The database is busy, please retry",
+			Retryable: true,
+		}
+	}
+	return nil
+}
+
+// entityName แปลงชื่อตารางเป็นชื่อ entity เช่น "tb_vendor_type" => "vendor_type"
+func entityName(table string) string {
+	return strings.TrimPrefix(table, "tb_")
+}
+
+func orRecord(entity string) string {
+	if entity == "" {
+		return "record"
+	}
+	return entity
+}
+
+func match(re *regexp.Regexp, s string) string {
+	if m := re.FindStringSubmatch(s); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// sqlDetails ตัด key ที่ไม่มีค่าออก คืนค่า nil หากไม่เหลือข้อมูล
+func sqlDetails(details map[string]string) interface{} {
+	for k, v := range details {
+		if v == "" {
+			delete(details, k)
+		}
+	}
+	if len(details) == 0 {
+		return nil
+	}
+	return details
+}
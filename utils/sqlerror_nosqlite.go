@@ -0,0 +1,8 @@
+//go:build !cgo
+
+package utils
+
+// mapSQLiteError เมื่อ build โดยไม่มี cgo จะไม่มี Driver ของ SQLite จึงไม่มี Error ของ SQLite ให้ map
+func mapSQLiteError(err error) (*AppError, bool) {
+	return nil, false
+}
@@ -0,0 +1,48 @@
+//go:build cgo
+
+package utils
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mattn/go-sqlite3"
+)
+
+// SQLite รายงาน constraint ในรูป "UNIQUE constraint failed: tb_vendor.vendor_id"
+var sqliteColumnPattern = regexp.MustCompile(`constraint failed: (\w+)\.(\w+)`)
+
+// mapSQLiteError map Error ของ SQLite (ฐานข้อมูลที่ใช้แทน SQL Server ในการทดสอบ) เป็น Response เดียวกับ SQL Server
+// SQLite ไม่บอกตารางใน FOREIGN KEY Error จึงตอบได้เพียงว่าข้อมูลที่อ้างถึงไม่ถูกต้อง
+// ok = false หาก err ไม่ใช่ Error ของ SQLite
+func mapSQLiteError(err error) (mapped *AppError, ok bool) {
+	var e sqlite3.Error
+	if !errors.As(err, &e) {
+		return nil, false
+	}
+	var table, column string
+	if m := sqliteColumnPattern.FindStringSubmatch(e.Error()); m != nil {
+		table, column = m[1], m[2]
+	}
+	switch e.ExtendedCode {
+	case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+		return NewAppError(fiber.StatusConflict, CodeDuplicate, "A record with the same key already exists").
+			WithDetails(sqlDetails(map[string]string{"entity": entityName(table)})), true
+	case sqlite3.ErrConstraintForeignKey:
+		return NewAppError(fiber.StatusConflict, CodeReferenceViolation, "Referenced record does not exist or is still referenced"), true
+	case sqlite3.ErrConstraintNotNull, sqlite3.ErrConstraintCheck:
+		return NewAppError(fiber.StatusUnprocessableEntity, CodeValidation, "Value is not allowed").
+			WithDetails(sqlDetails(map[string]string{"field": column})), true
+	}
+	switch e.Code {
+	case sqlite3.ErrBusy, sqlite3.ErrLocked:
+		return &AppError{
+			Status:    fiber.StatusServiceUnavailable,
+			Code:      CodeDeadlock,
+			Message:   "The database is busy, please retry",
+			Retryable: true,
+		}, true
+	}
+	return nil, true
+}
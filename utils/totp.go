@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// TOTPDigits และ TOTPPeriod เป็นค่ามาตรฐานที่ Google Authenticator / Microsoft Authenticator รองรับ
+	TOTPDigits = 6
+	TOTPPeriod = 30
+
+	// totpSkew ยอมรับรหัสของช่วงเวลาก่อนหน้า/ถัดไป 1 ช่วง (±30 วินาที)
+	totpSkew = 1
+
+	// RecoveryCodeCount จำนวน Recovery Code ที่ออกให้ต่อครั้ง
+	RecoveryCodeCount = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret สร้าง Secret แบบสุ่ม 160 bit (Base32) สำหรับลงทะเบียน Authenticator
+func GenerateTOTPSecret() (string, error) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(buf), nil
+}
+
+// TOTPURI สร้าง otpauth:// URI สำหรับแปลงเป็น QR Code
+func TOTPURI(issuer, account, secret string) string {
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(TOTPDigits))
+	q.Set("period", fmt.Sprint(TOTPPeriod))
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// TOTPCode คำนวณรหัส TOTP (RFC 6238, HMAC-SHA1) ของช่วงเวลา step
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", err
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TOTPDigits, value%mod), nil
+}
+
+// ValidateTOTP ตรวจสอบรหัสจาก Authenticator ณ เวลา now (ยอมให้คลาดเคลื่อน ±1 ช่วง)
+// คืนค่า step ที่ตรงกัน เพื่อให้ผู้เรียกบันทึกไว้ป้องกันการใช้รหัสเดิมซ้ำ
+func ValidateTOTP(secret, code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+	step := now.Unix() / TOTPPeriod
+	for i := int64(-totpSkew); i <= totpSkew; i++ {
+		expected, err := TOTPCode(secret, step+i)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step + i, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateRecoveryCodes สร้าง Recovery Code แบบใช้ครั้งเดียว (รูปแบบ xxxxx-xxxxx)
+// คืนค่ารหัสจริง (แสดงให้ผู้ใช้ครั้งเดียว) และ hash ที่ใช้เก็บลงฐานข้อมูล
+func GenerateRecoveryCodes(n int) (plain []string, hashes []string, err error) {
+	const alphabet = "abcdefghijkmnpqrstuvwxyz23456789" // 32 ตัวอักษร (ตัด l, o, 0, 1 ที่สับสนง่าย)
+	for i := 0; i < n; i++ {
+		buf := make([]byte, 10)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		for j := range buf {
+			buf[j] = alphabet[buf[j]&31]
+		}
+		code := string(buf[:5]) + "-" + string(buf[5:])
+		plain = append(plain, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	return plain, hashes, nil
+}
+
+// HashRecoveryCode ทำ hash Recovery Code (ไม่สนตัวพิมพ์และขีดกลาง)
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	return HashAPIKey(code)
+}
@@ -2,6 +2,7 @@
 package utils
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"os"
@@ -39,9 +40,12 @@ func init() {
 	}
 }
 
-func ExecuteTransaction(db *sql.DB, steps []func(tx *sql.Tx) error) error {
+// ExecuteTransaction รัน steps ตามลำดับใน Transaction เดียวที่ผูกกับ ctx (มักเป็น c.UserContext() ของ Request)
+// หาก step ใดผิดพลาดหรือ ctx หมดเวลา/ถูกยกเลิกระหว่างทาง จะ Rollback และคืน error นั้น (ctx.Err() กรณีหมดเวลา)
+// step ที่รัน SQL ควรใช้ tx.ExecContext/QueryContext กับ ctx เดียวกันเพื่อให้ Query ที่ค้างอยู่ถูกยกเลิกด้วย
+func ExecuteTransaction(ctx context.Context, db *sql.DB, steps []func(tx *sql.Tx) error) error {
 	start := time.Now()
-	tx, err := db.Begin()
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		txLogger.Printf("[BEGIN][ERR] %v", err)
 		return err
@@ -56,9 +60,17 @@ func ExecuteTransaction(db *sql.DB, steps []func(tx *sql.Tx) error) error {
 	}()
 
 	for i, step := range steps {
-		if err := step(tx); err != nil {
+		err := ctx.Err()
+		if err == nil {
+			err = step(tx)
+		}
+		if err != nil {
 			_ = tx.Rollback()
-			txLogger.Printf("[ROLLBACK] step=%d err=%v elapsed=%s", i+1, err, time.Since(start))
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				txLogger.Printf("[ROLLBACK][CTX] step=%d err=%v ctx=%v elapsed=%s", i+1, err, ctxErr, time.Since(start))
+			} else {
+				txLogger.Printf("[ROLLBACK] step=%d err=%v elapsed=%s", i+1, err, time.Since(start))
+			}
 			return err
 		}
 	}
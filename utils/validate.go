@@ -0,0 +1,296 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// FieldError คือ Error ของ field เดียว (field ใช้ชื่อตาม json tag เช่น "items[0].qty")
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Bind อ่าน Request Body ลงใน v แล้วตรวจสอบตาม tag `validate` ทุกกฎ (ใช้กับ Insert และ Update ที่แทนที่ทั้งแถว)
+func Bind(c *fiber.Ctx, v interface{}) error {
+	return bind(c, v, false)
+}
+
+// BindPartial เหมือน Bind แต่ข้ามกฎ required (ใช้กับ Update ที่ field ว่างหมายถึงคงค่าเดิม)
+// field ที่ส่งมายังต้องถูกรูปแบบ เช่น tax_id, email, ค่าไม่ติดลบ
+func BindPartial(c *fiber.Ctx, v interface{}) error {
+	return bind(c, v, true)
+}
+
+func bind(c *fiber.Ctx, v interface{}, partial bool) error {
+	if err := c.BodyParser(v); err != nil {
+		return BadRequest("Invalid request body")
+	}
+	if errs := Validate(v, partial); len(errs) > 0 {
+		return NewAppError(fiber.StatusUnprocessableEntity, CodeValidation, "Validation failed").
+			WithDetails(fiber.Map{"errors": errs})
+	}
+	return nil
+}
+
+// Validate ตรวจสอบ struct ตาม tag `validate` และคืนค่า Error ของทุก field (nil หากถูกต้อง)
+// กฎที่รองรับ (คั่นด้วย ","):
+//
+//	required      ต้องมีค่า (string ต้องไม่ว่าง, pointer ต้องไม่เป็น nil, slice ต้องมีสมาชิก)
+//	min=N, max=N  ความยาว string (จำนวนตัวอักษร) หรือจำนวนสมาชิกของ slice
+//	gt=N, gte=N   ค่าตัวเลขต้องมากกว่า / มากกว่าหรือเท่ากับ N
+//	lte=N         ค่าตัวเลขต้องไม่เกิน N
+//	oneof=A B     ค่าต้องเป็นหนึ่งในรายการ
+//	email         รูปแบบอีเมล
+//	taxid         เลขประจำตัวผู้เสียภาษีไทย 13 หลักพร้อม checksum
+//	zip           รหัสไปรษณีย์ไทย 5 หลัก
+//	isbn          ISBN-10 หรือ ISBN-13 พร้อม checksum (ยอมให้มี "-" หรือช่องว่าง)
+//
+// กฎอื่นนอกจาก required จะข้าม field ที่ไม่มีค่า (nil หรือ string ว่าง)
+// struct และ slice ของ struct ที่ซ้อนอยู่จะถูกตรวจสอบต่อโดยอัตโนมัติ
+func Validate(v interface{}, partial bool) []FieldError {
+	var errs []FieldError
+	validateValue(reflect.ValueOf(v), "", partial, &errs)
+	return errs
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func validateValue(v reflect.Value, path string, partial bool, errs *[]FieldError) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			name := fieldName(sf)
+			if name == "-" {
+				continue
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			fv := v.Field(i)
+			if tag := sf.Tag.Get("validate"); tag != "" {
+				if !checkField(fv, name, tag, partial, errs) {
+					continue
+				}
+			}
+			validateValue(fv, name, partial, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), partial, errs)
+		}
+	}
+}
+
+func fieldName(sf reflect.StructField) string {
+	name := strings.Split(sf.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return sf.Name
+	}
+	return name
+}
+
+// checkField ตรวจสอบกฎของ field เดียว คืนค่า false หาก field ไม่ผ่าน (ไม่ต้องตรวจสอบภายในต่อ)
+func checkField(v reflect.Value, field, tag string, partial bool, errs *[]FieldError) bool {
+	empty := isEmpty(v)
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if name == "required" {
+			if empty && !partial {
+				*errs = append(*errs, FieldError{field, name, field + " is required"})
+				return false
+			}
+			continue
+		}
+		if empty {
+			continue
+		}
+		if msg := applyRule(indirect(v), name, param); msg != "" {
+			*errs = append(*errs, FieldError{field, name, field + " " + msg})
+			return false
+		}
+	}
+	return true
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	return v
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return true
+		}
+		return isEmpty(v.Elem())
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return v.Interface().(time.Time).IsZero()
+		}
+	}
+	return false
+}
+
+func applyRule(v reflect.Value, name, param string) string {
+	switch name {
+	case "min", "max":
+		n, _ := strconv.Atoi(param)
+		size, unit := length(v)
+		if name == "min" && size < n {
+			return fmt.Sprintf("must have at least %d %s", n, unit)
+		}
+		if name == "max" && size > n {
+			return fmt.Sprintf("must have at most %d %s", n, unit)
+		}
+	case "gt", "gte", "lte":
+		limit, _ := strconv.ParseFloat(param, 64)
+		x, ok := number(v)
+		if !ok {
+			return ""
+		}
+		if name == "gt" && x <= limit {
+			return "must be greater than " + param
+		}
+		if name == "gte" && x < limit {
+			return "must be greater than or equal to " + param
+		}
+		if name == "lte" && x > limit {
+			return "must be less than or equal to " + param
+		}
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		for _, opt := range strings.Fields(param) {
+			if s == opt {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(strings.Fields(param), ", ")
+	case "email":
+		if !emailPattern.MatchString(v.String()) {
+			return "must be a valid email address"
+		}
+	case "taxid":
+		if !IsThaiTaxID(v.String()) {
+			return "must be a valid 13-digit Thai tax ID"
+		}
+	case "zip":
+		if !zipPattern.MatchString(v.String()) {
+			return "must be a 5-digit zip code"
+		}
+	case "isbn":
+		if !IsISBN(v.String()) {
+			return "must be a valid ISBN-10 or ISBN-13"
+		}
+	default:
+		panic("utils.Validate: unknown rule " + strconv.Quote(name))
+	}
+	return ""
+}
+
+func length(v reflect.Value) (int, string) {
+	if v.Kind() == reflect.String {
+		return utf8.RuneCountInString(v.String()), "characters"
+	}
+	return v.Len(), "items"
+}
+
+func number(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+var (
+	emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	zipPattern   = regexp.MustCompile(`^[1-9][0-9]{4}$`)
+)
+
+// IsThaiTaxID ตรวจสอบเลขประจำตัวผู้เสียภาษี/เลขบัตรประชาชนไทย 13 หลัก
+// หลักที่ 13 = (11 - (ผลรวมของหลักที่ i คูณ (14 - i) สำหรับ i = 1..12) mod 11) mod 10
+func IsThaiTaxID(s string) bool {
+	if len(s) != 13 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 13; i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+		if i < 12 {
+			sum += int(s[i]-'0') * (13 - i)
+		}
+	}
+	return (11-sum%11)%10 == int(s[12]-'0')
+}
+
+// IsISBN ตรวจสอบ ISBN-10 (หลักสุดท้ายเป็น X ได้) หรือ ISBN-13 พร้อม checksum
+func IsISBN(s string) bool {
+	s = strings.NewReplacer("-", "", " ", "").Replace(s)
+	switch len(s) {
+	case 10:
+		sum := 0
+		for i := 0; i < 10; i++ {
+			var d int
+			switch {
+			case s[i] >= '0' && s[i] <= '9':
+				d = int(s[i] - '0')
+			case i == 9 && (s[i] == 'X' || s[i] == 'x'):
+				d = 10
+			default:
+				return false
+			}
+			sum += d * (10 - i)
+		}
+		return sum%11 == 0
+	case 13:
+		sum := 0
+		for i := 0; i < 13; i++ {
+			if s[i] < '0' || s[i] > '9' {
+				return false
+			}
+			d := int(s[i] - '0')
+			if i%2 == 1 {
+				d *= 3
+			}
+			sum += d
+		}
+		return sum%10 == 0
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"PenbunAPI/dialect"
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// rowVersionLayout คือรูปแบบของ RowVersion ละเอียดถึงมิลลิวินาที ซึ่งครอบคลุมความละเอียดของ update_date ทั้งหมด
+// (DATETIME ของ SQL Server ละเอียด 1/300 วินาที และ SQLite บันทึกถึงมิลลิวินาที) สองค่าที่ต่างกันจึงไม่ได้รุ่นเดียวกัน
+const rowVersionLayout = "20060102150405.000"
+
+// responseDateLayouts คือรูปแบบของ update_date ที่ Response ใช้ (time.Time, string จาก Driver และ formatDate ของ Repository)
+var responseDateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05.999999999"}
+
+// RowVersion คือรุ่นของแถวจาก update_date เป็นตัวเลขล้วน เช่น 20261019093000123 (ใช้เป็น ETag ของข้อมูลหนึ่งแถว)
+// อ่านตามนาฬิกาที่บันทึกไว้โดยไม่แปลง Time Zone เพราะ update_date เก็บเวลาประเทศไทยแบบไม่มี offset
+func RowVersion(t time.Time) string {
+	return strings.Replace(t.Round(time.Millisecond).Format(rowVersionLayout), ".", "", 1)
+}
+
+// FormatDate แปลงวันที่จากฐานข้อมูลเป็นรูปแบบที่ Response ใช้ (เช่น 2026-10-19T09:30:00.123)
+// มิลลิวินาทีแสดงเฉพาะเมื่อไม่เป็นศูนย์ ETag ที่คำนวณจาก Response จึงตรงกับ RowVersion ของ update_date ในฐานข้อมูล
+func FormatDate(t time.Time) string {
+	return t.Round(time.Millisecond).Format("2006-01-02T15:04:05.999")
+}
+
+// ParseRowVersion คืน RowVersion ของ update_date ใน Response (false หากอ่านเป็นวันที่ไม่ได้)
+func ParseRowVersion(date string) (string, bool) {
+	for _, layout := range responseDateLayouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			return RowVersion(t), true
+		}
+	}
+	return "", false
+}
+
+// MatchETag รายงานว่า header (If-None-Match หรือ If-Match ที่อาจมีหลายค่าคั่นด้วย , หรือเป็น *) ตรงกับ tag หรือไม่
+// โดยเทียบแบบ Weak (ไม่สนใจ W/)
+func MatchETag(header, tag string) bool {
+	tag = strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordChanged คือ 412 เมื่อ If-Match ไม่ตรงกับรุ่นปัจจุบันของข้อมูล
+func RecordChanged() *AppError {
+	return PreconditionFailed("The record was changed by someone else, reload it and try again")
+}
+
+type ifMatchKey struct{}
+
+// WithIfMatch คืน ctx ที่มี If-Match ของ Request (middleware.IfMatch) ให้ Repository ตรวจด้วย CheckRowVersion
+func WithIfMatch(ctx context.Context, header string) context.Context {
+	return context.WithValue(ctx, ifMatchKey{}, header)
+}
+
+// IfMatch คืน If-Match ที่ WithIfMatch ใส่ไว้ใน ctx (false หาก Request ไม่ได้ส่งมา)
+func IfMatch(ctx context.Context) (string, bool) {
+	header, _ := ctx.Value(ifMatchKey{}).(string)
+	return header, header != ""
+}
+
+// CheckRowVersion ตรวจ If-Match ใน ctx กับ update_date ของแถว idColumn = id ที่อ่านแบบล็อกแถว (dialect.LockRow)
+// ใช้เป็นขั้นตอนแรกของ Transaction ของการเขียน ผู้อื่นจึงแก้ไขแถวนั้นระหว่างการตรวจกับการเขียนไม่ได้
+// ไม่มี If-Match = ไม่อ่าน, If-Match: * = ผ่านหากพบแถว, ไม่พบแถว = sql.ErrNoRows
+// และไม่ตรง (รวมถึง update_date เป็น NULL) = RecordChanged
+func CheckRowVersion(ctx context.Context, tx *sql.Tx, d dialect.Dialect, table, idColumn string, id interface{}) error {
+	header, ok := IfMatch(ctx)
+	if !ok {
+		return nil
+	}
+	var updateDate sql.NullTime
+	if err := tx.QueryRowContext(ctx, "SELECT update_date FROM "+d.LockRow(table)+" WHERE "+idColumn+" = @ID",
+		sql.Named("ID", id)).Scan(&updateDate); err != nil {
+		return err
+	}
+	if strings.TrimSpace(header) != "*" && (!updateDate.Valid || !MatchETag(header, `"`+RowVersion(updateDate.Time)+`"`)) {
+		return RecordChanged()
+	}
+	return nil
+}